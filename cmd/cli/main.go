@@ -1,19 +1,36 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/notfrancois/filesystem-daemon/delta"
 	"github.com/notfrancois/filesystem-daemon/proto"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // CLI configuration
@@ -24,6 +41,7 @@ var (
 	timeout       int
 	outputFormat  string
 	verbose       bool
+	leases        []string
 )
 
 // Client connection
@@ -53,6 +71,7 @@ Allows operations on files and directories through a remote daemon.`,
 	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 30, "Command timeout in seconds")
 	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text, json)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().StringSliceVar(&leases, "lease", nil, "Lease ID to present for changes to locked paths (repeatable)")
 
 	// Add commands
 	rootCmd.AddCommand(
@@ -63,11 +82,34 @@ Allows operations on files and directories through a remote daemon.`,
 		newDeleteCommand(),
 		newCopyCommand(),
 		newMoveCommand(),
+		newSymlinkCommand(),
+		newChmodCommand(),
+		newChownCommand(),
+		newTouchCommand(),
+		newXattrCommand(),
+		newAclCommand(),
+		newWriteCommand(),
+		newAppendCommand(),
+		newTruncateCommand(),
+		newLockCommand(),
+		newVersionsCommand(),
+		newSnapshotCommand(),
+		newDiffCommand(),
+		newDiffFileCommand(),
+		newPatchCommand(),
+		newSyncCommand(),
+		newPushCommand(),
+		newPullCommand(),
+		newManifestCommand(),
+		newChecksumCommand(),
+		newDuplicatesCommand(),
 		newUploadCommand(),
 		newDownloadCommand(),
 		newSearchCommand(),
+		newGrepCommand(),
 		newHierarchyCommand(),
 		newDirSizeCommand(),
+		newDiskUsageCommand(),
 		newStatusCommand(),
 	)
 
@@ -104,6 +146,22 @@ func connectToDaemon(cmd *cobra.Command, args []string) {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
+	// Present the given leases with every request
+	if len(leases) > 0 {
+		var pairs []string
+		for _, id := range leases {
+			pairs = append(pairs, "lease-id", id)
+		}
+		opts = append(opts,
+			grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+				return invoker(metadata.AppendToOutgoingContext(ctx, pairs...), method, req, reply, cc, callOpts...)
+			}),
+			grpc.WithStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return streamer(metadata.AppendToOutgoingContext(ctx, pairs...), desc, cc, method, callOpts...)
+			}),
+		)
+	}
+
 	// Connect to the server
 	conn, err = grpc.DialContext(ctx, serverAddress, opts...)
 	if err != nil {
@@ -159,6 +217,13 @@ func formatOutput(data interface{}) {
 func newListCommand() *cobra.Command {
 	var recursive bool
 	var pattern string
+	var sortBy string
+	var descending bool
+	var pageSize int
+	var include []string
+	var exclude []string
+	var ignoreFiles bool
+	var checksums []string
 
 	cmd := &cobra.Command{
 		Use:     "list [path]",
@@ -174,48 +239,92 @@ func newListCommand() *cobra.Command {
 				path = args[0]
 			}
 
-			// Make request
-			request := &proto.ListRequest{
-				Path:      path,
-				Recursive: recursive,
-				Pattern:   pattern,
+			if sortBy == "mtime" {
+				sortBy = "modified"
 			}
-
-			response, err := client.ListDirectory(ctx, request)
-			if err != nil {
-				fmt.Printf("Error listing directory: %v\n", err)
+			sortField, ok := proto.SortField_value["SORT_"+strings.ToUpper(sortBy)]
+			if !ok {
+				fmt.Printf("Invalid sort field %q (expected name, size, mtime or type)\n", sortBy)
 				os.Exit(1)
 			}
 
-			// Process response
-			if outputFormat == "json" {
-				formatOutput(response)
-			} else {
+			// Make request
+			request := &proto.ListRequest{
+				Path:           path,
+				Recursive:      recursive,
+				Pattern:        pattern,
+				PageSize:       int32(pageSize),
+				SortBy:         proto.SortField(sortField),
+				Descending:     descending,
+				Include:        include,
+				Exclude:        exclude,
+				UseIgnoreFiles: ignoreFiles,
+				Checksums:      checksums,
+			}
+
+			if outputFormat != "json" {
 				// Display as text table
 				fmt.Printf("Contents of %s:\n", path)
 				fmt.Println("Type\tSize\tModified\t\tName")
 				fmt.Println("--------------------------------------------------------------")
-				for _, item := range response.Items {
-					fileType := "F"
-					if item.IsDirectory {
-						fileType = "D"
+			}
+
+			// Fetch pages until the server has no more
+			var items []*proto.FileItem
+			total := 0
+			for {
+				response, err := client.ListDirectory(ctx, request)
+				if err != nil {
+					fmt.Printf("Error listing directory: %v\n", err)
+					os.Exit(1)
+				}
+
+				if outputFormat == "json" {
+					items = append(items, response.Items...)
+				} else {
+					for _, item := range response.Items {
+						fileType := getTypeLetter(item.FileType, item.IsDirectory)
+						modTime := time.Unix(item.ModifiedTime, 0).Format("2006-01-02 15:04:05")
+						fmt.Printf("%s\t%d\t%s\t%s%s", fileType, item.Size, modTime, item.Name, linkSuffix(item.IsSymlink, item.LinkTarget))
+						for _, name := range sortedKeys(item.Checksums) {
+							fmt.Printf("\t%s:%s", name, item.Checksums[name])
+						}
+						fmt.Println()
 					}
-					modTime := time.Unix(item.ModifiedTime, 0).Format("2006-01-02 15:04:05")
-					fmt.Printf("%s\t%d\t%s\t%s\n", fileType, item.Size, modTime, item.Name)
 				}
-				fmt.Printf("\nTotal: %d items\n", len(response.Items))
+				total += len(response.Items)
+
+				if response.NextPageToken == "" {
+					break
+				}
+				request.PageToken = response.NextPageToken
+			}
+
+			// Process response
+			if outputFormat == "json" {
+				formatOutput(&proto.ListResponse{Items: items})
+			} else {
+				fmt.Printf("\nTotal: %d items\n", total)
 			}
 		},
 	}
 
 	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "List recursively")
 	cmd.Flags().StringVarP(&pattern, "pattern", "p", "", "Filter by pattern (e.g. *.go)")
+	cmd.Flags().StringVar(&sortBy, "sort", "name", "Sort by name, size, mtime or type")
+	cmd.Flags().BoolVar(&descending, "desc", false, "Sort in descending order")
+	cmd.Flags().IntVar(&pageSize, "page-size", 1000, "Items fetched per request")
+	cmd.Flags().StringSliceVar(&checksums, "checksum", nil, "Include checksums of files: md5, sha1, sha256, sha512 or crc32c (repeatable)")
+	addRuleFlags(cmd, &include, &exclude, &ignoreFiles)
 
 	return cmd
 }
 
 // Create a new command for getting file info
 func newInfoCommand() *cobra.Command {
+	var xattrs bool
+	var checksums []string
+
 	cmd := &cobra.Command{
 		Use:     "info [path]",
 		Aliases: []string{"stat"},
@@ -225,7 +334,7 @@ func newInfoCommand() *cobra.Command {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
 			defer cancel()
 
-			request := &proto.FileRequest{Path: args[0]}
+			request := &proto.FileRequest{Path: args[0], IncludeXattrs: xattrs, Checksums: checksums}
 			response, err := client.GetFileInfo(ctx, request)
 			if err != nil {
 				fmt.Printf("Error getting file info: %v\n", err)
@@ -238,19 +347,37 @@ func newInfoCommand() *cobra.Command {
 				fmt.Println("File Information:")
 				fmt.Printf("Name:         %s\n", response.Name)
 				fmt.Printf("Path:         %s\n", response.Path)
-				fmt.Printf("Type:         %s\n", getTypeString(response.IsDirectory))
+				fmt.Printf("Type:         %s\n", getTypeString(response.FileType, response.IsDirectory))
+				if response.IsSymlink {
+					fmt.Printf("Link Target:  %s\n", response.LinkTarget)
+				}
 				fmt.Printf("Size:         %d bytes\n", response.Size)
-				fmt.Printf("Modified:     %s\n", time.Unix(response.ModifiedTime, 0).Format(time.RFC1123))
-				fmt.Printf("Created:      %s\n", time.Unix(response.CreationTime, 0).Format(time.RFC1123))
-				fmt.Printf("Accessed:     %s\n", time.Unix(response.AccessTime, 0).Format(time.RFC1123))
+				fmt.Printf("Modified:     %s\n", formatTimestamp(response.ModifiedTime, response.ModifiedTimeNs))
+				fmt.Printf("Changed:      %s\n", formatTimestamp(response.ChangeTime, response.ChangeTimeNs))
+				fmt.Printf("Accessed:     %s\n", formatTimestamp(response.AccessTime, response.AccessTimeNs))
+				fmt.Printf("Created:      %s\n", formatTimestamp(response.CreationTime, response.CreationTimeNs))
 				fmt.Printf("MIME Type:    %s\n", response.MimeType)
-				fmt.Printf("Permissions:  %s\n", response.Permissions)
-				fmt.Printf("Owner:        %s\n", response.Owner)
-				fmt.Printf("Group:        %s\n", response.Group)
+				fmt.Printf("Permissions:  %s (%s)\n", response.Permissions, response.Mode)
+				fmt.Printf("Inode:        %d\n", response.Inode)
+				fmt.Printf("Device:       %d\n", response.Device)
+				fmt.Printf("Links:        %d\n", response.LinkCount)
+				fmt.Printf("Blocks:       %d\n", response.Blocks)
+				fmt.Printf("ETag:         %s\n", response.Etag)
+				fmt.Printf("Owner:        %s (%d)\n", response.Owner, response.Uid)
+				fmt.Printf("Group:        %s (%d)\n", response.Group, response.Gid)
+				for _, xattr := range response.Xattrs {
+					fmt.Printf("Xattr:        %s=%s\n", xattr.Name, formatXattrValue(xattr.Value))
+				}
+				for _, name := range sortedKeys(response.Checksums) {
+					fmt.Printf("Checksum:     %s %s\n", name, response.Checksums[name])
+				}
 			}
 		},
 	}
 
+	cmd.Flags().BoolVar(&xattrs, "xattrs", false, "Include extended attributes")
+	cmd.Flags().StringSliceVar(&checksums, "checksum", nil, "Include checksums: md5, sha1, sha256, sha512 or crc32c (repeatable)")
+
 	return cmd
 }
 
@@ -334,6 +461,7 @@ func newCreateDirCommand() *cobra.Command {
 // Create a new command for deleting a file or directory
 func newDeleteCommand() *cobra.Command {
 	var recursive bool
+	var etags etagFlags
 
 	cmd := &cobra.Command{
 		Use:     "delete [path]",
@@ -345,8 +473,10 @@ func newDeleteCommand() *cobra.Command {
 			defer cancel()
 
 			request := &proto.DeleteRequest{
-				Path:      args[0],
-				Recursive: recursive,
+				Path:        args[0],
+				Recursive:   recursive,
+				IfMatch:     etags.ifMatch,
+				IfNoneMatch: etags.ifNoneMatch,
 			}
 
 			response, err := client.Delete(ctx, request)
@@ -368,6 +498,7 @@ func newDeleteCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Delete directories recursively")
+	etags.register(cmd)
 
 	return cmd
 }
@@ -375,6 +506,8 @@ func newDeleteCommand() *cobra.Command {
 // Create a new command for copying a file or directory
 func newCopyCommand() *cobra.Command {
 	var overwrite bool
+	var preserveXattrs bool
+	var etags etagFlags
 
 	cmd := &cobra.Command{
 		Use:     "copy [source] [destination]",
@@ -386,9 +519,12 @@ func newCopyCommand() *cobra.Command {
 			defer cancel()
 
 			request := &proto.CopyRequest{
-				Source:      args[0],
-				Destination: args[1],
-				Overwrite:   overwrite,
+				Source:         args[0],
+				Destination:    args[1],
+				Overwrite:      overwrite,
+				PreserveXattrs: preserveXattrs,
+				IfMatch:        etags.ifMatch,
+				IfNoneMatch:    etags.ifNoneMatch,
 			}
 
 			response, err := client.Copy(ctx, request)
@@ -410,6 +546,8 @@ func newCopyCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&overwrite, "overwrite", "f", false, "Overwrite destination if it exists")
+	cmd.Flags().BoolVar(&preserveXattrs, "preserve-xattrs", false, "Copy extended attributes and ACLs along")
+	etags.register(cmd)
 
 	return cmd
 }
@@ -417,6 +555,8 @@ func newCopyCommand() *cobra.Command {
 // Create a new command for moving/renaming a file or directory
 func newMoveCommand() *cobra.Command {
 	var overwrite bool
+	var etags etagFlags
+	var sourceIfMatch string
 
 	cmd := &cobra.Command{
 		Use:     "move [source] [destination]",
@@ -428,9 +568,12 @@ func newMoveCommand() *cobra.Command {
 			defer cancel()
 
 			request := &proto.MoveRequest{
-				Source:      args[0],
-				Destination: args[1],
-				Overwrite:   overwrite,
+				Source:        args[0],
+				Destination:   args[1],
+				Overwrite:     overwrite,
+				IfMatch:       etags.ifMatch,
+				IfNoneMatch:   etags.ifNoneMatch,
+				SourceIfMatch: sourceIfMatch,
 			}
 
 			response, err := client.Move(ctx, request)
@@ -452,271 +595,2931 @@ func newMoveCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&overwrite, "overwrite", "f", false, "Overwrite destination if it exists")
+	etags.register(cmd)
+	cmd.Flags().StringVar(&sourceIfMatch, "source-if-match", "", "Only move if the source has one of these ETags")
 
 	return cmd
 }
 
-// Create a new command for uploading a file
-func newUploadCommand() *cobra.Command {
-	var chunkSize int
+// Create a new command for creating a symbolic link
+func newSymlinkCommand() *cobra.Command {
+	var overwrite bool
 
 	cmd := &cobra.Command{
-		Use:   "upload [local_file] [remote_path]",
-		Short: "Upload a file to the server",
-		Args:  cobra.ExactArgs(2),
+		Use:     "symlink [target] [link]",
+		Aliases: []string{"ln"},
+		Short:   "Create a symbolic link",
+		Args:    cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
 			defer cancel()
 
-			localFile := args[0]
-			remotePath := args[1]
-
-			// Open the local file
-			file, err := os.Open(localFile)
-			if err != nil {
-				fmt.Printf("Error opening local file: %v\n", err)
-				os.Exit(1)
+			request := &proto.SymlinkRequest{
+				Target:    args[0],
+				Path:      args[1],
+				Overwrite: overwrite,
 			}
-			defer file.Close()
 
-			// Create upload stream
-			stream, err := client.UploadFile(ctx)
+			response, err := client.CreateSymlink(ctx, request)
 			if err != nil {
-				fmt.Printf("Error creating upload stream: %v\n", err)
+				fmt.Printf("Error creating symlink: %v\n", err)
 				os.Exit(1)
 			}
 
-			// Get file info for progress reporting
-			fileInfo, err := file.Stat()
-			if err != nil {
-				fmt.Printf("Error getting file info: %v\n", err)
-				os.Exit(1)
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else {
+				if response.Success {
+					fmt.Printf("Successfully linked: %s -> %s\n", args[1], args[0])
+				} else {
+					fmt.Printf("Failed to create symlink: %s\n", response.Error)
+				}
 			}
-			totalSize := fileInfo.Size()
+		},
+	}
 
-			// Read and send file in chunks
-			buffer := make([]byte, chunkSize)
-			totalSent := int64(0)
-			for {
-				n, err := file.Read(buffer)
-				if err == io.EOF {
-					break
-				}
-				if err != nil {
-					fmt.Printf("Error reading file: %v\n", err)
-					os.Exit(1)
-				}
+	cmd.Flags().BoolVarP(&overwrite, "overwrite", "f", false, "Replace an existing file or link")
 
-				// Send chunk
-				chunk := &proto.FileChunk{
-					FilePath: remotePath,
-					Content:  buffer[:n],
-					Offset:   totalSent,
-					IsLast:   false,
-				}
-				
-				if err := stream.Send(chunk); err != nil {
-					fmt.Printf("Error sending chunk: %v\n", err)
-					os.Exit(1)
-				}
+	return cmd
+}
 
-				totalSent += int64(n)
-				
-				// Print progress
-				if verbose {
-					progress := float64(totalSent) / float64(totalSize) * 100
-					fmt.Printf("\rUploading: %.2f%% (%d/%d bytes)", progress, totalSent, totalSize)
-				}
-			}
+// Create a new command for changing permissions
+func newChmodCommand() *cobra.Command {
+	var recursive bool
+	var fileMode, dirMode string
 
-			// Send last empty chunk to indicate end of file
-			lastChunk := &proto.FileChunk{
-				FilePath: remotePath,
-				Content:  []byte{},
-				Offset:   totalSent,
-				IsLast:   true,
-			}
-			
-			if err := stream.Send(lastChunk); err != nil {
-				fmt.Printf("\nError sending final chunk: %v\n", err)
+	cmd := &cobra.Command{
+		Use:   "chmod [mode] [path]",
+		Short: "Change permissions (octal or symbolic, e.g. 0644 or u=rwX,go=rX)",
+		Long: `Change permissions of a file or directory.
+
+The mode may be omitted when --file-mode or --dir-mode is given, e.g.
+  fsdaemon chmod -R --file-mode 0644 --dir-mode 0755 /var/www`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			var mode string
+			path := args[len(args)-1]
+			if len(args) == 2 {
+				mode = args[0]
+			} else if fileMode == "" && dirMode == "" {
+				fmt.Println("Error: a mode, --file-mode or --dir-mode is required")
 				os.Exit(1)
 			}
 
-			// Get response
-			response, err := stream.CloseAndRecv()
-			if err != nil {
-				fmt.Printf("\nError receiving response: %v\n", err)
-				os.Exit(1)
+			request := &proto.SetPermissionsRequest{
+				Path:      path,
+				Mode:      mode,
+				Recursive: recursive,
+				FileMode:  fileMode,
+				DirMode:   dirMode,
 			}
 
-			if verbose {
-				fmt.Println()
+			response, err := client.SetPermissions(ctx, request)
+			if err != nil {
+				fmt.Printf("Error changing permissions: %v\n", err)
+				os.Exit(1)
 			}
 
 			if outputFormat == "json" {
 				formatOutput(response)
 			} else {
 				if response.Success {
-					fmt.Printf("Successfully uploaded %s to %s (%d bytes)\n", localFile, remotePath, totalSent)
+					fmt.Printf("Successfully changed permissions: %s\n", path)
 				} else {
-					fmt.Printf("Failed to upload file: %s\n", response.Error)
+					fmt.Printf("Failed to change permissions: %s\n", response.Error)
 				}
 			}
 		},
 	}
 
-	cmd.Flags().IntVarP(&chunkSize, "chunk-size", "c", 1024*1024, "Chunk size in bytes")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "Change directories and their contents recursively")
+	cmd.Flags().StringVar(&fileMode, "file-mode", "", "Mode for files, overriding [mode]")
+	cmd.Flags().StringVar(&dirMode, "dir-mode", "", "Mode for directories, overriding [mode]")
 
 	return cmd
 }
 
-// Create a new command for downloading a file
-func newDownloadCommand() *cobra.Command {
+// Create a new command for changing owner and group
+func newChownCommand() *cobra.Command {
+	var recursive bool
+
 	cmd := &cobra.Command{
-		Use:   "download [remote_path] [local_file]",
-		Short: "Download a file from the server",
+		Use:   "chown [owner][:group] [path]",
+		Short: "Change owner and group (names or IDs)",
 		Args:  cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
 			defer cancel()
 
-			remotePath := args[0]
-			localFile := args[1]
-
-			// Create local file
-			file, err := os.Create(localFile)
-			if err != nil {
-				fmt.Printf("Error creating local file: %v\n", err)
-				os.Exit(1)
+			owner, group, _ := strings.Cut(args[0], ":")
+			request := &proto.SetOwnerRequest{
+				Path:      args[1],
+				Owner:     owner,
+				Group:     group,
+				Recursive: recursive,
 			}
-			defer file.Close()
 
-			// Create download stream
-			stream, err := client.DownloadFile(ctx, &proto.FileRequest{Path: remotePath})
+			response, err := client.SetOwner(ctx, request)
 			if err != nil {
-				fmt.Printf("Error creating download stream: %v\n", err)
+				fmt.Printf("Error changing owner: %v\n", err)
 				os.Exit(1)
 			}
 
-			// Receive and write chunks
-			totalReceived := int64(0)
-			for {
-				chunk, err := stream.Recv()
-				if err == io.EOF {
-					break
-				}
-				if err != nil {
-					fmt.Printf("Error receiving chunk: %v\n", err)
-					os.Exit(1)
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else {
+				if response.Success {
+					fmt.Printf("Successfully changed owner: %s\n", args[1])
+				} else {
+					fmt.Printf("Failed to change owner: %s\n", response.Error)
 				}
+			}
+		},
+	}
 
-				// Write chunk to file
-				n, err := file.Write(chunk.Content)
+	cmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "Change directories and their contents recursively")
+
+	return cmd
+}
+
+// Create a new command for changing timestamps
+func newTouchCommand() *cobra.Command {
+	var noCreate, accessOnly, modifiedOnly bool
+	var date string
+
+	cmd := &cobra.Command{
+		Use:   "touch [path]",
+		Short: "Update timestamps, creating an empty file if needed",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			// 0 asks the daemon for the current time
+			var timestamp int64
+			if date != "" {
+				seconds, err := parseTimeBound(date)
 				if err != nil {
-					fmt.Printf("Error writing to file: %v\n", err)
+					fmt.Printf("Invalid --date: %v\n", err)
 					os.Exit(1)
 				}
+				timestamp = seconds * int64(time.Second)
+			}
 
-				totalReceived += int64(n)
-				
-				// Print progress
-				if verbose {
-					fmt.Printf("\rDownloading: %d bytes received", totalReceived)
-				}
+			// -1 leaves a timestamp unchanged
+			accessTime, modifiedTime := timestamp, timestamp
+			if accessOnly && !modifiedOnly {
+				modifiedTime = -1
+			}
+			if modifiedOnly && !accessOnly {
+				accessTime = -1
+			}
 
-				if chunk.IsLast {
-					break
-				}
+			request := &proto.SetTimesRequest{
+				Path:           args[0],
+				ModifiedTimeNs: modifiedTime,
+				AccessTimeNs:   accessTime,
+				Create:         !noCreate,
 			}
 
-			if verbose {
-				fmt.Println()
+			response, err := client.SetTimes(ctx, request)
+			if err != nil {
+				fmt.Printf("Error touching file: %v\n", err)
+				os.Exit(1)
 			}
 
 			if outputFormat == "json" {
-				result := map[string]interface{}{
-					"success": true,
-					"bytes_received": totalReceived,
-					"local_file": localFile,
-					"remote_path": remotePath,
-				}
-				formatOutput(result)
+				formatOutput(response)
 			} else {
-				fmt.Printf("Successfully downloaded %s to %s (%d bytes)\n", remotePath, localFile, totalReceived)
+				if response.Success {
+					fmt.Printf("Successfully touched: %s\n", args[0])
+				} else {
+					fmt.Printf("Failed to touch file: %s\n", response.Error)
+				}
 			}
 		},
 	}
 
+	cmd.Flags().BoolVarP(&noCreate, "no-create", "c", false, "Do not create the file if it does not exist")
+	cmd.Flags().BoolVarP(&accessOnly, "access", "a", false, "Change only the access time")
+	cmd.Flags().BoolVarP(&modifiedOnly, "modified", "m", false, "Change only the modification time")
+	cmd.Flags().StringVarP(&date, "date", "d", "", "Time to set instead of now: a date (2006-01-02 15:04:05) or a duration ago (24h, 7d)")
+
 	return cmd
 }
 
-// Create a new command for searching files
-func newSearchCommand() *cobra.Command {
-	var (
-		caseSensitive  bool
-		recursive      bool
-		directoriesOnly bool
-		filesOnly      bool
-		maxResults     int
-	)
-
+// Create a new command for managing extended attributes
+func newXattrCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "search [path] [pattern]",
-		Short: "Search for files and directories",
-		Args:  cobra.ExactArgs(2),
+		Use:   "xattr",
+		Short: "Get, set and remove extended attributes",
+	}
+
+	get := &cobra.Command{
+		Use:   "get [path] [name]",
+		Short: "Show all extended attributes, or the one named",
+		Args:  cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
 			defer cancel()
 
-			request := &proto.SearchRequest{
-				BasePath:        args[0],
-				Pattern:         args[1],
-				CaseSensitive:   caseSensitive,
-				Recursive:       recursive,
-				DirectoriesOnly: directoriesOnly,
-				FilesOnly:       filesOnly,
-				MaxResults:      int32(maxResults),
+			request := &proto.XattrRequest{Path: args[0]}
+			if len(args) == 2 {
+				request.Name = args[1]
 			}
 
-			response, err := client.Search(ctx, request)
+			response, err := client.GetXattrs(ctx, request)
 			if err != nil {
-				fmt.Printf("Error searching: %v\n", err)
+				fmt.Printf("Error getting attributes: %v\n", err)
 				os.Exit(1)
 			}
 
 			if outputFormat == "json" {
 				formatOutput(response)
 			} else {
-				fmt.Printf("Search results for pattern '%s' in '%s':\n", args[1], args[0])
-				fmt.Println("Type\tSize\tModified\t\tPath")
-				fmt.Println("--------------------------------------------------------------")
-				for _, item := range response.Items {
-					fileType := "F"
-					if item.IsDirectory {
-						fileType = "D"
-					}
-					modTime := time.Unix(item.ModifiedTime, 0).Format("2006-01-02 15:04:05")
-					fmt.Printf("%s\t%d\t%s\t%s\n", fileType, item.Size, modTime, item.Path)
+				for _, xattr := range response.Xattrs {
+					fmt.Printf("%s=%s\n", xattr.Name, formatXattrValue(xattr.Value))
 				}
-				fmt.Printf("\nTotal matches: %d\n", len(response.Items))
 			}
 		},
 	}
 
-	cmd.Flags().BoolVarP(&caseSensitive, "case-sensitive", "c", false, "Use case-sensitive matching")
-	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Search recursively")
-	cmd.Flags().BoolVarP(&directoriesOnly, "dirs-only", "d", false, "Match directories only")
-	cmd.Flags().BoolVarP(&filesOnly, "files-only", "f", false, "Match files only")
-	cmd.Flags().IntVarP(&maxResults, "max-results", "m", 100, "Maximum number of results")
+	var create, replace bool
+	set := &cobra.Command{
+		Use:   "set [path] [name] [value]",
+		Short: "Set an extended attribute",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
 
-	return cmd
-}
+			request := &proto.SetXattrRequest{
+				Path:    args[0],
+				Name:    args[1],
+				Value:   []byte(args[2]),
+				Create:  create,
+				Replace: replace,
+			}
 
-// Create a new command for getting directory hierarchy
-func newHierarchyCommand() *cobra.Command {
-	var (
-		maxDepth int
-		pattern  string
+			response, err := client.SetXattr(ctx, request)
+			if err != nil {
+				fmt.Printf("Error setting attribute: %v\n", err)
+				os.Exit(1)
+			}
+			printOperation(response, "Successfully set "+args[1], "Failed to set attribute")
+		},
+	}
+	set.Flags().BoolVar(&create, "create", false, "Fail if the attribute already exists")
+	set.Flags().BoolVar(&replace, "replace", false, "Fail if the attribute does not exist")
+
+	remove := &cobra.Command{
+		Use:     "rm [path] [name]",
+		Aliases: []string{"remove"},
+		Short:   "Remove an extended attribute",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			response, err := client.RemoveXattr(ctx, &proto.XattrRequest{Path: args[0], Name: args[1]})
+			if err != nil {
+				fmt.Printf("Error removing attribute: %v\n", err)
+				os.Exit(1)
+			}
+			printOperation(response, "Successfully removed "+args[1], "Failed to remove attribute")
+		},
+	}
+
+	cmd.AddCommand(get, set, remove)
+	return cmd
+}
+
+// Create a new command for managing POSIX ACLs
+func newAclCommand() *cobra.Command {
+	var defaultACL bool
+
+	cmd := &cobra.Command{
+		Use:   "acl",
+		Short: "Get and set POSIX ACLs",
+	}
+	cmd.PersistentFlags().BoolVarP(&defaultACL, "default", "d", false, "Use the default ACL of a directory")
+
+	get := &cobra.Command{
+		Use:   "get [path]",
+		Short: "Show the ACL of a file or directory",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			response, err := client.GetAcl(ctx, &proto.AclRequest{Path: args[0], Default: defaultACL})
+			if err != nil {
+				fmt.Printf("Error getting ACL: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else if response.Text != "" {
+				// One entry per line, as getfacl prints them
+				fmt.Println(strings.ReplaceAll(response.Text, ",", "\n"))
+			}
+		},
+	}
+
+	set := &cobra.Command{
+		Use:   "set [path] [acl]",
+		Short: "Replace an ACL, e.g. u::rw-,u:alice:r--,g::r--,o::---",
+		Long: `Replace the ACL of a file or directory. A mask entry is added when
+named entries are given without one. With --default an empty ACL ("")
+removes the default ACL.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.SetAclRequest{Path: args[0], Text: args[1], Default: defaultACL}
+			response, err := client.SetAcl(ctx, request)
+			if err != nil {
+				fmt.Printf("Error setting ACL: %v\n", err)
+				os.Exit(1)
+			}
+			printOperation(response, "Successfully set ACL: "+args[0], "Failed to set ACL")
+		},
+	}
+
+	cmd.AddCommand(get, set)
+	return cmd
+}
+
+// Print an OperationResponse as JSON or as a success or failure line
+func printOperation(response *proto.OperationResponse, success, failure string) {
+	if outputFormat == "json" {
+		formatOutput(response)
+	} else if response.Success {
+		fmt.Println(success)
+	} else {
+		fmt.Printf("%s: %s\n", failure, response.Error)
+	}
+}
+
+// Format an attribute value as text when printable, as hex otherwise
+func formatXattrValue(value []byte) string {
+	text := strings.TrimRight(string(value), "\x00")
+	if utf8.ValidString(text) && !strings.ContainsFunc(text, func(r rune) bool { return !unicode.IsPrint(r) }) {
+		return strconv.Quote(text)
+	}
+	return "0x" + hex.EncodeToString(value)
+}
+
+// Create a new command for writing bytes at an offset
+func newWriteCommand() *cobra.Command {
+	var offset int64
+	var data, localFile string
+	var create, sync bool
+	var precondition preconditionFlags
+
+	cmd := &cobra.Command{
+		Use:   "write [path]",
+		Short: "Write data at an offset of a remote file",
+		Long: `Write data at an offset of a remote file without re-uploading it.
+The data comes from --data, --file or standard input.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			content, err := readInput(data, localFile)
+			if err != nil {
+				fmt.Printf("Error reading data: %v\n", err)
+				os.Exit(1)
+			}
+
+			request := &proto.WriteRequest{
+				Path:         args[0],
+				Offset:       offset,
+				Data:         content,
+				Create:       create,
+				Precondition: precondition.build(),
+				Sync:         sync,
+			}
+
+			response, err := client.WriteFile(ctx, request)
+			if err != nil {
+				fmt.Printf("Error writing file: %v\n", err)
+				os.Exit(1)
+			}
+			printWrite(response, args[0])
+		},
+	}
+
+	cmd.Flags().Int64Var(&offset, "offset", 0, "Byte offset to write at")
+	cmd.Flags().StringVar(&data, "data", "", "Data to write")
+	cmd.Flags().StringVar(&localFile, "file", "", "Local file with the data to write")
+	cmd.Flags().BoolVar(&create, "create", false, "Create the file if it does not exist")
+	cmd.Flags().BoolVar(&sync, "sync", false, "Flush the data to disk before returning")
+	precondition.register(cmd)
+
+	return cmd
+}
+
+// Create a new command for appending to a file
+func newAppendCommand() *cobra.Command {
+	var localFile string
+	var create, sync, lines bool
+	var precondition preconditionFlags
+
+	cmd := &cobra.Command{
+		Use:   "append [path] [text]",
+		Short: "Append text, a local file or standard input to a remote file",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			var text string
+			if len(args) == 2 {
+				text = args[1]
+			}
+			content, err := readInput(text, localFile)
+			if err != nil {
+				fmt.Printf("Error reading data: %v\n", err)
+				os.Exit(1)
+			}
+
+			request := &proto.AppendRequest{
+				Path:         args[0],
+				Data:         content,
+				Create:       create,
+				Precondition: precondition.build(),
+				Sync:         sync,
+				Lines:        lines,
+			}
+
+			response, err := client.AppendFile(ctx, request)
+			if err != nil {
+				fmt.Printf("Error appending to file: %v\n", err)
+				os.Exit(1)
+			}
+			printWrite(response, args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&localFile, "file", "", "Local file with the data to append")
+	cmd.Flags().BoolVar(&create, "create", false, "Create the file if it does not exist")
+	cmd.Flags().BoolVar(&sync, "sync", false, "Flush the data to disk before returning")
+	cmd.Flags().BoolVarP(&lines, "lines", "l", false, "Append as a whole line that never interleaves with other writers")
+	precondition.register(cmd)
+
+	return cmd
+}
+
+// Create a new command for truncating a file
+func newTruncateCommand() *cobra.Command {
+	var precondition preconditionFlags
+
+	cmd := &cobra.Command{
+		Use:   "truncate [path] [size]",
+		Short: "Shrink or extend a remote file to a size (e.g. 0, 512, 10K)",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			size, err := parseSize(args[1])
+			if err != nil {
+				fmt.Printf("Invalid size: %v\n", err)
+				os.Exit(1)
+			}
+
+			request := &proto.TruncateRequest{
+				Path:         args[0],
+				Size:         size,
+				Precondition: precondition.build(),
+			}
+
+			response, err := client.Truncate(ctx, request)
+			if err != nil {
+				fmt.Printf("Error truncating file: %v\n", err)
+				os.Exit(1)
+			}
+			printWrite(response, args[0])
+		},
+	}
+
+	precondition.register(cmd)
+
+	return cmd
+}
+
+// preconditionFlags are the --if-size, --if-mtime and --if-match flags of editing commands
+type preconditionFlags struct {
+	size    int64
+	modTime int64
+	etag    string
+}
+
+func (p *preconditionFlags) register(cmd *cobra.Command) {
+	cmd.Flags().Int64Var(&p.size, "if-size", -1, "Only change the file if its size is this many bytes")
+	cmd.Flags().Int64Var(&p.modTime, "if-mtime", 0, "Only change the file if its modification time is this (Unix nanoseconds)")
+	cmd.Flags().StringVar(&p.etag, "if-match", "", "Only change the file if it has one of these ETags")
+}
+
+// build returns the precondition, or nil when no flag was given
+func (p *preconditionFlags) build() *proto.WritePrecondition {
+	if p.size < 0 && p.modTime == 0 && p.etag == "" {
+		return nil
+	}
+	precondition := &proto.WritePrecondition{ModifiedTimeNs: p.modTime, Etag: p.etag}
+	if p.size >= 0 {
+		precondition.Size = &p.size
+	}
+	return precondition
+}
+
+// etagFlags are the --if-match and --if-none-match flags of mutating commands
+type etagFlags struct {
+	ifMatch     string
+	ifNoneMatch string
+}
+
+func (e *etagFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&e.ifMatch, "if-match", "", "Only proceed if the target has one of these ETags (\"*\" for any)")
+	cmd.Flags().StringVar(&e.ifNoneMatch, "if-none-match", "", "Only proceed if the target has none of these ETags (\"*\" if it must not exist)")
+}
+
+// Read command input from a literal, a local file or standard input
+func readInput(literal, localFile string) ([]byte, error) {
+	switch {
+	case literal != "":
+		return []byte(literal), nil
+	case localFile != "":
+		return os.ReadFile(localFile)
+	default:
+		return io.ReadAll(os.Stdin)
+	}
+}
+
+// Print a WriteResponse with the resulting size, mtime and ETag for follow-up --if-* flags
+func printWrite(response *proto.WriteResponse, path string) {
+	if outputFormat == "json" {
+		formatOutput(response)
+	} else if response.Success {
+		fmt.Printf("%s: %s (size %d, mtime %d, etag %s)\n", response.Message, path, response.Size, response.ModifiedTimeNs, response.Etag)
+	} else {
+		fmt.Printf("Failed to write %s: %s\n", path, response.Error)
+	}
+}
+
+// Create a new command for managing lease locks
+func newLockCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Take, renew, release and list lease locks",
+		Long: `Lease locks coordinate changes between clients. While a lease is held on a
+path, changes to it, its parents or anything below it are refused unless
+the command presents the lease with --lease.`,
+	}
+
+	var shared, useFlock bool
+	var ttl int64
+	var owner string
+	acquire := &cobra.Command{
+		Use:   "acquire [path]",
+		Short: "Take a lease and print its ID",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.LockRequest{
+				Path:       args[0],
+				TtlSeconds: ttl,
+				Owner:      owner,
+				Flock:      useFlock,
+			}
+			if shared {
+				request.Mode = proto.LockMode_LOCK_SHARED
+			}
+
+			response, err := client.AcquireLock(ctx, request)
+			if err != nil {
+				fmt.Printf("Error acquiring lock: %v\n", err)
+				os.Exit(1)
+			}
+			printLock(response)
+		},
+	}
+	acquire.Flags().BoolVar(&shared, "shared", false, "Take a shared lease instead of an exclusive one")
+	acquire.Flags().Int64Var(&ttl, "ttl", 0, "Lease lifetime in seconds (default 60)")
+	acquire.Flags().StringVar(&owner, "owner", "", "Description of the holder shown to others")
+	acquire.Flags().BoolVar(&useFlock, "flock", false, "Also flock the path so local processes see the lock")
+
+	var renewTTL int64
+	renew := &cobra.Command{
+		Use:   "renew [lease_id]",
+		Short: "Extend a lease",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			response, err := client.RenewLock(ctx, &proto.RenewLockRequest{LeaseId: args[0], TtlSeconds: renewTTL})
+			if err != nil {
+				fmt.Printf("Error renewing lock: %v\n", err)
+				os.Exit(1)
+			}
+			printLock(response)
+		},
+	}
+	renew.Flags().Int64Var(&renewTTL, "ttl", 0, "New lifetime in seconds (default: keep the current one)")
+
+	release := &cobra.Command{
+		Use:   "release [lease_id]",
+		Short: "Give up a lease",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			response, err := client.ReleaseLock(ctx, &proto.LeaseRequest{LeaseId: args[0]})
+			if err != nil {
+				fmt.Printf("Error releasing lock: %v\n", err)
+				os.Exit(1)
+			}
+			printOperation(response, "Successfully released "+args[0], "Failed to release lock")
+		},
+	}
+
+	list := &cobra.Command{
+		Use:     "list [path]",
+		Aliases: []string{"ls"},
+		Short:   "List the leases on a path, its parents and below it",
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.PathRequest{Path: "."}
+			if len(args) == 1 {
+				request.Path = args[0]
+			}
+
+			response, err := client.ListLocks(ctx, request)
+			if err != nil {
+				fmt.Printf("Error listing locks: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else {
+				for _, lock := range response.Locks {
+					fmt.Printf("%s  %-9s  %-30s  expires %s  %s\n", lock.LeaseId, lockModeString(lock.Mode), lock.Path,
+						formatTimestamp(lock.ExpiresTime, 0), lock.Owner)
+				}
+			}
+		},
+	}
+
+	cmd.AddCommand(acquire, renew, release, list)
+	return cmd
+}
+
+// Print a held lease
+func printLock(lock *proto.Lock) {
+	if outputFormat == "json" {
+		formatOutput(lock)
+		return
+	}
+	fmt.Printf("Lease:    %s\n", lock.LeaseId)
+	fmt.Printf("Path:     %s\n", lock.Path)
+	fmt.Printf("Mode:     %s\n", lockModeString(lock.Mode))
+	if lock.Owner != "" {
+		fmt.Printf("Owner:    %s\n", lock.Owner)
+	}
+	fmt.Printf("Expires:  %s (TTL %ds)\n", formatTimestamp(lock.ExpiresTime, 0), lock.TtlSeconds)
+	if lock.Flock {
+		fmt.Println("Flock:    held")
+	}
+}
+
+func lockModeString(mode proto.LockMode) string {
+	if mode == proto.LockMode_LOCK_SHARED {
+		return "shared"
+	}
+	return "exclusive"
+}
+
+// Create a new command for browsing and restoring saved versions
+func newVersionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "versions",
+		Short: "List and restore previous versions of files",
+		Long: `The daemon keeps the previous content of files in versioned directories
+whenever an upload, copy, move or delete replaces them. Use
+"download --version" to fetch a version without restoring it.`,
+	}
+
+	list := &cobra.Command{
+		Use:     "list [path]",
+		Aliases: []string{"ls"},
+		Short:   "List the saved versions of a file, newest first",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			response, err := client.ListVersions(ctx, &proto.PathRequest{Path: args[0]})
+			if err != nil {
+				fmt.Printf("Error listing versions: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else {
+				for _, version := range response.Versions {
+					fmt.Printf("%s  %-8s  %10s  modified %s\n", version.VersionId, version.Reason, formatSize(version.Size),
+						formatTimestamp(0, version.ModifiedTimeNs))
+				}
+			}
+		},
+	}
+
+	var etags etagFlags
+	restore := &cobra.Command{
+		Use:   "restore [path] [version_id]",
+		Short: "Put a saved version back in place",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.RestoreVersionRequest{
+				Path:        args[0],
+				VersionId:   args[1],
+				IfMatch:     etags.ifMatch,
+				IfNoneMatch: etags.ifNoneMatch,
+			}
+
+			response, err := client.RestoreVersion(ctx, request)
+			if err != nil {
+				fmt.Printf("Error restoring version: %v\n", err)
+				os.Exit(1)
+			}
+			printOperation(response, "Successfully restored "+args[0]+" to "+args[1], "Failed to restore version")
+		},
+	}
+	etags.register(restore)
+
+	cmd.AddCommand(list, restore)
+	return cmd
+}
+
+// Create a new command for managing directory snapshots
+func newSnapshotCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Create, compare and restore directory snapshots",
+		Long: `Snapshots capture a directory tree on the server. Files that did not
+change since the previous snapshot of the same directory are hard links
+to it, so only changed files take up space.`,
+	}
+
+	var name string
+	var xattrs bool
+	create := &cobra.Command{
+		Use:   "create [path]",
+		Short: "Snapshot a directory",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.CreateSnapshotRequest{
+				Path:           args[0],
+				Name:           name,
+				PreserveXattrs: xattrs,
+			}
+
+			response, err := client.CreateSnapshot(ctx, request)
+			if err != nil {
+				fmt.Printf("Error creating snapshot: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else {
+				fmt.Printf("Created snapshot %s of %s: %d files, %s, %d unchanged\n", response.Name, response.Path,
+					response.Files, formatSize(response.Size), response.LinkedFiles)
+			}
+		},
+	}
+	create.Flags().StringVar(&name, "name", "", "Snapshot name (default: the current UTC time)")
+	create.Flags().BoolVar(&xattrs, "xattrs", false, "Also capture extended attributes")
+
+	list := &cobra.Command{
+		Use:     "list [path]",
+		Aliases: []string{"ls"},
+		Short:   "List snapshots, newest first",
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.ListSnapshotsRequest{}
+			if len(args) == 1 {
+				request.Path = args[0]
+			}
+
+			response, err := client.ListSnapshots(ctx, request)
+			if err != nil {
+				fmt.Printf("Error listing snapshots: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else {
+				for _, snapshot := range response.Snapshots {
+					fmt.Printf("%-24s  %-30s  %s  %6d files  %10s\n", snapshot.Name, snapshot.Path,
+						formatTimestamp(snapshot.CreatedTime, 0), snapshot.Files, formatSize(snapshot.Size))
+				}
+			}
+		},
+	}
+
+	diff := &cobra.Command{
+		Use:   "diff [name]",
+		Short: "Show what changed in the directory since a snapshot",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			response, err := client.DiffSnapshot(ctx, &proto.SnapshotRequest{Name: args[0]})
+			if err != nil {
+				fmt.Printf("Error comparing snapshot: %v\n", err)
+				os.Exit(1)
+			}
+			printChanges(response)
+		},
+	}
+
+	var destination string
+	var deleteExtra bool
+	restore := &cobra.Command{
+		Use:   "restore [name]",
+		Short: "Copy a snapshot back to its directory or another one",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.RestoreSnapshotRequest{
+				Name:        args[0],
+				Destination: destination,
+				DeleteExtra: deleteExtra,
+			}
+
+			response, err := client.RestoreSnapshot(ctx, request)
+			if err != nil {
+				fmt.Printf("Error restoring snapshot: %v\n", err)
+				os.Exit(1)
+			}
+			printOperation(response, "Successfully restored "+args[0], "Failed to restore snapshot")
+		},
+	}
+	restore.Flags().StringVar(&destination, "to", "", "Restore into this directory instead of the original one")
+	restore.Flags().BoolVar(&deleteExtra, "delete", false, "Delete files that are not in the snapshot")
+
+	del := &cobra.Command{
+		Use:     "delete [name]",
+		Aliases: []string{"rm"},
+		Short:   "Delete a snapshot",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			response, err := client.DeleteSnapshot(ctx, &proto.SnapshotRequest{Name: args[0]})
+			if err != nil {
+				fmt.Printf("Error deleting snapshot: %v\n", err)
+				os.Exit(1)
+			}
+			printOperation(response, "Successfully deleted "+args[0], "Failed to delete snapshot")
+		},
+	}
+
+	cmd.AddCommand(create, list, diff, restore, del)
+	return cmd
+}
+
+// Print a list of changes, one per line
+func printChanges(response *proto.DiffResponse) {
+	if outputFormat == "json" {
+		formatOutput(response)
+		return
+	}
+	for _, change := range response.Changes {
+		printChange(change)
+	}
+}
+
+// Print a change with a +, - or ~ marker and for modified entries what differs
+func printChange(change *proto.PathChange) {
+	switch change.Change {
+	case proto.ChangeType_CHANGE_ADDED:
+		fmt.Printf("+ %s\n", change.Path)
+	case proto.ChangeType_CHANGE_DELETED:
+		fmt.Printf("- %s\n", change.Path)
+	default:
+		fmt.Printf("~ %s (%s)\n", change.Path, strings.Join(change.Differences, ", "))
+	}
+}
+
+// Create a new command for comparing two directories
+func newDiffCommand() *cobra.Command {
+	var mode string
+
+	cmd := &cobra.Command{
+		Use:   "diff [left_dir] [right_dir]",
+		Short: "Compare two directories on the server",
+		Long: `Lists entries only in the left directory (-), only in the right one (+)
+and in both but different (~), with paths relative to the directories.
+Sizes, types and symlink targets are always compared; --mode adds
+modification times (quick), permissions and owners (metadata) or a
+SHA-256 of the content (content).`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			compareMode, ok := map[string]proto.CompareMode{
+				"quick":    proto.CompareMode_COMPARE_QUICK,
+				"metadata": proto.CompareMode_COMPARE_METADATA,
+				"content":  proto.CompareMode_COMPARE_CONTENT,
+			}[mode]
+			if !ok {
+				fmt.Printf("Error: invalid mode %q (want quick, metadata or content)\n", mode)
+				os.Exit(1)
+			}
+
+			request := &proto.CompareRequest{
+				LeftPath:  args[0],
+				RightPath: args[1],
+				Mode:      compareMode,
+			}
+
+			stream, err := client.CompareDirectories(ctx, request)
+			if err != nil {
+				fmt.Printf("Error comparing directories: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Paths come back in the right directory
+			prefix := strings.TrimPrefix(path.Clean("/"+args[1]), "/") + "/"
+			var changes []*proto.PathChange
+			for {
+				change, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Printf("Error receiving difference: %v\n", err)
+					os.Exit(1)
+				}
+
+				if outputFormat == "json" {
+					changes = append(changes, change)
+					continue
+				}
+				change.Path = strings.TrimPrefix(change.Path, prefix)
+				printChange(change)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(changes)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&mode, "mode", "quick", "What to compare besides sizes: quick, metadata or content")
+
+	return cmd
+}
+
+// Create a new command for showing a unified diff of two files
+func newDiffFileCommand() *cobra.Command {
+	var (
+		localFile        string
+		contextLines     int
+		ignoreWhitespace bool
+		maxSize          string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff-file [path] [other_path]",
+		Short: "Show a unified diff between two remote files, or a remote and a local file",
+		Long: `Compares two files on the server, or with --local the remote file with
+a local one, for example to review an upload before making it. The
+printed ETag can be passed to "patch --if-match".`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.DiffFilesRequest{
+				Path:             args[0],
+				ContextLines:     int32(contextLines),
+				IgnoreWhitespace: ignoreWhitespace,
+			}
+			switch {
+			case len(args) == 2 && localFile == "":
+				request.OtherPath = args[1]
+			case len(args) == 1 && localFile != "":
+				content, err := os.ReadFile(localFile)
+				if err != nil {
+					fmt.Printf("Error reading local file: %v\n", err)
+					os.Exit(1)
+				}
+				request.Content = content
+			default:
+				fmt.Println("Error: give either a second remote path or --local")
+				os.Exit(1)
+			}
+			if maxSize != "" {
+				size, err := parseSize(maxSize)
+				if err != nil {
+					fmt.Printf("Invalid size: %v\n", err)
+					os.Exit(1)
+				}
+				request.MaxSize = size
+			}
+
+			response, err := client.DiffFiles(ctx, request)
+			if err != nil {
+				fmt.Printf("Error comparing files: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+				return
+			}
+			switch {
+			case response.Identical:
+				fmt.Println("Files are identical")
+			case response.Binary:
+				fmt.Println("Binary files differ")
+			default:
+				fmt.Print(response.Diff)
+			}
+			if verbose {
+				fmt.Printf("ETag: %s\n", response.Etag)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&localFile, "local", "", "Compare with this local file instead of a remote one")
+	cmd.Flags().IntVarP(&contextLines, "unified", "U", 3, "Lines of context around each change")
+	cmd.Flags().BoolVarP(&ignoreWhitespace, "ignore-all-space", "w", false, "Ignore white space when comparing lines")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Refuse larger files (e.g. 512K, 10M; default 10M)")
+
+	return cmd
+}
+
+// Create a new command for applying a unified diff to a remote file
+func newPatchCommand() *cobra.Command {
+	var precondition preconditionFlags
+
+	cmd := &cobra.Command{
+		Use:   "patch [path] [patch_file]",
+		Short: "Apply a unified diff to a remote file (reads stdin without patch_file)",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			var patchFile string
+			if len(args) == 2 {
+				patchFile = args[1]
+			}
+			patch, err := readInput("", patchFile)
+			if err != nil {
+				fmt.Printf("Error reading patch: %v\n", err)
+				os.Exit(1)
+			}
+
+			request := &proto.ApplyPatchRequest{
+				Path:         args[0],
+				Patch:        string(patch),
+				Precondition: precondition.build(),
+			}
+
+			response, err := client.ApplyPatch(ctx, request)
+			if err != nil {
+				fmt.Printf("Error applying patch: %v\n", err)
+				os.Exit(1)
+			}
+			printWrite(response, args[0])
+		},
+	}
+
+	precondition.register(cmd)
+
+	return cmd
+}
+
+// Create a new command for transferring only the changed parts of files
+func newSyncCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Upload or download a file, transferring only what changed",
+		Long: `Uses the rsync algorithm: the side with the old copy of a file sends
+signatures of its blocks, and the other side sends only the data that
+is not in those blocks. The result is checked against a SHA-256 of the
+source and then replaces the old copy atomically.`,
+	}
+
+	upload := &cobra.Command{
+		Use:   "upload [local_file] [remote_path]",
+		Short: "Update a remote file from a local one",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				fmt.Printf("Error opening local file: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			info, err := file.Stat()
+			if err != nil {
+				fmt.Printf("Error getting file info: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Fetch the signature of the remote copy; without one everything is sent
+			first := &proto.SignatureChunk{BlockSize: delta.BlockSize(info.Size())}
+			var blocks []delta.Block
+			signatures, err := client.GetSignature(ctx, &proto.SignatureRequest{Path: args[1]})
+			for err == nil {
+				var chunk *proto.SignatureChunk
+				chunk, err = signatures.Recv()
+				if err != nil {
+					break
+				}
+				if chunk.BlockSize != 0 {
+					first = chunk
+				}
+				for _, block := range chunk.Blocks {
+					blocks = append(blocks, delta.Block{Weak: block.Weak, Strong: block.Strong})
+				}
+			}
+			if err != io.EOF && status.Code(err) != codes.NotFound {
+				fmt.Printf("Error getting signature: %v\n", err)
+				os.Exit(1)
+			}
+
+			stream, err := client.ApplyDelta(ctx)
+			if err != nil {
+				fmt.Printf("Error creating delta stream: %v\n", err)
+				os.Exit(1)
+			}
+
+			hash := sha256.New()
+			chunk := &proto.DeltaChunk{Path: args[1], BlockSize: first.BlockSize, BasisEtag: first.Etag}
+			batched := 0
+			err = delta.Diff(io.TeeReader(file, hash), first.BlockSize, blocks, func(op delta.Op) error {
+				chunk.Ops = append(chunk.Ops, &proto.DeltaOp{BlockIndex: op.Index, BlockCount: op.Count, Data: op.Data})
+				batched += len(op.Data)
+				if batched < 1024*1024 {
+					return nil
+				}
+				if err := stream.Send(chunk); err != nil {
+					return err
+				}
+				chunk, batched = &proto.DeltaChunk{}, 0
+				return nil
+			})
+			if err == nil {
+				chunk.Sha256 = hash.Sum(nil)
+				err = stream.Send(chunk)
+			}
+			if err != nil && err != io.EOF {
+				fmt.Printf("Error sending delta: %v\n", err)
+				os.Exit(1)
+			}
+
+			response, err := stream.CloseAndRecv()
+			if err != nil {
+				fmt.Printf("Error syncing file: %v\n", err)
+				os.Exit(1)
+			}
+			printWrite(response, args[1])
+		},
+	}
+
+	download := &cobra.Command{
+		Use:   "download [remote_path] [local_file]",
+		Short: "Update a local file from a remote one",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			localFile := args[1]
+			var basis *os.File
+			var basisInfo os.FileInfo
+			blockSize := delta.BlockSize(0)
+			if file, err := os.Open(localFile); err == nil {
+				defer file.Close()
+				if basisInfo, err = file.Stat(); err != nil {
+					fmt.Printf("Error getting file info: %v\n", err)
+					os.Exit(1)
+				}
+				basis, blockSize = file, delta.BlockSize(basisInfo.Size())
+			} else if !os.IsNotExist(err) {
+				fmt.Printf("Error opening local file: %v\n", err)
+				os.Exit(1)
+			}
+
+			stream, err := client.GetDelta(ctx)
+			if err != nil {
+				fmt.Printf("Error creating delta stream: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Send the signature of the local copy
+			chunk := &proto.SignatureChunk{Path: args[0], BlockSize: blockSize}
+			if basis != nil {
+				chunk.FileSize = basisInfo.Size()
+				err = delta.Sign(basis, blockSize, func(block delta.Block) error {
+					chunk.Blocks = append(chunk.Blocks, &proto.BlockSignature{Weak: block.Weak, Strong: block.Strong})
+					if len(chunk.Blocks) < 4096 {
+						return nil
+					}
+					if err := stream.Send(chunk); err != nil {
+						return err
+					}
+					chunk = &proto.SignatureChunk{}
+					return nil
+				})
+			}
+			if err == nil {
+				err = stream.Send(chunk)
+			}
+			if err == nil {
+				err = stream.CloseSend()
+			}
+			if err != nil && err != io.EOF {
+				fmt.Printf("Error sending signature: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Rebuild the file next to the local copy
+			tmp, err := os.CreateTemp(filepath.Dir(localFile), "."+filepath.Base(localFile)+".sync-*")
+			if err != nil {
+				fmt.Printf("Error creating local file: %v\n", err)
+				os.Exit(1)
+			}
+			defer os.Remove(tmp.Name())
+			defer tmp.Close()
+
+			var basisAt io.ReaderAt = strings.NewReader("")
+			var basisSize int64
+			if basis != nil {
+				basisAt, basisSize = basis, basisInfo.Size()
+			}
+			hash := sha256.New()
+			out := io.MultiWriter(tmp, hash)
+			var expected []byte
+			var received, total int64
+			for {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Printf("Error receiving delta: %v\n", err)
+					os.Exit(1)
+				}
+				for _, op := range chunk.Ops {
+					if err := delta.Apply(out, basisAt, basisSize, blockSize, delta.Op{Index: op.BlockIndex, Count: op.BlockCount, Data: op.Data}); err != nil {
+						fmt.Printf("Error applying delta: %v\n", err)
+						os.Exit(1)
+					}
+					received += int64(len(op.Data))
+				}
+				if len(chunk.Sha256) > 0 {
+					expected = chunk.Sha256
+				}
+			}
+			if expected == nil || string(hash.Sum(nil)) != string(expected) {
+				fmt.Println("Error: checksum of the rebuilt file does not match")
+				os.Exit(1)
+			}
+
+			mode := os.FileMode(0644)
+			if basisInfo != nil {
+				mode = basisInfo.Mode().Perm()
+			}
+			if info, err := tmp.Stat(); err == nil {
+				total = info.Size()
+			}
+			err = tmp.Chmod(mode)
+			if err == nil {
+				err = tmp.Close()
+			}
+			if err == nil {
+				err = os.Rename(tmp.Name(), localFile)
+			}
+			if err != nil {
+				fmt.Printf("Error replacing local file: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Synced %s to %s (%s of %s transferred)\n", args[0], localFile, formatSize(received), formatSize(total))
+		},
+	}
+
+	cmd.AddCommand(upload, download)
+	return cmd
+}
+
+// Create a new command for generating and verifying manifests
+func newManifestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "manifest",
+		Short: "Record and check the SHA-256 of every file in a directory",
+		Long: `A manifest lists the regular files below a directory with their SHA-256.
+In text output it uses the format of sha256sum, so "sha256sum -c" can check
+a copy of the directory; with -o json it also records sizes, permissions
+and modification times. verify accepts either format.`,
+	}
+
+	var exclude []string
+	create := &cobra.Command{
+		Use:   "create [path]",
+		Short: "Print the manifest of a directory",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			stream, err := client.GenerateManifest(ctx, &proto.ManifestRequest{Path: args[0], Exclude: exclude})
+			if err != nil {
+				fmt.Printf("Error generating manifest: %v\n", err)
+				os.Exit(1)
+			}
+
+			var entries []*proto.ManifestEntry
+			for {
+				entry, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Printf("Error receiving manifest entry: %v\n", err)
+					os.Exit(1)
+				}
+				if outputFormat == "json" {
+					entries = append(entries, entry)
+					continue
+				}
+				fmt.Print(sha256sumLine(entry))
+			}
+
+			if outputFormat == "json" {
+				if entries == nil {
+					entries = []*proto.ManifestEntry{}
+				}
+				formatOutput(map[string]interface{}{"files": entries})
+			}
+		},
+	}
+	create.Flags().StringArrayVar(&exclude, "exclude", nil, "Leave out entries matching a gitignore-style pattern (repeatable)")
+
+	var verifyExclude []string
+	var checkMetadata bool
+	verify := &cobra.Command{
+		Use:   "verify [path] [manifest_file]",
+		Short: "Report missing, extra and modified files of a directory",
+		Long: `Checks a directory on the server against a manifest, "-" for standard
+input. Files in the manifest but not in the directory are listed with -,
+files only in the directory with + and files that differ with ~. The
+command fails if there is any difference.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			var data []byte
+			var err error
+			if args[1] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[1])
+			}
+			if err != nil {
+				fmt.Printf("Error reading manifest: %v\n", err)
+				os.Exit(1)
+			}
+			entries, err := parseManifest(data)
+			if err != nil {
+				fmt.Printf("Error reading manifest: %v\n", err)
+				os.Exit(1)
+			}
+
+			stream, err := client.VerifyManifest(ctx)
+			if err != nil {
+				fmt.Printf("Error creating verify stream: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Send the manifest in batches, then close our side
+			request := &proto.VerifyManifestRequest{Path: args[0], CheckMetadata: checkMetadata, Exclude: verifyExclude}
+			for {
+				n := min(len(entries), 1000)
+				request.Entries = entries[:n]
+				entries = entries[n:]
+				if err = stream.Send(request); err != nil || len(entries) == 0 {
+					break
+				}
+				request = &proto.VerifyManifestRequest{}
+			}
+			if err == nil {
+				err = stream.CloseSend()
+			}
+			if err != nil && err != io.EOF {
+				fmt.Printf("Error sending manifest: %v\n", err)
+				os.Exit(1)
+			}
+
+			prefix := strings.TrimPrefix(path.Clean("/"+args[0]), "/") + "/"
+			var changes []*proto.PathChange
+			for {
+				change, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Printf("Error verifying manifest: %v\n", err)
+					os.Exit(1)
+				}
+				change.Path = strings.TrimPrefix(change.Path, prefix)
+				changes = append(changes, change)
+				if outputFormat != "json" {
+					printChange(change)
+				}
+			}
+
+			if outputFormat == "json" {
+				formatOutput(&proto.DiffResponse{Changes: changes})
+			} else if len(changes) == 0 {
+				fmt.Println("All files match the manifest")
+			} else {
+				counts := make(map[proto.ChangeType]int)
+				for _, change := range changes {
+					counts[change.Change]++
+				}
+				fmt.Printf("%d missing, %d extra, %d modified\n",
+					counts[proto.ChangeType_CHANGE_DELETED], counts[proto.ChangeType_CHANGE_ADDED], counts[proto.ChangeType_CHANGE_MODIFIED])
+			}
+			if len(changes) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	verify.Flags().BoolVar(&checkMetadata, "check-metadata", false, "Also compare permissions and modification times recorded in a JSON manifest")
+	verify.Flags().StringArrayVar(&verifyExclude, "exclude", nil, "Ignore entries matching a gitignore-style pattern (repeatable)")
+
+	cmd.AddCommand(create, verify)
+	return cmd
+}
+
+// sha256sumLine formats a manifest entry the way sha256sum does, escaping
+// backslashes and newlines in the name and marking such lines with a leading
+// backslash
+func sha256sumLine(entry *proto.ManifestEntry) string {
+	name := entry.Path
+	if !strings.ContainsAny(name, "\\\n\r") {
+		return entry.Sha256 + "  " + name + "\n"
+	}
+	name = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(name)
+	return "\\" + entry.Sha256 + "  " + name + "\n"
+}
+
+// parseManifest reads a manifest in JSON, as written by manifest create -o
+// json, or in the format of sha256sum
+func parseManifest(data []byte) ([]*proto.ManifestEntry, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var manifest struct {
+			Files []*proto.ManifestEntry `json:"files"`
+		}
+		if err := json.Unmarshal(trimmed, &manifest); err != nil {
+			return nil, err
+		}
+		return manifest.Files, nil
+	}
+
+	var entries []*proto.ManifestEntry
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		escaped := strings.HasPrefix(line, "\\")
+		line = strings.TrimPrefix(line, "\\")
+
+		// A hash, a space and a space or "*" for binary mode, then the name
+		if len(line) < 67 || line[64] != ' ' || (line[65] != ' ' && line[65] != '*') {
+			return nil, fmt.Errorf("line %d is not in sha256sum format", n+1)
+		}
+		if _, err := hex.DecodeString(line[:64]); err != nil {
+			return nil, fmt.Errorf("line %d has an invalid SHA-256", n+1)
+		}
+		name := line[66:]
+		if escaped {
+			name = strings.NewReplacer("\\\\", "\\", "\\n", "\n", "\\r", "\r").Replace(name)
+		}
+		entries = append(entries, &proto.ManifestEntry{Path: name, Sha256: strings.ToLower(line[:64])})
+	}
+	return entries, nil
+}
+
+// Create a new command for computing checksums of files
+func newChecksumCommand() *cobra.Command {
+	var algorithms []string
+	var refresh bool
+
+	cmd := &cobra.Command{
+		Use:     "checksum [path...]",
+		Aliases: []string{"sum"},
+		Short:   "Compute checksums of files on the server",
+		Long: `Computes md5, sha1, sha256, sha512 or crc32c checksums of files in a
+single read of each file, without downloading them. The server caches the
+results until a file changes. With one algorithm the output has the format
+of sha256sum and friends, with several the BSD tagged format.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			var responses []*proto.ChecksumResponse
+			failed := false
+			for _, path := range args {
+				response, err := client.GetChecksum(ctx, &proto.ChecksumRequest{Path: path, Algorithms: algorithms, Refresh: refresh})
+				if err != nil {
+					fmt.Printf("Error computing checksum of %s: %v\n", path, err)
+					failed = true
+					continue
+				}
+
+				if outputFormat == "json" {
+					responses = append(responses, response)
+					continue
+				}
+				if len(response.Checksums) == 1 {
+					for _, sum := range response.Checksums {
+						fmt.Printf("%s  %s\n", sum, path)
+					}
+					continue
+				}
+				for _, name := range sortedKeys(response.Checksums) {
+					fmt.Printf("%s (%s) = %s\n", strings.ToUpper(name), path, response.Checksums[name])
+				}
+			}
+
+			if outputFormat == "json" {
+				formatOutput(responses)
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&algorithms, "algorithm", "a", []string{"sha256"}, "Checksums to compute: md5, sha1, sha256, sha512 or crc32c (repeatable)")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Read the files even if the server has cached checksums")
+
+	return cmd
+}
+
+// sortedKeys returns the keys of a map of checksums in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Create a new command for finding and hard linking duplicate files
+func newDuplicatesCommand() *cobra.Command {
+	var minSize string
+	var exclude []string
+	var link bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:     "duplicates [path]",
+		Aliases: []string{"dupes"},
+		Short:   "Find files with the same content below a directory",
+		Long: `Lists groups of files below a directory that have identical content, the
+groups of larger files first. Hard links to one file are shown in its group
+but do not count as wasted space.
+
+With --link every group is reduced to a single file by replacing the other
+paths with hard links to it. Files differing in permissions or owner are
+left alone. The links share their content, so a later in-place change to
+one of them, such as an upload or edit, changes all of them.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			size, err := parseSize(minSize)
+			if err != nil {
+				fmt.Printf("Invalid --min-size: %v\n", err)
+				os.Exit(1)
+			}
+
+			if link || dryRun {
+				response, err := client.DeduplicateWithHardlinks(ctx, &proto.DeduplicateRequest{
+					Path:    args[0],
+					MinSize: size,
+					Exclude: exclude,
+					DryRun:  dryRun,
+				})
+				if err != nil {
+					fmt.Printf("Error deduplicating files: %v\n", err)
+					os.Exit(1)
+				}
+				if outputFormat == "json" {
+					formatOutput(response)
+				} else {
+					for _, skipped := range response.Skipped {
+						fmt.Printf("Skipped %s\n", skipped)
+					}
+					fmt.Println(response.Message)
+				}
+				if !response.Success {
+					os.Exit(1)
+				}
+				return
+			}
+
+			stream, err := client.FindDuplicates(ctx, &proto.DuplicatesRequest{Path: args[0], MinSize: size, Exclude: exclude})
+			if err != nil {
+				fmt.Printf("Error finding duplicates: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Paths come back relative to the base directory
+			prefix := strings.TrimPrefix(path.Clean("/"+args[0]), "/") + "/"
+			var groups []*proto.DuplicateGroup
+			var count, wasted int64
+			for {
+				group, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Printf("Error receiving duplicates: %v\n", err)
+					os.Exit(1)
+				}
+				for i, p := range group.Paths {
+					group.Paths[i] = strings.TrimPrefix(p, prefix)
+				}
+				count++
+				wasted += group.WastedBytes
+
+				if outputFormat == "json" {
+					groups = append(groups, group)
+					continue
+				}
+				if count > 1 {
+					fmt.Println()
+				}
+				fmt.Printf("%d copies of %s, %s wasted (sha256 %.12s)\n", group.Copies, formatSize(group.Size), formatSize(group.WastedBytes), group.Sha256)
+				for _, p := range group.Paths {
+					fmt.Printf("  %s\n", p)
+				}
+			}
+
+			if outputFormat == "json" {
+				if groups == nil {
+					groups = []*proto.DuplicateGroup{}
+				}
+				formatOutput(map[string]interface{}{"groups": groups, "wasted_bytes": wasted})
+				return
+			}
+			if count == 0 {
+				fmt.Println("No duplicates found")
+				return
+			}
+			fmt.Printf("\nTotal: %d groups, %s wasted\n", count, formatSize(wasted))
+		},
+	}
+
+	cmd.Flags().StringVar(&minSize, "min-size", "", "Ignore smaller files (e.g. 10K, 5M, 1G)")
+	cmd.Flags().StringArrayVar(&exclude, "exclude", nil, "Leave out entries matching a gitignore-style pattern (repeatable)")
+	cmd.Flags().BoolVar(&link, "link", false, "Replace duplicates with hard links to one copy")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Only show what --link would do")
+
+	return cmd
+}
+
+// Create a new command for mirroring a local directory to the server
+func newPushCommand() *cobra.Command {
+	var flags mirrorFlags
+
+	cmd := &cobra.Command{
+		Use:   "push [local_dir] [remote_dir]",
+		Short: "Upload the files of a local directory that differ on the server",
+		Long: `Compares a local directory with a directory on the server and uploads
+the files that are missing or differ there, several at a time. Files are
+compared by size and modification time, or by content with --checksum.
+Uploaded files keep their modification time and permissions, so the next
+push skips them. With --delete, files on the server that do not exist
+locally are deleted; excluded and protected files are never touched. Files
+the server hides, such as those on its deny list, are skipped.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			runMirror(&mirror{flags: flags, push: true, localDir: args[0], remoteDir: args[1]})
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+// Create a new command for mirroring a directory on the server to a local one
+func newPullCommand() *cobra.Command {
+	var flags mirrorFlags
+
+	cmd := &cobra.Command{
+		Use:   "pull [remote_dir] [local_dir]",
+		Short: "Download the files of a remote directory that differ locally",
+		Long: `Compares a directory on the server with a local directory and downloads
+the files that are missing or differ locally, several at a time. Files are
+compared by size and modification time, or by content with --checksum.
+Downloaded files keep their modification time and permissions. With
+--delete, local files that do not exist on the server are deleted;
+excluded and protected files are never touched. Files the server hides,
+such as a .git directory on its deny list, look like files it does not
+have, so protect them with --protect (e.g. --protect .git/).`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			runMirror(&mirror{flags: flags, push: false, localDir: args[1], remoteDir: args[0]})
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+// mirrorFlags are the options of push and pull
+type mirrorFlags struct {
+	checksum bool
+	delete   bool
+	dryRun   bool
+	jobs     int
+	exclude  []string
+	protect  []string
+}
+
+func (f *mirrorFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&f.checksum, "checksum", "c", false, "Compare files of the same size by content instead of modification time")
+	cmd.Flags().BoolVar(&f.delete, "delete", false, "Delete files that do not exist in the source directory")
+	cmd.Flags().BoolVarP(&f.dryRun, "dry-run", "n", false, "Only show what would be transferred and deleted")
+	cmd.Flags().IntVarP(&f.jobs, "jobs", "j", 4, "Number of files transferred at the same time")
+	cmd.Flags().StringArrayVar(&f.exclude, "exclude", nil, "Skip entries matching a gitignore-style pattern (repeatable)")
+	cmd.Flags().StringArrayVar(&f.protect, "protect", nil, "Never delete destination entries matching a gitignore-style pattern (repeatable)")
+}
+
+// mirror brings a destination tree in line with a source tree: from the
+// local directory to the remote one for push, the other way for pull
+type mirror struct {
+	flags     mirrorFlags
+	push      bool
+	localDir  string
+	remoteDir string
+}
+
+// mirrorEntry is a file or directory of a mirrored tree. Entries are keyed by
+// their slash-separated path relative to the root of the tree.
+type mirrorEntry struct {
+	fileType proto.FileType // Regular file, directory, or FILE_TYPE_UNKNOWN for anything else
+	size     int64
+	mtimeNs  int64
+	mode     os.FileMode
+}
+
+// mirrored reports whether the entry is of a type that push and pull transfer
+func (e mirrorEntry) mirrored() bool {
+	return e.fileType == proto.FileType_FILE_TYPE_REGULAR || e.fileType == proto.FileType_FILE_TYPE_DIRECTORY
+}
+
+// mirrorAction is a change to the destination tree
+type mirrorAction struct {
+	path  string
+	entry mirrorEntry // The source entry; for deletions, the destination entry
+	check bool        // Copy only if the contents differ
+}
+
+// mirrorSummary counts what a push or pull did
+type mirrorSummary struct {
+	Copied      int   `json:"copied"`
+	Bytes       int64 `json:"bytes"`
+	Directories int   `json:"directories_created"`
+	Deleted     int   `json:"deleted"`
+	Unchanged   int   `json:"unchanged"`
+	Skipped     int   `json:"skipped"`
+	Failed      int   `json:"failed"`
+	DryRun      bool  `json:"dry_run"`
+}
+
+// runMirror compares both trees, applies the differences and prints a summary
+func runMirror(m *mirror) {
+	for _, pattern := range append(append([]string{}, m.flags.exclude...), m.flags.protect...) {
+		if _, err := path.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+			fmt.Printf("Invalid pattern %q: %v\n", pattern, err)
+			os.Exit(1)
+		}
+	}
+	m.remoteDir = strings.TrimPrefix(path.Clean("/"+m.remoteDir), "/")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	remote, err := m.remoteTree(ctx)
+	cancel()
+	if err != nil {
+		fmt.Printf("Error listing remote directory: %v\n", err)
+		os.Exit(1)
+	}
+	local, err := m.localTree()
+	if err != nil {
+		fmt.Printf("Error listing local directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	source, destination := local, remote
+	if !m.push {
+		source, destination = remote, local
+	}
+	summary, errs := m.apply(source, destination)
+
+	if outputFormat == "json" {
+		formatOutput(summary)
+	} else {
+		for _, err := range errs {
+			fmt.Printf("Error: %v\n", err)
+		}
+		verb := map[bool]string{true: "Pushed", false: "Pulled"}[m.push]
+		if summary.DryRun {
+			verb = map[bool]string{true: "Would push", false: "Would pull"}[m.push]
+		}
+		fmt.Printf("%s %d files (%s), created %d directories, deleted %d, %d unchanged",
+			verb, summary.Copied, formatSize(summary.Bytes), summary.Directories, summary.Deleted, summary.Unchanged)
+		if summary.Skipped > 0 {
+			fmt.Printf(", %d skipped", summary.Skipped)
+		}
+		if summary.Failed > 0 {
+			fmt.Printf(", %d failed", summary.Failed)
+		}
+		fmt.Println()
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// apply plans and makes the changes that turn destination into source.
+// Destination entries of the wrong type are removed first, then missing
+// directories are created, files are copied by a pool of workers and, with
+// --delete, extraneous entries are deleted, except protected ones and the
+// directories holding them.
+func (m *mirror) apply(source, destination map[string]mirrorEntry) (mirrorSummary, []error) {
+	summary := mirrorSummary{DryRun: m.flags.dryRun}
+	var replaced, dirs, copies, deletes []mirrorAction
+
+	// Destination paths --delete must keep
+	kept := make(map[string]bool)
+	for rel, entry := range destination {
+		if m.protected(rel, entry.fileType == proto.FileType_FILE_TYPE_DIRECTORY) {
+			for dir := rel; dir != "."; dir = path.Dir(dir) {
+				kept[dir] = true
+			}
+		}
+	}
+
+	// Destination paths that go away along with everything below them
+	gone := make(map[string]bool)
+	below := func(rel string) bool {
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			if gone[dir] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rel := range sortedPaths(destination) {
+		entry := destination[rel]
+		if below(rel) {
+			continue
+		}
+		src, ok := source[rel]
+		switch {
+		case !ok && m.flags.delete && !kept[rel]:
+			deletes = append(deletes, mirrorAction{path: rel, entry: entry})
+			gone[rel] = true
+		case ok && src.mirrored() && entry.mirrored() && src.fileType != entry.fileType:
+			replaced = append(replaced, mirrorAction{path: rel, entry: entry})
+			gone[rel] = true
+		}
+	}
+
+	for _, rel := range sortedPaths(source) {
+		entry := source[rel]
+		old, exists := destination[rel]
+		if gone[rel] {
+			exists = false
+		}
+		if !entry.mirrored() || exists && !old.mirrored() {
+			summary.Skipped++
+			continue
+		}
+		switch {
+		case entry.fileType == proto.FileType_FILE_TYPE_DIRECTORY:
+			if !exists {
+				dirs = append(dirs, mirrorAction{path: rel, entry: entry})
+			}
+		case !exists || old.size != entry.size:
+			copies = append(copies, mirrorAction{path: rel, entry: entry})
+		case m.flags.checksum:
+			copies = append(copies, mirrorAction{path: rel, entry: entry, check: true})
+		case old.mtimeNs != entry.mtimeNs:
+			copies = append(copies, mirrorAction{path: rel, entry: entry})
+		default:
+			summary.Unchanged++
+		}
+	}
+
+	var errs []error
+	var mu sync.Mutex
+	report := func(verb string, action mirrorAction, err error) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %v", verb, action.path, err))
+			summary.Failed++
+			return false
+		}
+		if verbose || m.flags.dryRun {
+			fmt.Printf("%s %s\n", verb, action.path)
+		}
+		return true
+	}
+	skipHidden := func(action mirrorAction) {
+		mu.Lock()
+		defer mu.Unlock()
+		summary.Skipped++
+		if verbose {
+			fmt.Printf("skip %s: hidden by the server\n", action.path)
+		}
+	}
+
+	for _, action := range replaced {
+		report("delete", action, m.run(func(ctx context.Context) error { return m.remove(ctx, action.path) }))
+	}
+	for _, action := range dirs {
+		err := m.run(func(ctx context.Context) error { return m.mkdir(ctx, action) })
+		if m.hidden(err) {
+			skipHidden(action)
+		} else if report("mkdir", action, err) {
+			summary.Directories++
+		}
+	}
+
+	verb := map[bool]string{true: "upload", false: "download"}[m.push]
+	work := make(chan mirrorAction)
+	var wg sync.WaitGroup
+	for i := 0; i < max(m.flags.jobs, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for action := range work {
+				copied, err := m.copy(action)
+				if m.hidden(err) {
+					skipHidden(action)
+				} else if err == nil && !copied {
+					mu.Lock()
+					summary.Unchanged++
+					mu.Unlock()
+				} else if report(verb, action, err) {
+					mu.Lock()
+					summary.Copied++
+					summary.Bytes += action.entry.size
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, action := range copies {
+		work <- action
+	}
+	close(work)
+	wg.Wait()
+
+	for _, action := range deletes {
+		if report("delete", action, m.run(func(ctx context.Context) error { return m.remove(ctx, action.path) })) {
+			summary.Deleted++
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return summary, errs
+}
+
+// protected reports whether --protect covers a destination entry, through
+// the entry itself or one of its parent directories
+func (m *mirror) protected(rel string, isDir bool) bool {
+	if mirrorExcluded(m.flags.protect, rel, isDir) {
+		return true
+	}
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if mirrorExcluded(m.flags.protect, dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// hidden reports whether a push failed because the server hides the path.
+// Uploads and mkdir create whatever is missing, so NotFound can only mean
+// the path is on the server's deny list.
+func (m *mirror) hidden(err error) bool {
+	return m.push && status.Code(err) == codes.NotFound
+}
+
+// run runs a change with its own timeout, or skips it in a dry run
+func (m *mirror) run(change func(ctx context.Context) error) error {
+	if m.flags.dryRun {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	return change(ctx)
+}
+
+func (m *mirror) localPath(rel string) string {
+	return filepath.Join(m.localDir, filepath.FromSlash(rel))
+}
+
+func (m *mirror) remotePath(rel string) string {
+	return path.Join(m.remoteDir, rel)
+}
+
+// localTree lists the local directory. A missing directory is empty when pulling.
+func (m *mirror) localTree() (map[string]mirrorEntry, error) {
+	tree := make(map[string]mirrorEntry)
+	err := filepath.WalkDir(m.localDir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if name == m.localDir && os.IsNotExist(err) && !m.push {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(m.localDir, name)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			if !entry.IsDir() {
+				return fmt.Errorf("%s is not a directory", m.localDir)
+			}
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if mirrorExcluded(m.flags.exclude, rel, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		fileType := proto.FileType_FILE_TYPE_UNKNOWN
+		switch {
+		case info.Mode().IsRegular():
+			fileType = proto.FileType_FILE_TYPE_REGULAR
+		case info.IsDir():
+			fileType = proto.FileType_FILE_TYPE_DIRECTORY
+		}
+		tree[rel] = mirrorEntry{
+			fileType: fileType,
+			size:     info.Size(),
+			mtimeNs:  info.ModTime().UnixNano(),
+			mode:     info.Mode(),
+		}
+		return nil
+	})
+	return tree, err
+}
+
+// remoteTree lists the remote directory, leaving exclusions to the server.
+// A missing directory is empty when pushing.
+func (m *mirror) remoteTree(ctx context.Context) (map[string]mirrorEntry, error) {
+	tree := make(map[string]mirrorEntry)
+	request := &proto.ListRequest{
+		Path:      m.remotePath("."),
+		Recursive: true,
+		PageSize:  1000,
+		Exclude:   m.flags.exclude,
+	}
+	for {
+		response, err := client.ListDirectory(ctx, request)
+		if err != nil {
+			if m.push && status.Code(err) == codes.NotFound {
+				return tree, nil
+			}
+			return nil, err
+		}
+
+		for _, item := range response.Items {
+			rel := item.Path
+			if m.remoteDir != "" {
+				rel = strings.TrimPrefix(rel, m.remoteDir+"/")
+			}
+			fileType := item.FileType
+			if item.IsSymlink {
+				fileType = proto.FileType_FILE_TYPE_UNKNOWN
+			}
+			mode, _ := strconv.ParseUint(item.Permissions, 8, 32)
+			tree[rel] = mirrorEntry{
+				fileType: fileType,
+				size:     item.Size,
+				mtimeNs:  item.ModifiedTimeNs,
+				mode:     os.FileMode(mode),
+			}
+		}
+
+		if response.NextPageToken == "" {
+			return tree, nil
+		}
+		request.PageToken = response.NextPageToken
+	}
+}
+
+// mkdir creates a directory of the destination tree
+func (m *mirror) mkdir(ctx context.Context, action mirrorAction) error {
+	if !m.push {
+		return os.MkdirAll(m.localPath(action.path), action.entry.mode.Perm())
+	}
+	response, err := client.CreateDirectory(ctx, &proto.CreateDirectoryRequest{
+		Path:        m.remotePath(action.path),
+		Permissions: int32(action.entry.mode.Perm()),
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.New(response.Error)
+	}
+	return nil
+}
+
+// remove deletes an entry of the destination tree and everything below it
+func (m *mirror) remove(ctx context.Context, rel string) error {
+	if !m.push {
+		return os.RemoveAll(m.localPath(rel))
+	}
+	response, err := client.Delete(ctx, &proto.DeleteRequest{Path: m.remotePath(rel), Recursive: true})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.New(response.Error)
+	}
+	return nil
+}
+
+// copy transfers a file to the destination tree. It reports false without
+// copying when the action asks for a check and the contents are the same;
+// the check is made even in a dry run.
+func (m *mirror) copy(action mirrorAction) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	if action.check {
+		same, err := m.sameContent(ctx, action.path)
+		if err != nil || same {
+			return false, err
+		}
+	}
+	if m.flags.dryRun {
+		return true, nil
+	}
+	if m.push {
+		return true, m.upload(ctx, action)
+	}
+	return true, m.download(ctx, action)
+}
+
+// sameContent compares a local file with the remote file of the same size
+// by the signatures of their blocks, so only a hash of every megabyte of the
+// remote file is sent
+func (m *mirror) sameContent(ctx context.Context, rel string) (bool, error) {
+	stream, err := client.GetSignature(ctx, &proto.SignatureRequest{Path: m.remotePath(rel), BlockSize: delta.MaxBlockSize})
+	if err != nil {
+		return false, err
+	}
+	var remote []*proto.BlockSignature
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		remote = append(remote, chunk.Blocks...)
+	}
+
+	file, err := os.Open(m.localPath(rel))
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	same, i := true, 0
+	err = delta.Sign(file, delta.MaxBlockSize, func(block delta.Block) error {
+		if i >= len(remote) || remote[i].Weak != block.Weak || !bytes.Equal(remote[i].Strong, block.Strong) {
+			same = false
+		}
+		i++
+		return nil
+	})
+	return same && i == len(remote), err
+}
+
+// upload sends a local file to the server, then gives it the modification
+// time and permissions of the local one
+func (m *mirror) upload(ctx context.Context, action mirrorAction) error {
+	file, err := os.Open(m.localPath(action.path))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stream, err := client.UploadFile(ctx)
+	if err != nil {
+		return err
+	}
+	remotePath := m.remotePath(action.path)
+	buffer := make([]byte, 1024*1024)
+	var offset int64
+	// Only the first chunk names the file
+	chunkPath := remotePath
+	for {
+		n, err := file.Read(buffer)
+		if n > 0 {
+			if err := stream.Send(&proto.FileChunk{FilePath: chunkPath, Content: buffer[:n], Offset: offset}); err != nil {
+				break // The server ended the stream; CloseAndRecv reports why
+			}
+			offset += int64(n)
+			chunkPath = ""
+		}
+		if err == io.EOF {
+			stream.Send(&proto.FileChunk{FilePath: chunkPath, Offset: offset, IsLast: true})
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.New(response.Error)
+	}
+
+	timesResponse, err := client.SetTimes(ctx, &proto.SetTimesRequest{Path: remotePath, ModifiedTimeNs: action.entry.mtimeNs, AccessTimeNs: -1})
+	if err == nil && !timesResponse.Success {
+		err = errors.New(timesResponse.Error)
+	}
+	if err != nil {
+		return err
+	}
+	modeResponse, err := client.SetPermissions(ctx, &proto.SetPermissionsRequest{Path: remotePath, Mode: fmt.Sprintf("%o", action.entry.mode.Perm())})
+	if err == nil && !modeResponse.Success {
+		err = errors.New(modeResponse.Error)
+	}
+	return err
+}
+
+// download fetches a remote file into a temporary file next to the local
+// one, which it replaces once complete
+func (m *mirror) download(ctx context.Context, action mirrorAction) error {
+	localFile := m.localPath(action.path)
+	if err := os.MkdirAll(filepath.Dir(localFile), 0755); err != nil {
+		return err
+	}
+	stream, err := client.DownloadFile(ctx, &proto.FileRequest{Path: m.remotePath(action.path)})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(localFile), "."+filepath.Base(localFile)+".pull-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tmp.Write(chunk.Content); err != nil {
+			return err
+		}
+		if chunk.IsLast {
+			break
+		}
+	}
+
+	if err := tmp.Chmod(action.entry.mode.Perm()); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	mtime := time.Unix(0, action.entry.mtimeNs)
+	if err := os.Chtimes(tmp.Name(), mtime, mtime); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), localFile)
+}
+
+// mirrorExcluded applies exclude patterns to a path of the local tree the way
+// the server applies them to the remote one: a pattern without a slash matches
+// a name at any depth, one with a slash the whole path, a trailing slash only
+// matches directories and "!" re-includes. The last matching pattern decides.
+func mirrorExcluded(patterns []string, rel string, isDir bool) bool {
+	excluded := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimRight(pattern, "/")
+		}
+
+		var matched bool
+		if strings.Contains(pattern, "/") {
+			matched = matchGlobSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(rel, "/"))
+		} else {
+			matched, _ = path.Match(pattern, path.Base(rel))
+		}
+		if matched {
+			excluded = !negate
+		}
+	}
+	return excluded
+}
+
+// matchGlobSegments matches path segments against glob segments, where "**"
+// matches any number of segments
+func matchGlobSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchGlobSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// sortedPaths returns the paths of a tree with parents before their children
+func sortedPaths(tree map[string]mirrorEntry) []string {
+	paths := make([]string, 0, len(tree))
+	for rel := range tree {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Create a new command for uploading a file
+func newUploadCommand() *cobra.Command {
+	var chunkSize int
+	var etags etagFlags
+
+	cmd := &cobra.Command{
+		Use:   "upload [local_file] [remote_path]",
+		Short: "Upload a file to the server",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			localFile := args[0]
+			remotePath := args[1]
+
+			// Open the local file
+			file, err := os.Open(localFile)
+			if err != nil {
+				fmt.Printf("Error opening local file: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+
+			// Create upload stream
+			stream, err := client.UploadFile(ctx)
+			if err != nil {
+				fmt.Printf("Error creating upload stream: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Get file info for progress reporting
+			fileInfo, err := file.Stat()
+			if err != nil {
+				fmt.Printf("Error getting file info: %v\n", err)
+				os.Exit(1)
+			}
+			totalSize := fileInfo.Size()
+
+			// Read and send file in chunks; only the first one names the
+			// file and carries the preconditions
+			buffer := make([]byte, chunkSize)
+			totalSent := int64(0)
+			for {
+				n, err := file.Read(buffer)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Printf("Error reading file: %v\n", err)
+					os.Exit(1)
+				}
+
+				// Send chunk
+				chunk := &proto.FileChunk{
+					Content: buffer[:n],
+					Offset:  totalSent,
+					IsLast:  false,
+				}
+				if totalSent == 0 {
+					chunk.FilePath, chunk.IfMatch, chunk.IfNoneMatch = remotePath, etags.ifMatch, etags.ifNoneMatch
+				}
+				
+				if err := stream.Send(chunk); err != nil {
+					fmt.Printf("Error sending chunk: %v\n", err)
+					os.Exit(1)
+				}
+
+				totalSent += int64(n)
+				
+				// Print progress
+				if verbose {
+					progress := float64(totalSent) / float64(totalSize) * 100
+					fmt.Printf("\rUploading: %.2f%% (%d/%d bytes)", progress, totalSent, totalSize)
+				}
+			}
+
+			// Send last empty chunk to indicate end of file
+			lastChunk := &proto.FileChunk{
+				Content: []byte{},
+				Offset:  totalSent,
+				IsLast:  true,
+			}
+			if totalSent == 0 {
+				// An empty file is sent as a single chunk
+				lastChunk.FilePath, lastChunk.IfMatch, lastChunk.IfNoneMatch = remotePath, etags.ifMatch, etags.ifNoneMatch
+			}
+			
+			if err := stream.Send(lastChunk); err != nil {
+				fmt.Printf("\nError sending final chunk: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Get response
+			response, err := stream.CloseAndRecv()
+			if err != nil {
+				fmt.Printf("\nError receiving response: %v\n", err)
+				os.Exit(1)
+			}
+
+			if verbose {
+				fmt.Println()
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else {
+				if response.Success {
+					fmt.Printf("Successfully uploaded %s to %s (%d bytes, etag %s)\n", localFile, remotePath, totalSent, response.Etag)
+				} else {
+					fmt.Printf("Failed to upload file: %s\n", response.Error)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&chunkSize, "chunk-size", "c", 1024*1024, "Chunk size in bytes")
+	etags.register(cmd)
+
+	return cmd
+}
+
+// Create a new command for downloading a file
+func newDownloadCommand() *cobra.Command {
+	var versionID string
+
+	cmd := &cobra.Command{
+		Use:   "download [remote_path] [local_file]",
+		Short: "Download a file from the server",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			remotePath := args[0]
+			localFile := args[1]
+
+			// Create local file
+			file, err := os.Create(localFile)
+			if err != nil {
+				fmt.Printf("Error creating local file: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+
+			// Create download stream
+			stream, err := client.DownloadFile(ctx, &proto.FileRequest{Path: remotePath, VersionId: versionID})
+			if err != nil {
+				fmt.Printf("Error creating download stream: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Receive and write chunks
+			totalReceived := int64(0)
+			for {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Printf("Error receiving chunk: %v\n", err)
+					os.Exit(1)
+				}
+
+				// Write chunk to file
+				n, err := file.Write(chunk.Content)
+				if err != nil {
+					fmt.Printf("Error writing to file: %v\n", err)
+					os.Exit(1)
+				}
+
+				totalReceived += int64(n)
+				
+				// Print progress
+				if verbose {
+					fmt.Printf("\rDownloading: %d bytes received", totalReceived)
+				}
+
+				if chunk.IsLast {
+					break
+				}
+			}
+
+			if verbose {
+				fmt.Println()
+			}
+
+			if outputFormat == "json" {
+				result := map[string]interface{}{
+					"success": true,
+					"bytes_received": totalReceived,
+					"local_file": localFile,
+					"remote_path": remotePath,
+				}
+				formatOutput(result)
+			} else {
+				fmt.Printf("Successfully downloaded %s to %s (%d bytes)\n", remotePath, localFile, totalReceived)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&versionID, "version", "", "Download this saved version (see versions list)")
+
+	return cmd
+}
+
+// Create a new command for searching files
+func newSearchCommand() *cobra.Command {
+	var (
+		caseSensitive  bool
+		recursive      bool
+		directoriesOnly bool
+		filesOnly      bool
+		maxResults     int
+		nameRegex      string
+		pathGlob       string
+		minSize        string
+		maxSize        string
+		newerThan      string
+		olderThan      string
+		fileType       string
+		mimeTypes      []string
+		owner          string
+		group          string
+		emptyOnly      bool
+		stream         bool
+		pageSize       int
+		pageToken      string
+		include        []string
+		exclude        []string
+		ignoreFiles    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search [path] [pattern]",
+		Short: "Search for files and directories",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.SearchRequest{
+				BasePath:        args[0],
+				Pattern:         args[1],
+				CaseSensitive:   caseSensitive,
+				Recursive:       recursive,
+				DirectoriesOnly: directoriesOnly,
+				FilesOnly:       filesOnly,
+				MaxResults:      int32(maxResults),
+				NameRegex:       nameRegex,
+				PathGlob:        pathGlob,
+				MimeTypes:       mimeTypes,
+				Owner:           owner,
+				Group:           group,
+				EmptyOnly:       emptyOnly,
+				Include:         include,
+				Exclude:         exclude,
+				UseIgnoreFiles:  ignoreFiles,
+			}
+
+			// Translate find-style type letters
+			switch fileType {
+			case "":
+			case "f":
+				request.FilesOnly = true
+			case "d":
+				request.DirectoriesOnly = true
+			case "l":
+				request.SymlinksOnly = true
+			default:
+				fmt.Printf("Invalid type %q (expected f, d or l)\n", fileType)
+				os.Exit(1)
+			}
+
+			var err error
+			if request.MinSize, err = parseSize(minSize); err != nil {
+				fmt.Printf("Invalid --min-size: %v\n", err)
+				os.Exit(1)
+			}
+			if request.MaxSize, err = parseSize(maxSize); err != nil {
+				fmt.Printf("Invalid --max-size: %v\n", err)
+				os.Exit(1)
+			}
+			if request.ModifiedAfter, err = parseTimeBound(newerThan); err != nil {
+				fmt.Printf("Invalid --newer-than: %v\n", err)
+				os.Exit(1)
+			}
+			if request.ModifiedBefore, err = parseTimeBound(olderThan); err != nil {
+				fmt.Printf("Invalid --older-than: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Streaming prints matches as the server finds them
+			if stream {
+				searchStream, err := client.SearchStream(ctx, request)
+				if err != nil {
+					fmt.Printf("Error searching: %v\n", err)
+					os.Exit(1)
+				}
+
+				var items []*proto.FileItem
+				for {
+					item, err := searchStream.Recv()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						fmt.Printf("Error receiving result: %v\n", err)
+						os.Exit(1)
+					}
+
+					if outputFormat == "json" {
+						items = append(items, item)
+						continue
+					}
+					fmt.Println(item.Path)
+				}
+
+				if outputFormat == "json" {
+					formatOutput(items)
+				}
+				return
+			}
+
+			request.PageSize = int32(pageSize)
+			request.PageToken = pageToken
+
+			response, err := client.Search(ctx, request)
+			if err != nil {
+				fmt.Printf("Error searching: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+			} else {
+				fmt.Printf("Search results for pattern '%s' in '%s':\n", args[1], args[0])
+				fmt.Println("Type\tSize\tModified\t\tPath")
+				fmt.Println("--------------------------------------------------------------")
+				for _, item := range response.Items {
+					fileType := getTypeLetter(item.FileType, item.IsDirectory)
+					modTime := time.Unix(item.ModifiedTime, 0).Format("2006-01-02 15:04:05")
+					fmt.Printf("%s\t%d\t%s\t%s%s\n", fileType, item.Size, modTime, item.Path, linkSuffix(item.IsSymlink, item.LinkTarget))
+				}
+				fmt.Printf("\nTotal matches: %d\n", len(response.Items))
+				if response.NextPageToken != "" {
+					fmt.Printf("Next page token: %s\n", response.NextPageToken)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&caseSensitive, "case-sensitive", "c", false, "Use case-sensitive matching")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Search recursively")
+	cmd.Flags().BoolVarP(&directoriesOnly, "dirs-only", "d", false, "Match directories only")
+	cmd.Flags().BoolVarP(&filesOnly, "files-only", "f", false, "Match files only")
+	cmd.Flags().IntVarP(&maxResults, "max-results", "m", 100, "Maximum number of results")
+	cmd.Flags().StringVar(&nameRegex, "regex", "", "Match names against a regular expression")
+	cmd.Flags().StringVar(&pathGlob, "path", "", "Match relative paths against a glob (** spans directories)")
+	cmd.Flags().StringVar(&minSize, "min-size", "", "Minimum size (e.g. 10K, 5M, 1G)")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Maximum size (e.g. 10K, 5M, 1G)")
+	cmd.Flags().StringVar(&newerThan, "newer-than", "", "Modified within a duration (e.g. 24h, 7d) or after a date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Modified before a duration ago (e.g. 24h, 7d) or a date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&fileType, "type", "", "Entry type: f (file), d (directory), l (symlink)")
+	cmd.Flags().StringSliceVar(&mimeTypes, "mime", nil, "MIME type or prefix such as image/ (repeatable)")
+	cmd.Flags().StringVar(&owner, "owner", "", "Owner user name or UID")
+	cmd.Flags().StringVar(&group, "group", "", "Owner group name or GID")
+	cmd.Flags().BoolVar(&emptyOnly, "empty", false, "Match empty files and directories only")
+	cmd.Flags().BoolVar(&stream, "stream", false, "Print matches as they are found")
+	cmd.Flags().IntVar(&pageSize, "page-size", 0, "Results per page (0 to disable paging)")
+	cmd.Flags().StringVar(&pageToken, "page-token", "", "Page token from a previous search")
+	addRuleFlags(cmd, &include, &exclude, &ignoreFiles)
+
+	return cmd
+}
+
+// Create a new command for searching file contents
+func newGrepCommand() *cobra.Command {
+	var (
+		regex        bool
+		ignoreCase   bool
+		recursive    bool
+		include      []string
+		exclude      []string
+		maxFileSize  int64
+		binary       bool
+		contextLines int
+		maxMatches   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "grep [path] [query]",
+		Short: "Search file contents",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			request := &proto.GrepRequest{
+				BasePath:      args[0],
+				Query:         args[1],
+				Regex:         regex,
+				CaseSensitive: !ignoreCase,
+				Recursive:     recursive,
+				Include:       include,
+				Exclude:       exclude,
+				MaxFileSize:   maxFileSize,
+				IncludeBinary: binary,
+				ContextLines:  int32(contextLines),
+				MaxMatches:    int32(maxMatches),
+			}
+
+			stream, err := client.GrepFiles(ctx, request)
+			if err != nil {
+				fmt.Printf("Error searching contents: %v\n", err)
+				os.Exit(1)
+			}
+
+			var matches []*proto.GrepMatch
+			for {
+				match, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Printf("Error receiving match: %v\n", err)
+					os.Exit(1)
+				}
+
+				if outputFormat == "json" {
+					matches = append(matches, match)
+					continue
+				}
+
+				// Print matches as they arrive, grep style
+				if contextLines > 0 {
+					first := match.LineNumber - int64(len(match.BeforeContext))
+					for i, line := range match.BeforeContext {
+						fmt.Printf("%s-%d-%s\n", match.Path, first+int64(i), line)
+					}
+				}
+				fmt.Printf("%s:%d:%d:%s\n", match.Path, match.LineNumber, match.Column, match.Line)
+				if contextLines > 0 {
+					for i, line := range match.AfterContext {
+						fmt.Printf("%s-%d-%s\n", match.Path, match.LineNumber+int64(i)+1, line)
+					}
+					fmt.Println("--")
+				}
+			}
+
+			if outputFormat == "json" {
+				formatOutput(matches)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&regex, "regex", "E", false, "Treat query as an RE2 regular expression")
+	cmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Use case-insensitive matching")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Search recursively")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "Only search files matching pattern (repeatable)")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "Skip files and directories matching pattern (repeatable)")
+	cmd.Flags().Int64Var(&maxFileSize, "max-size", 0, "Skip files larger than this many bytes (0 for server default)")
+	cmd.Flags().BoolVar(&binary, "binary", false, "Also search binary files")
+	cmd.Flags().IntVarP(&contextLines, "context", "C", 0, "Lines of context around each match")
+	cmd.Flags().IntVarP(&maxMatches, "max-count", "m", 0, "Stop after this many matches (0 for unlimited)")
+
+	return cmd
+}
+
+// Create a new command for getting directory hierarchy
+func newHierarchyCommand() *cobra.Command {
+	var (
+		maxDepth    int
+		pattern     string
+		include     []string
+		exclude     []string
+		ignoreFiles bool
 	)
 
 	cmd := &cobra.Command{
@@ -734,9 +3537,12 @@ func newHierarchyCommand() *cobra.Command {
 			}
 
 			request := &proto.HierarchyRequest{
-				Path:     path,
-				MaxDepth: int32(maxDepth),
-				Pattern:  pattern,
+				Path:           path,
+				MaxDepth:       int32(maxDepth),
+				Pattern:        pattern,
+				Include:        include,
+				Exclude:        exclude,
+				UseIgnoreFiles: ignoreFiles,
 			}
 
 			response, err := client.GetHierarchy(ctx, request)
@@ -760,6 +3566,7 @@ func newHierarchyCommand() *cobra.Command {
 
 	cmd.Flags().IntVarP(&maxDepth, "max-depth", "d", 0, "Maximum depth (0 for unlimited)")
 	cmd.Flags().StringVarP(&pattern, "pattern", "p", "", "Filter by pattern")
+	addRuleFlags(cmd, &include, &exclude, &ignoreFiles)
 
 	return cmd
 }
@@ -767,10 +3574,9 @@ func newHierarchyCommand() *cobra.Command {
 // Create a new command for getting directory size
 func newDirSizeCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "size [path]",
-		Aliases: []string{"du"},
-		Short:   "Get the size of a directory",
-		Args:    cobra.ExactArgs(1),
+		Use:   "size [path]",
+		Short: "Get the size of a directory",
+		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
 			defer cancel()
@@ -793,6 +3599,121 @@ func newDirSizeCommand() *cobra.Command {
 	return cmd
 }
 
+// Create a new command for analyzing the disk usage of a directory
+func newDiskUsageCommand() *cobra.Command {
+	var maxDepth int32
+	var top int32
+	var exclude []string
+	var byType bool
+
+	cmd := &cobra.Command{
+		Use:   "du [path]",
+		Short: "Show what takes up disk space in a directory",
+		Long: `Breaks down the disk usage of a directory to a depth like du -d, largest
+first, followed by the largest files and directories and the totals by file
+extension. Usage counts allocated blocks, so sparse files count for less
+than their size and hard linked files count once.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			response, err := client.GetDiskUsage(ctx, &proto.DiskUsageRequest{
+				Path:       args[0],
+				MaxDepth:   maxDepth,
+				Top:        top,
+				Exclude:    exclude,
+				ByMimeType: byType,
+			})
+			if err != nil {
+				fmt.Printf("Error getting disk usage: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFormat == "json" {
+				formatOutput(response)
+				return
+			}
+
+			// Paths come back relative to the base directory
+			prefix := strings.TrimPrefix(path.Clean("/"+args[0]), "/") + "/"
+			name := func(entry *proto.DiskUsageEntry) string {
+				name := strings.TrimPrefix(entry.Path, prefix)
+				if entry.IsDirectory {
+					name += "/"
+				}
+				return name
+			}
+
+			total := response.Total
+			fmt.Printf("Disk usage of %s: %s in %d files and %d directories (apparent size %s)\n",
+				args[0], formatSize(total.DiskUsage), total.Files, total.Directories, formatSize(total.ApparentSize))
+
+			if len(response.Breakdown) > 0 {
+				fmt.Println()
+				for _, entry := range response.Breakdown {
+					printUsageBar(name(entry), entry.DiskUsage, total.DiskUsage)
+				}
+			}
+			if len(response.LargestFiles) > 0 {
+				fmt.Println("\nLargest files:")
+				for _, entry := range response.LargestFiles {
+					printUsageBar(name(entry), entry.DiskUsage, total.DiskUsage)
+				}
+			}
+			if len(response.LargestDirectories) > 0 {
+				fmt.Println("\nLargest directories:")
+				for _, entry := range response.LargestDirectories {
+					printUsageBar(name(entry), entry.DiskUsage, total.DiskUsage)
+				}
+			}
+			groups := []struct {
+				title  string
+				groups []*proto.DiskUsageGroup
+			}{
+				{"By extension:", response.ByExtension},
+				{"By MIME type:", response.ByMimeType},
+			}
+			for _, g := range groups {
+				if len(g.groups) == 0 {
+					continue
+				}
+				fmt.Printf("\n%s\n", g.title)
+				for _, group := range g.groups {
+					label := group.Name
+					if label == "" {
+						label = "(none)"
+					}
+					printUsageBar(fmt.Sprintf("%s (%d files)", label, group.Files), group.DiskUsage, total.DiskUsage)
+				}
+			}
+			if response.Truncated {
+				fmt.Println("\n(Some lists were cut short, use a smaller --max-depth)")
+			}
+		},
+	}
+
+	cmd.Flags().Int32VarP(&maxDepth, "max-depth", "d", 1, "Levels of subdirectories and files to break down")
+	cmd.Flags().Int32VarP(&top, "top", "n", 10, "Number of largest files and directories to show")
+	cmd.Flags().StringArrayVar(&exclude, "exclude", nil, "Leave out entries matching a gitignore-style pattern (repeatable)")
+	cmd.Flags().BoolVar(&byType, "by-type", false, "Also total files by MIME type (reads the start of every file)")
+
+	return cmd
+}
+
+// printUsageBar prints a line of a disk usage chart: the size, a bar and
+// share of the total, and the label
+func printUsageBar(label string, usage, total int64) {
+	const width = 30
+	share := 0.0
+	if total > 0 {
+		share = float64(usage) / float64(total)
+	}
+	filled := int(share*width + 0.5)
+	bar := strings.Repeat("#", filled) + strings.Repeat(".", width-filled)
+	fmt.Printf("%10s  [%s] %5.1f%%  %s\n", formatSize(usage), bar, share*100, label)
+}
+
 // Create a new command for checking daemon status
 func newStatusCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -839,14 +3760,74 @@ func newStatusCommand() *cobra.Command {
 
 // Utility functions
 
+// Add the gitignore-style include/exclude flags shared by listing commands
+func addRuleFlags(cmd *cobra.Command, include, exclude *[]string, ignoreFiles *bool) {
+	cmd.Flags().StringArrayVar(include, "include", nil, "Only show entries matching a gitignore-style pattern (repeatable)")
+	cmd.Flags().StringArrayVar(exclude, "exclude", nil, "Hide entries matching a gitignore-style pattern (repeatable)")
+	cmd.Flags().BoolVar(ignoreFiles, "ignore-files", false, "Honor .gitignore and .fsdaemonignore files")
+}
+
 // Get string representation of file type
-func getTypeString(isDirectory bool) string {
+func getTypeString(fileType proto.FileType, isDirectory bool) string {
+	switch fileType {
+	case proto.FileType_FILE_TYPE_SYMLINK:
+		return "Symlink"
+	case proto.FileType_FILE_TYPE_FIFO:
+		return "FIFO"
+	case proto.FileType_FILE_TYPE_SOCKET:
+		return "Socket"
+	case proto.FileType_FILE_TYPE_CHAR_DEVICE:
+		return "Character Device"
+	case proto.FileType_FILE_TYPE_BLOCK_DEVICE:
+		return "Block Device"
+	}
+	// Older servers only report is_directory
 	if isDirectory {
 		return "Directory"
 	}
 	return "File"
 }
 
+// Get the one-letter type shown in listings
+func getTypeLetter(fileType proto.FileType, isDirectory bool) string {
+	switch fileType {
+	case proto.FileType_FILE_TYPE_SYMLINK:
+		return "L"
+	case proto.FileType_FILE_TYPE_FIFO:
+		return "P"
+	case proto.FileType_FILE_TYPE_SOCKET:
+		return "S"
+	case proto.FileType_FILE_TYPE_CHAR_DEVICE:
+		return "C"
+	case proto.FileType_FILE_TYPE_BLOCK_DEVICE:
+		return "B"
+	}
+	if isDirectory {
+		return "D"
+	}
+	return "F"
+}
+
+// linkSuffix returns " -> target" for symbolic links
+func linkSuffix(isSymlink bool, target string) string {
+	if !isSymlink {
+		return ""
+	}
+	return " -> " + target
+}
+
+// Format a timestamp, preferring nanosecond precision; 0 means unknown
+func formatTimestamp(seconds, nanos int64) string {
+	switch {
+	case nanos != 0:
+		return time.Unix(0, nanos).Format("2006-01-02 15:04:05.000000000 -0700")
+	case seconds != 0:
+		return time.Unix(seconds, 0).Format("2006-01-02 15:04:05 -0700")
+	default:
+		return "unknown"
+	}
+}
+
 // Format file size to human-readable format
 func formatSize(size int64) string {
 	const unit = 1024
@@ -861,6 +3842,54 @@ func formatSize(size int64) string {
 	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
+// Parse a human-readable size such as 512, 10K, 5M or 1G
+func parseSize(value string) (int64, error) {
+	value = strings.TrimSpace(strings.ToUpper(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "B"), "I")
+	if n := len(value); n > 0 {
+		if i := strings.IndexByte("KMGTPE", value[n-1]); i >= 0 {
+			for ; i >= 0; i-- {
+				multiplier *= 1024
+			}
+			value = value[:n-1]
+		}
+	}
+
+	size, err := strconv.ParseFloat(value, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(size * float64(multiplier)), nil
+}
+
+// Parse a time bound given as a duration before now (24h, 7d) or a date
+func parseTimeBound(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return time.Now().AddDate(0, 0, -days).Unix(), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d).Unix(), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("expected a duration like 24h or 7d, or a date like 2006-01-02")
+}
+
 // Print hierarchy recursively
 func printHierarchy(item *proto.FileItem, prefix string, isLast bool) {
 	// Print current item
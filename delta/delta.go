@@ -0,0 +1,323 @@
+// Package delta implements the rsync algorithm for transferring a file to a
+// side that already has an older copy of it. The side with the old copy, the
+// basis, sends signatures of its fixed-size blocks; the side with the new
+// file finds those blocks at any offset with a rolling checksum and sends
+// only the data in between, as instructions to copy basis blocks or insert
+// literal data.
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// MinBlockSize and MaxBlockSize bound the block size of a signature
+	MinBlockSize = 512
+	MaxBlockSize = 1 << 20
+
+	// StrongSize is the length of the strong hash of a block
+	StrongSize = 16
+
+	// maxLiteral is the most data a single Op carries
+	maxLiteral = 64 * 1024
+)
+
+// Block is the signature of a block of the basis file
+type Block struct {
+	Weak   uint32 // Rolling checksum
+	Strong []byte // Truncated SHA-256
+}
+
+// Op rebuilds a part of the new file: it copies Count blocks of the basis
+// starting at Index, or inserts Data when Count is 0
+type Op struct {
+	Index int64
+	Count int64
+	Data  []byte
+}
+
+// BlockSize picks a block size for a basis file of size bytes. Like rsync it
+// grows with the square root of the size, so the signature of a large file
+// stays small while a change still costs little more than a block.
+func BlockSize(size int64) int64 {
+	blockSize := int64(math.Sqrt(float64(size))) &^ 1023
+	return min(max(blockSize, 2048), 128*1024)
+}
+
+// ValidBlockSize reports whether a block size is within the supported range
+func ValidBlockSize(blockSize int64) bool {
+	return blockSize >= MinBlockSize && blockSize <= MaxBlockSize
+}
+
+// Sign reads the basis and calls emit with the signature of every block, in order
+func Sign(basis io.Reader, blockSize int64, emit func(Block) error) error {
+	if !ValidBlockSize(blockSize) {
+		return fmt.Errorf("invalid block size %d", blockSize)
+	}
+
+	buf := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(basis, buf)
+		if n > 0 {
+			if err := emit(Block{Weak: weakSum(buf[:n]), Strong: strongSum(buf[:n])}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Diff reads the new file and calls emit with the ops that rebuild it from
+// the basis the blocks were signed from. Consecutive blocks are merged into
+// one op and literal data is split into ops of at most 64 KiB.
+func Diff(file io.Reader, blockSize int64, blocks []Block, emit func(Op) error) error {
+	if !ValidBlockSize(blockSize) {
+		return fmt.Errorf("invalid block size %d", blockSize)
+	}
+	d := &differ{
+		blockSize: int(blockSize),
+		blocks:    blocks,
+		index:     make(map[uint32][]int64, len(blocks)),
+		emit:      emit,
+		next:      -1,
+	}
+	for i, block := range blocks {
+		d.index[block.Weak] = append(d.index[block.Weak], int64(i))
+	}
+	return d.run(bufio.NewReaderSize(file, maxLiteral))
+}
+
+// differ holds the state of Diff. buf holds the pending literal data
+// followed by the window being matched against the blocks.
+type differ struct {
+	blockSize int
+	blocks    []Block
+	index     map[uint32][]int64 // Blocks by weak checksum
+	emit      func(Op) error
+
+	buf      []byte
+	winStart int   // Start of the window in buf; buf[:winStart] is literal
+	copy     Op    // Pending run of copied blocks
+	next     int64 // Block that would extend the pending run
+}
+
+func (d *differ) run(r *bufio.Reader) error {
+	var roll rollingSum
+	fresh := true // The window's checksum must be computed from scratch
+	eof := false
+
+	for {
+		// Fill the window
+		for !eof && len(d.buf)-d.winStart < d.blockSize {
+			c, err := r.ReadByte()
+			if err == io.EOF {
+				eof = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			d.buf = append(d.buf, c)
+			if !fresh {
+				roll.add(c)
+			}
+		}
+
+		window := d.buf[d.winStart:]
+		if len(window) == 0 {
+			break
+		}
+		if fresh {
+			roll = newRollingSum(window)
+			fresh = false
+		}
+
+		// Only the last block of the basis can be shorter than a full block
+		if len(window) == d.blockSize || eof {
+			if block, ok := d.match(roll.sum(), window); ok {
+				if err := d.copyBlock(block); err != nil {
+					return err
+				}
+				d.buf = d.buf[:0]
+				d.winStart = 0
+				fresh = true
+				continue
+			}
+		}
+		if eof {
+			// Without more input the window can not slide any further
+			d.winStart = len(d.buf)
+			break
+		}
+
+		// Slide the window by a byte; the byte leaving it becomes literal
+		roll.remove(d.buf[d.winStart], len(window))
+		d.winStart++
+		if d.winStart >= maxLiteral {
+			if err := d.flushLiteral(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := d.flushLiteral(); err != nil {
+		return err
+	}
+	return d.flushCopy()
+}
+
+// match finds the block a window is a copy of, preferring the one that
+// continues the pending run
+func (d *differ) match(weak uint32, window []byte) (int64, bool) {
+	candidates := d.index[weak]
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	strong := strongSum(window)
+	found := int64(-1)
+	for _, i := range candidates {
+		if !bytes.Equal(d.blocks[i].Strong, strong) {
+			continue
+		}
+		if i == d.next {
+			return i, true
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	return found, found >= 0
+}
+
+// copyBlock records a matched block, after the literal data before it
+func (d *differ) copyBlock(block int64) error {
+	if err := d.flushLiteral(); err != nil {
+		return err
+	}
+	if d.copy.Count > 0 && block == d.next {
+		d.copy.Count++
+	} else {
+		if err := d.flushCopy(); err != nil {
+			return err
+		}
+		d.copy = Op{Index: block, Count: 1}
+	}
+	d.next = block + 1
+	return nil
+}
+
+func (d *differ) flushCopy() error {
+	if d.copy.Count == 0 {
+		return nil
+	}
+	op := d.copy
+	d.copy = Op{}
+	return d.emit(op)
+}
+
+// flushLiteral emits the literal data before the window and drops it from buf
+func (d *differ) flushLiteral() error {
+	if d.winStart == 0 {
+		return nil
+	}
+	if err := d.flushCopy(); err != nil {
+		return err
+	}
+	for literal := d.buf[:d.winStart]; len(literal) > 0; {
+		n := min(len(literal), maxLiteral)
+		if err := d.emit(Op{Data: append([]byte(nil), literal[:n]...)}); err != nil {
+			return err
+		}
+		literal = literal[n:]
+	}
+	d.buf = append(d.buf[:0], d.buf[d.winStart:]...)
+	d.winStart = 0
+	d.next = -1
+	return nil
+}
+
+// ErrBadOp is returned by Apply for ops outside the basis
+var ErrBadOp = errors.New("delta op refers to blocks outside the basis file")
+
+// Apply writes the part of the new file an op describes to w, reading copied
+// blocks from the basis of basisSize bytes
+func Apply(w io.Writer, basis io.ReaderAt, basisSize, blockSize int64, op Op) error {
+	if op.Count == 0 {
+		_, err := w.Write(op.Data)
+		return err
+	}
+
+	if !ValidBlockSize(blockSize) {
+		return ErrBadOp
+	}
+	blocks := basisSize/blockSize + 1
+	if op.Index < 0 || op.Count < 0 || op.Index >= blocks || op.Count > blocks {
+		return ErrBadOp
+	}
+	start := op.Index * blockSize
+	end := start + op.Count*blockSize
+	if end > basisSize {
+		// Only the last block can be short
+		if end-blockSize >= basisSize {
+			return ErrBadOp
+		}
+		end = basisSize
+	}
+	_, err := io.Copy(w, io.NewSectionReader(basis, start, end-start))
+	return err
+}
+
+// weakSum is rsync's rolling checksum of a block
+func weakSum(block []byte) uint32 {
+	return newRollingSum(block).sum()
+}
+
+// strongSum is the strong hash of a block
+func strongSum(block []byte) []byte {
+	sum := sha256.Sum256(block)
+	return sum[:StrongSize]
+}
+
+// rollingSum is rsync's checksum: a is the sum of the bytes of a window and
+// b the sum of the bytes weighted by their distance from the window's end,
+// both modulo 2^16. Moving the window by a byte updates them in constant time.
+type rollingSum struct {
+	a, b uint32
+}
+
+func newRollingSum(window []byte) rollingSum {
+	var r rollingSum
+	n := uint32(len(window))
+	for i, c := range window {
+		r.a += uint32(c)
+		r.b += (n - uint32(i)) * uint32(c)
+	}
+	return r
+}
+
+// add appends a byte to the end of the window
+func (r *rollingSum) add(c byte) {
+	r.a += uint32(c)
+	r.b += r.a
+}
+
+// remove drops the first byte of a window of length n
+func (r *rollingSum) remove(c byte, n int) {
+	r.a -= uint32(c)
+	r.b -= uint32(n) * uint32(c)
+}
+
+func (r rollingSum) sum() uint32 {
+	return r.a&0xffff | r.b<<16
+}
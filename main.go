@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -30,6 +31,16 @@ var Config struct {
 	CertFile     string
 	KeyFile      string
 	TLSEnabled   bool
+	DenyList     string
+	Symlinks     string
+	Versioning   string
+	VersionDir   string
+	KeepVersions int
+	KeepDays     int
+	SnapshotDir  string
+	ChecksumDir  string
+	ChecksumKeep int
+	AllowSetID   bool
 }
 
 func init() {
@@ -39,6 +50,11 @@ func init() {
 	Config.CertFile = "/etc/filesystem-daemon/certs/server.crt"
 	Config.KeyFile = "/etc/filesystem-daemon/certs/server.key"
 	Config.TLSEnabled = true
+	Config.Symlinks = "follow-within-root"
+	Config.VersionDir = "/var/lib/filesystem-daemon/versions"
+	Config.KeepVersions = 20
+	Config.KeepDays = 30
+	Config.ChecksumKeep = 100000
 
 	// Command line flags
 	flag.StringVar(&Config.WatchDir, "watch-dir", Config.WatchDir, "Directory to watch")
@@ -46,6 +62,16 @@ func init() {
 	flag.StringVar(&Config.CertFile, "cert", Config.CertFile, "TLS certificate file")
 	flag.StringVar(&Config.KeyFile, "key", Config.KeyFile, "TLS key file")
 	flag.BoolVar(&Config.TLSEnabled, "tls", Config.TLSEnabled, "Enable TLS")
+	flag.StringVar(&Config.DenyList, "deny", Config.DenyList, "Comma-separated gitignore-style patterns hidden from clients (e.g. .env,.git/,wp-config.php)")
+	flag.StringVar(&Config.Symlinks, "symlinks", Config.Symlinks, "Symlink policy: follow, follow-within-root or never")
+	flag.StringVar(&Config.Versioning, "versioning", Config.Versioning, "Comma-separated directories whose files keep previous versions (\".\" for all)")
+	flag.StringVar(&Config.VersionDir, "version-dir", Config.VersionDir, "Directory outside the watch directory that stores versions")
+	flag.IntVar(&Config.KeepVersions, "keep-versions", Config.KeepVersions, "Versions kept per file (0 for no limit)")
+	flag.IntVar(&Config.KeepDays, "keep-days", Config.KeepDays, "Days versions are kept (0 for no limit)")
+	flag.StringVar(&Config.SnapshotDir, "snapshot-dir", Config.SnapshotDir, "Directory below the watch directory that stores snapshots (disabled unless set)")
+	flag.StringVar(&Config.ChecksumDir, "checksum-dir", Config.ChecksumDir, "Directory below the watch directory that caches checksums (disabled unless set)")
+	flag.IntVar(&Config.ChecksumKeep, "checksum-keep", Config.ChecksumKeep, "Files whose checksums are cached (0 for no limit)")
+	flag.BoolVar(&Config.AllowSetID, "allow-setid", Config.AllowSetID, "Let clients set the setuid and setgid bits of files not owned by root")
 	flag.Parse()
 
 	// Initialize TLS configuration
@@ -83,6 +109,52 @@ func main() {
 		log.Printf("Warning: Failed to set PR_SET_NO_NEW_PRIVS: %v", err)
 	}
 
+	// Create the filesystem service and apply the deny list
+	filesystemService, err := service.NewFilesystemService(Config.WatchDir)
+	if err != nil {
+		log.Fatalf("Failed to open watch directory: %v", err)
+	}
+	defer filesystemService.Close()
+	if Config.DenyList != "" {
+		if err := filesystemService.SetDenyList(strings.Split(Config.DenyList, ",")); err != nil {
+			log.Fatalf("Invalid deny list: %v", err)
+		}
+		log.Printf("Hiding paths matching: %s", Config.DenyList)
+	}
+	if err := filesystemService.SetSymlinkPolicy(Config.Symlinks); err != nil {
+		log.Fatalf("Invalid symlink policy: %v", err)
+	}
+	log.Printf("Symlink policy: %s", Config.Symlinks)
+	// After the deny list, which would otherwise unhide the snapshot and checksum stores
+	if Config.Versioning != "" {
+		if err := filesystemService.SetVersioning(strings.Split(Config.Versioning, ","), Config.VersionDir, Config.KeepVersions, Config.KeepDays); err != nil {
+			log.Fatalf("Invalid versioning: %v", err)
+		}
+		log.Printf("Keeping up to %d versions for %d days of files in: %s", Config.KeepVersions, Config.KeepDays, Config.Versioning)
+	}
+	if Config.SnapshotDir != "" {
+		if err := filesystemService.SetSnapshotDir(Config.SnapshotDir); err != nil {
+			log.Fatalf("Invalid snapshot directory: %v", err)
+		}
+		log.Printf("Keeping snapshots in: %s", Config.SnapshotDir)
+	}
+	if Config.ChecksumDir != "" {
+		if err := filesystemService.SetChecksumCacheDir(Config.ChecksumDir, Config.ChecksumKeep); err != nil {
+			log.Fatalf("Invalid checksum directory: %v", err)
+		}
+		log.Printf("Caching checksums of up to %d files in: %s", Config.ChecksumKeep, Config.ChecksumDir)
+	}
+	if Config.AllowSetID {
+		filesystemService.SetAllowSetID(true)
+		log.Printf("Clients may set the setuid and setgid bits of files not owned by root")
+	}
+
+	// The deny list and lease locks are enforced for every RPC by interceptors
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(filesystemService.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(filesystemService.StreamServerInterceptor()),
+	}
+
 	// By default, use TLS for production
 	var grpcServer *grpc.Server
 	var lis net.Listener
@@ -102,7 +174,7 @@ func main() {
 			log.Fatalf("Failed to listen: %v", err)
 		}
 
-		grpcServer = grpc.NewServer()
+		grpcServer = grpc.NewServer(serverOpts...)
 	} else {
 		// Always use TLS for production or if dev mode is not explicitly enabled
 		if devMode && prodEnv {
@@ -125,11 +197,10 @@ func main() {
 		Config.TLSConfig.Certificates = []tls.Certificate{cert}
 		
 		creds := credentials.NewTLS(Config.TLSConfig)
-		grpcServer = grpc.NewServer(grpc.Creds(creds))
+		grpcServer = grpc.NewServer(append(serverOpts, grpc.Creds(creds))...)
 	}
 
-	// Create and register the filesystem service
-	filesystemService := service.NewFilesystemService(Config.WatchDir)
+	// Register the filesystem service
 	proto.RegisterFilesystemServiceServer(grpcServer, filesystemService)
 
 	// Enable reflection for easier client debugging and development
@@ -138,6 +209,7 @@ func main() {
 	// Log information about available methods
 	log.Printf("Filesystem service registered with the following operations:")
 	log.Printf(" - ListDirectory: List contents of a directory")
+	log.Printf(" - ListDirectoryStream: List contents of a directory (streaming)")
 	log.Printf(" - GetFileInfo: Get detailed information about a file")
 	log.Printf(" - CreateDirectory: Create a new directory")
 	log.Printf(" - Delete: Delete a file or directory")
@@ -148,6 +220,25 @@ func main() {
 	log.Printf(" - Exists: Check if a path exists")
 	log.Printf(" - GetDirectorySize: Get the size of a directory")
 	log.Printf(" - Search: Search for files/directories")
+	log.Printf(" - SearchStream: Search for files/directories (streaming)")
+	log.Printf(" - GrepFiles: Search file contents (streaming)")
+	log.Printf(" - CreateSymlink: Create a symbolic link")
+	log.Printf(" - SetPermissions: Change permissions")
+	log.Printf(" - SetOwner: Change owner and group")
+	log.Printf(" - SetTimes: Change timestamps or create an empty file")
+	log.Printf(" - GetXattrs/SetXattr/RemoveXattr: Manage extended attributes")
+	log.Printf(" - GetAcl/SetAcl: Manage POSIX ACLs")
+	log.Printf(" - WriteFile/AppendFile/Truncate: Edit files in place")
+	log.Printf(" - AcquireLock/RenewLock/ReleaseLock/ListLocks: Coordinate changes with lease locks")
+	log.Printf(" - ListVersions/RestoreVersion: Browse and restore previous versions of files")
+	log.Printf(" - CreateSnapshot/ListSnapshots/DiffSnapshot/RestoreSnapshot/DeleteSnapshot: Manage directory snapshots")
+	log.Printf(" - CompareDirectories: Compare two directory trees (streaming)")
+	log.Printf(" - DiffFiles/ApplyPatch: Show and apply unified diffs of text files")
+	log.Printf(" - GetSignature/ApplyDelta/GetDelta: Transfer files as rsync-style deltas")
+	log.Printf(" - GenerateManifest/VerifyManifest: Record and check the SHA-256 of every file in a directory")
+	log.Printf(" - GetChecksum: Compute MD5, SHA-1, SHA-256, SHA-512 and CRC-32C checksums with caching")
+	log.Printf(" - FindDuplicates/DeduplicateWithHardlinks: Find files with the same content and hard link them")
+	log.Printf(" - GetDiskUsage: Break down disk usage by directory, extension and MIME type")
 
 	// Start file system monitoring for changes (optional background task)
 	go func() {
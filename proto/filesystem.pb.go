@@ -21,14 +21,346 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// SortField selects the ordering of listed items
+type SortField int32
+
+const (
+	SortField_SORT_NAME     SortField = 0
+	SortField_SORT_SIZE     SortField = 1
+	SortField_SORT_MODIFIED SortField = 2
+	SortField_SORT_TYPE     SortField = 3 // Directories first, then files, then other types
+)
+
+// Enum value maps for SortField.
+var (
+	SortField_name = map[int32]string{
+		0: "SORT_NAME",
+		1: "SORT_SIZE",
+		2: "SORT_MODIFIED",
+		3: "SORT_TYPE",
+	}
+	SortField_value = map[string]int32{
+		"SORT_NAME":     0,
+		"SORT_SIZE":     1,
+		"SORT_MODIFIED": 2,
+		"SORT_TYPE":     3,
+	}
+)
+
+func (x SortField) Enum() *SortField {
+	p := new(SortField)
+	*p = x
+	return p
+}
+
+func (x SortField) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortField) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_filesystem_proto_enumTypes[0].Descriptor()
+}
+
+func (SortField) Type() protoreflect.EnumType {
+	return &file_proto_filesystem_proto_enumTypes[0]
+}
+
+func (x SortField) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortField.Descriptor instead.
+func (SortField) EnumDescriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{0}
+}
+
+// FileType distinguishes regular files from directories and special files
+type FileType int32
+
+const (
+	FileType_FILE_TYPE_UNKNOWN      FileType = 0
+	FileType_FILE_TYPE_REGULAR      FileType = 1
+	FileType_FILE_TYPE_DIRECTORY    FileType = 2
+	FileType_FILE_TYPE_SYMLINK      FileType = 3
+	FileType_FILE_TYPE_FIFO         FileType = 4
+	FileType_FILE_TYPE_SOCKET       FileType = 5
+	FileType_FILE_TYPE_CHAR_DEVICE  FileType = 6
+	FileType_FILE_TYPE_BLOCK_DEVICE FileType = 7
+)
+
+// Enum value maps for FileType.
+var (
+	FileType_name = map[int32]string{
+		0: "FILE_TYPE_UNKNOWN",
+		1: "FILE_TYPE_REGULAR",
+		2: "FILE_TYPE_DIRECTORY",
+		3: "FILE_TYPE_SYMLINK",
+		4: "FILE_TYPE_FIFO",
+		5: "FILE_TYPE_SOCKET",
+		6: "FILE_TYPE_CHAR_DEVICE",
+		7: "FILE_TYPE_BLOCK_DEVICE",
+	}
+	FileType_value = map[string]int32{
+		"FILE_TYPE_UNKNOWN":      0,
+		"FILE_TYPE_REGULAR":      1,
+		"FILE_TYPE_DIRECTORY":    2,
+		"FILE_TYPE_SYMLINK":      3,
+		"FILE_TYPE_FIFO":         4,
+		"FILE_TYPE_SOCKET":       5,
+		"FILE_TYPE_CHAR_DEVICE":  6,
+		"FILE_TYPE_BLOCK_DEVICE": 7,
+	}
+)
+
+func (x FileType) Enum() *FileType {
+	p := new(FileType)
+	*p = x
+	return p
+}
+
+func (x FileType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FileType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_filesystem_proto_enumTypes[1].Descriptor()
+}
+
+func (FileType) Type() protoreflect.EnumType {
+	return &file_proto_filesystem_proto_enumTypes[1]
+}
+
+func (x FileType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FileType.Descriptor instead.
+func (FileType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{1}
+}
+
+// AclTag is the kind of a POSIX ACL entry
+type AclTag int32
+
+const (
+	AclTag_ACL_TAG_UNDEFINED AclTag = 0
+	AclTag_ACL_TAG_USER_OBJ  AclTag = 1 // Owner, "user::"
+	AclTag_ACL_TAG_USER      AclTag = 2 // Named user, "user:name:"
+	AclTag_ACL_TAG_GROUP_OBJ AclTag = 3 // Owning group, "group::"
+	AclTag_ACL_TAG_GROUP     AclTag = 4 // Named group, "group:name:"
+	AclTag_ACL_TAG_MASK      AclTag = 5 // Upper bound for the group class, "mask::"
+	AclTag_ACL_TAG_OTHER     AclTag = 6 // Everyone else, "other::"
+)
+
+// Enum value maps for AclTag.
+var (
+	AclTag_name = map[int32]string{
+		0: "ACL_TAG_UNDEFINED",
+		1: "ACL_TAG_USER_OBJ",
+		2: "ACL_TAG_USER",
+		3: "ACL_TAG_GROUP_OBJ",
+		4: "ACL_TAG_GROUP",
+		5: "ACL_TAG_MASK",
+		6: "ACL_TAG_OTHER",
+	}
+	AclTag_value = map[string]int32{
+		"ACL_TAG_UNDEFINED": 0,
+		"ACL_TAG_USER_OBJ":  1,
+		"ACL_TAG_USER":      2,
+		"ACL_TAG_GROUP_OBJ": 3,
+		"ACL_TAG_GROUP":     4,
+		"ACL_TAG_MASK":      5,
+		"ACL_TAG_OTHER":     6,
+	}
+)
+
+func (x AclTag) Enum() *AclTag {
+	p := new(AclTag)
+	*p = x
+	return p
+}
+
+func (x AclTag) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AclTag) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_filesystem_proto_enumTypes[2].Descriptor()
+}
+
+func (AclTag) Type() protoreflect.EnumType {
+	return &file_proto_filesystem_proto_enumTypes[2]
+}
+
+func (x AclTag) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AclTag.Descriptor instead.
+func (AclTag) EnumDescriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{2}
+}
+
+type LockMode int32
+
+const (
+	LockMode_LOCK_EXCLUSIVE LockMode = 0
+	LockMode_LOCK_SHARED    LockMode = 1 // Any number of shared leases can be held on overlapping paths
+)
+
+// Enum value maps for LockMode.
+var (
+	LockMode_name = map[int32]string{
+		0: "LOCK_EXCLUSIVE",
+		1: "LOCK_SHARED",
+	}
+	LockMode_value = map[string]int32{
+		"LOCK_EXCLUSIVE": 0,
+		"LOCK_SHARED":    1,
+	}
+)
+
+func (x LockMode) Enum() *LockMode {
+	p := new(LockMode)
+	*p = x
+	return p
+}
+
+func (x LockMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LockMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_filesystem_proto_enumTypes[3].Descriptor()
+}
+
+func (LockMode) Type() protoreflect.EnumType {
+	return &file_proto_filesystem_proto_enumTypes[3]
+}
+
+func (x LockMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LockMode.Descriptor instead.
+func (LockMode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{3}
+}
+
+type ChangeType int32
+
+const (
+	ChangeType_CHANGE_ADDED    ChangeType = 0
+	ChangeType_CHANGE_DELETED  ChangeType = 1
+	ChangeType_CHANGE_MODIFIED ChangeType = 2 // See PathChange.differences
+)
+
+// Enum value maps for ChangeType.
+var (
+	ChangeType_name = map[int32]string{
+		0: "CHANGE_ADDED",
+		1: "CHANGE_DELETED",
+		2: "CHANGE_MODIFIED",
+	}
+	ChangeType_value = map[string]int32{
+		"CHANGE_ADDED":    0,
+		"CHANGE_DELETED":  1,
+		"CHANGE_MODIFIED": 2,
+	}
+)
+
+func (x ChangeType) Enum() *ChangeType {
+	p := new(ChangeType)
+	*p = x
+	return p
+}
+
+func (x ChangeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_filesystem_proto_enumTypes[4].Descriptor()
+}
+
+func (ChangeType) Type() protoreflect.EnumType {
+	return &file_proto_filesystem_proto_enumTypes[4]
+}
+
+func (x ChangeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeType.Descriptor instead.
+func (ChangeType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{4}
+}
+
+type CompareMode int32
+
+const (
+	CompareMode_COMPARE_QUICK    CompareMode = 0 // Size and modification time
+	CompareMode_COMPARE_METADATA CompareMode = 1 // Permissions and owner
+	CompareMode_COMPARE_CONTENT  CompareMode = 2 // SHA-256 of the content
+)
+
+// Enum value maps for CompareMode.
+var (
+	CompareMode_name = map[int32]string{
+		0: "COMPARE_QUICK",
+		1: "COMPARE_METADATA",
+		2: "COMPARE_CONTENT",
+	}
+	CompareMode_value = map[string]int32{
+		"COMPARE_QUICK":    0,
+		"COMPARE_METADATA": 1,
+		"COMPARE_CONTENT":  2,
+	}
+)
+
+func (x CompareMode) Enum() *CompareMode {
+	p := new(CompareMode)
+	*p = x
+	return p
+}
+
+func (x CompareMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CompareMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_filesystem_proto_enumTypes[5].Descriptor()
+}
+
+func (CompareMode) Type() protoreflect.EnumType {
+	return &file_proto_filesystem_proto_enumTypes[5]
+}
+
+func (x CompareMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CompareMode.Descriptor instead.
+func (CompareMode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{5}
+}
+
 // ListRequest specifies a directory to list
 type ListRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Recursive     bool                   `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"`
-	Pattern       string                 `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"` // Optional glob pattern
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Recursive      bool                   `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	Pattern        string                 `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"`                                         // Optional glob pattern
+	PageSize       int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`                      // Maximum items per page (0 for all items)
+	PageToken      string                 `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`                    // next_page_token from a previous response
+	SortBy         SortField              `protobuf:"varint,6,opt,name=sort_by,json=sortBy,proto3,enum=filesystem.SortField" json:"sort_by,omitempty"`  // Server-side ordering
+	Descending     bool                   `protobuf:"varint,7,opt,name=descending,proto3" json:"descending,omitempty"`                                  // Reverse the ordering
+	Include        []string               `protobuf:"bytes,8,rep,name=include,proto3" json:"include,omitempty"`                                         // gitignore-style patterns an entry must match
+	Exclude        []string               `protobuf:"bytes,9,rep,name=exclude,proto3" json:"exclude,omitempty"`                                         // gitignore-style patterns that hide entries
+	UseIgnoreFiles bool                   `protobuf:"varint,10,opt,name=use_ignore_files,json=useIgnoreFiles,proto3" json:"use_ignore_files,omitempty"` // Honor .gitignore and .fsdaemonignore files
+	Checksums      []string               `protobuf:"bytes,11,rep,name=checksums,proto3" json:"checksums,omitempty"`                                    // Also return these checksums of regular files, see ChecksumRequest
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ListRequest) Reset() {
@@ -82,6 +414,62 @@ func (x *ListRequest) GetPattern() string {
 	return ""
 }
 
+func (x *ListRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListRequest) GetSortBy() SortField {
+	if x != nil {
+		return x.SortBy
+	}
+	return SortField_SORT_NAME
+}
+
+func (x *ListRequest) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+func (x *ListRequest) GetInclude() []string {
+	if x != nil {
+		return x.Include
+	}
+	return nil
+}
+
+func (x *ListRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *ListRequest) GetUseIgnoreFiles() bool {
+	if x != nil {
+		return x.UseIgnoreFiles
+	}
+	return false
+}
+
+func (x *ListRequest) GetChecksums() []string {
+	if x != nil {
+		return x.Checksums
+	}
+	return nil
+}
+
 // FileItem represents a file or directory
 type FileItem struct {
 	state        protoimpl.MessageState `protogen:"open.v1"`
@@ -92,10 +480,21 @@ type FileItem struct {
 	ModifiedTime int64                  `protobuf:"varint,5,opt,name=modified_time,json=modifiedTime,proto3" json:"modified_time,omitempty"`
 	Permissions  string                 `protobuf:"bytes,6,opt,name=permissions,proto3" json:"permissions,omitempty"`
 	// Fields added to support hierarchy
-	Children      []*FileItem `protobuf:"bytes,7,rep,name=children,proto3" json:"children,omitempty"`                       // Child items if this is a directory
-	ParentPath    string      `protobuf:"bytes,8,opt,name=parent_path,json=parentPath,proto3" json:"parent_path,omitempty"` // Path to parent directory
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Children       []*FileItem       `protobuf:"bytes,7,rep,name=children,proto3" json:"children,omitempty"`                        // Child items if this is a directory
+	ParentPath     string            `protobuf:"bytes,8,opt,name=parent_path,json=parentPath,proto3" json:"parent_path,omitempty"`  // Path to parent directory
+	IsSymlink      bool              `protobuf:"varint,9,opt,name=is_symlink,json=isSymlink,proto3" json:"is_symlink,omitempty"`    // The entry is a symbolic link; other fields describe its target if it was followed
+	LinkTarget     string            `protobuf:"bytes,10,opt,name=link_target,json=linkTarget,proto3" json:"link_target,omitempty"` // Target of the symbolic link as stored in the link
+	FileType       FileType          `protobuf:"varint,11,opt,name=file_type,json=fileType,proto3,enum=filesystem.FileType" json:"file_type,omitempty"`
+	Mode           string            `protobuf:"bytes,12,opt,name=mode,proto3" json:"mode,omitempty"` // ls-style mode string such as "-rwsr-xr-x"
+	Inode          uint64            `protobuf:"varint,13,opt,name=inode,proto3" json:"inode,omitempty"`
+	Device         uint64            `protobuf:"varint,14,opt,name=device,proto3" json:"device,omitempty"`                                                                                // ID of the device containing the file
+	LinkCount      uint64            `protobuf:"varint,15,opt,name=link_count,json=linkCount,proto3" json:"link_count,omitempty"`                                                         // Number of hard links
+	Blocks         int64             `protobuf:"varint,16,opt,name=blocks,proto3" json:"blocks,omitempty"`                                                                                // Allocated 512-byte blocks
+	ModifiedTimeNs int64             `protobuf:"varint,17,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"`                                        // modified_time with nanosecond precision (Unix nanoseconds)
+	Etag           string            `protobuf:"bytes,18,opt,name=etag,proto3" json:"etag,omitempty"`                                                                                     // Changes whenever the file is modified or replaced
+	Checksums      map[string]string `protobuf:"bytes,19,rep,name=checksums,proto3" json:"checksums,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Only filled when the request asks for checksums
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *FileItem) Reset() {
@@ -184,10 +583,88 @@ func (x *FileItem) GetParentPath() string {
 	return ""
 }
 
+func (x *FileItem) GetIsSymlink() bool {
+	if x != nil {
+		return x.IsSymlink
+	}
+	return false
+}
+
+func (x *FileItem) GetLinkTarget() string {
+	if x != nil {
+		return x.LinkTarget
+	}
+	return ""
+}
+
+func (x *FileItem) GetFileType() FileType {
+	if x != nil {
+		return x.FileType
+	}
+	return FileType_FILE_TYPE_UNKNOWN
+}
+
+func (x *FileItem) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *FileItem) GetInode() uint64 {
+	if x != nil {
+		return x.Inode
+	}
+	return 0
+}
+
+func (x *FileItem) GetDevice() uint64 {
+	if x != nil {
+		return x.Device
+	}
+	return 0
+}
+
+func (x *FileItem) GetLinkCount() uint64 {
+	if x != nil {
+		return x.LinkCount
+	}
+	return 0
+}
+
+func (x *FileItem) GetBlocks() int64 {
+	if x != nil {
+		return x.Blocks
+	}
+	return 0
+}
+
+func (x *FileItem) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *FileItem) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *FileItem) GetChecksums() map[string]string {
+	if x != nil {
+		return x.Checksums
+	}
+	return nil
+}
+
 // ListResponse contains directory contents
 type ListResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Items         []*FileItem            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Set when more results are available
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -229,10 +706,20 @@ func (x *ListResponse) GetItems() []*FileItem {
 	return nil
 }
 
+func (x *ListResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
 // FileRequest specifies a file path
 type FileRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	IncludeXattrs bool                   `protobuf:"varint,2,opt,name=include_xattrs,json=includeXattrs,proto3" json:"include_xattrs,omitempty"` // GetFileInfo: also return extended attributes
+	VersionId     string                 `protobuf:"bytes,3,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`              // DownloadFile: send this saved version instead of the current content
+	Checksums     []string               `protobuf:"bytes,4,rep,name=checksums,proto3" json:"checksums,omitempty"`                               // GetFileInfo: also return these checksums of a regular file, see ChecksumRequest
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -274,33 +761,73 @@ func (x *FileRequest) GetPath() string {
 	return ""
 }
 
-// FileInfo contains detailed information about a file
-type FileInfo struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
-	IsDirectory   bool                   `protobuf:"varint,3,opt,name=is_directory,json=isDirectory,proto3" json:"is_directory,omitempty"`
-	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
-	ModifiedTime  int64                  `protobuf:"varint,5,opt,name=modified_time,json=modifiedTime,proto3" json:"modified_time,omitempty"`
-	CreationTime  int64                  `protobuf:"varint,6,opt,name=creation_time,json=creationTime,proto3" json:"creation_time,omitempty"`
-	AccessTime    int64                  `protobuf:"varint,7,opt,name=access_time,json=accessTime,proto3" json:"access_time,omitempty"`
-	MimeType      string                 `protobuf:"bytes,8,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
-	Permissions   string                 `protobuf:"bytes,9,opt,name=permissions,proto3" json:"permissions,omitempty"`
-	Owner         string                 `protobuf:"bytes,10,opt,name=owner,proto3" json:"owner,omitempty"`
-	Group         string                 `protobuf:"bytes,11,opt,name=group,proto3" json:"group,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *FileRequest) GetIncludeXattrs() bool {
+	if x != nil {
+		return x.IncludeXattrs
+	}
+	return false
 }
 
-func (x *FileInfo) Reset() {
-	*x = FileInfo{}
-	mi := &file_proto_filesystem_proto_msgTypes[4]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *FileRequest) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
 }
 
-func (x *FileInfo) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *FileRequest) GetChecksums() []string {
+	if x != nil {
+		return x.Checksums
+	}
+	return nil
+}
+
+// FileInfo contains detailed information about a file
+type FileInfo struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Name         string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path         string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	IsDirectory  bool                   `protobuf:"varint,3,opt,name=is_directory,json=isDirectory,proto3" json:"is_directory,omitempty"`
+	Size         int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	ModifiedTime int64                  `protobuf:"varint,5,opt,name=modified_time,json=modifiedTime,proto3" json:"modified_time,omitempty"`
+	CreationTime int64                  `protobuf:"varint,6,opt,name=creation_time,json=creationTime,proto3" json:"creation_time,omitempty"` // Birth time, 0 where the filesystem does not record it
+	AccessTime   int64                  `protobuf:"varint,7,opt,name=access_time,json=accessTime,proto3" json:"access_time,omitempty"`
+	MimeType     string                 `protobuf:"bytes,8,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Permissions  string                 `protobuf:"bytes,9,opt,name=permissions,proto3" json:"permissions,omitempty"`
+	Owner        string                 `protobuf:"bytes,10,opt,name=owner,proto3" json:"owner,omitempty"`                             // User name, or the numeric UID if it has no name
+	Group        string                 `protobuf:"bytes,11,opt,name=group,proto3" json:"group,omitempty"`                             // Group name, or the numeric GID if it has no name
+	IsSymlink    bool                   `protobuf:"varint,12,opt,name=is_symlink,json=isSymlink,proto3" json:"is_symlink,omitempty"`   // The path is a symbolic link; other fields describe its target if it was followed
+	LinkTarget   string                 `protobuf:"bytes,13,opt,name=link_target,json=linkTarget,proto3" json:"link_target,omitempty"` // Target of the symbolic link as stored in the link
+	FileType     FileType               `protobuf:"varint,14,opt,name=file_type,json=fileType,proto3,enum=filesystem.FileType" json:"file_type,omitempty"`
+	Mode         string                 `protobuf:"bytes,15,opt,name=mode,proto3" json:"mode,omitempty"` // ls-style mode string such as "-rwsr-xr-x"
+	Inode        uint64                 `protobuf:"varint,16,opt,name=inode,proto3" json:"inode,omitempty"`
+	Device       uint64                 `protobuf:"varint,17,opt,name=device,proto3" json:"device,omitempty"`                        // ID of the device containing the file
+	LinkCount    uint64                 `protobuf:"varint,18,opt,name=link_count,json=linkCount,proto3" json:"link_count,omitempty"` // Number of hard links
+	Blocks       int64                  `protobuf:"varint,19,opt,name=blocks,proto3" json:"blocks,omitempty"`                        // Allocated 512-byte blocks
+	Uid          uint32                 `protobuf:"varint,20,opt,name=uid,proto3" json:"uid,omitempty"`
+	Gid          uint32                 `protobuf:"varint,21,opt,name=gid,proto3" json:"gid,omitempty"`
+	ChangeTime   int64                  `protobuf:"varint,22,opt,name=change_time,json=changeTime,proto3" json:"change_time,omitempty"` // Inode change time
+	// Time fields with nanosecond precision (Unix nanoseconds)
+	ModifiedTimeNs int64             `protobuf:"varint,23,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"`
+	CreationTimeNs int64             `protobuf:"varint,24,opt,name=creation_time_ns,json=creationTimeNs,proto3" json:"creation_time_ns,omitempty"`
+	AccessTimeNs   int64             `protobuf:"varint,25,opt,name=access_time_ns,json=accessTimeNs,proto3" json:"access_time_ns,omitempty"`
+	ChangeTimeNs   int64             `protobuf:"varint,26,opt,name=change_time_ns,json=changeTimeNs,proto3" json:"change_time_ns,omitempty"`
+	Xattrs         []*Xattr          `protobuf:"bytes,27,rep,name=xattrs,proto3" json:"xattrs,omitempty"`                                                                                 // Only filled when include_xattrs is set
+	Etag           string            `protobuf:"bytes,28,opt,name=etag,proto3" json:"etag,omitempty"`                                                                                     // Changes whenever the file is modified or replaced
+	Checksums      map[string]string `protobuf:"bytes,29,rep,name=checksums,proto3" json:"checksums,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Only filled when the request asks for checksums
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FileInfo) Reset() {
+	*x = FileInfo{}
+	mi := &file_proto_filesystem_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
 func (*FileInfo) ProtoMessage() {}
@@ -399,6 +926,132 @@ func (x *FileInfo) GetGroup() string {
 	return ""
 }
 
+func (x *FileInfo) GetIsSymlink() bool {
+	if x != nil {
+		return x.IsSymlink
+	}
+	return false
+}
+
+func (x *FileInfo) GetLinkTarget() string {
+	if x != nil {
+		return x.LinkTarget
+	}
+	return ""
+}
+
+func (x *FileInfo) GetFileType() FileType {
+	if x != nil {
+		return x.FileType
+	}
+	return FileType_FILE_TYPE_UNKNOWN
+}
+
+func (x *FileInfo) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *FileInfo) GetInode() uint64 {
+	if x != nil {
+		return x.Inode
+	}
+	return 0
+}
+
+func (x *FileInfo) GetDevice() uint64 {
+	if x != nil {
+		return x.Device
+	}
+	return 0
+}
+
+func (x *FileInfo) GetLinkCount() uint64 {
+	if x != nil {
+		return x.LinkCount
+	}
+	return 0
+}
+
+func (x *FileInfo) GetBlocks() int64 {
+	if x != nil {
+		return x.Blocks
+	}
+	return 0
+}
+
+func (x *FileInfo) GetUid() uint32 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *FileInfo) GetGid() uint32 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *FileInfo) GetChangeTime() int64 {
+	if x != nil {
+		return x.ChangeTime
+	}
+	return 0
+}
+
+func (x *FileInfo) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *FileInfo) GetCreationTimeNs() int64 {
+	if x != nil {
+		return x.CreationTimeNs
+	}
+	return 0
+}
+
+func (x *FileInfo) GetAccessTimeNs() int64 {
+	if x != nil {
+		return x.AccessTimeNs
+	}
+	return 0
+}
+
+func (x *FileInfo) GetChangeTimeNs() int64 {
+	if x != nil {
+		return x.ChangeTimeNs
+	}
+	return 0
+}
+
+func (x *FileInfo) GetXattrs() []*Xattr {
+	if x != nil {
+		return x.Xattrs
+	}
+	return nil
+}
+
+func (x *FileInfo) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *FileInfo) GetChecksums() map[string]string {
+	if x != nil {
+		return x.Checksums
+	}
+	return nil
+}
+
 // CreateDirectoryRequest specifies path for new directory
 type CreateDirectoryRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -454,9 +1107,14 @@ func (x *CreateDirectoryRequest) GetPermissions() int32 {
 
 // DeleteRequest specifies path to delete
 type DeleteRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Recursive     bool                   `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"` // For directories
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Path      string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Recursive bool                   `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"` // For directories
+	// Preconditions on the path's ETag. if_match fails unless the path exists
+	// with one of the given ETags ("*" for any); if_none_match fails if it
+	// exists with one of them ("*" for any). Lists are comma separated.
+	IfMatch       string `protobuf:"bytes,3,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"`
+	IfNoneMatch   string `protobuf:"bytes,4,opt,name=if_none_match,json=ifNoneMatch,proto3" json:"if_none_match,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -505,14 +1163,31 @@ func (x *DeleteRequest) GetRecursive() bool {
 	return false
 }
 
+func (x *DeleteRequest) GetIfMatch() string {
+	if x != nil {
+		return x.IfMatch
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetIfNoneMatch() string {
+	if x != nil {
+		return x.IfNoneMatch
+	}
+	return ""
+}
+
 // CopyRequest specifies source and destination
 type CopyRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
-	Destination   string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
-	Overwrite     bool                   `protobuf:"varint,3,opt,name=overwrite,proto3" json:"overwrite,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Source         string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Destination    string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	Overwrite      bool                   `protobuf:"varint,3,opt,name=overwrite,proto3" json:"overwrite,omitempty"`
+	PreserveXattrs bool                   `protobuf:"varint,4,opt,name=preserve_xattrs,json=preserveXattrs,proto3" json:"preserve_xattrs,omitempty"` // Carry extended attributes, including POSIX ACLs, along
+	IfMatch        string                 `protobuf:"bytes,5,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"`                       // ETag preconditions on the destination, as in DeleteRequest
+	IfNoneMatch    string                 `protobuf:"bytes,6,opt,name=if_none_match,json=ifNoneMatch,proto3" json:"if_none_match,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *CopyRequest) Reset() {
@@ -566,12 +1241,36 @@ func (x *CopyRequest) GetOverwrite() bool {
 	return false
 }
 
+func (x *CopyRequest) GetPreserveXattrs() bool {
+	if x != nil {
+		return x.PreserveXattrs
+	}
+	return false
+}
+
+func (x *CopyRequest) GetIfMatch() string {
+	if x != nil {
+		return x.IfMatch
+	}
+	return ""
+}
+
+func (x *CopyRequest) GetIfNoneMatch() string {
+	if x != nil {
+		return x.IfNoneMatch
+	}
+	return ""
+}
+
 // MoveRequest specifies source and destination
 type MoveRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
 	Destination   string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
 	Overwrite     bool                   `protobuf:"varint,3,opt,name=overwrite,proto3" json:"overwrite,omitempty"`
+	IfMatch       string                 `protobuf:"bytes,4,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"` // ETag preconditions on the destination, as in DeleteRequest
+	IfNoneMatch   string                 `protobuf:"bytes,5,opt,name=if_none_match,json=ifNoneMatch,proto3" json:"if_none_match,omitempty"`
+	SourceIfMatch string                 `protobuf:"bytes,6,opt,name=source_if_match,json=sourceIfMatch,proto3" json:"source_if_match,omitempty"` // ETag precondition on the source
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -627,28 +1326,51 @@ func (x *MoveRequest) GetOverwrite() bool {
 	return false
 }
 
-// PathRequest specifies a path for operations
-type PathRequest struct {
+func (x *MoveRequest) GetIfMatch() string {
+	if x != nil {
+		return x.IfMatch
+	}
+	return ""
+}
+
+func (x *MoveRequest) GetIfNoneMatch() string {
+	if x != nil {
+		return x.IfNoneMatch
+	}
+	return ""
+}
+
+func (x *MoveRequest) GetSourceIfMatch() string {
+	if x != nil {
+		return x.SourceIfMatch
+	}
+	return ""
+}
+
+// SymlinkRequest specifies a symbolic link to create
+type SymlinkRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`            // Location of the new link
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`        // Target stored in the link, relative to the link's directory or absolute
+	Overwrite     bool                   `protobuf:"varint,3,opt,name=overwrite,proto3" json:"overwrite,omitempty"` // Replace an existing file or link at path
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PathRequest) Reset() {
-	*x = PathRequest{}
+func (x *SymlinkRequest) Reset() {
+	*x = SymlinkRequest{}
 	mi := &file_proto_filesystem_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PathRequest) String() string {
+func (x *SymlinkRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PathRequest) ProtoMessage() {}
+func (*SymlinkRequest) ProtoMessage() {}
 
-func (x *PathRequest) ProtoReflect() protoreflect.Message {
+func (x *SymlinkRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_filesystem_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -660,41 +1382,60 @@ func (x *PathRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PathRequest.ProtoReflect.Descriptor instead.
-func (*PathRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SymlinkRequest.ProtoReflect.Descriptor instead.
+func (*SymlinkRequest) Descriptor() ([]byte, []int) {
 	return file_proto_filesystem_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *PathRequest) GetPath() string {
+func (x *SymlinkRequest) GetPath() string {
 	if x != nil {
 		return x.Path
 	}
 	return ""
 }
 
-// ExistsResponse indicates if a path exists
-type ExistsResponse struct {
+func (x *SymlinkRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SymlinkRequest) GetOverwrite() bool {
+	if x != nil {
+		return x.Overwrite
+	}
+	return false
+}
+
+// SetPermissionsRequest changes permission bits. Modes are octal ("0644") or
+// symbolic like chmod ("u=rwX,go-w"); file_mode and dir_mode override mode
+// for files and directories respectively.
+type SetPermissionsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Exists        bool                   `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
-	IsDirectory   bool                   `protobuf:"varint,2,opt,name=is_directory,json=isDirectory,proto3" json:"is_directory,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Mode          string                 `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	Recursive     bool                   `protobuf:"varint,3,opt,name=recursive,proto3" json:"recursive,omitempty"` // Also change everything beneath a directory; symlinks are skipped
+	FileMode      string                 `protobuf:"bytes,4,opt,name=file_mode,json=fileMode,proto3" json:"file_mode,omitempty"`
+	DirMode       string                 `protobuf:"bytes,5,opt,name=dir_mode,json=dirMode,proto3" json:"dir_mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ExistsResponse) Reset() {
-	*x = ExistsResponse{}
+func (x *SetPermissionsRequest) Reset() {
+	*x = SetPermissionsRequest{}
 	mi := &file_proto_filesystem_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ExistsResponse) String() string {
+func (x *SetPermissionsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExistsResponse) ProtoMessage() {}
+func (*SetPermissionsRequest) ProtoMessage() {}
 
-func (x *ExistsResponse) ProtoReflect() protoreflect.Message {
+func (x *SetPermissionsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_filesystem_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -706,47 +1447,72 @@ func (x *ExistsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExistsResponse.ProtoReflect.Descriptor instead.
-func (*ExistsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SetPermissionsRequest.ProtoReflect.Descriptor instead.
+func (*SetPermissionsRequest) Descriptor() ([]byte, []int) {
 	return file_proto_filesystem_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *ExistsResponse) GetExists() bool {
+func (x *SetPermissionsRequest) GetPath() string {
 	if x != nil {
-		return x.Exists
+		return x.Path
 	}
-	return false
+	return ""
 }
 
-func (x *ExistsResponse) GetIsDirectory() bool {
+func (x *SetPermissionsRequest) GetMode() string {
 	if x != nil {
-		return x.IsDirectory
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *SetPermissionsRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
 	}
 	return false
 }
 
-// SizeResponse returns size in bytes
-type SizeResponse struct {
+func (x *SetPermissionsRequest) GetFileMode() string {
+	if x != nil {
+		return x.FileMode
+	}
+	return ""
+}
+
+func (x *SetPermissionsRequest) GetDirMode() string {
+	if x != nil {
+		return x.DirMode
+	}
+	return ""
+}
+
+// SetOwnerRequest changes owner and group; an empty field is left unchanged.
+// Changing the owner requires a privileged daemon.
+type SetOwnerRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`          // User name or UID
+	Group         string                 `protobuf:"bytes,3,opt,name=group,proto3" json:"group,omitempty"`          // Group name or GID
+	Recursive     bool                   `protobuf:"varint,4,opt,name=recursive,proto3" json:"recursive,omitempty"` // Also change everything beneath a directory; symlinks are skipped
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SizeResponse) Reset() {
-	*x = SizeResponse{}
+func (x *SetOwnerRequest) Reset() {
+	*x = SetOwnerRequest{}
 	mi := &file_proto_filesystem_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SizeResponse) String() string {
+func (x *SetOwnerRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SizeResponse) ProtoMessage() {}
+func (*SetOwnerRequest) ProtoMessage() {}
 
-func (x *SizeResponse) ProtoReflect() protoreflect.Message {
+func (x *SetOwnerRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_filesystem_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -758,44 +1524,4139 @@ func (x *SizeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SizeResponse.ProtoReflect.Descriptor instead.
-func (*SizeResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SetOwnerRequest.ProtoReflect.Descriptor instead.
+func (*SetOwnerRequest) Descriptor() ([]byte, []int) {
 	return file_proto_filesystem_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *SizeResponse) GetSize() int64 {
+func (x *SetOwnerRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SetOwnerRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *SetOwnerRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *SetOwnerRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+// SetTimesRequest sets timestamps in Unix nanoseconds: 0 means the current
+// time and -1 leaves the timestamp unchanged
+type SetTimesRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	ModifiedTimeNs int64                  `protobuf:"varint,2,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"`
+	AccessTimeNs   int64                  `protobuf:"varint,3,opt,name=access_time_ns,json=accessTimeNs,proto3" json:"access_time_ns,omitempty"`
+	Create         bool                   `protobuf:"varint,4,opt,name=create,proto3" json:"create,omitempty"` // Create an empty file if path does not exist
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SetTimesRequest) Reset() {
+	*x = SetTimesRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetTimesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetTimesRequest) ProtoMessage() {}
+
+func (x *SetTimesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetTimesRequest.ProtoReflect.Descriptor instead.
+func (*SetTimesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetTimesRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SetTimesRequest) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *SetTimesRequest) GetAccessTimeNs() int64 {
+	if x != nil {
+		return x.AccessTimeNs
+	}
+	return 0
+}
+
+func (x *SetTimesRequest) GetCreate() bool {
+	if x != nil {
+		return x.Create
+	}
+	return false
+}
+
+// Xattr is an extended attribute, e.g. "user.origin"
+type Xattr struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Xattr) Reset() {
+	*x = Xattr{}
+	mi := &file_proto_filesystem_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Xattr) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Xattr) ProtoMessage() {}
+
+func (x *Xattr) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Xattr.ProtoReflect.Descriptor instead.
+func (*Xattr) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Xattr) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Xattr) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// XattrRequest names a file and, for RemoveXattr, an attribute. GetXattrs
+// returns every attribute when name is empty.
+type XattrRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *XattrRequest) Reset() {
+	*x = XattrRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *XattrRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*XattrRequest) ProtoMessage() {}
+
+func (x *XattrRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use XattrRequest.ProtoReflect.Descriptor instead.
+func (*XattrRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *XattrRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *XattrRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// XattrResponse lists extended attributes sorted by name
+type XattrResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Xattrs        []*Xattr               `protobuf:"bytes,1,rep,name=xattrs,proto3" json:"xattrs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *XattrResponse) Reset() {
+	*x = XattrResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *XattrResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*XattrResponse) ProtoMessage() {}
+
+func (x *XattrResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use XattrResponse.ProtoReflect.Descriptor instead.
+func (*XattrResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *XattrResponse) GetXattrs() []*Xattr {
+	if x != nil {
+		return x.Xattrs
+	}
+	return nil
+}
+
+// SetXattrRequest sets an extended attribute. Only user.* attributes can be
+// changed; ACLs are set with SetAcl.
+type SetXattrRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Value         []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Create        bool                   `protobuf:"varint,4,opt,name=create,proto3" json:"create,omitempty"`   // Fail if the attribute already exists
+	Replace       bool                   `protobuf:"varint,5,opt,name=replace,proto3" json:"replace,omitempty"` // Fail if the attribute does not exist
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetXattrRequest) Reset() {
+	*x = SetXattrRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetXattrRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetXattrRequest) ProtoMessage() {}
+
+func (x *SetXattrRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetXattrRequest.ProtoReflect.Descriptor instead.
+func (*SetXattrRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetXattrRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SetXattrRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SetXattrRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *SetXattrRequest) GetCreate() bool {
+	if x != nil {
+		return x.Create
+	}
+	return false
+}
+
+func (x *SetXattrRequest) GetReplace() bool {
+	if x != nil {
+		return x.Replace
+	}
+	return false
+}
+
+// AclEntry is one entry of a POSIX ACL
+type AclEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           AclTag                 `protobuf:"varint,1,opt,name=tag,proto3,enum=filesystem.AclTag" json:"tag,omitempty"`
+	Id            uint32                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`                  // UID or GID for named entries
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`               // User or group name; may be given instead of id
+	Permissions   string                 `protobuf:"bytes,4,opt,name=permissions,proto3" json:"permissions,omitempty"` // "rwx" with "-" for missing permissions
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AclEntry) Reset() {
+	*x = AclEntry{}
+	mi := &file_proto_filesystem_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AclEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AclEntry) ProtoMessage() {}
+
+func (x *AclEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AclEntry.ProtoReflect.Descriptor instead.
+func (*AclEntry) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AclEntry) GetTag() AclTag {
+	if x != nil {
+		return x.Tag
+	}
+	return AclTag_ACL_TAG_UNDEFINED
+}
+
+func (x *AclEntry) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AclEntry) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AclEntry) GetPermissions() string {
+	if x != nil {
+		return x.Permissions
+	}
+	return ""
+}
+
+// AclRequest selects the access ACL, or the default ACL of a directory
+type AclRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Default       bool                   `protobuf:"varint,2,opt,name=default,proto3" json:"default,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AclRequest) Reset() {
+	*x = AclRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AclRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AclRequest) ProtoMessage() {}
+
+func (x *AclRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AclRequest.ProtoReflect.Descriptor instead.
+func (*AclRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AclRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *AclRequest) GetDefault() bool {
+	if x != nil {
+		return x.Default
+	}
+	return false
+}
+
+// AclResponse holds an ACL. Without an ACL the entries follow from the mode.
+type AclResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*AclEntry            `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"` // getfacl style, e.g. "user::rw-,user:alice:r--,group::r--,mask::r--,other::---"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AclResponse) Reset() {
+	*x = AclResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AclResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AclResponse) ProtoMessage() {}
+
+func (x *AclResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AclResponse.ProtoReflect.Descriptor instead.
+func (*AclResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AclResponse) GetEntries() []*AclEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *AclResponse) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// SetAclRequest replaces an ACL with entries, or with text in the form
+// AclResponse uses (u:, g:, m: and o: abbreviations are accepted). An empty
+// ACL removes a default ACL.
+type SetAclRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Entries       []*AclEntry            `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	Default       bool                   `protobuf:"varint,4,opt,name=default,proto3" json:"default,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetAclRequest) Reset() {
+	*x = SetAclRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAclRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAclRequest) ProtoMessage() {}
+
+func (x *SetAclRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAclRequest.ProtoReflect.Descriptor instead.
+func (*SetAclRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SetAclRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SetAclRequest) GetEntries() []*AclEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *SetAclRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SetAclRequest) GetDefault() bool {
+	if x != nil {
+		return x.Default
+	}
+	return false
+}
+
+// WritePrecondition makes a change conditional on the file's current state,
+// so that concurrent writers do not overwrite each other's changes.
+// An unset size, a modified_time_ns of 0 or an empty etag skips that check.
+type WritePrecondition struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Size           *int64                 `protobuf:"varint,1,opt,name=size,proto3,oneof" json:"size,omitempty"`
+	ModifiedTimeNs int64                  `protobuf:"varint,2,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"`
+	Etag           string                 `protobuf:"bytes,3,opt,name=etag,proto3" json:"etag,omitempty"` // Must match the file's ETag when set
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WritePrecondition) Reset() {
+	*x = WritePrecondition{}
+	mi := &file_proto_filesystem_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WritePrecondition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WritePrecondition) ProtoMessage() {}
+
+func (x *WritePrecondition) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WritePrecondition.ProtoReflect.Descriptor instead.
+func (*WritePrecondition) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WritePrecondition) GetSize() int64 {
+	if x != nil && x.Size != nil {
+		return *x.Size
+	}
+	return 0
+}
+
+func (x *WritePrecondition) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *WritePrecondition) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+// WriteRequest writes data at offset, extending the file as needed
+type WriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Create        bool                   `protobuf:"varint,4,opt,name=create,proto3" json:"create,omitempty"` // Create the file, and missing parents, if it does not exist
+	Precondition  *WritePrecondition     `protobuf:"bytes,5,opt,name=precondition,proto3" json:"precondition,omitempty"`
+	Sync          bool                   `protobuf:"varint,6,opt,name=sync,proto3" json:"sync,omitempty"` // Flush the data to disk before responding
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteRequest) Reset() {
+	*x = WriteRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteRequest) ProtoMessage() {}
+
+func (x *WriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *WriteRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *WriteRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *WriteRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *WriteRequest) GetCreate() bool {
+	if x != nil {
+		return x.Create
+	}
+	return false
+}
+
+func (x *WriteRequest) GetPrecondition() *WritePrecondition {
+	if x != nil {
+		return x.Precondition
+	}
+	return nil
+}
+
+func (x *WriteRequest) GetSync() bool {
+	if x != nil {
+		return x.Sync
+	}
+	return false
+}
+
+// AppendRequest appends data to the end of a file
+type AppendRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Path         string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Data         []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Create       bool                   `protobuf:"varint,3,opt,name=create,proto3" json:"create,omitempty"`
+	Precondition *WritePrecondition     `protobuf:"bytes,4,opt,name=precondition,proto3" json:"precondition,omitempty"`
+	Sync         bool                   `protobuf:"varint,5,opt,name=sync,proto3" json:"sync,omitempty"`
+	// Write with O_APPEND and end data with a newline, so that lines from
+	// concurrent writers, including other processes, never interleave
+	Lines         bool `protobuf:"varint,6,opt,name=lines,proto3" json:"lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendRequest) Reset() {
+	*x = AppendRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendRequest) ProtoMessage() {}
+
+func (x *AppendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendRequest.ProtoReflect.Descriptor instead.
+func (*AppendRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AppendRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *AppendRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *AppendRequest) GetCreate() bool {
+	if x != nil {
+		return x.Create
+	}
+	return false
+}
+
+func (x *AppendRequest) GetPrecondition() *WritePrecondition {
+	if x != nil {
+		return x.Precondition
+	}
+	return nil
+}
+
+func (x *AppendRequest) GetSync() bool {
+	if x != nil {
+		return x.Sync
+	}
+	return false
+}
+
+func (x *AppendRequest) GetLines() bool {
+	if x != nil {
+		return x.Lines
+	}
+	return false
+}
+
+// TruncateRequest changes the size of a file
+type TruncateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Precondition  *WritePrecondition     `protobuf:"bytes,3,opt,name=precondition,proto3" json:"precondition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TruncateRequest) Reset() {
+	*x = TruncateRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TruncateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TruncateRequest) ProtoMessage() {}
+
+func (x *TruncateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TruncateRequest.ProtoReflect.Descriptor instead.
+func (*TruncateRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *TruncateRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *TruncateRequest) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *TruncateRequest) GetPrecondition() *WritePrecondition {
+	if x != nil {
+		return x.Precondition
+	}
+	return nil
+}
+
+// WriteResponse reports a change and the resulting state of the file,
+// which a following request can use as its precondition
+type WriteResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Success        bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message        string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Error          string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	BytesWritten   int64                  `protobuf:"varint,4,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	Size           int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	ModifiedTimeNs int64                  `protobuf:"varint,6,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"`
+	Etag           string                 `protobuf:"bytes,7,opt,name=etag,proto3" json:"etag,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WriteResponse) Reset() {
+	*x = WriteResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteResponse) ProtoMessage() {}
+
+func (x *WriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteResponse.ProtoReflect.Descriptor instead.
+func (*WriteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *WriteResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WriteResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *WriteResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WriteResponse) GetBytesWritten() int64 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+func (x *WriteResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *WriteResponse) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *WriteResponse) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+// LockRequest asks for a lease on a path, which need not exist yet
+type LockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Mode          LockMode               `protobuf:"varint,2,opt,name=mode,proto3,enum=filesystem.LockMode" json:"mode,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"` // Lifetime of the lease, 0 for 60 seconds
+	Owner         string                 `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`                              // Free-form description of the holder, shown by ListLocks
+	Flock         bool                   `protobuf:"varint,5,opt,name=flock,proto3" json:"flock,omitempty"`                             // Also flock(2) the existing file or directory, so local processes see the lock
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockRequest) Reset() {
+	*x = LockRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockRequest) ProtoMessage() {}
+
+func (x *LockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockRequest.ProtoReflect.Descriptor instead.
+func (*LockRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *LockRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *LockRequest) GetMode() LockMode {
+	if x != nil {
+		return x.Mode
+	}
+	return LockMode_LOCK_EXCLUSIVE
+}
+
+func (x *LockRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *LockRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *LockRequest) GetFlock() bool {
+	if x != nil {
+		return x.Flock
+	}
+	return false
+}
+
+// RenewLockRequest extends a lease to ttl_seconds from now
+type RenewLockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       string                 `protobuf:"bytes,1,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"` // 0 keeps the lease's current lifetime
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewLockRequest) Reset() {
+	*x = RenewLockRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewLockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewLockRequest) ProtoMessage() {}
+
+func (x *RenewLockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewLockRequest.ProtoReflect.Descriptor instead.
+func (*RenewLockRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RenewLockRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+func (x *RenewLockRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+// LeaseRequest names a lease
+type LeaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       string                 `protobuf:"bytes,1,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseRequest) Reset() {
+	*x = LeaseRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseRequest) ProtoMessage() {}
+
+func (x *LeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseRequest.ProtoReflect.Descriptor instead.
+func (*LeaseRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *LeaseRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+// Lock describes a held lease
+type Lock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       string                 `protobuf:"bytes,1,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Mode          LockMode               `protobuf:"varint,3,opt,name=mode,proto3,enum=filesystem.LockMode" json:"mode,omitempty"`
+	Owner         string                 `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	AcquiredTime  int64                  `protobuf:"varint,5,opt,name=acquired_time,json=acquiredTime,proto3" json:"acquired_time,omitempty"`
+	ExpiresTime   int64                  `protobuf:"varint,6,opt,name=expires_time,json=expiresTime,proto3" json:"expires_time,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,7,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	Flock         bool                   `protobuf:"varint,8,opt,name=flock,proto3" json:"flock,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Lock) Reset() {
+	*x = Lock{}
+	mi := &file_proto_filesystem_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Lock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Lock) ProtoMessage() {}
+
+func (x *Lock) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Lock.ProtoReflect.Descriptor instead.
+func (*Lock) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *Lock) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+func (x *Lock) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Lock) GetMode() LockMode {
+	if x != nil {
+		return x.Mode
+	}
+	return LockMode_LOCK_EXCLUSIVE
+}
+
+func (x *Lock) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Lock) GetAcquiredTime() int64 {
+	if x != nil {
+		return x.AcquiredTime
+	}
+	return 0
+}
+
+func (x *Lock) GetExpiresTime() int64 {
+	if x != nil {
+		return x.ExpiresTime
+	}
+	return 0
+}
+
+func (x *Lock) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *Lock) GetFlock() bool {
+	if x != nil {
+		return x.Flock
+	}
+	return false
+}
+
+// ListLocksResponse contains the matching leases, oldest first
+type ListLocksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Locks         []*Lock                `protobuf:"bytes,1,rep,name=locks,proto3" json:"locks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLocksResponse) Reset() {
+	*x = ListLocksResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLocksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLocksResponse) ProtoMessage() {}
+
+func (x *ListLocksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLocksResponse.ProtoReflect.Descriptor instead.
+func (*ListLocksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListLocksResponse) GetLocks() []*Lock {
+	if x != nil {
+		return x.Locks
+	}
+	return nil
+}
+
+// FileVersion describes content of a file saved before a change replaced it
+type FileVersion struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	VersionId      string                 `protobuf:"bytes,1,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	Path           string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Reason         string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"` // The change that replaced it: upload, copy, move, delete or restore
+	Size           int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Permissions    string                 `protobuf:"bytes,5,opt,name=permissions,proto3" json:"permissions,omitempty"`
+	ModifiedTimeNs int64                  `protobuf:"varint,6,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"` // Modification time of the saved content
+	CreatedTime    int64                  `protobuf:"varint,7,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`            // When the version was saved
+	CreatedTimeNs  int64                  `protobuf:"varint,8,opt,name=created_time_ns,json=createdTimeNs,proto3" json:"created_time_ns,omitempty"`
+	Etag           string                 `protobuf:"bytes,9,opt,name=etag,proto3" json:"etag,omitempty"` // ETag the file had when it was saved
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FileVersion) Reset() {
+	*x = FileVersion{}
+	mi := &file_proto_filesystem_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileVersion) ProtoMessage() {}
+
+func (x *FileVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileVersion.ProtoReflect.Descriptor instead.
+func (*FileVersion) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *FileVersion) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+func (x *FileVersion) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileVersion) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *FileVersion) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileVersion) GetPermissions() string {
+	if x != nil {
+		return x.Permissions
+	}
+	return ""
+}
+
+func (x *FileVersion) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *FileVersion) GetCreatedTime() int64 {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return 0
+}
+
+func (x *FileVersion) GetCreatedTimeNs() int64 {
+	if x != nil {
+		return x.CreatedTimeNs
+	}
+	return 0
+}
+
+func (x *FileVersion) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+// ListVersionsResponse contains the saved versions of a file
+type ListVersionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []*FileVersion         `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsResponse) Reset() {
+	*x = ListVersionsResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsResponse) ProtoMessage() {}
+
+func (x *ListVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ListVersionsResponse) GetVersions() []*FileVersion {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+// RestoreVersionRequest restores a saved version of path. The ETag
+// preconditions apply to the current file as in DeleteRequest.
+type RestoreVersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	VersionId     string                 `protobuf:"bytes,2,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	IfMatch       string                 `protobuf:"bytes,3,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"`
+	IfNoneMatch   string                 `protobuf:"bytes,4,opt,name=if_none_match,json=ifNoneMatch,proto3" json:"if_none_match,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreVersionRequest) Reset() {
+	*x = RestoreVersionRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreVersionRequest) ProtoMessage() {}
+
+func (x *RestoreVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreVersionRequest.ProtoReflect.Descriptor instead.
+func (*RestoreVersionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *RestoreVersionRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RestoreVersionRequest) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+func (x *RestoreVersionRequest) GetIfMatch() string {
+	if x != nil {
+		return x.IfMatch
+	}
+	return ""
+}
+
+func (x *RestoreVersionRequest) GetIfNoneMatch() string {
+	if x != nil {
+		return x.IfNoneMatch
+	}
+	return ""
+}
+
+// CreateSnapshotRequest captures the directory at path
+type CreateSnapshotRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                                            // Letters, digits, ".", "_" and "-"; the UTC time when empty
+	PreserveXattrs bool                   `protobuf:"varint,3,opt,name=preserve_xattrs,json=preserveXattrs,proto3" json:"preserve_xattrs,omitempty"` // Capture extended attributes and ACLs as well
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateSnapshotRequest) Reset() {
+	*x = CreateSnapshotRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSnapshotRequest) ProtoMessage() {}
+
+func (x *CreateSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*CreateSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *CreateSnapshotRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *CreateSnapshotRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateSnapshotRequest) GetPreserveXattrs() bool {
+	if x != nil {
+		return x.PreserveXattrs
+	}
+	return false
+}
+
+// Snapshot describes a captured directory tree
+type Snapshot struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Name           string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path           string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"` // Directory the snapshot was taken of
+	CreatedTime    int64                  `protobuf:"varint,3,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`
+	Files          int64                  `protobuf:"varint,4,opt,name=files,proto3" json:"files,omitempty"`
+	Size           int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`                                  // Total size of the files
+	LinkedFiles    int64                  `protobuf:"varint,6,opt,name=linked_files,json=linkedFiles,proto3" json:"linked_files,omitempty"` // Files shared with the previous snapshot
+	Previous       string                 `protobuf:"bytes,7,opt,name=previous,proto3" json:"previous,omitempty"`                           // Name of the snapshot files were shared with
+	PreserveXattrs bool                   `protobuf:"varint,8,opt,name=preserve_xattrs,json=preserveXattrs,proto3" json:"preserve_xattrs,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_proto_filesystem_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Snapshot) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Snapshot) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Snapshot) GetCreatedTime() int64 {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return 0
+}
+
+func (x *Snapshot) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *Snapshot) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Snapshot) GetLinkedFiles() int64 {
+	if x != nil {
+		return x.LinkedFiles
+	}
+	return 0
+}
+
+func (x *Snapshot) GetPrevious() string {
+	if x != nil {
+		return x.Previous
+	}
+	return ""
+}
+
+func (x *Snapshot) GetPreserveXattrs() bool {
+	if x != nil {
+		return x.PreserveXattrs
+	}
+	return false
+}
+
+// ListSnapshotsRequest lists the snapshots of path, or all when it is empty
+type ListSnapshotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSnapshotsRequest) Reset() {
+	*x = ListSnapshotsRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSnapshotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSnapshotsRequest) ProtoMessage() {}
+
+func (x *ListSnapshotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSnapshotsRequest.ProtoReflect.Descriptor instead.
+func (*ListSnapshotsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ListSnapshotsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+// ListSnapshotsResponse contains snapshots, newest first
+type ListSnapshotsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshots     []*Snapshot            `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSnapshotsResponse) Reset() {
+	*x = ListSnapshotsResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSnapshotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSnapshotsResponse) ProtoMessage() {}
+
+func (x *ListSnapshotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSnapshotsResponse.ProtoReflect.Descriptor instead.
+func (*ListSnapshotsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListSnapshotsResponse) GetSnapshots() []*Snapshot {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+// SnapshotRequest names a snapshot
+type SnapshotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *SnapshotRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// RestoreSnapshotRequest copies a snapshot to destination, or back to the
+// directory it was taken of when destination is empty. Files whose size and
+// modification time match the snapshot are left alone.
+type RestoreSnapshotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Destination   string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	DeleteExtra   bool                   `protobuf:"varint,3,opt,name=delete_extra,json=deleteExtra,proto3" json:"delete_extra,omitempty"` // Also remove files that are not in the snapshot
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreSnapshotRequest) Reset() {
+	*x = RestoreSnapshotRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreSnapshotRequest) ProtoMessage() {}
+
+func (x *RestoreSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*RestoreSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *RestoreSnapshotRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RestoreSnapshotRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *RestoreSnapshotRequest) GetDeleteExtra() bool {
+	if x != nil {
+		return x.DeleteExtra
+	}
+	return false
+}
+
+// PathChange is a difference between two directory trees
+type PathChange struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // Path in the current tree
+	Change         ChangeType             `protobuf:"varint,2,opt,name=change,proto3,enum=filesystem.ChangeType" json:"change,omitempty"`
+	FileType       FileType               `protobuf:"varint,3,opt,name=file_type,json=fileType,proto3,enum=filesystem.FileType" json:"file_type,omitempty"` // Type in the current tree, or the old one when deleted
+	Size           int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	ModifiedTimeNs int64                  `protobuf:"varint,5,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"`
+	Differences    []string               `protobuf:"bytes,6,rep,name=differences,proto3" json:"differences,omitempty"` // For modified entries: type, size, mtime, mode, owner, content or target
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PathChange) Reset() {
+	*x = PathChange{}
+	mi := &file_proto_filesystem_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathChange) ProtoMessage() {}
+
+func (x *PathChange) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathChange.ProtoReflect.Descriptor instead.
+func (*PathChange) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *PathChange) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *PathChange) GetChange() ChangeType {
+	if x != nil {
+		return x.Change
+	}
+	return ChangeType_CHANGE_ADDED
+}
+
+func (x *PathChange) GetFileType() FileType {
+	if x != nil {
+		return x.FileType
+	}
+	return FileType_FILE_TYPE_UNKNOWN
+}
+
+func (x *PathChange) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *PathChange) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *PathChange) GetDifferences() []string {
+	if x != nil {
+		return x.Differences
+	}
+	return nil
+}
+
+// DiffResponse lists differences by path, a directory right before its entries
+type DiffResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Changes       []*PathChange          `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffResponse) Reset() {
+	*x = DiffResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffResponse) ProtoMessage() {}
+
+func (x *DiffResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffResponse.ProtoReflect.Descriptor instead.
+func (*DiffResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *DiffResponse) GetChanges() []*PathChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+// CompareRequest compares the tree at right_path with the one at left_path.
+// Entries only in the left tree are reported as deleted and entries only in
+// the right one as added, all with their path in the right tree.
+type CompareRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeftPath      string                 `protobuf:"bytes,1,opt,name=left_path,json=leftPath,proto3" json:"left_path,omitempty"`
+	RightPath     string                 `protobuf:"bytes,2,opt,name=right_path,json=rightPath,proto3" json:"right_path,omitempty"`
+	Mode          CompareMode            `protobuf:"varint,3,opt,name=mode,proto3,enum=filesystem.CompareMode" json:"mode,omitempty"` // Types, sizes and link targets are always compared
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompareRequest) Reset() {
+	*x = CompareRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompareRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareRequest) ProtoMessage() {}
+
+func (x *CompareRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompareRequest.ProtoReflect.Descriptor instead.
+func (*CompareRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *CompareRequest) GetLeftPath() string {
+	if x != nil {
+		return x.LeftPath
+	}
+	return ""
+}
+
+func (x *CompareRequest) GetRightPath() string {
+	if x != nil {
+		return x.RightPath
+	}
+	return ""
+}
+
+func (x *CompareRequest) GetMode() CompareMode {
+	if x != nil {
+		return x.Mode
+	}
+	return CompareMode_COMPARE_QUICK
+}
+
+// DiffFilesRequest compares path, as the old file, with other_path, or with
+// content when other_path is empty
+type DiffFilesRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Path             string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	OtherPath        string                 `protobuf:"bytes,2,opt,name=other_path,json=otherPath,proto3" json:"other_path,omitempty"`
+	Content          []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	ContextLines     int32                  `protobuf:"varint,4,opt,name=context_lines,json=contextLines,proto3" json:"context_lines,omitempty"`             // Unchanged lines around each change, like diff -U
+	IgnoreWhitespace bool                   `protobuf:"varint,5,opt,name=ignore_whitespace,json=ignoreWhitespace,proto3" json:"ignore_whitespace,omitempty"` // Treat lines that differ only in white space as equal, like diff -w
+	MaxSize          int64                  `protobuf:"varint,6,opt,name=max_size,json=maxSize,proto3" json:"max_size,omitempty"`                            // Refuse larger files (0 for the server default of 10 MiB)
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *DiffFilesRequest) Reset() {
+	*x = DiffFilesRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffFilesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffFilesRequest) ProtoMessage() {}
+
+func (x *DiffFilesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffFilesRequest.ProtoReflect.Descriptor instead.
+func (*DiffFilesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *DiffFilesRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DiffFilesRequest) GetOtherPath() string {
+	if x != nil {
+		return x.OtherPath
+	}
+	return ""
+}
+
+func (x *DiffFilesRequest) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *DiffFilesRequest) GetContextLines() int32 {
+	if x != nil {
+		return x.ContextLines
+	}
+	return 0
+}
+
+func (x *DiffFilesRequest) GetIgnoreWhitespace() bool {
+	if x != nil {
+		return x.IgnoreWhitespace
+	}
+	return false
+}
+
+func (x *DiffFilesRequest) GetMaxSize() int64 {
+	if x != nil {
+		return x.MaxSize
+	}
+	return 0
+}
+
+// DiffFilesResponse holds a unified diff, empty when the files are equal
+type DiffFilesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Diff          string                 `protobuf:"bytes,1,opt,name=diff,proto3" json:"diff,omitempty"`
+	Identical     bool                   `protobuf:"varint,2,opt,name=identical,proto3" json:"identical,omitempty"`
+	Binary        bool                   `protobuf:"varint,3,opt,name=binary,proto3" json:"binary,omitempty"` // Either file is binary; no diff is made
+	Etag          string                 `protobuf:"bytes,4,opt,name=etag,proto3" json:"etag,omitempty"`      // ETag of path, for the precondition of a following ApplyPatch
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffFilesResponse) Reset() {
+	*x = DiffFilesResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffFilesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffFilesResponse) ProtoMessage() {}
+
+func (x *DiffFilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffFilesResponse.ProtoReflect.Descriptor instead.
+func (*DiffFilesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *DiffFilesResponse) GetDiff() string {
+	if x != nil {
+		return x.Diff
+	}
+	return ""
+}
+
+func (x *DiffFilesResponse) GetIdentical() bool {
+	if x != nil {
+		return x.Identical
+	}
+	return false
+}
+
+func (x *DiffFilesResponse) GetBinary() bool {
+	if x != nil {
+		return x.Binary
+	}
+	return false
+}
+
+func (x *DiffFilesResponse) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+// ApplyPatchRequest applies a unified diff of a single file to path. Hunks
+// must apply exactly at the lines they name; if one does not, the file is
+// left unchanged. The patched file replaces the original atomically, keeping
+// its permissions, owner and extended attributes.
+type ApplyPatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Patch         string                 `protobuf:"bytes,2,opt,name=patch,proto3" json:"patch,omitempty"`
+	Precondition  *WritePrecondition     `protobuf:"bytes,3,opt,name=precondition,proto3" json:"precondition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyPatchRequest) Reset() {
+	*x = ApplyPatchRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyPatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyPatchRequest) ProtoMessage() {}
+
+func (x *ApplyPatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyPatchRequest.ProtoReflect.Descriptor instead.
+func (*ApplyPatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ApplyPatchRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ApplyPatchRequest) GetPatch() string {
+	if x != nil {
+		return x.Patch
+	}
+	return ""
+}
+
+func (x *ApplyPatchRequest) GetPrecondition() *WritePrecondition {
+	if x != nil {
+		return x.Precondition
+	}
+	return nil
+}
+
+// SignatureRequest asks for the block signatures of a file
+type SignatureRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	BlockSize     int64                  `protobuf:"varint,2,opt,name=block_size,json=blockSize,proto3" json:"block_size,omitempty"` // 0 picks one from the file size
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignatureRequest) Reset() {
+	*x = SignatureRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignatureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignatureRequest) ProtoMessage() {}
+
+func (x *SignatureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignatureRequest.ProtoReflect.Descriptor instead.
+func (*SignatureRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *SignatureRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SignatureRequest) GetBlockSize() int64 {
+	if x != nil {
+		return x.BlockSize
+	}
+	return 0
+}
+
+// BlockSignature identifies a block by rsync's rolling checksum and a strong hash
+type BlockSignature struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Weak          uint32                 `protobuf:"varint,1,opt,name=weak,proto3" json:"weak,omitempty"`
+	Strong        []byte                 `protobuf:"bytes,2,opt,name=strong,proto3" json:"strong,omitempty"` // First 16 bytes of the SHA-256 of the block
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockSignature) Reset() {
+	*x = BlockSignature{}
+	mi := &file_proto_filesystem_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockSignature) ProtoMessage() {}
+
+func (x *BlockSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockSignature.ProtoReflect.Descriptor instead.
+func (*BlockSignature) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *BlockSignature) GetWeak() uint32 {
+	if x != nil {
+		return x.Weak
+	}
+	return 0
+}
+
+func (x *BlockSignature) GetStrong() []byte {
+	if x != nil {
+		return x.Strong
+	}
+	return nil
+}
+
+// SignatureChunk carries block signatures in file order. The first chunk of
+// a stream also describes the file they were made of.
+type SignatureChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	BlockSize     int64                  `protobuf:"varint,2,opt,name=block_size,json=blockSize,proto3" json:"block_size,omitempty"`
+	FileSize      int64                  `protobuf:"varint,3,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	Etag          string                 `protobuf:"bytes,4,opt,name=etag,proto3" json:"etag,omitempty"` // GetSignature: pass as basis_etag to ApplyDelta
+	Blocks        []*BlockSignature      `protobuf:"bytes,5,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignatureChunk) Reset() {
+	*x = SignatureChunk{}
+	mi := &file_proto_filesystem_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignatureChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignatureChunk) ProtoMessage() {}
+
+func (x *SignatureChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignatureChunk.ProtoReflect.Descriptor instead.
+func (*SignatureChunk) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *SignatureChunk) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SignatureChunk) GetBlockSize() int64 {
+	if x != nil {
+		return x.BlockSize
+	}
+	return 0
+}
+
+func (x *SignatureChunk) GetFileSize() int64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *SignatureChunk) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *SignatureChunk) GetBlocks() []*BlockSignature {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+// DeltaOp copies block_count blocks of the basis file starting at
+// block_index, or inserts data when block_count is 0
+type DeltaOp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockIndex    int64                  `protobuf:"varint,1,opt,name=block_index,json=blockIndex,proto3" json:"block_index,omitempty"`
+	BlockCount    int64                  `protobuf:"varint,2,opt,name=block_count,json=blockCount,proto3" json:"block_count,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeltaOp) Reset() {
+	*x = DeltaOp{}
+	mi := &file_proto_filesystem_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeltaOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeltaOp) ProtoMessage() {}
+
+func (x *DeltaOp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeltaOp.ProtoReflect.Descriptor instead.
+func (*DeltaOp) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *DeltaOp) GetBlockIndex() int64 {
+	if x != nil {
+		return x.BlockIndex
+	}
+	return 0
+}
+
+func (x *DeltaOp) GetBlockCount() int64 {
+	if x != nil {
+		return x.BlockCount
+	}
+	return 0
+}
+
+func (x *DeltaOp) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// DeltaChunk carries the ops that rebuild a file, in order. The first chunk
+// of a stream also names the file and the block size of the signature the
+// delta was made against, and the last one carries the SHA-256 of the new
+// file, which the receiver checks before replacing its copy.
+type DeltaChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	BlockSize     int64                  `protobuf:"varint,2,opt,name=block_size,json=blockSize,proto3" json:"block_size,omitempty"`
+	BasisEtag     string                 `protobuf:"bytes,3,opt,name=basis_etag,json=basisEtag,proto3" json:"basis_etag,omitempty"` // ApplyDelta: fail unless the file still has this ETag
+	Ops           []*DeltaOp             `protobuf:"bytes,4,rep,name=ops,proto3" json:"ops,omitempty"`
+	Sha256        []byte                 `protobuf:"bytes,5,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeltaChunk) Reset() {
+	*x = DeltaChunk{}
+	mi := &file_proto_filesystem_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeltaChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeltaChunk) ProtoMessage() {}
+
+func (x *DeltaChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeltaChunk.ProtoReflect.Descriptor instead.
+func (*DeltaChunk) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *DeltaChunk) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DeltaChunk) GetBlockSize() int64 {
+	if x != nil {
+		return x.BlockSize
+	}
+	return 0
+}
+
+func (x *DeltaChunk) GetBasisEtag() string {
+	if x != nil {
+		return x.BasisEtag
+	}
+	return ""
+}
+
+func (x *DeltaChunk) GetOps() []*DeltaOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+func (x *DeltaChunk) GetSha256() []byte {
+	if x != nil {
+		return x.Sha256
+	}
+	return nil
+}
+
+// DiskUsageRequest selects the directory GetDiskUsage analyzes
+type DiskUsageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	MaxDepth      int32                  `protobuf:"varint,2,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`         // Levels of the breakdown as with du -d; 0 for the total only
+	Top           int32                  `protobuf:"varint,3,opt,name=top,proto3" json:"top,omitempty"`                                   // Largest files and directories to report (0 for 10, at most 1000)
+	Exclude       []string               `protobuf:"bytes,4,rep,name=exclude,proto3" json:"exclude,omitempty"`                            // gitignore-style patterns of entries to leave out
+	ByMimeType    bool                   `protobuf:"varint,5,opt,name=by_mime_type,json=byMimeType,proto3" json:"by_mime_type,omitempty"` // Also total files by sniffed MIME type, which reads the start of every file
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiskUsageRequest) Reset() {
+	*x = DiskUsageRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiskUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiskUsageRequest) ProtoMessage() {}
+
+func (x *DiskUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiskUsageRequest.ProtoReflect.Descriptor instead.
+func (*DiskUsageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *DiskUsageRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DiskUsageRequest) GetMaxDepth() int32 {
+	if x != nil {
+		return x.MaxDepth
+	}
+	return 0
+}
+
+func (x *DiskUsageRequest) GetTop() int32 {
+	if x != nil {
+		return x.Top
+	}
+	return 0
+}
+
+func (x *DiskUsageRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *DiskUsageRequest) GetByMimeType() bool {
+	if x != nil {
+		return x.ByMimeType
+	}
+	return false
+}
+
+// DiskUsageEntry is the usage of a file, or of a directory and everything below it
+type DiskUsageEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // Relative to the base directory
+	IsDirectory   bool                   `protobuf:"varint,2,opt,name=is_directory,json=isDirectory,proto3" json:"is_directory,omitempty"`
+	DiskUsage     int64                  `protobuf:"varint,3,opt,name=disk_usage,json=diskUsage,proto3" json:"disk_usage,omitempty"`          // Allocated bytes (st_blocks), counting hard linked files once
+	ApparentSize  int64                  `protobuf:"varint,4,opt,name=apparent_size,json=apparentSize,proto3" json:"apparent_size,omitempty"` // Sum of the sizes of files, counting hard linked files once
+	Files         int64                  `protobuf:"varint,5,opt,name=files,proto3" json:"files,omitempty"`                                   // Entries other than directories
+	Directories   int64                  `protobuf:"varint,6,opt,name=directories,proto3" json:"directories,omitempty"`                       // Directories below this one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiskUsageEntry) Reset() {
+	*x = DiskUsageEntry{}
+	mi := &file_proto_filesystem_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiskUsageEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiskUsageEntry) ProtoMessage() {}
+
+func (x *DiskUsageEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiskUsageEntry.ProtoReflect.Descriptor instead.
+func (*DiskUsageEntry) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *DiskUsageEntry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DiskUsageEntry) GetIsDirectory() bool {
+	if x != nil {
+		return x.IsDirectory
+	}
+	return false
+}
+
+func (x *DiskUsageEntry) GetDiskUsage() int64 {
+	if x != nil {
+		return x.DiskUsage
+	}
+	return 0
+}
+
+func (x *DiskUsageEntry) GetApparentSize() int64 {
+	if x != nil {
+		return x.ApparentSize
+	}
+	return 0
+}
+
+func (x *DiskUsageEntry) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *DiskUsageEntry) GetDirectories() int64 {
+	if x != nil {
+		return x.Directories
+	}
+	return 0
+}
+
+// DiskUsageGroup totals the files with an extension or MIME type
+type DiskUsageGroup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // Lower case extension including the dot or MIME type; "" for none
+	DiskUsage     int64                  `protobuf:"varint,2,opt,name=disk_usage,json=diskUsage,proto3" json:"disk_usage,omitempty"`
+	ApparentSize  int64                  `protobuf:"varint,3,opt,name=apparent_size,json=apparentSize,proto3" json:"apparent_size,omitempty"`
+	Files         int64                  `protobuf:"varint,4,opt,name=files,proto3" json:"files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiskUsageGroup) Reset() {
+	*x = DiskUsageGroup{}
+	mi := &file_proto_filesystem_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiskUsageGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiskUsageGroup) ProtoMessage() {}
+
+func (x *DiskUsageGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiskUsageGroup.ProtoReflect.Descriptor instead.
+func (*DiskUsageGroup) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *DiskUsageGroup) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DiskUsageGroup) GetDiskUsage() int64 {
+	if x != nil {
+		return x.DiskUsage
+	}
+	return 0
+}
+
+func (x *DiskUsageGroup) GetApparentSize() int64 {
+	if x != nil {
+		return x.ApparentSize
+	}
+	return 0
+}
+
+func (x *DiskUsageGroup) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+// DiskUsageResponse is the analysis of a directory. Lists are sorted by disk
+// usage, largest first.
+type DiskUsageResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Total              *DiskUsageEntry        `protobuf:"bytes,1,opt,name=total,proto3" json:"total,omitempty"`
+	Breakdown          []*DiskUsageEntry      `protobuf:"bytes,2,rep,name=breakdown,proto3" json:"breakdown,omitempty"` // Files and directories up to max_depth below the path
+	LargestFiles       []*DiskUsageEntry      `protobuf:"bytes,3,rep,name=largest_files,json=largestFiles,proto3" json:"largest_files,omitempty"`
+	LargestDirectories []*DiskUsageEntry      `protobuf:"bytes,4,rep,name=largest_directories,json=largestDirectories,proto3" json:"largest_directories,omitempty"` // At any depth below the path
+	ByExtension        []*DiskUsageGroup      `protobuf:"bytes,5,rep,name=by_extension,json=byExtension,proto3" json:"by_extension,omitempty"`
+	ByMimeType         []*DiskUsageGroup      `protobuf:"bytes,6,rep,name=by_mime_type,json=byMimeType,proto3" json:"by_mime_type,omitempty"` // Only with by_mime_type
+	Truncated          bool                   `protobuf:"varint,7,opt,name=truncated,proto3" json:"truncated,omitempty"`                      // The breakdown or a grouping was cut short
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *DiskUsageResponse) Reset() {
+	*x = DiskUsageResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiskUsageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiskUsageResponse) ProtoMessage() {}
+
+func (x *DiskUsageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiskUsageResponse.ProtoReflect.Descriptor instead.
+func (*DiskUsageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *DiskUsageResponse) GetTotal() *DiskUsageEntry {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *DiskUsageResponse) GetBreakdown() []*DiskUsageEntry {
+	if x != nil {
+		return x.Breakdown
+	}
+	return nil
+}
+
+func (x *DiskUsageResponse) GetLargestFiles() []*DiskUsageEntry {
+	if x != nil {
+		return x.LargestFiles
+	}
+	return nil
+}
+
+func (x *DiskUsageResponse) GetLargestDirectories() []*DiskUsageEntry {
+	if x != nil {
+		return x.LargestDirectories
+	}
+	return nil
+}
+
+func (x *DiskUsageResponse) GetByExtension() []*DiskUsageGroup {
+	if x != nil {
+		return x.ByExtension
+	}
+	return nil
+}
+
+func (x *DiskUsageResponse) GetByMimeType() []*DiskUsageGroup {
+	if x != nil {
+		return x.ByMimeType
+	}
+	return nil
+}
+
+func (x *DiskUsageResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// DuplicatesRequest selects the files FindDuplicates compares
+type DuplicatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	MinSize       int64                  `protobuf:"varint,2,opt,name=min_size,json=minSize,proto3" json:"min_size,omitempty"` // Ignore smaller files; empty files are always ignored
+	Exclude       []string               `protobuf:"bytes,3,rep,name=exclude,proto3" json:"exclude,omitempty"`                 // gitignore-style patterns of entries to leave out
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DuplicatesRequest) Reset() {
+	*x = DuplicatesRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DuplicatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DuplicatesRequest) ProtoMessage() {}
+
+func (x *DuplicatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DuplicatesRequest.ProtoReflect.Descriptor instead.
+func (*DuplicatesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *DuplicatesRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DuplicatesRequest) GetMinSize() int64 {
+	if x != nil {
+		return x.MinSize
+	}
+	return 0
+}
+
+func (x *DuplicatesRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+// DuplicateGroup is a set of files with the same content. Hard links to the
+// same file are listed as well but count as a single copy.
+type DuplicateGroup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`                                  // Size of each file
+	Sha256        string                 `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`                               // Hex
+	Paths         []string               `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"`                                 // Relative to the base directory, sorted
+	Copies        int32                  `protobuf:"varint,4,opt,name=copies,proto3" json:"copies,omitempty"`                              // Distinct files (inodes) among the paths
+	WastedBytes   int64                  `protobuf:"varint,5,opt,name=wasted_bytes,json=wastedBytes,proto3" json:"wasted_bytes,omitempty"` // size * (copies - 1)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DuplicateGroup) Reset() {
+	*x = DuplicateGroup{}
+	mi := &file_proto_filesystem_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DuplicateGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DuplicateGroup) ProtoMessage() {}
+
+func (x *DuplicateGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DuplicateGroup.ProtoReflect.Descriptor instead.
+func (*DuplicateGroup) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *DuplicateGroup) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *DuplicateGroup) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *DuplicateGroup) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *DuplicateGroup) GetCopies() int32 {
+	if x != nil {
+		return x.Copies
+	}
+	return 0
+}
+
+func (x *DuplicateGroup) GetWastedBytes() int64 {
+	if x != nil {
+		return x.WastedBytes
+	}
+	return 0
+}
+
+// DeduplicateRequest selects the files DeduplicateWithHardlinks links
+type DeduplicateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	MinSize       int64                  `protobuf:"varint,2,opt,name=min_size,json=minSize,proto3" json:"min_size,omitempty"` // As in DuplicatesRequest
+	Exclude       []string               `protobuf:"bytes,3,rep,name=exclude,proto3" json:"exclude,omitempty"`
+	DryRun        bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"` // Only report what would be linked
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeduplicateRequest) Reset() {
+	*x = DeduplicateRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeduplicateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeduplicateRequest) ProtoMessage() {}
+
+func (x *DeduplicateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeduplicateRequest.ProtoReflect.Descriptor instead.
+func (*DeduplicateRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *DeduplicateRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DeduplicateRequest) GetMinSize() int64 {
+	if x != nil {
+		return x.MinSize
+	}
+	return 0
+}
+
+func (x *DeduplicateRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *DeduplicateRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// DeduplicateResponse summarizes a deduplication
+type DeduplicateResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Success        bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message        string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Groups         int64                  `protobuf:"varint,3,opt,name=groups,proto3" json:"groups,omitempty"`                                       // Groups of duplicates found
+	LinkedFiles    int64                  `protobuf:"varint,4,opt,name=linked_files,json=linkedFiles,proto3" json:"linked_files,omitempty"`          // Paths replaced by a hard link
+	ReclaimedBytes int64                  `protobuf:"varint,5,opt,name=reclaimed_bytes,json=reclaimedBytes,proto3" json:"reclaimed_bytes,omitempty"` // Size of the files no path refers to any more
+	Skipped        []string               `protobuf:"bytes,6,rep,name=skipped,proto3" json:"skipped,omitempty"`                                      // "path: reason" for duplicates left alone
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DeduplicateResponse) Reset() {
+	*x = DeduplicateResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeduplicateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeduplicateResponse) ProtoMessage() {}
+
+func (x *DeduplicateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeduplicateResponse.ProtoReflect.Descriptor instead.
+func (*DeduplicateResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *DeduplicateResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DeduplicateResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DeduplicateResponse) GetGroups() int64 {
+	if x != nil {
+		return x.Groups
+	}
+	return 0
+}
+
+func (x *DeduplicateResponse) GetLinkedFiles() int64 {
+	if x != nil {
+		return x.LinkedFiles
+	}
+	return 0
+}
+
+func (x *DeduplicateResponse) GetReclaimedBytes() int64 {
+	if x != nil {
+		return x.ReclaimedBytes
+	}
+	return 0
+}
+
+func (x *DeduplicateResponse) GetSkipped() []string {
+	if x != nil {
+		return x.Skipped
+	}
+	return nil
+}
+
+// ChecksumRequest names a file and the checksums to compute
+type ChecksumRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Algorithms    []string               `protobuf:"bytes,2,rep,name=algorithms,proto3" json:"algorithms,omitempty"` // md5, sha1, sha256, sha512 or crc32c; sha256 when empty
+	Refresh       bool                   `protobuf:"varint,3,opt,name=refresh,proto3" json:"refresh,omitempty"`      // Read the file even if the cache has the checksums
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChecksumRequest) Reset() {
+	*x = ChecksumRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChecksumRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChecksumRequest) ProtoMessage() {}
+
+func (x *ChecksumRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChecksumRequest.ProtoReflect.Descriptor instead.
+func (*ChecksumRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *ChecksumRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ChecksumRequest) GetAlgorithms() []string {
+	if x != nil {
+		return x.Algorithms
+	}
+	return nil
+}
+
+func (x *ChecksumRequest) GetRefresh() bool {
+	if x != nil {
+		return x.Refresh
+	}
+	return false
+}
+
+// ChecksumResponse carries hex checksums by algorithm
+type ChecksumResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Size           int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	ModifiedTimeNs int64                  `protobuf:"varint,3,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"`
+	Etag           string                 `protobuf:"bytes,4,opt,name=etag,proto3" json:"etag,omitempty"` // The file the checksums describe
+	Checksums      map[string]string      `protobuf:"bytes,5,rep,name=checksums,proto3" json:"checksums,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Cached         bool                   `protobuf:"varint,6,opt,name=cached,proto3" json:"cached,omitempty"` // All checksums came from the cache
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ChecksumResponse) Reset() {
+	*x = ChecksumResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChecksumResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChecksumResponse) ProtoMessage() {}
+
+func (x *ChecksumResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChecksumResponse.ProtoReflect.Descriptor instead.
+func (*ChecksumResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *ChecksumResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ChecksumResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ChecksumResponse) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *ChecksumResponse) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *ChecksumResponse) GetChecksums() map[string]string {
+	if x != nil {
+		return x.Checksums
+	}
+	return nil
+}
+
+func (x *ChecksumResponse) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+// ManifestRequest selects the directory a manifest describes
+type ManifestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Exclude       []string               `protobuf:"bytes,2,rep,name=exclude,proto3" json:"exclude,omitempty"` // gitignore-style patterns of entries to leave out
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManifestRequest) Reset() {
+	*x = ManifestRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManifestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManifestRequest) ProtoMessage() {}
+
+func (x *ManifestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManifestRequest.ProtoReflect.Descriptor instead.
+func (*ManifestRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ManifestRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ManifestRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+// ManifestEntry describes a regular file of a manifest
+type ManifestEntry struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // Relative to the manifest's directory (the deny list applies to the path it resolves to)
+	Size           int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Mode           string                 `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"` // Octal permission bits, including setuid, setgid and sticky, e.g. "0644"
+	ModifiedTimeNs int64                  `protobuf:"varint,4,opt,name=modified_time_ns,json=modifiedTimeNs,proto3" json:"modified_time_ns,omitempty"`
+	Sha256         string                 `protobuf:"bytes,5,opt,name=sha256,proto3" json:"sha256,omitempty"` // Hex
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ManifestEntry) Reset() {
+	*x = ManifestEntry{}
+	mi := &file_proto_filesystem_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManifestEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManifestEntry) ProtoMessage() {}
+
+func (x *ManifestEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManifestEntry.ProtoReflect.Descriptor instead.
+func (*ManifestEntry) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *ManifestEntry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ManifestEntry) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ManifestEntry) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *ManifestEntry) GetModifiedTimeNs() int64 {
+	if x != nil {
+		return x.ModifiedTimeNs
+	}
+	return 0
+}
+
+func (x *ManifestEntry) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+// VerifyManifestRequest carries entries of a manifest. The first message of
+// a stream also names the directory and the options. Entries need a path and
+// a sha256 or size; size is checked when it is set or there is no sha256.
+type VerifyManifestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Entries       []*ManifestEntry       `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+	CheckMetadata bool                   `protobuf:"varint,3,opt,name=check_metadata,json=checkMetadata,proto3" json:"check_metadata,omitempty"` // Also compare mode and modified_time_ns where entries set them
+	Exclude       []string               `protobuf:"bytes,4,rep,name=exclude,proto3" json:"exclude,omitempty"`                                   // As in ManifestRequest; excluded entries of the manifest are ignored
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyManifestRequest) Reset() {
+	*x = VerifyManifestRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyManifestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyManifestRequest) ProtoMessage() {}
+
+func (x *VerifyManifestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyManifestRequest.ProtoReflect.Descriptor instead.
+func (*VerifyManifestRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *VerifyManifestRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *VerifyManifestRequest) GetEntries() []*ManifestEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *VerifyManifestRequest) GetCheckMetadata() bool {
+	if x != nil {
+		return x.CheckMetadata
+	}
+	return false
+}
+
+func (x *VerifyManifestRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+// PathRequest specifies a path for operations
+type PathRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathRequest) Reset() {
+	*x = PathRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathRequest) ProtoMessage() {}
+
+func (x *PathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathRequest.ProtoReflect.Descriptor instead.
+func (*PathRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *PathRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+// ExistsResponse indicates if a path exists
+type ExistsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exists        bool                   `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+	IsDirectory   bool                   `protobuf:"varint,2,opt,name=is_directory,json=isDirectory,proto3" json:"is_directory,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExistsResponse) Reset() {
+	*x = ExistsResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExistsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExistsResponse) ProtoMessage() {}
+
+func (x *ExistsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExistsResponse.ProtoReflect.Descriptor instead.
+func (*ExistsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *ExistsResponse) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+func (x *ExistsResponse) GetIsDirectory() bool {
+	if x != nil {
+		return x.IsDirectory
+	}
+	return false
+}
+
+// SizeResponse returns size in bytes
+type SizeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SizeResponse) Reset() {
+	*x = SizeResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SizeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SizeResponse) ProtoMessage() {}
+
+func (x *SizeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SizeResponse.ProtoReflect.Descriptor instead.
+func (*SizeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *SizeResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+// FileChunk is used for file transfers
+type FileChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	IsLast        bool                   `protobuf:"varint,4,opt,name=is_last,json=isLast,proto3" json:"is_last,omitempty"`
+	Etag          string                 `protobuf:"bytes,5,opt,name=etag,proto3" json:"etag,omitempty"`                      // DownloadFile: ETag of the file being sent
+	IfMatch       string                 `protobuf:"bytes,6,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"` // UploadFile, first chunk: ETag preconditions on the destination, as in DeleteRequest
+	IfNoneMatch   string                 `protobuf:"bytes,7,opt,name=if_none_match,json=ifNoneMatch,proto3" json:"if_none_match,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunk) Reset() {
+	*x = FileChunk{}
+	mi := &file_proto_filesystem_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunk) ProtoMessage() {}
+
+func (x *FileChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
+func (*FileChunk) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *FileChunk) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *FileChunk) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *FileChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *FileChunk) GetIsLast() bool {
+	if x != nil {
+		return x.IsLast
+	}
+	return false
+}
+
+func (x *FileChunk) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *FileChunk) GetIfMatch() string {
+	if x != nil {
+		return x.IfMatch
+	}
+	return ""
+}
+
+func (x *FileChunk) GetIfNoneMatch() string {
+	if x != nil {
+		return x.IfNoneMatch
+	}
+	return ""
+}
+
+// OperationResponse returns result of an operation
+type OperationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Etag          string                 `protobuf:"bytes,4,opt,name=etag,proto3" json:"etag,omitempty"` // UploadFile: ETag of the uploaded file
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OperationResponse) Reset() {
+	*x = OperationResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OperationResponse) ProtoMessage() {}
+
+func (x *OperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OperationResponse.ProtoReflect.Descriptor instead.
+func (*OperationResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *OperationResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *OperationResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *OperationResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *OperationResponse) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+// SearchRequest defines search parameters
+type SearchRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BasePath        string                 `protobuf:"bytes,1,opt,name=base_path,json=basePath,proto3" json:"base_path,omitempty"`
+	Pattern         string                 `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	CaseSensitive   bool                   `protobuf:"varint,3,opt,name=case_sensitive,json=caseSensitive,proto3" json:"case_sensitive,omitempty"`
+	Recursive       bool                   `protobuf:"varint,4,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	DirectoriesOnly bool                   `protobuf:"varint,5,opt,name=directories_only,json=directoriesOnly,proto3" json:"directories_only,omitempty"`
+	FilesOnly       bool                   `protobuf:"varint,6,opt,name=files_only,json=filesOnly,proto3" json:"files_only,omitempty"`
+	MaxResults      int32                  `protobuf:"varint,7,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	// Additional filters, all combined with AND semantics
+	NameRegex      string   `protobuf:"bytes,8,opt,name=name_regex,json=nameRegex,proto3" json:"name_regex,omitempty"`                  // RE2 expression matched against the name
+	PathGlob       string   `protobuf:"bytes,9,opt,name=path_glob,json=pathGlob,proto3" json:"path_glob,omitempty"`                     // Glob matched against the path relative to base_path ("**" spans directories)
+	MinSize        int64    `protobuf:"varint,10,opt,name=min_size,json=minSize,proto3" json:"min_size,omitempty"`                      // Minimum size in bytes
+	MaxSize        int64    `protobuf:"varint,11,opt,name=max_size,json=maxSize,proto3" json:"max_size,omitempty"`                      // Maximum size in bytes (0 for no limit)
+	ModifiedAfter  int64    `protobuf:"varint,12,opt,name=modified_after,json=modifiedAfter,proto3" json:"modified_after,omitempty"`    // Unix timestamp, exclusive
+	ModifiedBefore int64    `protobuf:"varint,13,opt,name=modified_before,json=modifiedBefore,proto3" json:"modified_before,omitempty"` // Unix timestamp, exclusive
+	MimeTypes      []string `protobuf:"bytes,14,rep,name=mime_types,json=mimeTypes,proto3" json:"mime_types,omitempty"`                 // MIME types or prefixes such as "image/"
+	Owner          string   `protobuf:"bytes,15,opt,name=owner,proto3" json:"owner,omitempty"`                                          // User name or numeric UID
+	Group          string   `protobuf:"bytes,16,opt,name=group,proto3" json:"group,omitempty"`                                          // Group name or numeric GID
+	SymlinksOnly   bool     `protobuf:"varint,17,opt,name=symlinks_only,json=symlinksOnly,proto3" json:"symlinks_only,omitempty"`       // Match symbolic links only
+	EmptyOnly      bool     `protobuf:"varint,18,opt,name=empty_only,json=emptyOnly,proto3" json:"empty_only,omitempty"`                // Match empty files and directories only
+	// Pagination for the unary Search (results are in stable walk order)
+	PageSize       int32    `protobuf:"varint,19,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`                     // Maximum results per page (0 to use max_results without paging)
+	PageToken      string   `protobuf:"bytes,20,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`                   // next_page_token from a previous response
+	Include        []string `protobuf:"bytes,21,rep,name=include,proto3" json:"include,omitempty"`                                        // gitignore-style patterns an entry must match
+	Exclude        []string `protobuf:"bytes,22,rep,name=exclude,proto3" json:"exclude,omitempty"`                                        // gitignore-style patterns that hide entries
+	UseIgnoreFiles bool     `protobuf:"varint,23,opt,name=use_ignore_files,json=useIgnoreFiles,proto3" json:"use_ignore_files,omitempty"` // Honor .gitignore and .fsdaemonignore files
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *SearchRequest) GetBasePath() string {
+	if x != nil {
+		return x.BasePath
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetCaseSensitive() bool {
+	if x != nil {
+		return x.CaseSensitive
+	}
+	return false
+}
+
+func (x *SearchRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+func (x *SearchRequest) GetDirectoriesOnly() bool {
+	if x != nil {
+		return x.DirectoriesOnly
+	}
+	return false
+}
+
+func (x *SearchRequest) GetFilesOnly() bool {
+	if x != nil {
+		return x.FilesOnly
+	}
+	return false
+}
+
+func (x *SearchRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetNameRegex() string {
+	if x != nil {
+		return x.NameRegex
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetPathGlob() string {
+	if x != nil {
+		return x.PathGlob
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetMinSize() int64 {
+	if x != nil {
+		return x.MinSize
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetMaxSize() int64 {
+	if x != nil {
+		return x.MaxSize
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetModifiedAfter() int64 {
+	if x != nil {
+		return x.ModifiedAfter
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetModifiedBefore() int64 {
+	if x != nil {
+		return x.ModifiedBefore
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetMimeTypes() []string {
+	if x != nil {
+		return x.MimeTypes
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetSymlinksOnly() bool {
+	if x != nil {
+		return x.SymlinksOnly
+	}
+	return false
+}
+
+func (x *SearchRequest) GetEmptyOnly() bool {
+	if x != nil {
+		return x.EmptyOnly
+	}
+	return false
+}
+
+func (x *SearchRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetInclude() []string {
+	if x != nil {
+		return x.Include
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetUseIgnoreFiles() bool {
 	if x != nil {
-		return x.Size
+		return x.UseIgnoreFiles
 	}
-	return 0
+	return false
 }
 
-// FileChunk is used for file transfers
-type FileChunk struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
-	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
-	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
-	IsLast        bool                   `protobuf:"varint,4,opt,name=is_last,json=isLast,proto3" json:"is_last,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// HierarchyRequest specifies a directory to get hierarchy for
+type HierarchyRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	MaxDepth       int32                  `protobuf:"varint,2,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`                     // Maximum depth to traverse (0 for unlimited)
+	Pattern        string                 `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"`                                        // Optional glob pattern
+	Include        []string               `protobuf:"bytes,4,rep,name=include,proto3" json:"include,omitempty"`                                        // gitignore-style patterns an entry must match
+	Exclude        []string               `protobuf:"bytes,5,rep,name=exclude,proto3" json:"exclude,omitempty"`                                        // gitignore-style patterns that hide entries
+	UseIgnoreFiles bool                   `protobuf:"varint,6,opt,name=use_ignore_files,json=useIgnoreFiles,proto3" json:"use_ignore_files,omitempty"` // Honor .gitignore and .fsdaemonignore files
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *FileChunk) Reset() {
-	*x = FileChunk{}
-	mi := &file_proto_filesystem_proto_msgTypes[12]
+func (x *HierarchyRequest) Reset() {
+	*x = HierarchyRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[70]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FileChunk) String() string {
+func (x *HierarchyRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FileChunk) ProtoMessage() {}
+func (*HierarchyRequest) ProtoMessage() {}
 
-func (x *FileChunk) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_filesystem_proto_msgTypes[12]
+func (x *HierarchyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[70]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -806,64 +5667,77 @@ func (x *FileChunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
-func (*FileChunk) Descriptor() ([]byte, []int) {
-	return file_proto_filesystem_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use HierarchyRequest.ProtoReflect.Descriptor instead.
+func (*HierarchyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{70}
 }
 
-func (x *FileChunk) GetFilePath() string {
+func (x *HierarchyRequest) GetPath() string {
 	if x != nil {
-		return x.FilePath
+		return x.Path
 	}
 	return ""
 }
 
-func (x *FileChunk) GetContent() []byte {
+func (x *HierarchyRequest) GetMaxDepth() int32 {
 	if x != nil {
-		return x.Content
+		return x.MaxDepth
+	}
+	return 0
+}
+
+func (x *HierarchyRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *HierarchyRequest) GetInclude() []string {
+	if x != nil {
+		return x.Include
 	}
 	return nil
 }
 
-func (x *FileChunk) GetOffset() int64 {
+func (x *HierarchyRequest) GetExclude() []string {
 	if x != nil {
-		return x.Offset
+		return x.Exclude
 	}
-	return 0
+	return nil
 }
 
-func (x *FileChunk) GetIsLast() bool {
+func (x *HierarchyRequest) GetUseIgnoreFiles() bool {
 	if x != nil {
-		return x.IsLast
+		return x.UseIgnoreFiles
 	}
 	return false
 }
 
-// OperationResponse returns result of an operation
-type OperationResponse struct {
+// HierarchyResponse contains directory hierarchy
+type HierarchyResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Root          *FileItem              `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`            // Root directory with nested children
+	Truncated     bool                   `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"` // Indicates if hierarchy was truncated due to max_depth
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *OperationResponse) Reset() {
-	*x = OperationResponse{}
-	mi := &file_proto_filesystem_proto_msgTypes[13]
+func (x *HierarchyResponse) Reset() {
+	*x = HierarchyResponse{}
+	mi := &file_proto_filesystem_proto_msgTypes[71]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *OperationResponse) String() string {
+func (x *HierarchyResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OperationResponse) ProtoMessage() {}
+func (*HierarchyResponse) ProtoMessage() {}
 
-func (x *OperationResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_filesystem_proto_msgTypes[13]
+func (x *HierarchyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[71]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -874,61 +5748,58 @@ func (x *OperationResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OperationResponse.ProtoReflect.Descriptor instead.
-func (*OperationResponse) Descriptor() ([]byte, []int) {
-	return file_proto_filesystem_proto_rawDescGZIP(), []int{13}
-}
-
-func (x *OperationResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
+// Deprecated: Use HierarchyResponse.ProtoReflect.Descriptor instead.
+func (*HierarchyResponse) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{71}
 }
 
-func (x *OperationResponse) GetMessage() string {
+func (x *HierarchyResponse) GetRoot() *FileItem {
 	if x != nil {
-		return x.Message
+		return x.Root
 	}
-	return ""
+	return nil
 }
 
-func (x *OperationResponse) GetError() string {
+func (x *HierarchyResponse) GetTruncated() bool {
 	if x != nil {
-		return x.Error
+		return x.Truncated
 	}
-	return ""
+	return false
 }
 
-// SearchRequest defines search parameters
-type SearchRequest struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	BasePath        string                 `protobuf:"bytes,1,opt,name=base_path,json=basePath,proto3" json:"base_path,omitempty"`
-	Pattern         string                 `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
-	CaseSensitive   bool                   `protobuf:"varint,3,opt,name=case_sensitive,json=caseSensitive,proto3" json:"case_sensitive,omitempty"`
-	Recursive       bool                   `protobuf:"varint,4,opt,name=recursive,proto3" json:"recursive,omitempty"`
-	DirectoriesOnly bool                   `protobuf:"varint,5,opt,name=directories_only,json=directoriesOnly,proto3" json:"directories_only,omitempty"`
-	FilesOnly       bool                   `protobuf:"varint,6,opt,name=files_only,json=filesOnly,proto3" json:"files_only,omitempty"`
-	MaxResults      int32                  `protobuf:"varint,7,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+// GrepRequest defines content search parameters
+type GrepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BasePath      string                 `protobuf:"bytes,1,opt,name=base_path,json=basePath,proto3" json:"base_path,omitempty"`
+	Query         string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`  // Literal text or RE2 expression
+	Regex         bool                   `protobuf:"varint,3,opt,name=regex,proto3" json:"regex,omitempty"` // Treat query as an RE2 regular expression
+	CaseSensitive bool                   `protobuf:"varint,4,opt,name=case_sensitive,json=caseSensitive,proto3" json:"case_sensitive,omitempty"`
+	Recursive     bool                   `protobuf:"varint,5,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	Include       []string               `protobuf:"bytes,6,rep,name=include,proto3" json:"include,omitempty"`                                   // Only search files matching these globs
+	Exclude       []string               `protobuf:"bytes,7,rep,name=exclude,proto3" json:"exclude,omitempty"`                                   // Skip files and directories matching these globs
+	MaxFileSize   int64                  `protobuf:"varint,8,opt,name=max_file_size,json=maxFileSize,proto3" json:"max_file_size,omitempty"`     // Skip larger files (0 for the server default)
+	IncludeBinary bool                   `protobuf:"varint,9,opt,name=include_binary,json=includeBinary,proto3" json:"include_binary,omitempty"` // Search files detected as binary
+	ContextLines  int32                  `protobuf:"varint,10,opt,name=context_lines,json=contextLines,proto3" json:"context_lines,omitempty"`   // Lines of context before and after each match
+	MaxMatches    int32                  `protobuf:"varint,11,opt,name=max_matches,json=maxMatches,proto3" json:"max_matches,omitempty"`         // Stop after this many matches (0 for unlimited)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SearchRequest) Reset() {
-	*x = SearchRequest{}
-	mi := &file_proto_filesystem_proto_msgTypes[14]
+func (x *GrepRequest) Reset() {
+	*x = GrepRequest{}
+	mi := &file_proto_filesystem_proto_msgTypes[72]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SearchRequest) String() string {
+func (x *GrepRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SearchRequest) ProtoMessage() {}
+func (*GrepRequest) ProtoMessage() {}
 
-func (x *SearchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_filesystem_proto_msgTypes[14]
+func (x *GrepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[72]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -939,85 +5810,116 @@ func (x *SearchRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
-func (*SearchRequest) Descriptor() ([]byte, []int) {
-	return file_proto_filesystem_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use GrepRequest.ProtoReflect.Descriptor instead.
+func (*GrepRequest) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{72}
 }
 
-func (x *SearchRequest) GetBasePath() string {
+func (x *GrepRequest) GetBasePath() string {
 	if x != nil {
 		return x.BasePath
 	}
 	return ""
 }
 
-func (x *SearchRequest) GetPattern() string {
+func (x *GrepRequest) GetQuery() string {
 	if x != nil {
-		return x.Pattern
+		return x.Query
 	}
 	return ""
 }
 
-func (x *SearchRequest) GetCaseSensitive() bool {
+func (x *GrepRequest) GetRegex() bool {
+	if x != nil {
+		return x.Regex
+	}
+	return false
+}
+
+func (x *GrepRequest) GetCaseSensitive() bool {
 	if x != nil {
 		return x.CaseSensitive
 	}
 	return false
 }
 
-func (x *SearchRequest) GetRecursive() bool {
+func (x *GrepRequest) GetRecursive() bool {
 	if x != nil {
 		return x.Recursive
 	}
 	return false
 }
 
-func (x *SearchRequest) GetDirectoriesOnly() bool {
+func (x *GrepRequest) GetInclude() []string {
 	if x != nil {
-		return x.DirectoriesOnly
+		return x.Include
 	}
-	return false
+	return nil
 }
 
-func (x *SearchRequest) GetFilesOnly() bool {
+func (x *GrepRequest) GetExclude() []string {
 	if x != nil {
-		return x.FilesOnly
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *GrepRequest) GetMaxFileSize() int64 {
+	if x != nil {
+		return x.MaxFileSize
+	}
+	return 0
+}
+
+func (x *GrepRequest) GetIncludeBinary() bool {
+	if x != nil {
+		return x.IncludeBinary
 	}
 	return false
 }
 
-func (x *SearchRequest) GetMaxResults() int32 {
+func (x *GrepRequest) GetContextLines() int32 {
 	if x != nil {
-		return x.MaxResults
+		return x.ContextLines
 	}
 	return 0
 }
 
-// HierarchyRequest specifies a directory to get hierarchy for
-type HierarchyRequest struct {
+func (x *GrepRequest) GetMaxMatches() int32 {
+	if x != nil {
+		return x.MaxMatches
+	}
+	return 0
+}
+
+// GrepMatch is a single matching line
+type GrepMatch struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	MaxDepth      int32                  `protobuf:"varint,2,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"` // Maximum depth to traverse (0 for unlimited)
-	Pattern       string                 `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"`                    // Optional glob pattern
+	LineNumber    int64                  `protobuf:"varint,2,opt,name=line_number,json=lineNumber,proto3" json:"line_number,omitempty"` // 1-based line number
+	Column        int32                  `protobuf:"varint,3,opt,name=column,proto3" json:"column,omitempty"`                           // 1-based byte column of the first match
+	Line          string                 `protobuf:"bytes,4,opt,name=line,proto3" json:"line,omitempty"`
+	BeforeContext []string               `protobuf:"bytes,5,rep,name=before_context,json=beforeContext,proto3" json:"before_context,omitempty"`
+	AfterContext  []string               `protobuf:"bytes,6,rep,name=after_context,json=afterContext,proto3" json:"after_context,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HierarchyRequest) Reset() {
-	*x = HierarchyRequest{}
-	mi := &file_proto_filesystem_proto_msgTypes[15]
+func (x *GrepMatch) Reset() {
+	*x = GrepMatch{}
+	mi := &file_proto_filesystem_proto_msgTypes[73]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HierarchyRequest) String() string {
+func (x *GrepMatch) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HierarchyRequest) ProtoMessage() {}
+func (*GrepMatch) ProtoMessage() {}
 
-func (x *HierarchyRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_filesystem_proto_msgTypes[15]
+func (x *GrepMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_filesystem_proto_msgTypes[73]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1028,83 +5930,51 @@ func (x *HierarchyRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HierarchyRequest.ProtoReflect.Descriptor instead.
-func (*HierarchyRequest) Descriptor() ([]byte, []int) {
-	return file_proto_filesystem_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use GrepMatch.ProtoReflect.Descriptor instead.
+func (*GrepMatch) Descriptor() ([]byte, []int) {
+	return file_proto_filesystem_proto_rawDescGZIP(), []int{73}
 }
 
-func (x *HierarchyRequest) GetPath() string {
+func (x *GrepMatch) GetPath() string {
 	if x != nil {
 		return x.Path
 	}
 	return ""
 }
 
-func (x *HierarchyRequest) GetMaxDepth() int32 {
+func (x *GrepMatch) GetLineNumber() int64 {
 	if x != nil {
-		return x.MaxDepth
+		return x.LineNumber
 	}
 	return 0
 }
 
-func (x *HierarchyRequest) GetPattern() string {
+func (x *GrepMatch) GetColumn() int32 {
 	if x != nil {
-		return x.Pattern
+		return x.Column
 	}
-	return ""
-}
-
-// HierarchyResponse contains directory hierarchy
-type HierarchyResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Root          *FileItem              `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`            // Root directory with nested children
-	Truncated     bool                   `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"` // Indicates if hierarchy was truncated due to max_depth
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *HierarchyResponse) Reset() {
-	*x = HierarchyResponse{}
-	mi := &file_proto_filesystem_proto_msgTypes[16]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *HierarchyResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return 0
 }
 
-func (*HierarchyResponse) ProtoMessage() {}
-
-func (x *HierarchyResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_filesystem_proto_msgTypes[16]
+func (x *GrepMatch) GetLine() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Line
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use HierarchyResponse.ProtoReflect.Descriptor instead.
-func (*HierarchyResponse) Descriptor() ([]byte, []int) {
-	return file_proto_filesystem_proto_rawDescGZIP(), []int{16}
+	return ""
 }
 
-func (x *HierarchyResponse) GetRoot() *FileItem {
+func (x *GrepMatch) GetBeforeContext() []string {
 	if x != nil {
-		return x.Root
+		return x.BeforeContext
 	}
 	return nil
 }
 
-func (x *HierarchyResponse) GetTruncated() bool {
+func (x *GrepMatch) GetAfterContext() []string {
 	if x != nil {
-		return x.Truncated
+		return x.AfterContext
 	}
-	return false
+	return nil
 }
 
 var File_proto_filesystem_proto protoreflect.FileDescriptor
@@ -1112,11 +5982,23 @@ var File_proto_filesystem_proto protoreflect.FileDescriptor
 const file_proto_filesystem_proto_rawDesc = "" +
 	"\n" +
 	"\x16proto/filesystem.proto\x12\n" +
-	"filesystem\"Y\n" +
+	"filesystem\"\xe1\x02\n" +
 	"\vListRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1c\n" +
 	"\trecursive\x18\x02 \x01(\bR\trecursive\x12\x18\n" +
-	"\apattern\x18\x03 \x01(\tR\apattern\"\x83\x02\n" +
+	"\apattern\x18\x03 \x01(\tR\apattern\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x05 \x01(\tR\tpageToken\x12.\n" +
+	"\asort_by\x18\x06 \x01(\x0e2\x15.filesystem.SortFieldR\x06sortBy\x12\x1e\n" +
+	"\n" +
+	"descending\x18\a \x01(\bR\n" +
+	"descending\x12\x18\n" +
+	"\ainclude\x18\b \x03(\tR\ainclude\x12\x18\n" +
+	"\aexclude\x18\t \x03(\tR\aexclude\x12(\n" +
+	"\x10use_ignore_files\x18\n" +
+	" \x01(\bR\x0euseIgnoreFiles\x12\x1c\n" +
+	"\tchecksums\x18\v \x03(\tR\tchecksums\"\xae\x05\n" +
 	"\bFileItem\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
 	"\x04path\x18\x02 \x01(\tR\x04path\x12!\n" +
@@ -1126,11 +6008,34 @@ const file_proto_filesystem_proto_rawDesc = "" +
 	"\vpermissions\x18\x06 \x01(\tR\vpermissions\x120\n" +
 	"\bchildren\x18\a \x03(\v2\x14.filesystem.FileItemR\bchildren\x12\x1f\n" +
 	"\vparent_path\x18\b \x01(\tR\n" +
-	"parentPath\":\n" +
+	"parentPath\x12\x1d\n" +
+	"\n" +
+	"is_symlink\x18\t \x01(\bR\tisSymlink\x12\x1f\n" +
+	"\vlink_target\x18\n" +
+	" \x01(\tR\n" +
+	"linkTarget\x121\n" +
+	"\tfile_type\x18\v \x01(\x0e2\x14.filesystem.FileTypeR\bfileType\x12\x12\n" +
+	"\x04mode\x18\f \x01(\tR\x04mode\x12\x14\n" +
+	"\x05inode\x18\r \x01(\x04R\x05inode\x12\x16\n" +
+	"\x06device\x18\x0e \x01(\x04R\x06device\x12\x1d\n" +
+	"\n" +
+	"link_count\x18\x0f \x01(\x04R\tlinkCount\x12\x16\n" +
+	"\x06blocks\x18\x10 \x01(\x03R\x06blocks\x12(\n" +
+	"\x10modified_time_ns\x18\x11 \x01(\x03R\x0emodifiedTimeNs\x12\x12\n" +
+	"\x04etag\x18\x12 \x01(\tR\x04etag\x12A\n" +
+	"\tchecksums\x18\x13 \x03(\v2#.filesystem.FileItem.ChecksumsEntryR\tchecksums\x1a<\n" +
+	"\x0eChecksumsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"b\n" +
 	"\fListResponse\x12*\n" +
-	"\x05items\x18\x01 \x03(\v2\x14.filesystem.FileItemR\x05items\"!\n" +
+	"\x05items\x18\x01 \x03(\v2\x14.filesystem.FileItemR\x05items\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x85\x01\n" +
 	"\vFileRequest\x12\x12\n" +
-	"\x04path\x18\x01 \x01(\tR\x04path\"\xbf\x02\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12%\n" +
+	"\x0einclude_xattrs\x18\x02 \x01(\bR\rincludeXattrs\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x03 \x01(\tR\tversionId\x12\x1c\n" +
+	"\tchecksums\x18\x04 \x03(\tR\tchecksums\"\xd0\a\n" +
 	"\bFileInfo\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
 	"\x04path\x18\x02 \x01(\tR\x04path\x12!\n" +
@@ -1144,37 +6049,366 @@ const file_proto_filesystem_proto_rawDesc = "" +
 	"\vpermissions\x18\t \x01(\tR\vpermissions\x12\x14\n" +
 	"\x05owner\x18\n" +
 	" \x01(\tR\x05owner\x12\x14\n" +
-	"\x05group\x18\v \x01(\tR\x05group\"N\n" +
+	"\x05group\x18\v \x01(\tR\x05group\x12\x1d\n" +
+	"\n" +
+	"is_symlink\x18\f \x01(\bR\tisSymlink\x12\x1f\n" +
+	"\vlink_target\x18\r \x01(\tR\n" +
+	"linkTarget\x121\n" +
+	"\tfile_type\x18\x0e \x01(\x0e2\x14.filesystem.FileTypeR\bfileType\x12\x12\n" +
+	"\x04mode\x18\x0f \x01(\tR\x04mode\x12\x14\n" +
+	"\x05inode\x18\x10 \x01(\x04R\x05inode\x12\x16\n" +
+	"\x06device\x18\x11 \x01(\x04R\x06device\x12\x1d\n" +
+	"\n" +
+	"link_count\x18\x12 \x01(\x04R\tlinkCount\x12\x16\n" +
+	"\x06blocks\x18\x13 \x01(\x03R\x06blocks\x12\x10\n" +
+	"\x03uid\x18\x14 \x01(\rR\x03uid\x12\x10\n" +
+	"\x03gid\x18\x15 \x01(\rR\x03gid\x12\x1f\n" +
+	"\vchange_time\x18\x16 \x01(\x03R\n" +
+	"changeTime\x12(\n" +
+	"\x10modified_time_ns\x18\x17 \x01(\x03R\x0emodifiedTimeNs\x12(\n" +
+	"\x10creation_time_ns\x18\x18 \x01(\x03R\x0ecreationTimeNs\x12$\n" +
+	"\x0eaccess_time_ns\x18\x19 \x01(\x03R\faccessTimeNs\x12$\n" +
+	"\x0echange_time_ns\x18\x1a \x01(\x03R\fchangeTimeNs\x12)\n" +
+	"\x06xattrs\x18\x1b \x03(\v2\x11.filesystem.XattrR\x06xattrs\x12\x12\n" +
+	"\x04etag\x18\x1c \x01(\tR\x04etag\x12A\n" +
+	"\tchecksums\x18\x1d \x03(\v2#.filesystem.FileInfo.ChecksumsEntryR\tchecksums\x1a<\n" +
+	"\x0eChecksumsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"N\n" +
 	"\x16CreateDirectoryRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12 \n" +
-	"\vpermissions\x18\x02 \x01(\x05R\vpermissions\"A\n" +
+	"\vpermissions\x18\x02 \x01(\x05R\vpermissions\"\x80\x01\n" +
 	"\rDeleteRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1c\n" +
-	"\trecursive\x18\x02 \x01(\bR\trecursive\"e\n" +
+	"\trecursive\x18\x02 \x01(\bR\trecursive\x12\x19\n" +
+	"\bif_match\x18\x03 \x01(\tR\aifMatch\x12\"\n" +
+	"\rif_none_match\x18\x04 \x01(\tR\vifNoneMatch\"\xcd\x01\n" +
 	"\vCopyRequest\x12\x16\n" +
 	"\x06source\x18\x01 \x01(\tR\x06source\x12 \n" +
 	"\vdestination\x18\x02 \x01(\tR\vdestination\x12\x1c\n" +
-	"\toverwrite\x18\x03 \x01(\bR\toverwrite\"e\n" +
+	"\toverwrite\x18\x03 \x01(\bR\toverwrite\x12'\n" +
+	"\x0fpreserve_xattrs\x18\x04 \x01(\bR\x0epreserveXattrs\x12\x19\n" +
+	"\bif_match\x18\x05 \x01(\tR\aifMatch\x12\"\n" +
+	"\rif_none_match\x18\x06 \x01(\tR\vifNoneMatch\"\xcc\x01\n" +
 	"\vMoveRequest\x12\x16\n" +
 	"\x06source\x18\x01 \x01(\tR\x06source\x12 \n" +
 	"\vdestination\x18\x02 \x01(\tR\vdestination\x12\x1c\n" +
-	"\toverwrite\x18\x03 \x01(\bR\toverwrite\"!\n" +
+	"\toverwrite\x18\x03 \x01(\bR\toverwrite\x12\x19\n" +
+	"\bif_match\x18\x04 \x01(\tR\aifMatch\x12\"\n" +
+	"\rif_none_match\x18\x05 \x01(\tR\vifNoneMatch\x12&\n" +
+	"\x0fsource_if_match\x18\x06 \x01(\tR\rsourceIfMatch\"Z\n" +
+	"\x0eSymlinkRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12\x1c\n" +
+	"\toverwrite\x18\x03 \x01(\bR\toverwrite\"\x95\x01\n" +
+	"\x15SetPermissionsRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\tR\x04mode\x12\x1c\n" +
+	"\trecursive\x18\x03 \x01(\bR\trecursive\x12\x1b\n" +
+	"\tfile_mode\x18\x04 \x01(\tR\bfileMode\x12\x19\n" +
+	"\bdir_mode\x18\x05 \x01(\tR\adirMode\"o\n" +
+	"\x0fSetOwnerRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x14\n" +
+	"\x05group\x18\x03 \x01(\tR\x05group\x12\x1c\n" +
+	"\trecursive\x18\x04 \x01(\bR\trecursive\"\x8d\x01\n" +
+	"\x0fSetTimesRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12(\n" +
+	"\x10modified_time_ns\x18\x02 \x01(\x03R\x0emodifiedTimeNs\x12$\n" +
+	"\x0eaccess_time_ns\x18\x03 \x01(\x03R\faccessTimeNs\x12\x16\n" +
+	"\x06create\x18\x04 \x01(\bR\x06create\"1\n" +
+	"\x05Xattr\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\"6\n" +
+	"\fXattrRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\":\n" +
+	"\rXattrResponse\x12)\n" +
+	"\x06xattrs\x18\x01 \x03(\v2\x11.filesystem.XattrR\x06xattrs\"\x81\x01\n" +
+	"\x0fSetXattrRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x12\x16\n" +
+	"\x06create\x18\x04 \x01(\bR\x06create\x12\x18\n" +
+	"\areplace\x18\x05 \x01(\bR\areplace\"v\n" +
+	"\bAclEntry\x12$\n" +
+	"\x03tag\x18\x01 \x01(\x0e2\x12.filesystem.AclTagR\x03tag\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\rR\x02id\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12 \n" +
+	"\vpermissions\x18\x04 \x01(\tR\vpermissions\":\n" +
+	"\n" +
+	"AclRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\adefault\x18\x02 \x01(\bR\adefault\"Q\n" +
+	"\vAclResponse\x12.\n" +
+	"\aentries\x18\x01 \x03(\v2\x14.filesystem.AclEntryR\aentries\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"\x81\x01\n" +
+	"\rSetAclRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12.\n" +
+	"\aentries\x18\x02 \x03(\v2\x14.filesystem.AclEntryR\aentries\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12\x18\n" +
+	"\adefault\x18\x04 \x01(\bR\adefault\"s\n" +
+	"\x11WritePrecondition\x12\x17\n" +
+	"\x04size\x18\x01 \x01(\x03H\x00R\x04size\x88\x01\x01\x12(\n" +
+	"\x10modified_time_ns\x18\x02 \x01(\x03R\x0emodifiedTimeNs\x12\x12\n" +
+	"\x04etag\x18\x03 \x01(\tR\x04etagB\a\n" +
+	"\x05_size\"\xbd\x01\n" +
+	"\fWriteRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x16\n" +
+	"\x06create\x18\x04 \x01(\bR\x06create\x12A\n" +
+	"\fprecondition\x18\x05 \x01(\v2\x1d.filesystem.WritePreconditionR\fprecondition\x12\x12\n" +
+	"\x04sync\x18\x06 \x01(\bR\x04sync\"\xbc\x01\n" +
+	"\rAppendRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x16\n" +
+	"\x06create\x18\x03 \x01(\bR\x06create\x12A\n" +
+	"\fprecondition\x18\x04 \x01(\v2\x1d.filesystem.WritePreconditionR\fprecondition\x12\x12\n" +
+	"\x04sync\x18\x05 \x01(\bR\x04sync\x12\x14\n" +
+	"\x05lines\x18\x06 \x01(\bR\x05lines\"|\n" +
+	"\x0fTruncateRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12A\n" +
+	"\fprecondition\x18\x03 \x01(\v2\x1d.filesystem.WritePreconditionR\fprecondition\"\xd0\x01\n" +
+	"\rWriteResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12#\n" +
+	"\rbytes_written\x18\x04 \x01(\x03R\fbytesWritten\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x12(\n" +
+	"\x10modified_time_ns\x18\x06 \x01(\x03R\x0emodifiedTimeNs\x12\x12\n" +
+	"\x04etag\x18\a \x01(\tR\x04etag\"\x98\x01\n" +
+	"\vLockRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12(\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x14.filesystem.LockModeR\x04mode\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x03R\n" +
+	"ttlSeconds\x12\x14\n" +
+	"\x05owner\x18\x04 \x01(\tR\x05owner\x12\x14\n" +
+	"\x05flock\x18\x05 \x01(\bR\x05flock\"N\n" +
+	"\x10RenewLockRequest\x12\x19\n" +
+	"\blease_id\x18\x01 \x01(\tR\aleaseId\x12\x1f\n" +
+	"\vttl_seconds\x18\x02 \x01(\x03R\n" +
+	"ttlSeconds\")\n" +
+	"\fLeaseRequest\x12\x19\n" +
+	"\blease_id\x18\x01 \x01(\tR\aleaseId\"\xf4\x01\n" +
+	"\x04Lock\x12\x19\n" +
+	"\blease_id\x18\x01 \x01(\tR\aleaseId\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12(\n" +
+	"\x04mode\x18\x03 \x01(\x0e2\x14.filesystem.LockModeR\x04mode\x12\x14\n" +
+	"\x05owner\x18\x04 \x01(\tR\x05owner\x12#\n" +
+	"\racquired_time\x18\x05 \x01(\x03R\facquiredTime\x12!\n" +
+	"\fexpires_time\x18\x06 \x01(\x03R\vexpiresTime\x12\x1f\n" +
+	"\vttl_seconds\x18\a \x01(\x03R\n" +
+	"ttlSeconds\x12\x14\n" +
+	"\x05flock\x18\b \x01(\bR\x05flock\";\n" +
+	"\x11ListLocksResponse\x12&\n" +
+	"\x05locks\x18\x01 \x03(\v2\x10.filesystem.LockR\x05locks\"\x97\x02\n" +
+	"\vFileVersion\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x01 \x01(\tR\tversionId\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12 \n" +
+	"\vpermissions\x18\x05 \x01(\tR\vpermissions\x12(\n" +
+	"\x10modified_time_ns\x18\x06 \x01(\x03R\x0emodifiedTimeNs\x12!\n" +
+	"\fcreated_time\x18\a \x01(\x03R\vcreatedTime\x12&\n" +
+	"\x0fcreated_time_ns\x18\b \x01(\x03R\rcreatedTimeNs\x12\x12\n" +
+	"\x04etag\x18\t \x01(\tR\x04etag\"K\n" +
+	"\x14ListVersionsResponse\x123\n" +
+	"\bversions\x18\x01 \x03(\v2\x17.filesystem.FileVersionR\bversions\"\x89\x01\n" +
+	"\x15RestoreVersionRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x02 \x01(\tR\tversionId\x12\x19\n" +
+	"\bif_match\x18\x03 \x01(\tR\aifMatch\x12\"\n" +
+	"\rif_none_match\x18\x04 \x01(\tR\vifNoneMatch\"h\n" +
+	"\x15CreateSnapshotRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12'\n" +
+	"\x0fpreserve_xattrs\x18\x03 \x01(\bR\x0epreserveXattrs\"\xe7\x01\n" +
+	"\bSnapshot\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12!\n" +
+	"\fcreated_time\x18\x03 \x01(\x03R\vcreatedTime\x12\x14\n" +
+	"\x05files\x18\x04 \x01(\x03R\x05files\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x12!\n" +
+	"\flinked_files\x18\x06 \x01(\x03R\vlinkedFiles\x12\x1a\n" +
+	"\bprevious\x18\a \x01(\tR\bprevious\x12'\n" +
+	"\x0fpreserve_xattrs\x18\b \x01(\bR\x0epreserveXattrs\"*\n" +
+	"\x14ListSnapshotsRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"K\n" +
+	"\x15ListSnapshotsResponse\x122\n" +
+	"\tsnapshots\x18\x01 \x03(\v2\x14.filesystem.SnapshotR\tsnapshots\"%\n" +
+	"\x0fSnapshotRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"q\n" +
+	"\x16RestoreSnapshotRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdestination\x18\x02 \x01(\tR\vdestination\x12!\n" +
+	"\fdelete_extra\x18\x03 \x01(\bR\vdeleteExtra\"\xe3\x01\n" +
+	"\n" +
+	"PathChange\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12.\n" +
+	"\x06change\x18\x02 \x01(\x0e2\x16.filesystem.ChangeTypeR\x06change\x121\n" +
+	"\tfile_type\x18\x03 \x01(\x0e2\x14.filesystem.FileTypeR\bfileType\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12(\n" +
+	"\x10modified_time_ns\x18\x05 \x01(\x03R\x0emodifiedTimeNs\x12 \n" +
+	"\vdifferences\x18\x06 \x03(\tR\vdifferences\"@\n" +
+	"\fDiffResponse\x120\n" +
+	"\achanges\x18\x01 \x03(\v2\x16.filesystem.PathChangeR\achanges\"y\n" +
+	"\x0eCompareRequest\x12\x1b\n" +
+	"\tleft_path\x18\x01 \x01(\tR\bleftPath\x12\x1d\n" +
+	"\n" +
+	"right_path\x18\x02 \x01(\tR\trightPath\x12+\n" +
+	"\x04mode\x18\x03 \x01(\x0e2\x17.filesystem.CompareModeR\x04mode\"\xcc\x01\n" +
+	"\x10DiffFilesRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1d\n" +
+	"\n" +
+	"other_path\x18\x02 \x01(\tR\totherPath\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\x12#\n" +
+	"\rcontext_lines\x18\x04 \x01(\x05R\fcontextLines\x12+\n" +
+	"\x11ignore_whitespace\x18\x05 \x01(\bR\x10ignoreWhitespace\x12\x19\n" +
+	"\bmax_size\x18\x06 \x01(\x03R\amaxSize\"q\n" +
+	"\x11DiffFilesResponse\x12\x12\n" +
+	"\x04diff\x18\x01 \x01(\tR\x04diff\x12\x1c\n" +
+	"\tidentical\x18\x02 \x01(\bR\tidentical\x12\x16\n" +
+	"\x06binary\x18\x03 \x01(\bR\x06binary\x12\x12\n" +
+	"\x04etag\x18\x04 \x01(\tR\x04etag\"\x80\x01\n" +
+	"\x11ApplyPatchRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05patch\x18\x02 \x01(\tR\x05patch\x12A\n" +
+	"\fprecondition\x18\x03 \x01(\v2\x1d.filesystem.WritePreconditionR\fprecondition\"E\n" +
+	"\x10SignatureRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1d\n" +
+	"\n" +
+	"block_size\x18\x02 \x01(\x03R\tblockSize\"<\n" +
+	"\x0eBlockSignature\x12\x12\n" +
+	"\x04weak\x18\x01 \x01(\rR\x04weak\x12\x16\n" +
+	"\x06strong\x18\x02 \x01(\fR\x06strong\"\xa8\x01\n" +
+	"\x0eSignatureChunk\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1d\n" +
+	"\n" +
+	"block_size\x18\x02 \x01(\x03R\tblockSize\x12\x1b\n" +
+	"\tfile_size\x18\x03 \x01(\x03R\bfileSize\x12\x12\n" +
+	"\x04etag\x18\x04 \x01(\tR\x04etag\x122\n" +
+	"\x06blocks\x18\x05 \x03(\v2\x1a.filesystem.BlockSignatureR\x06blocks\"_\n" +
+	"\aDeltaOp\x12\x1f\n" +
+	"\vblock_index\x18\x01 \x01(\x03R\n" +
+	"blockIndex\x12\x1f\n" +
+	"\vblock_count\x18\x02 \x01(\x03R\n" +
+	"blockCount\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\x9d\x01\n" +
+	"\n" +
+	"DeltaChunk\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1d\n" +
+	"\n" +
+	"block_size\x18\x02 \x01(\x03R\tblockSize\x12\x1d\n" +
+	"\n" +
+	"basis_etag\x18\x03 \x01(\tR\tbasisEtag\x12%\n" +
+	"\x03ops\x18\x04 \x03(\v2\x13.filesystem.DeltaOpR\x03ops\x12\x16\n" +
+	"\x06sha256\x18\x05 \x01(\fR\x06sha256\"\x91\x01\n" +
+	"\x10DiskUsageRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1b\n" +
+	"\tmax_depth\x18\x02 \x01(\x05R\bmaxDepth\x12\x10\n" +
+	"\x03top\x18\x03 \x01(\x05R\x03top\x12\x18\n" +
+	"\aexclude\x18\x04 \x03(\tR\aexclude\x12 \n" +
+	"\fby_mime_type\x18\x05 \x01(\bR\n" +
+	"byMimeType\"\xc3\x01\n" +
+	"\x0eDiskUsageEntry\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12!\n" +
+	"\fis_directory\x18\x02 \x01(\bR\visDirectory\x12\x1d\n" +
+	"\n" +
+	"disk_usage\x18\x03 \x01(\x03R\tdiskUsage\x12#\n" +
+	"\rapparent_size\x18\x04 \x01(\x03R\fapparentSize\x12\x14\n" +
+	"\x05files\x18\x05 \x01(\x03R\x05files\x12 \n" +
+	"\vdirectories\x18\x06 \x01(\x03R\vdirectories\"~\n" +
+	"\x0eDiskUsageGroup\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"disk_usage\x18\x02 \x01(\x03R\tdiskUsage\x12#\n" +
+	"\rapparent_size\x18\x03 \x01(\x03R\fapparentSize\x12\x14\n" +
+	"\x05files\x18\x04 \x01(\x03R\x05files\"\xa8\x03\n" +
+	"\x11DiskUsageResponse\x120\n" +
+	"\x05total\x18\x01 \x01(\v2\x1a.filesystem.DiskUsageEntryR\x05total\x128\n" +
+	"\tbreakdown\x18\x02 \x03(\v2\x1a.filesystem.DiskUsageEntryR\tbreakdown\x12?\n" +
+	"\rlargest_files\x18\x03 \x03(\v2\x1a.filesystem.DiskUsageEntryR\flargestFiles\x12K\n" +
+	"\x13largest_directories\x18\x04 \x03(\v2\x1a.filesystem.DiskUsageEntryR\x12largestDirectories\x12=\n" +
+	"\fby_extension\x18\x05 \x03(\v2\x1a.filesystem.DiskUsageGroupR\vbyExtension\x12<\n" +
+	"\fby_mime_type\x18\x06 \x03(\v2\x1a.filesystem.DiskUsageGroupR\n" +
+	"byMimeType\x12\x1c\n" +
+	"\ttruncated\x18\a \x01(\bR\ttruncated\"\\\n" +
+	"\x11DuplicatesRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x19\n" +
+	"\bmin_size\x18\x02 \x01(\x03R\aminSize\x12\x18\n" +
+	"\aexclude\x18\x03 \x03(\tR\aexclude\"\x8d\x01\n" +
+	"\x0eDuplicateGroup\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x02 \x01(\tR\x06sha256\x12\x14\n" +
+	"\x05paths\x18\x03 \x03(\tR\x05paths\x12\x16\n" +
+	"\x06copies\x18\x04 \x01(\x05R\x06copies\x12!\n" +
+	"\fwasted_bytes\x18\x05 \x01(\x03R\vwastedBytes\"v\n" +
+	"\x12DeduplicateRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x19\n" +
+	"\bmin_size\x18\x02 \x01(\x03R\aminSize\x12\x18\n" +
+	"\aexclude\x18\x03 \x03(\tR\aexclude\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\"\xc7\x01\n" +
+	"\x13DeduplicateResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06groups\x18\x03 \x01(\x03R\x06groups\x12!\n" +
+	"\flinked_files\x18\x04 \x01(\x03R\vlinkedFiles\x12'\n" +
+	"\x0freclaimed_bytes\x18\x05 \x01(\x03R\x0ereclaimedBytes\x12\x18\n" +
+	"\askipped\x18\x06 \x03(\tR\askipped\"_\n" +
+	"\x0fChecksumRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1e\n" +
+	"\n" +
+	"algorithms\x18\x02 \x03(\tR\n" +
+	"algorithms\x12\x18\n" +
+	"\arefresh\x18\x03 \x01(\bR\arefresh\"\x99\x02\n" +
+	"\x10ChecksumResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12(\n" +
+	"\x10modified_time_ns\x18\x03 \x01(\x03R\x0emodifiedTimeNs\x12\x12\n" +
+	"\x04etag\x18\x04 \x01(\tR\x04etag\x12I\n" +
+	"\tchecksums\x18\x05 \x03(\v2+.filesystem.ChecksumResponse.ChecksumsEntryR\tchecksums\x12\x16\n" +
+	"\x06cached\x18\x06 \x01(\bR\x06cached\x1a<\n" +
+	"\x0eChecksumsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"?\n" +
+	"\x0fManifestRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aexclude\x18\x02 \x03(\tR\aexclude\"\x8d\x01\n" +
+	"\rManifestEntry\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\tR\x04mode\x12(\n" +
+	"\x10modified_time_ns\x18\x04 \x01(\x03R\x0emodifiedTimeNs\x12\x16\n" +
+	"\x06sha256\x18\x05 \x01(\tR\x06sha256\"\xa1\x01\n" +
+	"\x15VerifyManifestRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x123\n" +
+	"\aentries\x18\x02 \x03(\v2\x19.filesystem.ManifestEntryR\aentries\x12%\n" +
+	"\x0echeck_metadata\x18\x03 \x01(\bR\rcheckMetadata\x12\x18\n" +
+	"\aexclude\x18\x04 \x03(\tR\aexclude\"!\n" +
 	"\vPathRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\"K\n" +
 	"\x0eExistsResponse\x12\x16\n" +
 	"\x06exists\x18\x01 \x01(\bR\x06exists\x12!\n" +
 	"\fis_directory\x18\x02 \x01(\bR\visDirectory\"\"\n" +
 	"\fSizeResponse\x12\x12\n" +
-	"\x04size\x18\x01 \x01(\x03R\x04size\"s\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\"\xc6\x01\n" +
 	"\tFileChunk\x12\x1b\n" +
 	"\tfile_path\x18\x01 \x01(\tR\bfilePath\x12\x18\n" +
 	"\acontent\x18\x02 \x01(\fR\acontent\x12\x16\n" +
 	"\x06offset\x18\x03 \x01(\x03R\x06offset\x12\x17\n" +
-	"\ais_last\x18\x04 \x01(\bR\x06isLast\"]\n" +
+	"\ais_last\x18\x04 \x01(\bR\x06isLast\x12\x12\n" +
+	"\x04etag\x18\x05 \x01(\tR\x04etag\x12\x19\n" +
+	"\bif_match\x18\x06 \x01(\tR\aifMatch\x12\"\n" +
+	"\rif_none_match\x18\a \x01(\tR\vifNoneMatch\"q\n" +
 	"\x11OperationResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"\xf6\x01\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x12\n" +
+	"\x04etag\x18\x04 \x01(\tR\x04etag\"\xe1\x05\n" +
 	"\rSearchRequest\x12\x1b\n" +
 	"\tbase_path\x18\x01 \x01(\tR\bbasePath\x12\x18\n" +
 	"\apattern\x18\x02 \x01(\tR\apattern\x12%\n" +
@@ -1184,16 +6418,97 @@ const file_proto_filesystem_proto_rawDesc = "" +
 	"\n" +
 	"files_only\x18\x06 \x01(\bR\tfilesOnly\x12\x1f\n" +
 	"\vmax_results\x18\a \x01(\x05R\n" +
-	"maxResults\"]\n" +
+	"maxResults\x12\x1d\n" +
+	"\n" +
+	"name_regex\x18\b \x01(\tR\tnameRegex\x12\x1b\n" +
+	"\tpath_glob\x18\t \x01(\tR\bpathGlob\x12\x19\n" +
+	"\bmin_size\x18\n" +
+	" \x01(\x03R\aminSize\x12\x19\n" +
+	"\bmax_size\x18\v \x01(\x03R\amaxSize\x12%\n" +
+	"\x0emodified_after\x18\f \x01(\x03R\rmodifiedAfter\x12'\n" +
+	"\x0fmodified_before\x18\r \x01(\x03R\x0emodifiedBefore\x12\x1d\n" +
+	"\n" +
+	"mime_types\x18\x0e \x03(\tR\tmimeTypes\x12\x14\n" +
+	"\x05owner\x18\x0f \x01(\tR\x05owner\x12\x14\n" +
+	"\x05group\x18\x10 \x01(\tR\x05group\x12#\n" +
+	"\rsymlinks_only\x18\x11 \x01(\bR\fsymlinksOnly\x12\x1d\n" +
+	"\n" +
+	"empty_only\x18\x12 \x01(\bR\temptyOnly\x12\x1b\n" +
+	"\tpage_size\x18\x13 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x14 \x01(\tR\tpageToken\x12\x18\n" +
+	"\ainclude\x18\x15 \x03(\tR\ainclude\x12\x18\n" +
+	"\aexclude\x18\x16 \x03(\tR\aexclude\x12(\n" +
+	"\x10use_ignore_files\x18\x17 \x01(\bR\x0euseIgnoreFiles\"\xbb\x01\n" +
 	"\x10HierarchyRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1b\n" +
 	"\tmax_depth\x18\x02 \x01(\x05R\bmaxDepth\x12\x18\n" +
-	"\apattern\x18\x03 \x01(\tR\apattern\"[\n" +
+	"\apattern\x18\x03 \x01(\tR\apattern\x12\x18\n" +
+	"\ainclude\x18\x04 \x03(\tR\ainclude\x12\x18\n" +
+	"\aexclude\x18\x05 \x03(\tR\aexclude\x12(\n" +
+	"\x10use_ignore_files\x18\x06 \x01(\bR\x0euseIgnoreFiles\"[\n" +
 	"\x11HierarchyResponse\x12(\n" +
 	"\x04root\x18\x01 \x01(\v2\x14.filesystem.FileItemR\x04root\x12\x1c\n" +
-	"\ttruncated\x18\x02 \x01(\bR\ttruncated2\xe1\x06\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\"\xe0\x02\n" +
+	"\vGrepRequest\x12\x1b\n" +
+	"\tbase_path\x18\x01 \x01(\tR\bbasePath\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x14\n" +
+	"\x05regex\x18\x03 \x01(\bR\x05regex\x12%\n" +
+	"\x0ecase_sensitive\x18\x04 \x01(\bR\rcaseSensitive\x12\x1c\n" +
+	"\trecursive\x18\x05 \x01(\bR\trecursive\x12\x18\n" +
+	"\ainclude\x18\x06 \x03(\tR\ainclude\x12\x18\n" +
+	"\aexclude\x18\a \x03(\tR\aexclude\x12\"\n" +
+	"\rmax_file_size\x18\b \x01(\x03R\vmaxFileSize\x12%\n" +
+	"\x0einclude_binary\x18\t \x01(\bR\rincludeBinary\x12#\n" +
+	"\rcontext_lines\x18\n" +
+	" \x01(\x05R\fcontextLines\x12\x1f\n" +
+	"\vmax_matches\x18\v \x01(\x05R\n" +
+	"maxMatches\"\xb8\x01\n" +
+	"\tGrepMatch\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1f\n" +
+	"\vline_number\x18\x02 \x01(\x03R\n" +
+	"lineNumber\x12\x16\n" +
+	"\x06column\x18\x03 \x01(\x05R\x06column\x12\x12\n" +
+	"\x04line\x18\x04 \x01(\tR\x04line\x12%\n" +
+	"\x0ebefore_context\x18\x05 \x03(\tR\rbeforeContext\x12#\n" +
+	"\rafter_context\x18\x06 \x03(\tR\fafterContext*K\n" +
+	"\tSortField\x12\r\n" +
+	"\tSORT_NAME\x10\x00\x12\r\n" +
+	"\tSORT_SIZE\x10\x01\x12\x11\n" +
+	"\rSORT_MODIFIED\x10\x02\x12\r\n" +
+	"\tSORT_TYPE\x10\x03*\xc9\x01\n" +
+	"\bFileType\x12\x15\n" +
+	"\x11FILE_TYPE_UNKNOWN\x10\x00\x12\x15\n" +
+	"\x11FILE_TYPE_REGULAR\x10\x01\x12\x17\n" +
+	"\x13FILE_TYPE_DIRECTORY\x10\x02\x12\x15\n" +
+	"\x11FILE_TYPE_SYMLINK\x10\x03\x12\x12\n" +
+	"\x0eFILE_TYPE_FIFO\x10\x04\x12\x14\n" +
+	"\x10FILE_TYPE_SOCKET\x10\x05\x12\x19\n" +
+	"\x15FILE_TYPE_CHAR_DEVICE\x10\x06\x12\x1a\n" +
+	"\x16FILE_TYPE_BLOCK_DEVICE\x10\a*\x96\x01\n" +
+	"\x06AclTag\x12\x15\n" +
+	"\x11ACL_TAG_UNDEFINED\x10\x00\x12\x14\n" +
+	"\x10ACL_TAG_USER_OBJ\x10\x01\x12\x10\n" +
+	"\fACL_TAG_USER\x10\x02\x12\x15\n" +
+	"\x11ACL_TAG_GROUP_OBJ\x10\x03\x12\x11\n" +
+	"\rACL_TAG_GROUP\x10\x04\x12\x10\n" +
+	"\fACL_TAG_MASK\x10\x05\x12\x11\n" +
+	"\rACL_TAG_OTHER\x10\x06*/\n" +
+	"\bLockMode\x12\x12\n" +
+	"\x0eLOCK_EXCLUSIVE\x10\x00\x12\x0f\n" +
+	"\vLOCK_SHARED\x10\x01*G\n" +
+	"\n" +
+	"ChangeType\x12\x10\n" +
+	"\fCHANGE_ADDED\x10\x00\x12\x12\n" +
+	"\x0eCHANGE_DELETED\x10\x01\x12\x13\n" +
+	"\x0fCHANGE_MODIFIED\x10\x02*K\n" +
+	"\vCompareMode\x12\x11\n" +
+	"\rCOMPARE_QUICK\x10\x00\x12\x14\n" +
+	"\x10COMPARE_METADATA\x10\x01\x12\x13\n" +
+	"\x0fCOMPARE_CONTENT\x10\x022\x84\x1d\n" +
 	"\x11FilesystemService\x12D\n" +
-	"\rListDirectory\x12\x17.filesystem.ListRequest\x1a\x18.filesystem.ListResponse\"\x00\x12M\n" +
+	"\rListDirectory\x12\x17.filesystem.ListRequest\x1a\x18.filesystem.ListResponse\"\x00\x12H\n" +
+	"\x13ListDirectoryStream\x12\x17.filesystem.ListRequest\x1a\x14.filesystem.FileItem\"\x000\x01\x12M\n" +
 	"\fGetHierarchy\x12\x1c.filesystem.HierarchyRequest\x1a\x1d.filesystem.HierarchyResponse\"\x00\x12>\n" +
 	"\vGetFileInfo\x12\x17.filesystem.FileRequest\x1a\x14.filesystem.FileInfo\"\x00\x12V\n" +
 	"\x0fCreateDirectory\x12\".filesystem.CreateDirectoryRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12D\n" +
@@ -1205,7 +6520,47 @@ const file_proto_filesystem_proto_rawDesc = "" +
 	"\fDownloadFile\x12\x17.filesystem.FileRequest\x1a\x15.filesystem.FileChunk\"\x000\x01\x12?\n" +
 	"\x06Exists\x12\x17.filesystem.PathRequest\x1a\x1a.filesystem.ExistsResponse\"\x00\x12G\n" +
 	"\x10GetDirectorySize\x12\x17.filesystem.PathRequest\x1a\x18.filesystem.SizeResponse\"\x00\x12?\n" +
-	"\x06Search\x12\x19.filesystem.SearchRequest\x1a\x18.filesystem.ListResponse\"\x00B$Z\"github.com/filesystem-daemon/protob\x06proto3"
+	"\x06Search\x12\x19.filesystem.SearchRequest\x1a\x18.filesystem.ListResponse\"\x00\x12C\n" +
+	"\fSearchStream\x12\x19.filesystem.SearchRequest\x1a\x14.filesystem.FileItem\"\x000\x01\x12?\n" +
+	"\tGrepFiles\x12\x17.filesystem.GrepRequest\x1a\x15.filesystem.GrepMatch\"\x000\x01\x12L\n" +
+	"\rCreateSymlink\x12\x1a.filesystem.SymlinkRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12T\n" +
+	"\x0eSetPermissions\x12!.filesystem.SetPermissionsRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12H\n" +
+	"\bSetOwner\x12\x1b.filesystem.SetOwnerRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12H\n" +
+	"\bSetTimes\x12\x1b.filesystem.SetTimesRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12B\n" +
+	"\tGetXattrs\x12\x18.filesystem.XattrRequest\x1a\x19.filesystem.XattrResponse\"\x00\x12H\n" +
+	"\bSetXattr\x12\x1b.filesystem.SetXattrRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12H\n" +
+	"\vRemoveXattr\x12\x18.filesystem.XattrRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12;\n" +
+	"\x06GetAcl\x12\x16.filesystem.AclRequest\x1a\x17.filesystem.AclResponse\"\x00\x12D\n" +
+	"\x06SetAcl\x12\x19.filesystem.SetAclRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12B\n" +
+	"\tWriteFile\x12\x18.filesystem.WriteRequest\x1a\x19.filesystem.WriteResponse\"\x00\x12D\n" +
+	"\n" +
+	"AppendFile\x12\x19.filesystem.AppendRequest\x1a\x19.filesystem.WriteResponse\"\x00\x12D\n" +
+	"\bTruncate\x12\x1b.filesystem.TruncateRequest\x1a\x19.filesystem.WriteResponse\"\x00\x12:\n" +
+	"\vAcquireLock\x12\x17.filesystem.LockRequest\x1a\x10.filesystem.Lock\"\x00\x12=\n" +
+	"\tRenewLock\x12\x1c.filesystem.RenewLockRequest\x1a\x10.filesystem.Lock\"\x00\x12H\n" +
+	"\vReleaseLock\x12\x18.filesystem.LeaseRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12E\n" +
+	"\tListLocks\x12\x17.filesystem.PathRequest\x1a\x1d.filesystem.ListLocksResponse\"\x00\x12K\n" +
+	"\fListVersions\x12\x17.filesystem.PathRequest\x1a .filesystem.ListVersionsResponse\"\x00\x12T\n" +
+	"\x0eRestoreVersion\x12!.filesystem.RestoreVersionRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12K\n" +
+	"\x0eCreateSnapshot\x12!.filesystem.CreateSnapshotRequest\x1a\x14.filesystem.Snapshot\"\x00\x12V\n" +
+	"\rListSnapshots\x12 .filesystem.ListSnapshotsRequest\x1a!.filesystem.ListSnapshotsResponse\"\x00\x12G\n" +
+	"\fDiffSnapshot\x12\x1b.filesystem.SnapshotRequest\x1a\x18.filesystem.DiffResponse\"\x00\x12V\n" +
+	"\x0fRestoreSnapshot\x12\".filesystem.RestoreSnapshotRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12N\n" +
+	"\x0eDeleteSnapshot\x12\x1b.filesystem.SnapshotRequest\x1a\x1d.filesystem.OperationResponse\"\x00\x12L\n" +
+	"\x12CompareDirectories\x12\x1a.filesystem.CompareRequest\x1a\x16.filesystem.PathChange\"\x000\x01\x12J\n" +
+	"\tDiffFiles\x12\x1c.filesystem.DiffFilesRequest\x1a\x1d.filesystem.DiffFilesResponse\"\x00\x12H\n" +
+	"\n" +
+	"ApplyPatch\x12\x1d.filesystem.ApplyPatchRequest\x1a\x19.filesystem.WriteResponse\"\x00\x12L\n" +
+	"\fGetSignature\x12\x1c.filesystem.SignatureRequest\x1a\x1a.filesystem.SignatureChunk\"\x000\x01\x12C\n" +
+	"\n" +
+	"ApplyDelta\x12\x16.filesystem.DeltaChunk\x1a\x19.filesystem.WriteResponse\"\x00(\x01\x12D\n" +
+	"\bGetDelta\x12\x1a.filesystem.SignatureChunk\x1a\x16.filesystem.DeltaChunk\"\x00(\x010\x01\x12N\n" +
+	"\x10GenerateManifest\x12\x1b.filesystem.ManifestRequest\x1a\x19.filesystem.ManifestEntry\"\x000\x01\x12Q\n" +
+	"\x0eVerifyManifest\x12!.filesystem.VerifyManifestRequest\x1a\x16.filesystem.PathChange\"\x00(\x010\x01\x12J\n" +
+	"\vGetChecksum\x12\x1b.filesystem.ChecksumRequest\x1a\x1c.filesystem.ChecksumResponse\"\x00\x12O\n" +
+	"\x0eFindDuplicates\x12\x1d.filesystem.DuplicatesRequest\x1a\x1a.filesystem.DuplicateGroup\"\x000\x01\x12]\n" +
+	"\x18DeduplicateWithHardlinks\x12\x1e.filesystem.DeduplicateRequest\x1a\x1f.filesystem.DeduplicateResponse\"\x00\x12M\n" +
+	"\fGetDiskUsage\x12\x1c.filesystem.DiskUsageRequest\x1a\x1d.filesystem.DiskUsageResponse\"\x00B$Z\"github.com/filesystem-daemon/protob\x06proto3"
 
 var (
 	file_proto_filesystem_proto_rawDescOnce sync.Once
@@ -1219,59 +6574,235 @@ func file_proto_filesystem_proto_rawDescGZIP() []byte {
 	return file_proto_filesystem_proto_rawDescData
 }
 
-var file_proto_filesystem_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_proto_filesystem_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_proto_filesystem_proto_msgTypes = make([]protoimpl.MessageInfo, 77)
 var file_proto_filesystem_proto_goTypes = []any{
-	(*ListRequest)(nil),            // 0: filesystem.ListRequest
-	(*FileItem)(nil),               // 1: filesystem.FileItem
-	(*ListResponse)(nil),           // 2: filesystem.ListResponse
-	(*FileRequest)(nil),            // 3: filesystem.FileRequest
-	(*FileInfo)(nil),               // 4: filesystem.FileInfo
-	(*CreateDirectoryRequest)(nil), // 5: filesystem.CreateDirectoryRequest
-	(*DeleteRequest)(nil),          // 6: filesystem.DeleteRequest
-	(*CopyRequest)(nil),            // 7: filesystem.CopyRequest
-	(*MoveRequest)(nil),            // 8: filesystem.MoveRequest
-	(*PathRequest)(nil),            // 9: filesystem.PathRequest
-	(*ExistsResponse)(nil),         // 10: filesystem.ExistsResponse
-	(*SizeResponse)(nil),           // 11: filesystem.SizeResponse
-	(*FileChunk)(nil),              // 12: filesystem.FileChunk
-	(*OperationResponse)(nil),      // 13: filesystem.OperationResponse
-	(*SearchRequest)(nil),          // 14: filesystem.SearchRequest
-	(*HierarchyRequest)(nil),       // 15: filesystem.HierarchyRequest
-	(*HierarchyResponse)(nil),      // 16: filesystem.HierarchyResponse
+	(SortField)(0),                 // 0: filesystem.SortField
+	(FileType)(0),                  // 1: filesystem.FileType
+	(AclTag)(0),                    // 2: filesystem.AclTag
+	(LockMode)(0),                  // 3: filesystem.LockMode
+	(ChangeType)(0),                // 4: filesystem.ChangeType
+	(CompareMode)(0),               // 5: filesystem.CompareMode
+	(*ListRequest)(nil),            // 6: filesystem.ListRequest
+	(*FileItem)(nil),               // 7: filesystem.FileItem
+	(*ListResponse)(nil),           // 8: filesystem.ListResponse
+	(*FileRequest)(nil),            // 9: filesystem.FileRequest
+	(*FileInfo)(nil),               // 10: filesystem.FileInfo
+	(*CreateDirectoryRequest)(nil), // 11: filesystem.CreateDirectoryRequest
+	(*DeleteRequest)(nil),          // 12: filesystem.DeleteRequest
+	(*CopyRequest)(nil),            // 13: filesystem.CopyRequest
+	(*MoveRequest)(nil),            // 14: filesystem.MoveRequest
+	(*SymlinkRequest)(nil),         // 15: filesystem.SymlinkRequest
+	(*SetPermissionsRequest)(nil),  // 16: filesystem.SetPermissionsRequest
+	(*SetOwnerRequest)(nil),        // 17: filesystem.SetOwnerRequest
+	(*SetTimesRequest)(nil),        // 18: filesystem.SetTimesRequest
+	(*Xattr)(nil),                  // 19: filesystem.Xattr
+	(*XattrRequest)(nil),           // 20: filesystem.XattrRequest
+	(*XattrResponse)(nil),          // 21: filesystem.XattrResponse
+	(*SetXattrRequest)(nil),        // 22: filesystem.SetXattrRequest
+	(*AclEntry)(nil),               // 23: filesystem.AclEntry
+	(*AclRequest)(nil),             // 24: filesystem.AclRequest
+	(*AclResponse)(nil),            // 25: filesystem.AclResponse
+	(*SetAclRequest)(nil),          // 26: filesystem.SetAclRequest
+	(*WritePrecondition)(nil),      // 27: filesystem.WritePrecondition
+	(*WriteRequest)(nil),           // 28: filesystem.WriteRequest
+	(*AppendRequest)(nil),          // 29: filesystem.AppendRequest
+	(*TruncateRequest)(nil),        // 30: filesystem.TruncateRequest
+	(*WriteResponse)(nil),          // 31: filesystem.WriteResponse
+	(*LockRequest)(nil),            // 32: filesystem.LockRequest
+	(*RenewLockRequest)(nil),       // 33: filesystem.RenewLockRequest
+	(*LeaseRequest)(nil),           // 34: filesystem.LeaseRequest
+	(*Lock)(nil),                   // 35: filesystem.Lock
+	(*ListLocksResponse)(nil),      // 36: filesystem.ListLocksResponse
+	(*FileVersion)(nil),            // 37: filesystem.FileVersion
+	(*ListVersionsResponse)(nil),   // 38: filesystem.ListVersionsResponse
+	(*RestoreVersionRequest)(nil),  // 39: filesystem.RestoreVersionRequest
+	(*CreateSnapshotRequest)(nil),  // 40: filesystem.CreateSnapshotRequest
+	(*Snapshot)(nil),               // 41: filesystem.Snapshot
+	(*ListSnapshotsRequest)(nil),   // 42: filesystem.ListSnapshotsRequest
+	(*ListSnapshotsResponse)(nil),  // 43: filesystem.ListSnapshotsResponse
+	(*SnapshotRequest)(nil),        // 44: filesystem.SnapshotRequest
+	(*RestoreSnapshotRequest)(nil), // 45: filesystem.RestoreSnapshotRequest
+	(*PathChange)(nil),             // 46: filesystem.PathChange
+	(*DiffResponse)(nil),           // 47: filesystem.DiffResponse
+	(*CompareRequest)(nil),         // 48: filesystem.CompareRequest
+	(*DiffFilesRequest)(nil),       // 49: filesystem.DiffFilesRequest
+	(*DiffFilesResponse)(nil),      // 50: filesystem.DiffFilesResponse
+	(*ApplyPatchRequest)(nil),      // 51: filesystem.ApplyPatchRequest
+	(*SignatureRequest)(nil),       // 52: filesystem.SignatureRequest
+	(*BlockSignature)(nil),         // 53: filesystem.BlockSignature
+	(*SignatureChunk)(nil),         // 54: filesystem.SignatureChunk
+	(*DeltaOp)(nil),                // 55: filesystem.DeltaOp
+	(*DeltaChunk)(nil),             // 56: filesystem.DeltaChunk
+	(*DiskUsageRequest)(nil),       // 57: filesystem.DiskUsageRequest
+	(*DiskUsageEntry)(nil),         // 58: filesystem.DiskUsageEntry
+	(*DiskUsageGroup)(nil),         // 59: filesystem.DiskUsageGroup
+	(*DiskUsageResponse)(nil),      // 60: filesystem.DiskUsageResponse
+	(*DuplicatesRequest)(nil),      // 61: filesystem.DuplicatesRequest
+	(*DuplicateGroup)(nil),         // 62: filesystem.DuplicateGroup
+	(*DeduplicateRequest)(nil),     // 63: filesystem.DeduplicateRequest
+	(*DeduplicateResponse)(nil),    // 64: filesystem.DeduplicateResponse
+	(*ChecksumRequest)(nil),        // 65: filesystem.ChecksumRequest
+	(*ChecksumResponse)(nil),       // 66: filesystem.ChecksumResponse
+	(*ManifestRequest)(nil),        // 67: filesystem.ManifestRequest
+	(*ManifestEntry)(nil),          // 68: filesystem.ManifestEntry
+	(*VerifyManifestRequest)(nil),  // 69: filesystem.VerifyManifestRequest
+	(*PathRequest)(nil),            // 70: filesystem.PathRequest
+	(*ExistsResponse)(nil),         // 71: filesystem.ExistsResponse
+	(*SizeResponse)(nil),           // 72: filesystem.SizeResponse
+	(*FileChunk)(nil),              // 73: filesystem.FileChunk
+	(*OperationResponse)(nil),      // 74: filesystem.OperationResponse
+	(*SearchRequest)(nil),          // 75: filesystem.SearchRequest
+	(*HierarchyRequest)(nil),       // 76: filesystem.HierarchyRequest
+	(*HierarchyResponse)(nil),      // 77: filesystem.HierarchyResponse
+	(*GrepRequest)(nil),            // 78: filesystem.GrepRequest
+	(*GrepMatch)(nil),              // 79: filesystem.GrepMatch
+	nil,                            // 80: filesystem.FileItem.ChecksumsEntry
+	nil,                            // 81: filesystem.FileInfo.ChecksumsEntry
+	nil,                            // 82: filesystem.ChecksumResponse.ChecksumsEntry
 }
 var file_proto_filesystem_proto_depIdxs = []int32{
-	1,  // 0: filesystem.FileItem.children:type_name -> filesystem.FileItem
-	1,  // 1: filesystem.ListResponse.items:type_name -> filesystem.FileItem
-	1,  // 2: filesystem.HierarchyResponse.root:type_name -> filesystem.FileItem
-	0,  // 3: filesystem.FilesystemService.ListDirectory:input_type -> filesystem.ListRequest
-	15, // 4: filesystem.FilesystemService.GetHierarchy:input_type -> filesystem.HierarchyRequest
-	3,  // 5: filesystem.FilesystemService.GetFileInfo:input_type -> filesystem.FileRequest
-	5,  // 6: filesystem.FilesystemService.CreateDirectory:input_type -> filesystem.CreateDirectoryRequest
-	6,  // 7: filesystem.FilesystemService.Delete:input_type -> filesystem.DeleteRequest
-	7,  // 8: filesystem.FilesystemService.Copy:input_type -> filesystem.CopyRequest
-	8,  // 9: filesystem.FilesystemService.Move:input_type -> filesystem.MoveRequest
-	12, // 10: filesystem.FilesystemService.UploadFile:input_type -> filesystem.FileChunk
-	3,  // 11: filesystem.FilesystemService.DownloadFile:input_type -> filesystem.FileRequest
-	9,  // 12: filesystem.FilesystemService.Exists:input_type -> filesystem.PathRequest
-	9,  // 13: filesystem.FilesystemService.GetDirectorySize:input_type -> filesystem.PathRequest
-	14, // 14: filesystem.FilesystemService.Search:input_type -> filesystem.SearchRequest
-	2,  // 15: filesystem.FilesystemService.ListDirectory:output_type -> filesystem.ListResponse
-	16, // 16: filesystem.FilesystemService.GetHierarchy:output_type -> filesystem.HierarchyResponse
-	4,  // 17: filesystem.FilesystemService.GetFileInfo:output_type -> filesystem.FileInfo
-	13, // 18: filesystem.FilesystemService.CreateDirectory:output_type -> filesystem.OperationResponse
-	13, // 19: filesystem.FilesystemService.Delete:output_type -> filesystem.OperationResponse
-	13, // 20: filesystem.FilesystemService.Copy:output_type -> filesystem.OperationResponse
-	13, // 21: filesystem.FilesystemService.Move:output_type -> filesystem.OperationResponse
-	13, // 22: filesystem.FilesystemService.UploadFile:output_type -> filesystem.OperationResponse
-	12, // 23: filesystem.FilesystemService.DownloadFile:output_type -> filesystem.FileChunk
-	10, // 24: filesystem.FilesystemService.Exists:output_type -> filesystem.ExistsResponse
-	11, // 25: filesystem.FilesystemService.GetDirectorySize:output_type -> filesystem.SizeResponse
-	2,  // 26: filesystem.FilesystemService.Search:output_type -> filesystem.ListResponse
-	15, // [15:27] is the sub-list for method output_type
-	3,  // [3:15] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	0,  // 0: filesystem.ListRequest.sort_by:type_name -> filesystem.SortField
+	7,  // 1: filesystem.FileItem.children:type_name -> filesystem.FileItem
+	1,  // 2: filesystem.FileItem.file_type:type_name -> filesystem.FileType
+	80, // 3: filesystem.FileItem.checksums:type_name -> filesystem.FileItem.ChecksumsEntry
+	7,  // 4: filesystem.ListResponse.items:type_name -> filesystem.FileItem
+	1,  // 5: filesystem.FileInfo.file_type:type_name -> filesystem.FileType
+	19, // 6: filesystem.FileInfo.xattrs:type_name -> filesystem.Xattr
+	81, // 7: filesystem.FileInfo.checksums:type_name -> filesystem.FileInfo.ChecksumsEntry
+	19, // 8: filesystem.XattrResponse.xattrs:type_name -> filesystem.Xattr
+	2,  // 9: filesystem.AclEntry.tag:type_name -> filesystem.AclTag
+	23, // 10: filesystem.AclResponse.entries:type_name -> filesystem.AclEntry
+	23, // 11: filesystem.SetAclRequest.entries:type_name -> filesystem.AclEntry
+	27, // 12: filesystem.WriteRequest.precondition:type_name -> filesystem.WritePrecondition
+	27, // 13: filesystem.AppendRequest.precondition:type_name -> filesystem.WritePrecondition
+	27, // 14: filesystem.TruncateRequest.precondition:type_name -> filesystem.WritePrecondition
+	3,  // 15: filesystem.LockRequest.mode:type_name -> filesystem.LockMode
+	3,  // 16: filesystem.Lock.mode:type_name -> filesystem.LockMode
+	35, // 17: filesystem.ListLocksResponse.locks:type_name -> filesystem.Lock
+	37, // 18: filesystem.ListVersionsResponse.versions:type_name -> filesystem.FileVersion
+	41, // 19: filesystem.ListSnapshotsResponse.snapshots:type_name -> filesystem.Snapshot
+	4,  // 20: filesystem.PathChange.change:type_name -> filesystem.ChangeType
+	1,  // 21: filesystem.PathChange.file_type:type_name -> filesystem.FileType
+	46, // 22: filesystem.DiffResponse.changes:type_name -> filesystem.PathChange
+	5,  // 23: filesystem.CompareRequest.mode:type_name -> filesystem.CompareMode
+	27, // 24: filesystem.ApplyPatchRequest.precondition:type_name -> filesystem.WritePrecondition
+	53, // 25: filesystem.SignatureChunk.blocks:type_name -> filesystem.BlockSignature
+	55, // 26: filesystem.DeltaChunk.ops:type_name -> filesystem.DeltaOp
+	58, // 27: filesystem.DiskUsageResponse.total:type_name -> filesystem.DiskUsageEntry
+	58, // 28: filesystem.DiskUsageResponse.breakdown:type_name -> filesystem.DiskUsageEntry
+	58, // 29: filesystem.DiskUsageResponse.largest_files:type_name -> filesystem.DiskUsageEntry
+	58, // 30: filesystem.DiskUsageResponse.largest_directories:type_name -> filesystem.DiskUsageEntry
+	59, // 31: filesystem.DiskUsageResponse.by_extension:type_name -> filesystem.DiskUsageGroup
+	59, // 32: filesystem.DiskUsageResponse.by_mime_type:type_name -> filesystem.DiskUsageGroup
+	82, // 33: filesystem.ChecksumResponse.checksums:type_name -> filesystem.ChecksumResponse.ChecksumsEntry
+	68, // 34: filesystem.VerifyManifestRequest.entries:type_name -> filesystem.ManifestEntry
+	7,  // 35: filesystem.HierarchyResponse.root:type_name -> filesystem.FileItem
+	6,  // 36: filesystem.FilesystemService.ListDirectory:input_type -> filesystem.ListRequest
+	6,  // 37: filesystem.FilesystemService.ListDirectoryStream:input_type -> filesystem.ListRequest
+	76, // 38: filesystem.FilesystemService.GetHierarchy:input_type -> filesystem.HierarchyRequest
+	9,  // 39: filesystem.FilesystemService.GetFileInfo:input_type -> filesystem.FileRequest
+	11, // 40: filesystem.FilesystemService.CreateDirectory:input_type -> filesystem.CreateDirectoryRequest
+	12, // 41: filesystem.FilesystemService.Delete:input_type -> filesystem.DeleteRequest
+	13, // 42: filesystem.FilesystemService.Copy:input_type -> filesystem.CopyRequest
+	14, // 43: filesystem.FilesystemService.Move:input_type -> filesystem.MoveRequest
+	73, // 44: filesystem.FilesystemService.UploadFile:input_type -> filesystem.FileChunk
+	9,  // 45: filesystem.FilesystemService.DownloadFile:input_type -> filesystem.FileRequest
+	70, // 46: filesystem.FilesystemService.Exists:input_type -> filesystem.PathRequest
+	70, // 47: filesystem.FilesystemService.GetDirectorySize:input_type -> filesystem.PathRequest
+	75, // 48: filesystem.FilesystemService.Search:input_type -> filesystem.SearchRequest
+	75, // 49: filesystem.FilesystemService.SearchStream:input_type -> filesystem.SearchRequest
+	78, // 50: filesystem.FilesystemService.GrepFiles:input_type -> filesystem.GrepRequest
+	15, // 51: filesystem.FilesystemService.CreateSymlink:input_type -> filesystem.SymlinkRequest
+	16, // 52: filesystem.FilesystemService.SetPermissions:input_type -> filesystem.SetPermissionsRequest
+	17, // 53: filesystem.FilesystemService.SetOwner:input_type -> filesystem.SetOwnerRequest
+	18, // 54: filesystem.FilesystemService.SetTimes:input_type -> filesystem.SetTimesRequest
+	20, // 55: filesystem.FilesystemService.GetXattrs:input_type -> filesystem.XattrRequest
+	22, // 56: filesystem.FilesystemService.SetXattr:input_type -> filesystem.SetXattrRequest
+	20, // 57: filesystem.FilesystemService.RemoveXattr:input_type -> filesystem.XattrRequest
+	24, // 58: filesystem.FilesystemService.GetAcl:input_type -> filesystem.AclRequest
+	26, // 59: filesystem.FilesystemService.SetAcl:input_type -> filesystem.SetAclRequest
+	28, // 60: filesystem.FilesystemService.WriteFile:input_type -> filesystem.WriteRequest
+	29, // 61: filesystem.FilesystemService.AppendFile:input_type -> filesystem.AppendRequest
+	30, // 62: filesystem.FilesystemService.Truncate:input_type -> filesystem.TruncateRequest
+	32, // 63: filesystem.FilesystemService.AcquireLock:input_type -> filesystem.LockRequest
+	33, // 64: filesystem.FilesystemService.RenewLock:input_type -> filesystem.RenewLockRequest
+	34, // 65: filesystem.FilesystemService.ReleaseLock:input_type -> filesystem.LeaseRequest
+	70, // 66: filesystem.FilesystemService.ListLocks:input_type -> filesystem.PathRequest
+	70, // 67: filesystem.FilesystemService.ListVersions:input_type -> filesystem.PathRequest
+	39, // 68: filesystem.FilesystemService.RestoreVersion:input_type -> filesystem.RestoreVersionRequest
+	40, // 69: filesystem.FilesystemService.CreateSnapshot:input_type -> filesystem.CreateSnapshotRequest
+	42, // 70: filesystem.FilesystemService.ListSnapshots:input_type -> filesystem.ListSnapshotsRequest
+	44, // 71: filesystem.FilesystemService.DiffSnapshot:input_type -> filesystem.SnapshotRequest
+	45, // 72: filesystem.FilesystemService.RestoreSnapshot:input_type -> filesystem.RestoreSnapshotRequest
+	44, // 73: filesystem.FilesystemService.DeleteSnapshot:input_type -> filesystem.SnapshotRequest
+	48, // 74: filesystem.FilesystemService.CompareDirectories:input_type -> filesystem.CompareRequest
+	49, // 75: filesystem.FilesystemService.DiffFiles:input_type -> filesystem.DiffFilesRequest
+	51, // 76: filesystem.FilesystemService.ApplyPatch:input_type -> filesystem.ApplyPatchRequest
+	52, // 77: filesystem.FilesystemService.GetSignature:input_type -> filesystem.SignatureRequest
+	56, // 78: filesystem.FilesystemService.ApplyDelta:input_type -> filesystem.DeltaChunk
+	54, // 79: filesystem.FilesystemService.GetDelta:input_type -> filesystem.SignatureChunk
+	67, // 80: filesystem.FilesystemService.GenerateManifest:input_type -> filesystem.ManifestRequest
+	69, // 81: filesystem.FilesystemService.VerifyManifest:input_type -> filesystem.VerifyManifestRequest
+	65, // 82: filesystem.FilesystemService.GetChecksum:input_type -> filesystem.ChecksumRequest
+	61, // 83: filesystem.FilesystemService.FindDuplicates:input_type -> filesystem.DuplicatesRequest
+	63, // 84: filesystem.FilesystemService.DeduplicateWithHardlinks:input_type -> filesystem.DeduplicateRequest
+	57, // 85: filesystem.FilesystemService.GetDiskUsage:input_type -> filesystem.DiskUsageRequest
+	8,  // 86: filesystem.FilesystemService.ListDirectory:output_type -> filesystem.ListResponse
+	7,  // 87: filesystem.FilesystemService.ListDirectoryStream:output_type -> filesystem.FileItem
+	77, // 88: filesystem.FilesystemService.GetHierarchy:output_type -> filesystem.HierarchyResponse
+	10, // 89: filesystem.FilesystemService.GetFileInfo:output_type -> filesystem.FileInfo
+	74, // 90: filesystem.FilesystemService.CreateDirectory:output_type -> filesystem.OperationResponse
+	74, // 91: filesystem.FilesystemService.Delete:output_type -> filesystem.OperationResponse
+	74, // 92: filesystem.FilesystemService.Copy:output_type -> filesystem.OperationResponse
+	74, // 93: filesystem.FilesystemService.Move:output_type -> filesystem.OperationResponse
+	74, // 94: filesystem.FilesystemService.UploadFile:output_type -> filesystem.OperationResponse
+	73, // 95: filesystem.FilesystemService.DownloadFile:output_type -> filesystem.FileChunk
+	71, // 96: filesystem.FilesystemService.Exists:output_type -> filesystem.ExistsResponse
+	72, // 97: filesystem.FilesystemService.GetDirectorySize:output_type -> filesystem.SizeResponse
+	8,  // 98: filesystem.FilesystemService.Search:output_type -> filesystem.ListResponse
+	7,  // 99: filesystem.FilesystemService.SearchStream:output_type -> filesystem.FileItem
+	79, // 100: filesystem.FilesystemService.GrepFiles:output_type -> filesystem.GrepMatch
+	74, // 101: filesystem.FilesystemService.CreateSymlink:output_type -> filesystem.OperationResponse
+	74, // 102: filesystem.FilesystemService.SetPermissions:output_type -> filesystem.OperationResponse
+	74, // 103: filesystem.FilesystemService.SetOwner:output_type -> filesystem.OperationResponse
+	74, // 104: filesystem.FilesystemService.SetTimes:output_type -> filesystem.OperationResponse
+	21, // 105: filesystem.FilesystemService.GetXattrs:output_type -> filesystem.XattrResponse
+	74, // 106: filesystem.FilesystemService.SetXattr:output_type -> filesystem.OperationResponse
+	74, // 107: filesystem.FilesystemService.RemoveXattr:output_type -> filesystem.OperationResponse
+	25, // 108: filesystem.FilesystemService.GetAcl:output_type -> filesystem.AclResponse
+	74, // 109: filesystem.FilesystemService.SetAcl:output_type -> filesystem.OperationResponse
+	31, // 110: filesystem.FilesystemService.WriteFile:output_type -> filesystem.WriteResponse
+	31, // 111: filesystem.FilesystemService.AppendFile:output_type -> filesystem.WriteResponse
+	31, // 112: filesystem.FilesystemService.Truncate:output_type -> filesystem.WriteResponse
+	35, // 113: filesystem.FilesystemService.AcquireLock:output_type -> filesystem.Lock
+	35, // 114: filesystem.FilesystemService.RenewLock:output_type -> filesystem.Lock
+	74, // 115: filesystem.FilesystemService.ReleaseLock:output_type -> filesystem.OperationResponse
+	36, // 116: filesystem.FilesystemService.ListLocks:output_type -> filesystem.ListLocksResponse
+	38, // 117: filesystem.FilesystemService.ListVersions:output_type -> filesystem.ListVersionsResponse
+	74, // 118: filesystem.FilesystemService.RestoreVersion:output_type -> filesystem.OperationResponse
+	41, // 119: filesystem.FilesystemService.CreateSnapshot:output_type -> filesystem.Snapshot
+	43, // 120: filesystem.FilesystemService.ListSnapshots:output_type -> filesystem.ListSnapshotsResponse
+	47, // 121: filesystem.FilesystemService.DiffSnapshot:output_type -> filesystem.DiffResponse
+	74, // 122: filesystem.FilesystemService.RestoreSnapshot:output_type -> filesystem.OperationResponse
+	74, // 123: filesystem.FilesystemService.DeleteSnapshot:output_type -> filesystem.OperationResponse
+	46, // 124: filesystem.FilesystemService.CompareDirectories:output_type -> filesystem.PathChange
+	50, // 125: filesystem.FilesystemService.DiffFiles:output_type -> filesystem.DiffFilesResponse
+	31, // 126: filesystem.FilesystemService.ApplyPatch:output_type -> filesystem.WriteResponse
+	54, // 127: filesystem.FilesystemService.GetSignature:output_type -> filesystem.SignatureChunk
+	31, // 128: filesystem.FilesystemService.ApplyDelta:output_type -> filesystem.WriteResponse
+	56, // 129: filesystem.FilesystemService.GetDelta:output_type -> filesystem.DeltaChunk
+	68, // 130: filesystem.FilesystemService.GenerateManifest:output_type -> filesystem.ManifestEntry
+	46, // 131: filesystem.FilesystemService.VerifyManifest:output_type -> filesystem.PathChange
+	66, // 132: filesystem.FilesystemService.GetChecksum:output_type -> filesystem.ChecksumResponse
+	62, // 133: filesystem.FilesystemService.FindDuplicates:output_type -> filesystem.DuplicateGroup
+	64, // 134: filesystem.FilesystemService.DeduplicateWithHardlinks:output_type -> filesystem.DeduplicateResponse
+	60, // 135: filesystem.FilesystemService.GetDiskUsage:output_type -> filesystem.DiskUsageResponse
+	86, // [86:136] is the sub-list for method output_type
+	36, // [36:86] is the sub-list for method input_type
+	36, // [36:36] is the sub-list for extension type_name
+	36, // [36:36] is the sub-list for extension extendee
+	0,  // [0:36] is the sub-list for field type_name
 }
 
 func init() { file_proto_filesystem_proto_init() }
@@ -1279,18 +6810,20 @@ func file_proto_filesystem_proto_init() {
 	if File_proto_filesystem_proto != nil {
 		return
 	}
+	file_proto_filesystem_proto_msgTypes[21].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_filesystem_proto_rawDesc), len(file_proto_filesystem_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   17,
+			NumEnums:      6,
+			NumMessages:   77,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_filesystem_proto_goTypes,
 		DependencyIndexes: file_proto_filesystem_proto_depIdxs,
+		EnumInfos:         file_proto_filesystem_proto_enumTypes,
 		MessageInfos:      file_proto_filesystem_proto_msgTypes,
 	}.Build()
 	File_proto_filesystem_proto = out.File
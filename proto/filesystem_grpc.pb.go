@@ -19,18 +19,56 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	FilesystemService_ListDirectory_FullMethodName    = "/filesystem.FilesystemService/ListDirectory"
-	FilesystemService_GetHierarchy_FullMethodName     = "/filesystem.FilesystemService/GetHierarchy"
-	FilesystemService_GetFileInfo_FullMethodName      = "/filesystem.FilesystemService/GetFileInfo"
-	FilesystemService_CreateDirectory_FullMethodName  = "/filesystem.FilesystemService/CreateDirectory"
-	FilesystemService_Delete_FullMethodName           = "/filesystem.FilesystemService/Delete"
-	FilesystemService_Copy_FullMethodName             = "/filesystem.FilesystemService/Copy"
-	FilesystemService_Move_FullMethodName             = "/filesystem.FilesystemService/Move"
-	FilesystemService_UploadFile_FullMethodName       = "/filesystem.FilesystemService/UploadFile"
-	FilesystemService_DownloadFile_FullMethodName     = "/filesystem.FilesystemService/DownloadFile"
-	FilesystemService_Exists_FullMethodName           = "/filesystem.FilesystemService/Exists"
-	FilesystemService_GetDirectorySize_FullMethodName = "/filesystem.FilesystemService/GetDirectorySize"
-	FilesystemService_Search_FullMethodName           = "/filesystem.FilesystemService/Search"
+	FilesystemService_ListDirectory_FullMethodName            = "/filesystem.FilesystemService/ListDirectory"
+	FilesystemService_ListDirectoryStream_FullMethodName      = "/filesystem.FilesystemService/ListDirectoryStream"
+	FilesystemService_GetHierarchy_FullMethodName             = "/filesystem.FilesystemService/GetHierarchy"
+	FilesystemService_GetFileInfo_FullMethodName              = "/filesystem.FilesystemService/GetFileInfo"
+	FilesystemService_CreateDirectory_FullMethodName          = "/filesystem.FilesystemService/CreateDirectory"
+	FilesystemService_Delete_FullMethodName                   = "/filesystem.FilesystemService/Delete"
+	FilesystemService_Copy_FullMethodName                     = "/filesystem.FilesystemService/Copy"
+	FilesystemService_Move_FullMethodName                     = "/filesystem.FilesystemService/Move"
+	FilesystemService_UploadFile_FullMethodName               = "/filesystem.FilesystemService/UploadFile"
+	FilesystemService_DownloadFile_FullMethodName             = "/filesystem.FilesystemService/DownloadFile"
+	FilesystemService_Exists_FullMethodName                   = "/filesystem.FilesystemService/Exists"
+	FilesystemService_GetDirectorySize_FullMethodName         = "/filesystem.FilesystemService/GetDirectorySize"
+	FilesystemService_Search_FullMethodName                   = "/filesystem.FilesystemService/Search"
+	FilesystemService_SearchStream_FullMethodName             = "/filesystem.FilesystemService/SearchStream"
+	FilesystemService_GrepFiles_FullMethodName                = "/filesystem.FilesystemService/GrepFiles"
+	FilesystemService_CreateSymlink_FullMethodName            = "/filesystem.FilesystemService/CreateSymlink"
+	FilesystemService_SetPermissions_FullMethodName           = "/filesystem.FilesystemService/SetPermissions"
+	FilesystemService_SetOwner_FullMethodName                 = "/filesystem.FilesystemService/SetOwner"
+	FilesystemService_SetTimes_FullMethodName                 = "/filesystem.FilesystemService/SetTimes"
+	FilesystemService_GetXattrs_FullMethodName                = "/filesystem.FilesystemService/GetXattrs"
+	FilesystemService_SetXattr_FullMethodName                 = "/filesystem.FilesystemService/SetXattr"
+	FilesystemService_RemoveXattr_FullMethodName              = "/filesystem.FilesystemService/RemoveXattr"
+	FilesystemService_GetAcl_FullMethodName                   = "/filesystem.FilesystemService/GetAcl"
+	FilesystemService_SetAcl_FullMethodName                   = "/filesystem.FilesystemService/SetAcl"
+	FilesystemService_WriteFile_FullMethodName                = "/filesystem.FilesystemService/WriteFile"
+	FilesystemService_AppendFile_FullMethodName               = "/filesystem.FilesystemService/AppendFile"
+	FilesystemService_Truncate_FullMethodName                 = "/filesystem.FilesystemService/Truncate"
+	FilesystemService_AcquireLock_FullMethodName              = "/filesystem.FilesystemService/AcquireLock"
+	FilesystemService_RenewLock_FullMethodName                = "/filesystem.FilesystemService/RenewLock"
+	FilesystemService_ReleaseLock_FullMethodName              = "/filesystem.FilesystemService/ReleaseLock"
+	FilesystemService_ListLocks_FullMethodName                = "/filesystem.FilesystemService/ListLocks"
+	FilesystemService_ListVersions_FullMethodName             = "/filesystem.FilesystemService/ListVersions"
+	FilesystemService_RestoreVersion_FullMethodName           = "/filesystem.FilesystemService/RestoreVersion"
+	FilesystemService_CreateSnapshot_FullMethodName           = "/filesystem.FilesystemService/CreateSnapshot"
+	FilesystemService_ListSnapshots_FullMethodName            = "/filesystem.FilesystemService/ListSnapshots"
+	FilesystemService_DiffSnapshot_FullMethodName             = "/filesystem.FilesystemService/DiffSnapshot"
+	FilesystemService_RestoreSnapshot_FullMethodName          = "/filesystem.FilesystemService/RestoreSnapshot"
+	FilesystemService_DeleteSnapshot_FullMethodName           = "/filesystem.FilesystemService/DeleteSnapshot"
+	FilesystemService_CompareDirectories_FullMethodName       = "/filesystem.FilesystemService/CompareDirectories"
+	FilesystemService_DiffFiles_FullMethodName                = "/filesystem.FilesystemService/DiffFiles"
+	FilesystemService_ApplyPatch_FullMethodName               = "/filesystem.FilesystemService/ApplyPatch"
+	FilesystemService_GetSignature_FullMethodName             = "/filesystem.FilesystemService/GetSignature"
+	FilesystemService_ApplyDelta_FullMethodName               = "/filesystem.FilesystemService/ApplyDelta"
+	FilesystemService_GetDelta_FullMethodName                 = "/filesystem.FilesystemService/GetDelta"
+	FilesystemService_GenerateManifest_FullMethodName         = "/filesystem.FilesystemService/GenerateManifest"
+	FilesystemService_VerifyManifest_FullMethodName           = "/filesystem.FilesystemService/VerifyManifest"
+	FilesystemService_GetChecksum_FullMethodName              = "/filesystem.FilesystemService/GetChecksum"
+	FilesystemService_FindDuplicates_FullMethodName           = "/filesystem.FilesystemService/FindDuplicates"
+	FilesystemService_DeduplicateWithHardlinks_FullMethodName = "/filesystem.FilesystemService/DeduplicateWithHardlinks"
+	FilesystemService_GetDiskUsage_FullMethodName             = "/filesystem.FilesystemService/GetDiskUsage"
 )
 
 // FilesystemServiceClient is the client API for FilesystemService service.
@@ -41,6 +79,8 @@ const (
 type FilesystemServiceClient interface {
 	// List directory contents
 	ListDirectory(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// List directory contents (streaming to client)
+	ListDirectoryStream(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileItem], error)
 	// Get directory hierarchy (with nested structure)
 	GetHierarchy(ctx context.Context, in *HierarchyRequest, opts ...grpc.CallOption) (*HierarchyResponse, error)
 	// Get file information
@@ -63,6 +103,90 @@ type FilesystemServiceClient interface {
 	GetDirectorySize(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*SizeResponse, error)
 	// Search for files/directories
 	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// Search for files/directories (streaming to client)
+	SearchStream(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileItem], error)
+	// Search file contents (streaming to client)
+	GrepFiles(ctx context.Context, in *GrepRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GrepMatch], error)
+	// Create a symbolic link
+	CreateSymlink(ctx context.Context, in *SymlinkRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Change permission bits
+	SetPermissions(ctx context.Context, in *SetPermissionsRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Change owner and group
+	SetOwner(ctx context.Context, in *SetOwnerRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Change access and modification times, optionally creating an empty file
+	SetTimes(ctx context.Context, in *SetTimesRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// List extended attributes with their values
+	GetXattrs(ctx context.Context, in *XattrRequest, opts ...grpc.CallOption) (*XattrResponse, error)
+	// Set an extended attribute in the user. namespace
+	SetXattr(ctx context.Context, in *SetXattrRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Remove an extended attribute in the user. namespace
+	RemoveXattr(ctx context.Context, in *XattrRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Get the POSIX ACL of a file or directory
+	GetAcl(ctx context.Context, in *AclRequest, opts ...grpc.CallOption) (*AclResponse, error)
+	// Replace the POSIX ACL of a file or directory
+	SetAcl(ctx context.Context, in *SetAclRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Write bytes at an offset of an existing or new file
+	WriteFile(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error)
+	// Append bytes to the end of a file
+	AppendFile(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*WriteResponse, error)
+	// Shrink or extend a file to a size
+	Truncate(ctx context.Context, in *TruncateRequest, opts ...grpc.CallOption) (*WriteResponse, error)
+	// Take a shared or exclusive lease on a path. While a lease is held,
+	// changes to the path, its parents or anything below it are refused
+	// unless the request carries the lease ID as "lease-id" metadata.
+	AcquireLock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*Lock, error)
+	// Extend a lease before it expires
+	RenewLock(ctx context.Context, in *RenewLockRequest, opts ...grpc.CallOption) (*Lock, error)
+	// Give up a lease
+	ReleaseLock(ctx context.Context, in *LeaseRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// List the leases held on a path, its parents and anything below it
+	ListLocks(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*ListLocksResponse, error)
+	// List the saved versions of a file, newest first
+	ListVersions(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error)
+	// Put a saved version back in place, saving the current content first
+	RestoreVersion(ctx context.Context, in *RestoreVersionRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Capture a directory tree; files unchanged since the previous snapshot
+	// of the same directory are shared with it as hard links
+	CreateSnapshot(ctx context.Context, in *CreateSnapshotRequest, opts ...grpc.CallOption) (*Snapshot, error)
+	// List snapshots, newest first
+	ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error)
+	// Report what changed in a directory since a snapshot of it
+	DiffSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*DiffResponse, error)
+	// Copy a snapshot back into place or to another directory
+	RestoreSnapshot(ctx context.Context, in *RestoreSnapshotRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Remove a snapshot
+	DeleteSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Compare two directory trees (streaming to client)
+	CompareDirectories(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PathChange], error)
+	// Show a unified diff between two text files, or a file and content sent along
+	DiffFiles(ctx context.Context, in *DiffFilesRequest, opts ...grpc.CallOption) (*DiffFilesResponse, error)
+	// Apply a unified diff to a file, replacing it atomically
+	ApplyPatch(ctx context.Context, in *ApplyPatchRequest, opts ...grpc.CallOption) (*WriteResponse, error)
+	// Get the block signatures of a file for a delta upload (streaming to client)
+	GetSignature(ctx context.Context, in *SignatureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SignatureChunk], error)
+	// Rebuild a file from a delta against its current content and replace it
+	// atomically (streaming from client)
+	ApplyDelta(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[DeltaChunk, WriteResponse], error)
+	// Send the delta that turns the client's copy of a file into the server's.
+	// The client streams the signature of its copy first, then closes its side.
+	GetDelta(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SignatureChunk, DeltaChunk], error)
+	// List the regular files below a directory with their SHA-256 (streaming to client)
+	GenerateManifest(ctx context.Context, in *ManifestRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ManifestEntry], error)
+	// Check a directory against a manifest. The client streams the manifest
+	// first, then closes its side; the server sends missing, extra and
+	// modified files.
+	VerifyManifest(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[VerifyManifestRequest, PathChange], error)
+	// Compute checksums of a file in one pass, or return them from the cache
+	GetChecksum(ctx context.Context, in *ChecksumRequest, opts ...grpc.CallOption) (*ChecksumResponse, error)
+	// Group the files below a directory that have the same content, largest
+	// files first (streaming to client)
+	FindDuplicates(ctx context.Context, in *DuplicatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DuplicateGroup], error)
+	// Replace duplicate files with hard links to a single copy. The links share
+	// one inode, so changing one of the files in place changes all of them.
+	DeduplicateWithHardlinks(ctx context.Context, in *DeduplicateRequest, opts ...grpc.CallOption) (*DeduplicateResponse, error)
+	// Break down the disk usage of a directory like du, with the largest
+	// entries and totals by extension and MIME type
+	GetDiskUsage(ctx context.Context, in *DiskUsageRequest, opts ...grpc.CallOption) (*DiskUsageResponse, error)
 }
 
 type filesystemServiceClient struct {
@@ -83,6 +207,25 @@ func (c *filesystemServiceClient) ListDirectory(ctx context.Context, in *ListReq
 	return out, nil
 }
 
+func (c *filesystemServiceClient) ListDirectoryStream(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileItem], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[0], FilesystemService_ListDirectoryStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListRequest, FileItem]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_ListDirectoryStreamClient = grpc.ServerStreamingClient[FileItem]
+
 func (c *filesystemServiceClient) GetHierarchy(ctx context.Context, in *HierarchyRequest, opts ...grpc.CallOption) (*HierarchyResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(HierarchyResponse)
@@ -145,7 +288,7 @@ func (c *filesystemServiceClient) Move(ctx context.Context, in *MoveRequest, opt
 
 func (c *filesystemServiceClient) UploadFile(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileChunk, OperationResponse], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[0], FilesystemService_UploadFile_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[1], FilesystemService_UploadFile_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +301,7 @@ type FilesystemService_UploadFileClient = grpc.ClientStreamingClient[FileChunk,
 
 func (c *filesystemServiceClient) DownloadFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[1], FilesystemService_DownloadFile_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[2], FilesystemService_DownloadFile_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -205,297 +348,1532 @@ func (c *filesystemServiceClient) Search(ctx context.Context, in *SearchRequest,
 	return out, nil
 }
 
-// FilesystemServiceServer is the server API for FilesystemService service.
-// All implementations must embed UnimplementedFilesystemServiceServer
-// for forward compatibility.
-//
-// FilesystemService provides methods for file operations
-type FilesystemServiceServer interface {
-	// List directory contents
-	ListDirectory(context.Context, *ListRequest) (*ListResponse, error)
-	// Get directory hierarchy (with nested structure)
-	GetHierarchy(context.Context, *HierarchyRequest) (*HierarchyResponse, error)
-	// Get file information
-	GetFileInfo(context.Context, *FileRequest) (*FileInfo, error)
-	// Create directory
-	CreateDirectory(context.Context, *CreateDirectoryRequest) (*OperationResponse, error)
-	// Delete file or directory
-	Delete(context.Context, *DeleteRequest) (*OperationResponse, error)
-	// Copy file or directory
-	Copy(context.Context, *CopyRequest) (*OperationResponse, error)
-	// Move/rename file or directory
-	Move(context.Context, *MoveRequest) (*OperationResponse, error)
-	// Upload file (streaming from client)
-	UploadFile(grpc.ClientStreamingServer[FileChunk, OperationResponse]) error
-	// Download file (streaming to client)
-	DownloadFile(*FileRequest, grpc.ServerStreamingServer[FileChunk]) error
-	// Check if path exists
-	Exists(context.Context, *PathRequest) (*ExistsResponse, error)
-	// Get directory size
-	GetDirectorySize(context.Context, *PathRequest) (*SizeResponse, error)
-	// Search for files/directories
-	Search(context.Context, *SearchRequest) (*ListResponse, error)
-	mustEmbedUnimplementedFilesystemServiceServer()
+func (c *filesystemServiceClient) SearchStream(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileItem], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[3], FilesystemService_SearchStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchRequest, FileItem]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-// UnimplementedFilesystemServiceServer must be embedded to have
-// forward compatible implementations.
-//
-// NOTE: this should be embedded by value instead of pointer to avoid a nil
-// pointer dereference when methods are called.
-type UnimplementedFilesystemServiceServer struct{}
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_SearchStreamClient = grpc.ServerStreamingClient[FileItem]
 
-func (UnimplementedFilesystemServiceServer) ListDirectory(context.Context, *ListRequest) (*ListResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ListDirectory not implemented")
+func (c *filesystemServiceClient) GrepFiles(ctx context.Context, in *GrepRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GrepMatch], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[4], FilesystemService_GrepFiles_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GrepRequest, GrepMatch]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
-func (UnimplementedFilesystemServiceServer) GetHierarchy(context.Context, *HierarchyRequest) (*HierarchyResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetHierarchy not implemented")
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_GrepFilesClient = grpc.ServerStreamingClient[GrepMatch]
+
+func (c *filesystemServiceClient) CreateSymlink(ctx context.Context, in *SymlinkRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_CreateSymlink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedFilesystemServiceServer) GetFileInfo(context.Context, *FileRequest) (*FileInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetFileInfo not implemented")
+
+func (c *filesystemServiceClient) SetPermissions(ctx context.Context, in *SetPermissionsRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_SetPermissions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedFilesystemServiceServer) CreateDirectory(context.Context, *CreateDirectoryRequest) (*OperationResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CreateDirectory not implemented")
+
+func (c *filesystemServiceClient) SetOwner(ctx context.Context, in *SetOwnerRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_SetOwner_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedFilesystemServiceServer) Delete(context.Context, *DeleteRequest) (*OperationResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+
+func (c *filesystemServiceClient) SetTimes(ctx context.Context, in *SetTimesRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_SetTimes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedFilesystemServiceServer) Copy(context.Context, *CopyRequest) (*OperationResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Copy not implemented")
+
+func (c *filesystemServiceClient) GetXattrs(ctx context.Context, in *XattrRequest, opts ...grpc.CallOption) (*XattrResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(XattrResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_GetXattrs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedFilesystemServiceServer) Move(context.Context, *MoveRequest) (*OperationResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Move not implemented")
+
+func (c *filesystemServiceClient) SetXattr(ctx context.Context, in *SetXattrRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_SetXattr_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedFilesystemServiceServer) UploadFile(grpc.ClientStreamingServer[FileChunk, OperationResponse]) error {
-	return status.Errorf(codes.Unimplemented, "method UploadFile not implemented")
+
+func (c *filesystemServiceClient) RemoveXattr(ctx context.Context, in *XattrRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_RemoveXattr_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedFilesystemServiceServer) DownloadFile(*FileRequest, grpc.ServerStreamingServer[FileChunk]) error {
-	return status.Errorf(codes.Unimplemented, "method DownloadFile not implemented")
+
+func (c *filesystemServiceClient) GetAcl(ctx context.Context, in *AclRequest, opts ...grpc.CallOption) (*AclResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AclResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_GetAcl_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) SetAcl(ctx context.Context, in *SetAclRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_SetAcl_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) WriteFile(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_WriteFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) AppendFile(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*WriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_AppendFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) Truncate(ctx context.Context, in *TruncateRequest, opts ...grpc.CallOption) (*WriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_Truncate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) AcquireLock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*Lock, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Lock)
+	err := c.cc.Invoke(ctx, FilesystemService_AcquireLock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) RenewLock(ctx context.Context, in *RenewLockRequest, opts ...grpc.CallOption) (*Lock, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Lock)
+	err := c.cc.Invoke(ctx, FilesystemService_RenewLock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) ReleaseLock(ctx context.Context, in *LeaseRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_ReleaseLock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) ListLocks(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*ListLocksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLocksResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_ListLocks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) ListVersions(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVersionsResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_ListVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) RestoreVersion(ctx context.Context, in *RestoreVersionRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_RestoreVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) CreateSnapshot(ctx context.Context, in *CreateSnapshotRequest, opts ...grpc.CallOption) (*Snapshot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Snapshot)
+	err := c.cc.Invoke(ctx, FilesystemService_CreateSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSnapshotsResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_ListSnapshots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) DiffSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*DiffResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DiffResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_DiffSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) RestoreSnapshot(ctx context.Context, in *RestoreSnapshotRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_RestoreSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) DeleteSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_DeleteSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) CompareDirectories(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PathChange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[5], FilesystemService_CompareDirectories_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CompareRequest, PathChange]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_CompareDirectoriesClient = grpc.ServerStreamingClient[PathChange]
+
+func (c *filesystemServiceClient) DiffFiles(ctx context.Context, in *DiffFilesRequest, opts ...grpc.CallOption) (*DiffFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DiffFilesResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_DiffFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) ApplyPatch(ctx context.Context, in *ApplyPatchRequest, opts ...grpc.CallOption) (*WriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_ApplyPatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) GetSignature(ctx context.Context, in *SignatureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SignatureChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[6], FilesystemService_GetSignature_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SignatureRequest, SignatureChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_GetSignatureClient = grpc.ServerStreamingClient[SignatureChunk]
+
+func (c *filesystemServiceClient) ApplyDelta(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[DeltaChunk, WriteResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[7], FilesystemService_ApplyDelta_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DeltaChunk, WriteResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_ApplyDeltaClient = grpc.ClientStreamingClient[DeltaChunk, WriteResponse]
+
+func (c *filesystemServiceClient) GetDelta(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SignatureChunk, DeltaChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[8], FilesystemService_GetDelta_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SignatureChunk, DeltaChunk]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_GetDeltaClient = grpc.BidiStreamingClient[SignatureChunk, DeltaChunk]
+
+func (c *filesystemServiceClient) GenerateManifest(ctx context.Context, in *ManifestRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ManifestEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[9], FilesystemService_GenerateManifest_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ManifestRequest, ManifestEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_GenerateManifestClient = grpc.ServerStreamingClient[ManifestEntry]
+
+func (c *filesystemServiceClient) VerifyManifest(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[VerifyManifestRequest, PathChange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[10], FilesystemService_VerifyManifest_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[VerifyManifestRequest, PathChange]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_VerifyManifestClient = grpc.BidiStreamingClient[VerifyManifestRequest, PathChange]
+
+func (c *filesystemServiceClient) GetChecksum(ctx context.Context, in *ChecksumRequest, opts ...grpc.CallOption) (*ChecksumResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChecksumResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_GetChecksum_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) FindDuplicates(ctx context.Context, in *DuplicatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DuplicateGroup], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilesystemService_ServiceDesc.Streams[11], FilesystemService_FindDuplicates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DuplicatesRequest, DuplicateGroup]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_FindDuplicatesClient = grpc.ServerStreamingClient[DuplicateGroup]
+
+func (c *filesystemServiceClient) DeduplicateWithHardlinks(ctx context.Context, in *DeduplicateRequest, opts ...grpc.CallOption) (*DeduplicateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeduplicateResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_DeduplicateWithHardlinks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemServiceClient) GetDiskUsage(ctx context.Context, in *DiskUsageRequest, opts ...grpc.CallOption) (*DiskUsageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DiskUsageResponse)
+	err := c.cc.Invoke(ctx, FilesystemService_GetDiskUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FilesystemServiceServer is the server API for FilesystemService service.
+// All implementations must embed UnimplementedFilesystemServiceServer
+// for forward compatibility.
+//
+// FilesystemService provides methods for file operations
+type FilesystemServiceServer interface {
+	// List directory contents
+	ListDirectory(context.Context, *ListRequest) (*ListResponse, error)
+	// List directory contents (streaming to client)
+	ListDirectoryStream(*ListRequest, grpc.ServerStreamingServer[FileItem]) error
+	// Get directory hierarchy (with nested structure)
+	GetHierarchy(context.Context, *HierarchyRequest) (*HierarchyResponse, error)
+	// Get file information
+	GetFileInfo(context.Context, *FileRequest) (*FileInfo, error)
+	// Create directory
+	CreateDirectory(context.Context, *CreateDirectoryRequest) (*OperationResponse, error)
+	// Delete file or directory
+	Delete(context.Context, *DeleteRequest) (*OperationResponse, error)
+	// Copy file or directory
+	Copy(context.Context, *CopyRequest) (*OperationResponse, error)
+	// Move/rename file or directory
+	Move(context.Context, *MoveRequest) (*OperationResponse, error)
+	// Upload file (streaming from client)
+	UploadFile(grpc.ClientStreamingServer[FileChunk, OperationResponse]) error
+	// Download file (streaming to client)
+	DownloadFile(*FileRequest, grpc.ServerStreamingServer[FileChunk]) error
+	// Check if path exists
+	Exists(context.Context, *PathRequest) (*ExistsResponse, error)
+	// Get directory size
+	GetDirectorySize(context.Context, *PathRequest) (*SizeResponse, error)
+	// Search for files/directories
+	Search(context.Context, *SearchRequest) (*ListResponse, error)
+	// Search for files/directories (streaming to client)
+	SearchStream(*SearchRequest, grpc.ServerStreamingServer[FileItem]) error
+	// Search file contents (streaming to client)
+	GrepFiles(*GrepRequest, grpc.ServerStreamingServer[GrepMatch]) error
+	// Create a symbolic link
+	CreateSymlink(context.Context, *SymlinkRequest) (*OperationResponse, error)
+	// Change permission bits
+	SetPermissions(context.Context, *SetPermissionsRequest) (*OperationResponse, error)
+	// Change owner and group
+	SetOwner(context.Context, *SetOwnerRequest) (*OperationResponse, error)
+	// Change access and modification times, optionally creating an empty file
+	SetTimes(context.Context, *SetTimesRequest) (*OperationResponse, error)
+	// List extended attributes with their values
+	GetXattrs(context.Context, *XattrRequest) (*XattrResponse, error)
+	// Set an extended attribute in the user. namespace
+	SetXattr(context.Context, *SetXattrRequest) (*OperationResponse, error)
+	// Remove an extended attribute in the user. namespace
+	RemoveXattr(context.Context, *XattrRequest) (*OperationResponse, error)
+	// Get the POSIX ACL of a file or directory
+	GetAcl(context.Context, *AclRequest) (*AclResponse, error)
+	// Replace the POSIX ACL of a file or directory
+	SetAcl(context.Context, *SetAclRequest) (*OperationResponse, error)
+	// Write bytes at an offset of an existing or new file
+	WriteFile(context.Context, *WriteRequest) (*WriteResponse, error)
+	// Append bytes to the end of a file
+	AppendFile(context.Context, *AppendRequest) (*WriteResponse, error)
+	// Shrink or extend a file to a size
+	Truncate(context.Context, *TruncateRequest) (*WriteResponse, error)
+	// Take a shared or exclusive lease on a path. While a lease is held,
+	// changes to the path, its parents or anything below it are refused
+	// unless the request carries the lease ID as "lease-id" metadata.
+	AcquireLock(context.Context, *LockRequest) (*Lock, error)
+	// Extend a lease before it expires
+	RenewLock(context.Context, *RenewLockRequest) (*Lock, error)
+	// Give up a lease
+	ReleaseLock(context.Context, *LeaseRequest) (*OperationResponse, error)
+	// List the leases held on a path, its parents and anything below it
+	ListLocks(context.Context, *PathRequest) (*ListLocksResponse, error)
+	// List the saved versions of a file, newest first
+	ListVersions(context.Context, *PathRequest) (*ListVersionsResponse, error)
+	// Put a saved version back in place, saving the current content first
+	RestoreVersion(context.Context, *RestoreVersionRequest) (*OperationResponse, error)
+	// Capture a directory tree; files unchanged since the previous snapshot
+	// of the same directory are shared with it as hard links
+	CreateSnapshot(context.Context, *CreateSnapshotRequest) (*Snapshot, error)
+	// List snapshots, newest first
+	ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error)
+	// Report what changed in a directory since a snapshot of it
+	DiffSnapshot(context.Context, *SnapshotRequest) (*DiffResponse, error)
+	// Copy a snapshot back into place or to another directory
+	RestoreSnapshot(context.Context, *RestoreSnapshotRequest) (*OperationResponse, error)
+	// Remove a snapshot
+	DeleteSnapshot(context.Context, *SnapshotRequest) (*OperationResponse, error)
+	// Compare two directory trees (streaming to client)
+	CompareDirectories(*CompareRequest, grpc.ServerStreamingServer[PathChange]) error
+	// Show a unified diff between two text files, or a file and content sent along
+	DiffFiles(context.Context, *DiffFilesRequest) (*DiffFilesResponse, error)
+	// Apply a unified diff to a file, replacing it atomically
+	ApplyPatch(context.Context, *ApplyPatchRequest) (*WriteResponse, error)
+	// Get the block signatures of a file for a delta upload (streaming to client)
+	GetSignature(*SignatureRequest, grpc.ServerStreamingServer[SignatureChunk]) error
+	// Rebuild a file from a delta against its current content and replace it
+	// atomically (streaming from client)
+	ApplyDelta(grpc.ClientStreamingServer[DeltaChunk, WriteResponse]) error
+	// Send the delta that turns the client's copy of a file into the server's.
+	// The client streams the signature of its copy first, then closes its side.
+	GetDelta(grpc.BidiStreamingServer[SignatureChunk, DeltaChunk]) error
+	// List the regular files below a directory with their SHA-256 (streaming to client)
+	GenerateManifest(*ManifestRequest, grpc.ServerStreamingServer[ManifestEntry]) error
+	// Check a directory against a manifest. The client streams the manifest
+	// first, then closes its side; the server sends missing, extra and
+	// modified files.
+	VerifyManifest(grpc.BidiStreamingServer[VerifyManifestRequest, PathChange]) error
+	// Compute checksums of a file in one pass, or return them from the cache
+	GetChecksum(context.Context, *ChecksumRequest) (*ChecksumResponse, error)
+	// Group the files below a directory that have the same content, largest
+	// files first (streaming to client)
+	FindDuplicates(*DuplicatesRequest, grpc.ServerStreamingServer[DuplicateGroup]) error
+	// Replace duplicate files with hard links to a single copy. The links share
+	// one inode, so changing one of the files in place changes all of them.
+	DeduplicateWithHardlinks(context.Context, *DeduplicateRequest) (*DeduplicateResponse, error)
+	// Break down the disk usage of a directory like du, with the largest
+	// entries and totals by extension and MIME type
+	GetDiskUsage(context.Context, *DiskUsageRequest) (*DiskUsageResponse, error)
+	mustEmbedUnimplementedFilesystemServiceServer()
+}
+
+// UnimplementedFilesystemServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFilesystemServiceServer struct{}
+
+func (UnimplementedFilesystemServiceServer) ListDirectory(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDirectory not implemented")
+}
+func (UnimplementedFilesystemServiceServer) ListDirectoryStream(*ListRequest, grpc.ServerStreamingServer[FileItem]) error {
+	return status.Errorf(codes.Unimplemented, "method ListDirectoryStream not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetHierarchy(context.Context, *HierarchyRequest) (*HierarchyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHierarchy not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetFileInfo(context.Context, *FileRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFileInfo not implemented")
+}
+func (UnimplementedFilesystemServiceServer) CreateDirectory(context.Context, *CreateDirectoryRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDirectory not implemented")
+}
+func (UnimplementedFilesystemServiceServer) Delete(context.Context, *DeleteRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedFilesystemServiceServer) Copy(context.Context, *CopyRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Copy not implemented")
+}
+func (UnimplementedFilesystemServiceServer) Move(context.Context, *MoveRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Move not implemented")
+}
+func (UnimplementedFilesystemServiceServer) UploadFile(grpc.ClientStreamingServer[FileChunk, OperationResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadFile not implemented")
+}
+func (UnimplementedFilesystemServiceServer) DownloadFile(*FileRequest, grpc.ServerStreamingServer[FileChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadFile not implemented")
+}
+func (UnimplementedFilesystemServiceServer) Exists(context.Context, *PathRequest) (*ExistsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exists not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetDirectorySize(context.Context, *PathRequest) (*SizeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDirectorySize not implemented")
+}
+func (UnimplementedFilesystemServiceServer) Search(context.Context, *SearchRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedFilesystemServiceServer) SearchStream(*SearchRequest, grpc.ServerStreamingServer[FileItem]) error {
+	return status.Errorf(codes.Unimplemented, "method SearchStream not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GrepFiles(*GrepRequest, grpc.ServerStreamingServer[GrepMatch]) error {
+	return status.Errorf(codes.Unimplemented, "method GrepFiles not implemented")
+}
+func (UnimplementedFilesystemServiceServer) CreateSymlink(context.Context, *SymlinkRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSymlink not implemented")
+}
+func (UnimplementedFilesystemServiceServer) SetPermissions(context.Context, *SetPermissionsRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPermissions not implemented")
+}
+func (UnimplementedFilesystemServiceServer) SetOwner(context.Context, *SetOwnerRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetOwner not implemented")
+}
+func (UnimplementedFilesystemServiceServer) SetTimes(context.Context, *SetTimesRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTimes not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetXattrs(context.Context, *XattrRequest) (*XattrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetXattrs not implemented")
+}
+func (UnimplementedFilesystemServiceServer) SetXattr(context.Context, *SetXattrRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetXattr not implemented")
+}
+func (UnimplementedFilesystemServiceServer) RemoveXattr(context.Context, *XattrRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveXattr not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetAcl(context.Context, *AclRequest) (*AclResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAcl not implemented")
+}
+func (UnimplementedFilesystemServiceServer) SetAcl(context.Context, *SetAclRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAcl not implemented")
+}
+func (UnimplementedFilesystemServiceServer) WriteFile(context.Context, *WriteRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteFile not implemented")
+}
+func (UnimplementedFilesystemServiceServer) AppendFile(context.Context, *AppendRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendFile not implemented")
+}
+func (UnimplementedFilesystemServiceServer) Truncate(context.Context, *TruncateRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Truncate not implemented")
+}
+func (UnimplementedFilesystemServiceServer) AcquireLock(context.Context, *LockRequest) (*Lock, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcquireLock not implemented")
+}
+func (UnimplementedFilesystemServiceServer) RenewLock(context.Context, *RenewLockRequest) (*Lock, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewLock not implemented")
+}
+func (UnimplementedFilesystemServiceServer) ReleaseLock(context.Context, *LeaseRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseLock not implemented")
+}
+func (UnimplementedFilesystemServiceServer) ListLocks(context.Context, *PathRequest) (*ListLocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLocks not implemented")
+}
+func (UnimplementedFilesystemServiceServer) ListVersions(context.Context, *PathRequest) (*ListVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVersions not implemented")
+}
+func (UnimplementedFilesystemServiceServer) RestoreVersion(context.Context, *RestoreVersionRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreVersion not implemented")
+}
+func (UnimplementedFilesystemServiceServer) CreateSnapshot(context.Context, *CreateSnapshotRequest) (*Snapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSnapshot not implemented")
+}
+func (UnimplementedFilesystemServiceServer) ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSnapshots not implemented")
+}
+func (UnimplementedFilesystemServiceServer) DiffSnapshot(context.Context, *SnapshotRequest) (*DiffResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiffSnapshot not implemented")
+}
+func (UnimplementedFilesystemServiceServer) RestoreSnapshot(context.Context, *RestoreSnapshotRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreSnapshot not implemented")
+}
+func (UnimplementedFilesystemServiceServer) DeleteSnapshot(context.Context, *SnapshotRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSnapshot not implemented")
+}
+func (UnimplementedFilesystemServiceServer) CompareDirectories(*CompareRequest, grpc.ServerStreamingServer[PathChange]) error {
+	return status.Errorf(codes.Unimplemented, "method CompareDirectories not implemented")
+}
+func (UnimplementedFilesystemServiceServer) DiffFiles(context.Context, *DiffFilesRequest) (*DiffFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiffFiles not implemented")
+}
+func (UnimplementedFilesystemServiceServer) ApplyPatch(context.Context, *ApplyPatchRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyPatch not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetSignature(*SignatureRequest, grpc.ServerStreamingServer[SignatureChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetSignature not implemented")
+}
+func (UnimplementedFilesystemServiceServer) ApplyDelta(grpc.ClientStreamingServer[DeltaChunk, WriteResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ApplyDelta not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetDelta(grpc.BidiStreamingServer[SignatureChunk, DeltaChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetDelta not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GenerateManifest(*ManifestRequest, grpc.ServerStreamingServer[ManifestEntry]) error {
+	return status.Errorf(codes.Unimplemented, "method GenerateManifest not implemented")
+}
+func (UnimplementedFilesystemServiceServer) VerifyManifest(grpc.BidiStreamingServer[VerifyManifestRequest, PathChange]) error {
+	return status.Errorf(codes.Unimplemented, "method VerifyManifest not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetChecksum(context.Context, *ChecksumRequest) (*ChecksumResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChecksum not implemented")
+}
+func (UnimplementedFilesystemServiceServer) FindDuplicates(*DuplicatesRequest, grpc.ServerStreamingServer[DuplicateGroup]) error {
+	return status.Errorf(codes.Unimplemented, "method FindDuplicates not implemented")
+}
+func (UnimplementedFilesystemServiceServer) DeduplicateWithHardlinks(context.Context, *DeduplicateRequest) (*DeduplicateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeduplicateWithHardlinks not implemented")
+}
+func (UnimplementedFilesystemServiceServer) GetDiskUsage(context.Context, *DiskUsageRequest) (*DiskUsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDiskUsage not implemented")
+}
+func (UnimplementedFilesystemServiceServer) mustEmbedUnimplementedFilesystemServiceServer() {}
+func (UnimplementedFilesystemServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeFilesystemServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FilesystemServiceServer will
+// result in compilation errors.
+type UnsafeFilesystemServiceServer interface {
+	mustEmbedUnimplementedFilesystemServiceServer()
+}
+
+func RegisterFilesystemServiceServer(s grpc.ServiceRegistrar, srv FilesystemServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFilesystemServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FilesystemService_ServiceDesc, srv)
+}
+
+func _FilesystemService_ListDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).ListDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_ListDirectory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).ListDirectory(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_ListDirectoryStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FilesystemServiceServer).ListDirectoryStream(m, &grpc.GenericServerStream[ListRequest, FileItem]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_ListDirectoryStreamServer = grpc.ServerStreamingServer[FileItem]
+
+func _FilesystemService_GetHierarchy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HierarchyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).GetHierarchy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_GetHierarchy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).GetHierarchy(ctx, req.(*HierarchyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_GetFileInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).GetFileInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_GetFileInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).GetFileInfo(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_CreateDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateDirectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).CreateDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_CreateDirectory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).CreateDirectory(ctx, req.(*CreateDirectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_Copy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).Copy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_Copy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).Copy(ctx, req.(*CopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_Move_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).Move(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_Move_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).Move(ctx, req.(*MoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_UploadFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FilesystemServiceServer).UploadFile(&grpc.GenericServerStream[FileChunk, OperationResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_UploadFileServer = grpc.ClientStreamingServer[FileChunk, OperationResponse]
+
+func _FilesystemService_DownloadFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FilesystemServiceServer).DownloadFile(m, &grpc.GenericServerStream[FileRequest, FileChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_DownloadFileServer = grpc.ServerStreamingServer[FileChunk]
+
+func _FilesystemService_Exists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).Exists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_Exists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).Exists(ctx, req.(*PathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_GetDirectorySize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).GetDirectorySize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_GetDirectorySize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).GetDirectorySize(ctx, req.(*PathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_SearchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FilesystemServiceServer).SearchStream(m, &grpc.GenericServerStream[SearchRequest, FileItem]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_SearchStreamServer = grpc.ServerStreamingServer[FileItem]
+
+func _FilesystemService_GrepFiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GrepRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FilesystemServiceServer).GrepFiles(m, &grpc.GenericServerStream[GrepRequest, GrepMatch]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_GrepFilesServer = grpc.ServerStreamingServer[GrepMatch]
+
+func _FilesystemService_CreateSymlink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SymlinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).CreateSymlink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_CreateSymlink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).CreateSymlink(ctx, req.(*SymlinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_SetPermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).SetPermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_SetPermissions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).SetPermissions(ctx, req.(*SetPermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_SetOwner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetOwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).SetOwner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_SetOwner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).SetOwner(ctx, req.(*SetOwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_SetTimes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetTimesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).SetTimes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_SetTimes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).SetTimes(ctx, req.(*SetTimesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_GetXattrs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(XattrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).GetXattrs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_GetXattrs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).GetXattrs(ctx, req.(*XattrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_SetXattr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetXattrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).SetXattr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_SetXattr_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).SetXattr(ctx, req.(*SetXattrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_RemoveXattr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(XattrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).RemoveXattr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_RemoveXattr_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).RemoveXattr(ctx, req.(*XattrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_GetAcl_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AclRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).GetAcl(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_GetAcl_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).GetAcl(ctx, req.(*AclRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_SetAcl_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAclRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).SetAcl(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_SetAcl_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).SetAcl(ctx, req.(*SetAclRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_WriteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).WriteFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_WriteFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).WriteFile(ctx, req.(*WriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_AppendFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).AppendFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_AppendFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).AppendFile(ctx, req.(*AppendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_Truncate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TruncateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).Truncate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_Truncate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).Truncate(ctx, req.(*TruncateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilesystemService_AcquireLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).AcquireLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_AcquireLock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).AcquireLock(ctx, req.(*LockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedFilesystemServiceServer) Exists(context.Context, *PathRequest) (*ExistsResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Exists not implemented")
+
+func _FilesystemService_RenewLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewLockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).RenewLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_RenewLock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).RenewLock(ctx, req.(*RenewLockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedFilesystemServiceServer) GetDirectorySize(context.Context, *PathRequest) (*SizeResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetDirectorySize not implemented")
+
+func _FilesystemService_ReleaseLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).ReleaseLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_ReleaseLock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).ReleaseLock(ctx, req.(*LeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedFilesystemServiceServer) Search(context.Context, *SearchRequest) (*ListResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+
+func _FilesystemService_ListLocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).ListLocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_ListLocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).ListLocks(ctx, req.(*PathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedFilesystemServiceServer) mustEmbedUnimplementedFilesystemServiceServer() {}
-func (UnimplementedFilesystemServiceServer) testEmbeddedByValue()                           {}
 
-// UnsafeFilesystemServiceServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to FilesystemServiceServer will
-// result in compilation errors.
-type UnsafeFilesystemServiceServer interface {
-	mustEmbedUnimplementedFilesystemServiceServer()
+func _FilesystemService_ListVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).ListVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_ListVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).ListVersions(ctx, req.(*PathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterFilesystemServiceServer(s grpc.ServiceRegistrar, srv FilesystemServiceServer) {
-	// If the following call pancis, it indicates UnimplementedFilesystemServiceServer was
-	// embedded by pointer and is nil.  This will cause panics if an
-	// unimplemented method is ever invoked, so we test this at initialization
-	// time to prevent it from happening at runtime later due to I/O.
-	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
-		t.testEmbeddedByValue()
+func _FilesystemService_RestoreVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	s.RegisterService(&FilesystemService_ServiceDesc, srv)
+	if interceptor == nil {
+		return srv.(FilesystemServiceServer).RestoreVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilesystemService_RestoreVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServiceServer).RestoreVersion(ctx, req.(*RestoreVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_ListDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ListRequest)
+func _FilesystemService_CreateSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSnapshotRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).ListDirectory(ctx, in)
+		return srv.(FilesystemServiceServer).CreateSnapshot(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_ListDirectory_FullMethodName,
+		FullMethod: FilesystemService_CreateSnapshot_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).ListDirectory(ctx, req.(*ListRequest))
+		return srv.(FilesystemServiceServer).CreateSnapshot(ctx, req.(*CreateSnapshotRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_GetHierarchy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(HierarchyRequest)
+func _FilesystemService_ListSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSnapshotsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).GetHierarchy(ctx, in)
+		return srv.(FilesystemServiceServer).ListSnapshots(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_GetHierarchy_FullMethodName,
+		FullMethod: FilesystemService_ListSnapshots_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).GetHierarchy(ctx, req.(*HierarchyRequest))
+		return srv.(FilesystemServiceServer).ListSnapshots(ctx, req.(*ListSnapshotsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_GetFileInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(FileRequest)
+func _FilesystemService_DiffSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).GetFileInfo(ctx, in)
+		return srv.(FilesystemServiceServer).DiffSnapshot(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_GetFileInfo_FullMethodName,
+		FullMethod: FilesystemService_DiffSnapshot_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).GetFileInfo(ctx, req.(*FileRequest))
+		return srv.(FilesystemServiceServer).DiffSnapshot(ctx, req.(*SnapshotRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_CreateDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CreateDirectoryRequest)
+func _FilesystemService_RestoreSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreSnapshotRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).CreateDirectory(ctx, in)
+		return srv.(FilesystemServiceServer).RestoreSnapshot(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_CreateDirectory_FullMethodName,
+		FullMethod: FilesystemService_RestoreSnapshot_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).CreateDirectory(ctx, req.(*CreateDirectoryRequest))
+		return srv.(FilesystemServiceServer).RestoreSnapshot(ctx, req.(*RestoreSnapshotRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteRequest)
+func _FilesystemService_DeleteSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).Delete(ctx, in)
+		return srv.(FilesystemServiceServer).DeleteSnapshot(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_Delete_FullMethodName,
+		FullMethod: FilesystemService_DeleteSnapshot_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).Delete(ctx, req.(*DeleteRequest))
+		return srv.(FilesystemServiceServer).DeleteSnapshot(ctx, req.(*SnapshotRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_Copy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CopyRequest)
+func _FilesystemService_CompareDirectories_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CompareRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FilesystemServiceServer).CompareDirectories(m, &grpc.GenericServerStream[CompareRequest, PathChange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_CompareDirectoriesServer = grpc.ServerStreamingServer[PathChange]
+
+func _FilesystemService_DiffFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffFilesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).Copy(ctx, in)
+		return srv.(FilesystemServiceServer).DiffFiles(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_Copy_FullMethodName,
+		FullMethod: FilesystemService_DiffFiles_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).Copy(ctx, req.(*CopyRequest))
+		return srv.(FilesystemServiceServer).DiffFiles(ctx, req.(*DiffFilesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_Move_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MoveRequest)
+func _FilesystemService_ApplyPatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApplyPatchRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).Move(ctx, in)
+		return srv.(FilesystemServiceServer).ApplyPatch(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_Move_FullMethodName,
+		FullMethod: FilesystemService_ApplyPatch_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).Move(ctx, req.(*MoveRequest))
+		return srv.(FilesystemServiceServer).ApplyPatch(ctx, req.(*ApplyPatchRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_UploadFile_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(FilesystemServiceServer).UploadFile(&grpc.GenericServerStream[FileChunk, OperationResponse]{ServerStream: stream})
+func _FilesystemService_GetSignature_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SignatureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FilesystemServiceServer).GetSignature(m, &grpc.GenericServerStream[SignatureRequest, SignatureChunk]{ServerStream: stream})
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type FilesystemService_UploadFileServer = grpc.ClientStreamingServer[FileChunk, OperationResponse]
+type FilesystemService_GetSignatureServer = grpc.ServerStreamingServer[SignatureChunk]
 
-func _FilesystemService_DownloadFile_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(FileRequest)
+func _FilesystemService_ApplyDelta_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FilesystemServiceServer).ApplyDelta(&grpc.GenericServerStream[DeltaChunk, WriteResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_ApplyDeltaServer = grpc.ClientStreamingServer[DeltaChunk, WriteResponse]
+
+func _FilesystemService_GetDelta_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FilesystemServiceServer).GetDelta(&grpc.GenericServerStream[SignatureChunk, DeltaChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_GetDeltaServer = grpc.BidiStreamingServer[SignatureChunk, DeltaChunk]
+
+func _FilesystemService_GenerateManifest_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ManifestRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(FilesystemServiceServer).DownloadFile(m, &grpc.GenericServerStream[FileRequest, FileChunk]{ServerStream: stream})
+	return srv.(FilesystemServiceServer).GenerateManifest(m, &grpc.GenericServerStream[ManifestRequest, ManifestEntry]{ServerStream: stream})
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type FilesystemService_DownloadFileServer = grpc.ServerStreamingServer[FileChunk]
+type FilesystemService_GenerateManifestServer = grpc.ServerStreamingServer[ManifestEntry]
 
-func _FilesystemService_Exists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PathRequest)
+func _FilesystemService_VerifyManifest_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FilesystemServiceServer).VerifyManifest(&grpc.GenericServerStream[VerifyManifestRequest, PathChange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_VerifyManifestServer = grpc.BidiStreamingServer[VerifyManifestRequest, PathChange]
+
+func _FilesystemService_GetChecksum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChecksumRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).Exists(ctx, in)
+		return srv.(FilesystemServiceServer).GetChecksum(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_Exists_FullMethodName,
+		FullMethod: FilesystemService_GetChecksum_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).Exists(ctx, req.(*PathRequest))
+		return srv.(FilesystemServiceServer).GetChecksum(ctx, req.(*ChecksumRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_GetDirectorySize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PathRequest)
+func _FilesystemService_FindDuplicates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DuplicatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FilesystemServiceServer).FindDuplicates(m, &grpc.GenericServerStream[DuplicatesRequest, DuplicateGroup]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilesystemService_FindDuplicatesServer = grpc.ServerStreamingServer[DuplicateGroup]
+
+func _FilesystemService_DeduplicateWithHardlinks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeduplicateRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).GetDirectorySize(ctx, in)
+		return srv.(FilesystemServiceServer).DeduplicateWithHardlinks(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_GetDirectorySize_FullMethodName,
+		FullMethod: FilesystemService_DeduplicateWithHardlinks_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).GetDirectorySize(ctx, req.(*PathRequest))
+		return srv.(FilesystemServiceServer).DeduplicateWithHardlinks(ctx, req.(*DeduplicateRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _FilesystemService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SearchRequest)
+func _FilesystemService_GetDiskUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiskUsageRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(FilesystemServiceServer).Search(ctx, in)
+		return srv.(FilesystemServiceServer).GetDiskUsage(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: FilesystemService_Search_FullMethodName,
+		FullMethod: FilesystemService_GetDiskUsage_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(FilesystemServiceServer).Search(ctx, req.(*SearchRequest))
+		return srv.(FilesystemServiceServer).GetDiskUsage(ctx, req.(*DiskUsageRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -547,8 +1925,125 @@ var FilesystemService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Search",
 			Handler:    _FilesystemService_Search_Handler,
 		},
+		{
+			MethodName: "CreateSymlink",
+			Handler:    _FilesystemService_CreateSymlink_Handler,
+		},
+		{
+			MethodName: "SetPermissions",
+			Handler:    _FilesystemService_SetPermissions_Handler,
+		},
+		{
+			MethodName: "SetOwner",
+			Handler:    _FilesystemService_SetOwner_Handler,
+		},
+		{
+			MethodName: "SetTimes",
+			Handler:    _FilesystemService_SetTimes_Handler,
+		},
+		{
+			MethodName: "GetXattrs",
+			Handler:    _FilesystemService_GetXattrs_Handler,
+		},
+		{
+			MethodName: "SetXattr",
+			Handler:    _FilesystemService_SetXattr_Handler,
+		},
+		{
+			MethodName: "RemoveXattr",
+			Handler:    _FilesystemService_RemoveXattr_Handler,
+		},
+		{
+			MethodName: "GetAcl",
+			Handler:    _FilesystemService_GetAcl_Handler,
+		},
+		{
+			MethodName: "SetAcl",
+			Handler:    _FilesystemService_SetAcl_Handler,
+		},
+		{
+			MethodName: "WriteFile",
+			Handler:    _FilesystemService_WriteFile_Handler,
+		},
+		{
+			MethodName: "AppendFile",
+			Handler:    _FilesystemService_AppendFile_Handler,
+		},
+		{
+			MethodName: "Truncate",
+			Handler:    _FilesystemService_Truncate_Handler,
+		},
+		{
+			MethodName: "AcquireLock",
+			Handler:    _FilesystemService_AcquireLock_Handler,
+		},
+		{
+			MethodName: "RenewLock",
+			Handler:    _FilesystemService_RenewLock_Handler,
+		},
+		{
+			MethodName: "ReleaseLock",
+			Handler:    _FilesystemService_ReleaseLock_Handler,
+		},
+		{
+			MethodName: "ListLocks",
+			Handler:    _FilesystemService_ListLocks_Handler,
+		},
+		{
+			MethodName: "ListVersions",
+			Handler:    _FilesystemService_ListVersions_Handler,
+		},
+		{
+			MethodName: "RestoreVersion",
+			Handler:    _FilesystemService_RestoreVersion_Handler,
+		},
+		{
+			MethodName: "CreateSnapshot",
+			Handler:    _FilesystemService_CreateSnapshot_Handler,
+		},
+		{
+			MethodName: "ListSnapshots",
+			Handler:    _FilesystemService_ListSnapshots_Handler,
+		},
+		{
+			MethodName: "DiffSnapshot",
+			Handler:    _FilesystemService_DiffSnapshot_Handler,
+		},
+		{
+			MethodName: "RestoreSnapshot",
+			Handler:    _FilesystemService_RestoreSnapshot_Handler,
+		},
+		{
+			MethodName: "DeleteSnapshot",
+			Handler:    _FilesystemService_DeleteSnapshot_Handler,
+		},
+		{
+			MethodName: "DiffFiles",
+			Handler:    _FilesystemService_DiffFiles_Handler,
+		},
+		{
+			MethodName: "ApplyPatch",
+			Handler:    _FilesystemService_ApplyPatch_Handler,
+		},
+		{
+			MethodName: "GetChecksum",
+			Handler:    _FilesystemService_GetChecksum_Handler,
+		},
+		{
+			MethodName: "DeduplicateWithHardlinks",
+			Handler:    _FilesystemService_DeduplicateWithHardlinks_Handler,
+		},
+		{
+			MethodName: "GetDiskUsage",
+			Handler:    _FilesystemService_GetDiskUsage_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListDirectoryStream",
+			Handler:       _FilesystemService_ListDirectoryStream_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "UploadFile",
 			Handler:       _FilesystemService_UploadFile_Handler,
@@ -559,6 +2054,53 @@ var FilesystemService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _FilesystemService_DownloadFile_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "SearchStream",
+			Handler:       _FilesystemService_SearchStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GrepFiles",
+			Handler:       _FilesystemService_GrepFiles_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CompareDirectories",
+			Handler:       _FilesystemService_CompareDirectories_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetSignature",
+			Handler:       _FilesystemService_GetSignature_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ApplyDelta",
+			Handler:       _FilesystemService_ApplyDelta_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetDelta",
+			Handler:       _FilesystemService_GetDelta_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GenerateManifest",
+			Handler:       _FilesystemService_GenerateManifest_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "VerifyManifest",
+			Handler:       _FilesystemService_VerifyManifest_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "FindDuplicates",
+			Handler:       _FilesystemService_FindDuplicates_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/filesystem.proto",
 }
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// POSIX ACLs are stored in these attributes as a little endian version
+// header followed by (tag uint16, perm uint16, id uint32) entries
+const (
+	aclAccessXattr  = "system.posix_acl_access"
+	aclDefaultXattr = "system.posix_acl_default"
+	aclVersion      = 2
+	aclUndefinedID  = 0xffffffff
+)
+
+// aclTagBits maps entry tags to their on-disk values
+var aclTagBits = map[pb.AclTag]uint16{
+	pb.AclTag_ACL_TAG_USER_OBJ:  0x01,
+	pb.AclTag_ACL_TAG_USER:      0x02,
+	pb.AclTag_ACL_TAG_GROUP_OBJ: 0x04,
+	pb.AclTag_ACL_TAG_GROUP:     0x08,
+	pb.AclTag_ACL_TAG_MASK:      0x10,
+	pb.AclTag_ACL_TAG_OTHER:     0x20,
+}
+
+// GetAcl implements the GetAcl RPC method
+func (s *FilesystemService) GetAcl(ctx context.Context, req *AclRequest) (*AclResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.root.Stat(validPath)
+	if err != nil {
+		return nil, rootError(err, "Failed to get ACL")
+	}
+
+	attr := aclAccessXattr
+	if req.Default {
+		attr = aclDefaultXattr
+	}
+
+	var entries []*pb.AclEntry
+	data, err := s.root.Getxattr(validPath, attr)
+	switch {
+	case err == nil:
+		if entries, err = decodeACL(data); err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to decode ACL: %v", err)
+		}
+	case errors.Is(err, unix.ENODATA), errors.Is(err, unix.EOPNOTSUPP):
+		// Without an ACL the mode bits are the access ACL; there is no default ACL
+		if !req.Default {
+			entries = aclFromMode(info.Mode())
+		}
+	default:
+		return nil, xattrError(err, "Failed to get ACL")
+	}
+
+	for _, entry := range entries {
+		switch entry.Tag {
+		case pb.AclTag_ACL_TAG_USER:
+			entry.Name = userNames.name(entry.Id)
+		case pb.AclTag_ACL_TAG_GROUP:
+			entry.Name = groupNames.name(entry.Id)
+		}
+	}
+
+	return &AclResponse{Entries: entries, Text: formatACL(entries)}, nil
+}
+
+// SetAcl implements the SetAcl RPC method
+func (s *FilesystemService) SetAcl(ctx context.Context, req *SetAclRequest) (*OperationResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := req.Entries
+	if len(entries) == 0 && req.Text != "" {
+		if entries, err = parseACL(req.Text); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid ACL: %v", err)
+		}
+	}
+
+	info, err := s.root.Stat(validPath)
+	if err != nil {
+		return metadataError("Failed to set ACL", err)
+	}
+
+	attr := aclAccessXattr
+	if req.Default {
+		if !info.IsDir() {
+			return nil, status.Errorf(codes.FailedPrecondition, "Default ACLs only apply to directories")
+		}
+		attr = aclDefaultXattr
+
+		// An empty default ACL removes it
+		if len(entries) == 0 {
+			err := s.root.Removexattr(validPath, attr)
+			if err != nil && !errors.Is(err, unix.ENODATA) {
+				return metadataError("Failed to remove default ACL", err)
+			}
+			return &OperationResponse{
+				Success: true,
+				Message: "Default ACL removed successfully",
+			}, nil
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "ACL entries are required")
+	}
+	data, err := encodeACL(entries)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid ACL: %v", err)
+	}
+
+	if err := s.root.Setxattr(validPath, attr, data, 0); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			return &OperationResponse{Success: false, Error: "ACLs are not supported by the filesystem"}, nil
+		}
+		return metadataError("Failed to set ACL", err)
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: "ACL set successfully",
+	}, nil
+}
+
+// aclFromMode returns the minimal ACL equivalent to the permission bits
+func aclFromMode(mode os.FileMode) []*pb.AclEntry {
+	perm := uint16(mode.Perm())
+	return []*pb.AclEntry{
+		{Tag: pb.AclTag_ACL_TAG_USER_OBJ, Permissions: aclPermString(perm >> 6)},
+		{Tag: pb.AclTag_ACL_TAG_GROUP_OBJ, Permissions: aclPermString(perm >> 3)},
+		{Tag: pb.AclTag_ACL_TAG_OTHER, Permissions: aclPermString(perm)},
+	}
+}
+
+// decodeACL parses the xattr representation of an ACL
+func decodeACL(data []byte) ([]*pb.AclEntry, error) {
+	if len(data) < 4 || (len(data)-4)%8 != 0 {
+		return nil, fmt.Errorf("invalid length %d", len(data))
+	}
+	if version := binary.LittleEndian.Uint32(data); version != aclVersion {
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+
+	var entries []*pb.AclEntry
+	for off := 4; off < len(data); off += 8 {
+		bits := binary.LittleEndian.Uint16(data[off:])
+		tag := pb.AclTag_ACL_TAG_UNDEFINED
+		for t, b := range aclTagBits {
+			if b == bits {
+				tag = t
+			}
+		}
+		if tag == pb.AclTag_ACL_TAG_UNDEFINED {
+			return nil, fmt.Errorf("unknown tag %#x", bits)
+		}
+
+		entry := &pb.AclEntry{
+			Tag:         tag,
+			Permissions: aclPermString(binary.LittleEndian.Uint16(data[off+2:])),
+		}
+		if tag == pb.AclTag_ACL_TAG_USER || tag == pb.AclTag_ACL_TAG_GROUP {
+			entry.Id = binary.LittleEndian.Uint32(data[off+4:])
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// encodeACL validates entries and serializes them for the xattr. Names are
+// resolved to IDs, and a missing mask is computed like setfacl does.
+func encodeACL(entries []*pb.AclEntry) ([]byte, error) {
+	type rawEntry struct {
+		tag  pb.AclTag
+		perm uint16
+		id   uint32
+	}
+
+	seen := make(map[rawEntry]bool)
+	counts := make(map[pb.AclTag]int)
+	var raw []rawEntry
+	var groupClass uint16
+	for _, entry := range entries {
+		if _, ok := aclTagBits[entry.Tag]; !ok {
+			return nil, fmt.Errorf("entry without a valid tag")
+		}
+		perm, err := parseACLPerm(entry.Permissions)
+		if err != nil {
+			return nil, err
+		}
+
+		e := rawEntry{tag: entry.Tag, perm: perm, id: aclUndefinedID}
+		if entry.Tag == pb.AclTag_ACL_TAG_USER || entry.Tag == pb.AclTag_ACL_TAG_GROUP {
+			if e.id, err = aclEntryID(entry); err != nil {
+				return nil, err
+			}
+		}
+
+		key := rawEntry{tag: e.tag, id: e.id}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate entry %s", formatACLEntry(entry))
+		}
+		seen[key] = true
+		counts[e.tag]++
+
+		if e.tag == pb.AclTag_ACL_TAG_USER || e.tag == pb.AclTag_ACL_TAG_GROUP || e.tag == pb.AclTag_ACL_TAG_GROUP_OBJ {
+			groupClass |= perm
+		}
+		raw = append(raw, e)
+	}
+
+	for _, tag := range []pb.AclTag{pb.AclTag_ACL_TAG_USER_OBJ, pb.AclTag_ACL_TAG_GROUP_OBJ, pb.AclTag_ACL_TAG_OTHER} {
+		if counts[tag] != 1 {
+			return nil, fmt.Errorf("exactly one %s entry is required", aclTagName(tag))
+		}
+	}
+	if counts[pb.AclTag_ACL_TAG_MASK] == 0 && (counts[pb.AclTag_ACL_TAG_USER] > 0 || counts[pb.AclTag_ACL_TAG_GROUP] > 0) {
+		raw = append(raw, rawEntry{tag: pb.AclTag_ACL_TAG_MASK, perm: groupClass, id: aclUndefinedID})
+	}
+
+	// The kernel expects entries ordered by tag, then by ID
+	sort.Slice(raw, func(i, j int) bool {
+		if raw[i].tag != raw[j].tag {
+			return aclTagBits[raw[i].tag] < aclTagBits[raw[j].tag]
+		}
+		return raw[i].id < raw[j].id
+	})
+
+	data := make([]byte, 4+8*len(raw))
+	binary.LittleEndian.PutUint32(data, aclVersion)
+	for i, e := range raw {
+		off := 4 + 8*i
+		binary.LittleEndian.PutUint16(data[off:], aclTagBits[e.tag])
+		binary.LittleEndian.PutUint16(data[off+2:], e.perm)
+		binary.LittleEndian.PutUint32(data[off+4:], e.id)
+	}
+	return data, nil
+}
+
+// aclEntryID returns the UID or GID of a named entry, resolving its name if needed
+func aclEntryID(entry *pb.AclEntry) (uint32, error) {
+	if entry.Name == "" {
+		return entry.Id, nil
+	}
+
+	names := userNames
+	if entry.Tag == pb.AclTag_ACL_TAG_GROUP {
+		names = groupNames
+	}
+
+	id, err := names.id(entry.Name)
+	if err != nil || id >= aclUndefinedID {
+		return 0, fmt.Errorf("unknown %s %q", aclTagName(entry.Tag), entry.Name)
+	}
+	return id, nil
+}
+
+// parseACL parses the text form, e.g. "u::rw-,u:alice:r--,g::r--,o::---".
+// Entries may be separated by commas or newlines; "#" starts a comment.
+func parseACL(text string) ([]*pb.AclEntry, error) {
+	var entries []*pb.AclEntry
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("entry %q is not of the form tag:qualifier:permissions", line)
+		}
+
+		entry := &pb.AclEntry{Name: fields[1], Permissions: fields[2]}
+		named := fields[1] != ""
+		switch fields[0] {
+		case "u", "user":
+			entry.Tag = pb.AclTag_ACL_TAG_USER_OBJ
+			if named {
+				entry.Tag = pb.AclTag_ACL_TAG_USER
+			}
+		case "g", "group":
+			entry.Tag = pb.AclTag_ACL_TAG_GROUP_OBJ
+			if named {
+				entry.Tag = pb.AclTag_ACL_TAG_GROUP
+			}
+		case "m", "mask":
+			entry.Tag = pb.AclTag_ACL_TAG_MASK
+		case "o", "other":
+			entry.Tag = pb.AclTag_ACL_TAG_OTHER
+		default:
+			return nil, fmt.Errorf("unknown tag %q", fields[0])
+		}
+		if named && entry.Tag != pb.AclTag_ACL_TAG_USER && entry.Tag != pb.AclTag_ACL_TAG_GROUP {
+			return nil, fmt.Errorf("entry %q can not have a qualifier", line)
+		}
+		if _, err := parseACLPerm(entry.Permissions); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// formatACL returns the text form of an ACL, using names where known
+func formatACL(entries []*pb.AclEntry) string {
+	parts := make([]string, len(entries))
+	for i, entry := range entries {
+		parts[i] = formatACLEntry(entry)
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatACLEntry formats one entry, e.g. "user:alice:r--"
+func formatACLEntry(entry *pb.AclEntry) string {
+	var qualifier string
+	if entry.Tag == pb.AclTag_ACL_TAG_USER || entry.Tag == pb.AclTag_ACL_TAG_GROUP {
+		qualifier = entry.Name
+		if qualifier == "" {
+			qualifier = strconv.FormatUint(uint64(entry.Id), 10)
+		}
+	}
+	return aclTagName(entry.Tag) + ":" + qualifier + ":" + entry.Permissions
+}
+
+// aclTagName returns the tag's keyword in the text form
+func aclTagName(tag pb.AclTag) string {
+	switch tag {
+	case pb.AclTag_ACL_TAG_USER_OBJ, pb.AclTag_ACL_TAG_USER:
+		return "user"
+	case pb.AclTag_ACL_TAG_GROUP_OBJ, pb.AclTag_ACL_TAG_GROUP:
+		return "group"
+	case pb.AclTag_ACL_TAG_MASK:
+		return "mask"
+	case pb.AclTag_ACL_TAG_OTHER:
+		return "other"
+	default:
+		return "undefined"
+	}
+}
+
+// parseACLPerm parses permissions such as "rw-", "r-x" or "rx"
+func parseACLPerm(perm string) (uint16, error) {
+	var bits uint16
+	for _, c := range perm {
+		switch c {
+		case 'r':
+			bits |= 4
+		case 'w':
+			bits |= 2
+		case 'x':
+			bits |= 1
+		case '-':
+		default:
+			return 0, fmt.Errorf("invalid permissions %q", perm)
+		}
+	}
+	return bits, nil
+}
+
+// aclPermString formats the low three permission bits as "rwx"
+func aclPermString(bits uint16) string {
+	buf := []byte("---")
+	for i, c := range "rwx" {
+		if bits&(4>>uint(i)) != 0 {
+			buf[i] = byte(c)
+		}
+	}
+	return string(buf)
+}
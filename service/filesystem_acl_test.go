@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestACLEncoding(t *testing.T) {
+	entries, err := parseACL("u::rw-,u:0:r,g::r--,o::---")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := encodeACL(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeACL(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The mask is computed from the group class and entries come back sorted
+	if got, want := formatACL(decoded), "user::rw-,user:0:r--,group::r--,mask::r--,other::---"; got != want {
+		t.Errorf("round trip = %q, want %q", got, want)
+	}
+
+	for _, text := range []string{"u::rw-,o::---", "u::rw-,u::r--,g::r--,o::---", "u::rwz,g::r--,o::---", "m:x:rw-", "x::rw-"} {
+		entries, err := parseACL(text)
+		if err == nil {
+			_, err = encodeACL(entries)
+		}
+		if err == nil {
+			t.Errorf("ACL %q was accepted", text)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checksumAlgorithms are the checksums GetChecksum computes, by name
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"crc32c": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+}
+
+// checksumCache keeps the checksums of files in a directory below the base
+// directory, in one small JSON file per inode. An entry is only used while
+// the file has the ETag it was computed for, which changes with the inode,
+// size, mtime or ctime; a file touched back to its old mtime after a write
+// still has a new ctime.
+//
+// The cache is kept outside the files themselves because an extended
+// attribute would change their ctime, and with it their ETag.
+//
+// Entries of removed or changed files are never read again, so once the
+// cache holds more than keep entries the least recently computed ones are
+// pruned.
+type checksumCache struct {
+	dir     string       // Cache directory, relative to the base directory
+	keep    int          // Entries kept after pruning, 0 for no limit
+	stores  atomic.Int64 // Entries stored, to prune every pruneEvery stores
+	pruning sync.Mutex   // Held while a store prunes the cache
+}
+
+// checksumPruneShare is the share of keep stored between prunes, and
+// removed beyond keep when the cache is pruned
+const checksumPruneShare = 10
+
+// checksumTmpAge is how old an abandoned temporary cache file must be to be pruned
+const checksumTmpAge = time.Hour
+
+// cachedChecksums is the cache file of an inode
+type cachedChecksums struct {
+	Etag      string            `json:"etag"`
+	Checksums map[string]string `json:"checksums"`
+}
+
+// SetChecksumCacheDir caches the checksums of up to keep files in dir below
+// the base directory, or of any number if keep is 0. The directory is hidden
+// from clients like a deny list entry, so SetChecksumCacheDir must be called
+// after SetDenyList.
+func (s *FilesystemService) SetChecksumCacheDir(dir string, keep int) error {
+	dir = cleanRel(dir)
+	if dir == "." {
+		return fmt.Errorf("checksum cache must be a directory below the base directory")
+	}
+	if keep < 0 {
+		return fmt.Errorf("checksum cache size must not be negative")
+	}
+
+	rule, _, err := parseIgnoreRule("/"+filepath.ToSlash(dir)+"/", "")
+	if err != nil {
+		return fmt.Errorf("invalid checksum cache %q: %w", dir, err)
+	}
+	s.addDenyRules(rule)
+	s.checksums = &checksumCache{dir: dir, keep: keep}
+	return nil
+}
+
+// GetChecksum implements the GetChecksum RPC method
+func (s *FilesystemService) GetChecksum(ctx context.Context, req *ChecksumRequest) (*ChecksumResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	algorithms := req.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{"sha256"}
+	}
+	if err := checkAlgorithms(algorithms); err != nil {
+		return nil, err
+	}
+
+	sums, info, cached, err := s.fileChecksums(ctx, validPath, algorithms, req.Refresh)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, rootError(err, "Failed to compute checksums")
+	}
+
+	return &ChecksumResponse{
+		Path:           filepath.ToSlash(validPath),
+		Size:           info.Size(),
+		ModifiedTimeNs: info.ModTime().UnixNano(),
+		Etag:           etagOf(info),
+		Checksums:      sums,
+		Cached:         cached,
+	}, nil
+}
+
+// checkAlgorithms rejects checksum names GetChecksum does not know
+func checkAlgorithms(algorithms []string) error {
+	for _, name := range algorithms {
+		if _, ok := checksumAlgorithms[strings.ToLower(name)]; !ok {
+			names := make([]string, 0, len(checksumAlgorithms))
+			for known := range checksumAlgorithms {
+				names = append(names, known)
+			}
+			sort.Strings(names)
+			return status.Errorf(codes.InvalidArgument, "Unknown checksum algorithm %q (want one of %s)", name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// fileChecksums returns hex checksums of the regular file relPath by
+// algorithm, reading the file once for all that are not cached. It reports
+// whether every checksum came from the cache. Reading stops with an error
+// once ctx is done. The algorithms must have been checked with
+// checkAlgorithms.
+func (s *FilesystemService) fileChecksums(ctx context.Context, relPath string, algorithms []string, refresh bool) (map[string]string, os.FileInfo, bool, error) {
+	file, info, err := s.root.openRegular(relPath)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer file.Close()
+
+	etag := etagOf(info)
+	entry := cachedChecksums{Etag: etag, Checksums: make(map[string]string)}
+	if !refresh {
+		if cached, ok := s.loadChecksums(info); ok && cached.Etag == etag {
+			entry = cached
+		}
+	}
+
+	sums := make(map[string]string, len(algorithms))
+	hashes := make(map[string]hash.Hash)
+	var writers []io.Writer
+	for _, name := range algorithms {
+		name = strings.ToLower(name)
+		if sum, ok := entry.Checksums[name]; ok {
+			sums[name] = sum
+			continue
+		}
+		if _, ok := hashes[name]; !ok {
+			hashes[name] = checksumAlgorithms[name]()
+			writers = append(writers, hashes[name])
+		}
+	}
+	if len(hashes) == 0 {
+		return sums, info, true, nil
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), contextReader{ctx, file}); err != nil {
+		return nil, nil, false, err
+	}
+	for name, h := range hashes {
+		sums[name] = hex.EncodeToString(h.Sum(nil))
+		entry.Checksums[name] = sums[name]
+	}
+
+	// A file written to while it was read has no checksum worth keeping
+	if after, err := file.Stat(); err == nil && etagOf(after) == etag {
+		s.storeChecksums(info, entry)
+	}
+	return sums, info, false, nil
+}
+
+// path names the cache file of the inode info describes
+func (c *checksumCache) path(info os.FileInfo) string {
+	stat := statFieldsOf(info)
+	return filepath.Join(c.dir, fmt.Sprintf("%x-%x.json", stat.device, stat.inode))
+}
+
+// loadChecksums reads the cached checksums of an inode, if there are any
+func (s *FilesystemService) loadChecksums(info os.FileInfo) (cachedChecksums, bool) {
+	if s.checksums == nil {
+		return cachedChecksums{}, false
+	}
+	file, err := s.root.Open(s.checksums.path(info))
+	if err != nil {
+		return cachedChecksums{}, false
+	}
+	defer file.Close()
+
+	var entry cachedChecksums
+	if err := json.NewDecoder(file).Decode(&entry); err != nil || entry.Checksums == nil {
+		return cachedChecksums{}, false
+	}
+	return entry, true
+}
+
+// storeChecksums replaces the cache file of an inode. The cache is only an
+// optimization, so failures are ignored.
+func (s *FilesystemService) storeChecksums(info os.FileInfo, entry cachedChecksums) {
+	if s.checksums == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return
+	}
+
+	name := s.checksums.path(info)
+	tmpName := name + ".tmp-" + hex.EncodeToString(suffix)
+	if err := s.root.MkdirAll(s.checksums.dir, 0700); err != nil {
+		return
+	}
+	if err := s.root.writeNew(tmpName, data); err != nil {
+		s.root.Remove(tmpName)
+		return
+	}
+	if err := s.root.Rename(tmpName, name); err != nil {
+		s.root.Remove(tmpName)
+		return
+	}
+
+	cache := s.checksums
+	if pruneEvery := int64(cache.keep/checksumPruneShare + 1); cache.keep > 0 && cache.stores.Add(1)%pruneEvery == 0 {
+		s.pruneChecksums()
+	}
+}
+
+// pruneChecksums removes the least recently computed cache entries while
+// there are more than keep, down to keep less its prune share, along with
+// temporary files abandoned by a crash. A prune already underway is not
+// repeated.
+func (s *FilesystemService) pruneChecksums() {
+	cache := s.checksums
+	if !cache.pruning.TryLock() {
+		return
+	}
+	defer cache.pruning.Unlock()
+
+	entries, err := s.root.ReadDir(cache.dir)
+	if err != nil {
+		return
+	}
+	type cacheFile struct {
+		name    string
+		modTime time.Time
+	}
+	var files []cacheFile
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		name := filepath.Join(cache.dir, entry.Name())
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			if strings.Contains(entry.Name(), ".json.tmp-") && time.Since(info.ModTime()) > checksumTmpAge {
+				s.root.Remove(name)
+			}
+			continue
+		}
+		files = append(files, cacheFile{name, info.ModTime()})
+	}
+	if len(files) <= cache.keep {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files[:len(files)-cache.keep+cache.keep/checksumPruneShare] {
+		s.root.Remove(file.name)
+	}
+}
+
+// contextReader fails reads once ctx is done, so that hashing a large file
+// ends with the request that asked for it
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// addChecksums fills in the checksums a listing or GetFileInfo asked for.
+// They are left out for anything but regular files and when the file can
+// not be read.
+func (s *FilesystemService) addChecksums(ctx context.Context, relPath string, info os.FileInfo, algorithms []string) map[string]string {
+	if len(algorithms) == 0 || !info.Mode().IsRegular() {
+		return nil
+	}
+	sums, _, _, err := s.fileChecksums(ctx, relPath, algorithms, false)
+	if err != nil {
+		return nil
+	}
+	return sums
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChecksums(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	if err := f.service.SetChecksumCacheDir(".checksums", 0); err != nil {
+		t.Fatal(err)
+	}
+	f.write(t, "data/abc.txt", "abc")
+
+	sums := func(refresh bool, algorithms ...string) *ChecksumResponse {
+		t.Helper()
+		response, err := f.service.GetChecksum(ctx, &ChecksumRequest{Path: "data/abc.txt", Algorithms: algorithms, Refresh: refresh})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	first := sums(false, "md5", "SHA1", "crc32c")
+	want := map[string]string{
+		"md5":    "900150983cd24fb0d6963f7d28e17f72",
+		"sha1":   "a9993e364706816aba3e25717850c26c9cd0d89d",
+		"crc32c": "364b3fb7",
+	}
+	for name, sum := range want {
+		if first.Checksums[name] != sum {
+			t.Errorf("%s = %s, want %s", name, first.Checksums[name], sum)
+		}
+	}
+	if first.Cached || first.Size != 3 {
+		t.Errorf("first checksum: cached %v, size %d", first.Cached, first.Size)
+	}
+	if second := sums(false, "md5", "crc32c"); !second.Cached || second.Checksums["md5"] != want["md5"] {
+		t.Errorf("second checksum = %v, want cached", second)
+	}
+	if refreshed := sums(true, "md5"); refreshed.Cached {
+		t.Error("refreshed checksum came from the cache")
+	}
+
+	// The default is SHA-256, which is not cached yet
+	sha := sha256.Sum256([]byte("abc"))
+	if response := sums(false); response.Cached || response.Checksums["sha256"] != hex.EncodeToString(sha[:]) {
+		t.Errorf("default checksum = %v", response)
+	}
+
+	f.write(t, "data/abc.txt", "abd")
+	if changed := sums(false, "md5"); changed.Cached || changed.Checksums["md5"] == want["md5"] {
+		t.Errorf("checksum of changed file = %v, want recomputed", changed)
+	}
+
+	if _, err := f.service.GetChecksum(ctx, &ChecksumRequest{Path: "data/abc.txt", Algorithms: []string{"crc64"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown algorithm: got %v, want InvalidArgument", err)
+	}
+	if _, err := f.service.GetChecksum(ctx, &ChecksumRequest{Path: "data"}); err == nil {
+		t.Error("checksum of a directory succeeded")
+	}
+
+	info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: "data/abc.txt", Checksums: []string{"md5"}})
+	if err != nil || info.Checksums["md5"] == "" {
+		t.Errorf("GetFileInfo checksums = %v, %v", info.GetChecksums(), err)
+	}
+	listing, err := f.service.ListDirectory(ctx, &ListRequest{Path: "/", Recursive: true, Checksums: []string{"md5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := false
+	for _, item := range listing.Items {
+		if strings.HasPrefix(item.Path, ".checksums") {
+			t.Errorf("listing shows the checksum cache: %s", item.Path)
+		}
+		if item.Path == "data/abc.txt" {
+			listed = item.Checksums["md5"] == info.GetChecksums()["md5"]
+		}
+	}
+	if !listed {
+		t.Errorf("listing has no checksum for data/abc.txt: %v", listing.Items)
+	}
+}
+
+// The cache keeps the most recently computed entries once it is full
+func TestChecksumCachePruning(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	if err := f.service.SetChecksumCacheDir(".checksums", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 30 {
+		f.write(t, fmt.Sprintf("file%02d.txt", i), strconv.Itoa(i))
+		if _, err := f.service.GetChecksum(ctx, &ChecksumRequest{Path: fmt.Sprintf("file%02d.txt", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(f.path(".checksums"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || len(entries) > 10 {
+		t.Errorf("cache holds %d entries, want 1 to 10", len(entries))
+	}
+	if response, err := f.service.GetChecksum(ctx, &ChecksumRequest{Path: "file29.txt"}); err != nil || !response.Cached {
+		t.Errorf("latest checksum after pruning = %v, %v, want cached", response, err)
+	}
+}
+
+// Hashing stops with the request, and leaves nothing in the cache
+func TestChecksumCanceled(t *testing.T) {
+	f := newTestFixture(t)
+	if err := f.service.SetChecksumCacheDir(".checksums", 0); err != nil {
+		t.Fatal(err)
+	}
+	f.write(t, "big.bin", strings.Repeat("x", 1<<20))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.service.GetChecksum(ctx, &ChecksumRequest{Path: "big.bin"}); status.Code(err) != codes.Canceled {
+		t.Errorf("GetChecksum with a canceled context: got %v, want Canceled", err)
+	}
+	if entries, _ := os.ReadDir(f.path(".checksums")); len(entries) != 0 {
+		t.Errorf("canceled checksum was cached: %v", entries)
+	}
+}
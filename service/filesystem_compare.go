@@ -0,0 +1,315 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// compareChecks selects what entries of the same type are compared by, on
+// top of the size of files and the target of symlinks
+type compareChecks struct {
+	times   bool // Modification time of files
+	mode    bool // Permission bits
+	owner   bool // User and group
+	content bool // SHA-256 of files of the same size
+}
+
+// snapshotChecks is rsync's quick check plus permissions
+var snapshotChecks = compareChecks{times: true, mode: true}
+
+// treeEntry is an entry found by walkTree
+type treeEntry struct {
+	path   string // Relative to the base directory
+	info   os.FileInfo
+	target string // Symlink target
+}
+
+// treeChange is a difference found by walkDiff
+type treeChange struct {
+	path        string // Slash path of the entry in the new tree
+	change      pb.ChangeType
+	old, new    *treeEntry // nil for added and deleted entries respectively
+	differences []string   // What differs for modified entries
+}
+
+// CompareDirectories implements the CompareDirectories RPC method (streaming to client)
+func (s *FilesystemService) CompareDirectories(req *CompareRequest, stream FilesystemService_CompareDirectoriesServer) error {
+	ctx := stream.Context()
+
+	var checks compareChecks
+	switch req.Mode {
+	case pb.CompareMode_COMPARE_QUICK:
+		checks = compareChecks{times: true}
+	case pb.CompareMode_COMPARE_METADATA:
+		checks = compareChecks{mode: true, owner: true}
+	case pb.CompareMode_COMPARE_CONTENT:
+		checks = compareChecks{content: true}
+	default:
+		return status.Errorf(codes.InvalidArgument, "Invalid compare mode %v", req.Mode)
+	}
+
+	leftPath, err := s.validatePath(req.LeftPath)
+	if err != nil {
+		return err
+	}
+	rightPath, err := s.validatePath(req.RightPath)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{leftPath, rightPath} {
+		info, err := s.root.Stat(dir)
+		if err != nil {
+			return rootError(err, "Failed to access path")
+		}
+		if !info.IsDir() {
+			return status.Errorf(codes.InvalidArgument, "Path is not a directory: %s", filepath.ToSlash(dir))
+		}
+	}
+
+	err = s.walkDiff(ctx, leftPath, rightPath, checks, func(change treeChange) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return stream.Send(change.proto())
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return rootError(err, "Failed to compare directories")
+	}
+	return nil
+}
+
+// diffTrees collects the differences walkDiff finds with snapshotChecks
+func (s *FilesystemService) diffTrees(ctx context.Context, oldRoot, newRoot string) ([]treeChange, error) {
+	var changes []treeChange
+	err := s.walkDiff(ctx, oldRoot, newRoot, snapshotChecks, func(change treeChange) error {
+		changes = append(changes, change)
+		return nil
+	})
+	return changes, err
+}
+
+// walkDiff compares the tree at oldRoot with the one at newRoot, both
+// relative to the base directory, and calls emit for every difference in
+// the order a walk visits the paths. Both trees are walked side by side, so
+// differences are emitted as they are found. A missing tree counts as empty,
+// and entries denied below either root are left out.
+func (s *FilesystemService) walkDiff(ctx context.Context, oldRoot, newRoot string, checks compareChecks, emit func(treeChange) error) error {
+	nextOld, stopOld, oldErr := s.pullTree(oldRoot)
+	defer stopOld()
+	nextNew, stopNew, newErr := s.pullTree(newRoot)
+	defer stopNew()
+
+	newSlash := filepath.ToSlash(cleanRel(newRoot))
+	oldRel, oldEntry, oldOK := nextOld()
+	newRel, newEntry, newOK := nextNew()
+	for oldOK || newOK {
+		// A walk that failed must not make the rest of the other tree look new or gone
+		if !oldOK && *oldErr != nil {
+			return *oldErr
+		}
+		if !newOK && *newErr != nil {
+			return *newErr
+		}
+
+		order := 0
+		switch {
+		case !newOK:
+			order = -1
+		case !oldOK:
+			order = 1
+		default:
+			order = compareWalkOrder(oldRel, newRel)
+		}
+
+		var change treeChange
+		switch {
+		case order < 0:
+			change = treeChange{path: path.Join(newSlash, oldRel), change: pb.ChangeType_CHANGE_DELETED, old: oldEntry}
+			oldRel, oldEntry, oldOK = nextOld()
+		case order > 0:
+			change = treeChange{path: path.Join(newSlash, newRel), change: pb.ChangeType_CHANGE_ADDED, new: newEntry}
+			newRel, newEntry, newOK = nextNew()
+		default:
+			change = treeChange{path: path.Join(newSlash, newRel), change: pb.ChangeType_CHANGE_MODIFIED, old: oldEntry, new: newEntry}
+			oldRel, oldEntry, oldOK = nextOld()
+			newRel, newEntry, newOK = nextNew()
+			differences, err := s.entryDifferences(ctx, change.old, change.new, checks)
+			if err != nil {
+				return err
+			}
+			if len(differences) == 0 {
+				continue
+			}
+			change.differences = differences
+		}
+		if err := emit(change); err != nil {
+			return err
+		}
+	}
+
+	if *oldErr != nil {
+		return *oldErr
+	}
+	return *newErr
+}
+
+// pullTree returns the entries walkTree finds below root one at a time.
+// Once next reports no more entries, err holds what ended the walk early.
+func (s *FilesystemService) pullTree(root string) (next func() (string, *treeEntry, bool), stop func(), err *error) {
+	var walkErr error
+	next, stop = iter.Pull2(func(yield func(string, *treeEntry) bool) {
+		walkErr = s.walkTree(root, nil, func(rel string, entry *treeEntry) error {
+			if !yield(rel, entry) {
+				return errStopWalk
+			}
+			return nil
+		})
+	})
+	return next, stop, &walkErr
+}
+
+// compareWalkOrder orders slash paths the way a walk visits them: entries of
+// a directory by name, and a directory right before its own entries
+func compareWalkOrder(a, b string) int {
+	for {
+		aName, aRest, aMore := strings.Cut(a, "/")
+		bName, bRest, bMore := strings.Cut(b, "/")
+		if order := strings.Compare(aName, bName); order != 0 {
+			return order
+		}
+		switch {
+		case !aMore && !bMore:
+			return 0
+		case !aMore:
+			return -1
+		case !bMore:
+			return 1
+		}
+		a, b = aRest, bRest
+	}
+}
+
+// walkTree calls fn for the entries below root in lexical order, with their
+// slash path relative to root. Denied entries and those the rules exclude are
+// skipped with everything below them, and a missing root counts as empty.
+func (s *FilesystemService) walkTree(root string, rules *pathRules, fn func(rel string, entry *treeEntry) error) error {
+	rootSlash := filepath.ToSlash(cleanRel(root))
+
+	return s.root.WalkDir(root, func(walkPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if walkPath == rootSlash && os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if walkPath == rootSlash {
+			return nil
+		}
+		if s.deniedEntry(slashRel(walkPath), entry.IsDir()) || rules.excluded(slashRel(walkPath), entry.IsDir()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		e := &treeEntry{path: filepath.FromSlash(walkPath), info: info}
+		if info.Mode()&os.ModeSymlink != 0 {
+			e.target, _ = s.root.Readlink(e.path)
+		}
+
+		rel := walkPath
+		if rootSlash != "." {
+			rel = strings.TrimPrefix(walkPath, rootSlash+"/")
+		}
+		return fn(rel, e)
+	})
+}
+
+// entryDifferences lists what differs between two entries at the same path
+func (s *FilesystemService) entryDifferences(ctx context.Context, a, b *treeEntry, checks compareChecks) ([]string, error) {
+	modeA, modeB := a.info.Mode(), b.info.Mode()
+	if modeA.Type() != modeB.Type() {
+		return []string{"type"}, nil
+	}
+
+	var differences []string
+	regular, symlink := modeA.IsRegular(), modeA&os.ModeSymlink != 0
+	if regular && a.info.Size() != b.info.Size() {
+		differences = append(differences, "size")
+	}
+	if checks.times && regular && !a.info.ModTime().Equal(b.info.ModTime()) {
+		differences = append(differences, "mtime")
+	}
+	if checks.mode && !symlink && modeA.Perm() != modeB.Perm() {
+		differences = append(differences, "mode")
+	}
+	if checks.owner {
+		statA, statB := statFieldsOf(a.info), statFieldsOf(b.info)
+		if statA.uid != statB.uid || statA.gid != statB.gid {
+			differences = append(differences, "owner")
+		}
+	}
+	if checks.content && regular && a.info.Size() == b.info.Size() {
+		sumA, err := s.fileSHA256(ctx, a.path)
+		if err != nil {
+			return nil, err
+		}
+		sumB, err := s.fileSHA256(ctx, b.path)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(sumA, sumB) {
+			differences = append(differences, "content")
+		}
+	}
+	if symlink && a.target != b.target {
+		differences = append(differences, "target")
+	}
+	return differences, nil
+}
+
+// fileSHA256 hashes the content of a regular file, or takes the hash from the checksum cache
+func (s *FilesystemService) fileSHA256(ctx context.Context, relPath string) ([]byte, error) {
+	sums, _, _, err := s.fileChecksums(ctx, relPath, []string{"sha256"}, false)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(sums["sha256"])
+}
+
+func (c treeChange) proto() *pb.PathChange {
+	entry := c.new
+	if entry == nil {
+		entry = c.old
+	}
+	return &pb.PathChange{
+		Path:           c.path,
+		Change:         c.change,
+		FileType:       fileTypeOf(entry.info.Mode()),
+		Size:           entry.info.Size(),
+		ModifiedTimeNs: entry.info.ModTime().UnixNano(),
+		Differences:    c.differences,
+	}
+}
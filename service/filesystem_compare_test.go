@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// compareStream collects the changes CompareDirectories sends
+type compareStream struct {
+	grpc.ServerStream
+	changes []string
+}
+
+func (c *compareStream) Context() context.Context { return context.Background() }
+
+func (c *compareStream) Send(change *pb.PathChange) error {
+	entry := change.Change.String() + " " + change.Path
+	if len(change.Differences) > 0 {
+		entry += " " + strings.Join(change.Differences, "+")
+	}
+	c.changes = append(c.changes, entry)
+	return nil
+}
+
+func TestCompareDirectories(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	f.deny(t, ".env")
+	f.write(t, "prod/a.txt", "a")
+	f.write(t, "prod/b.txt", "b")
+	f.write(t, "prod/sub/c.txt", "c")
+	f.write(t, "prod/.env", "secret")
+
+	compare := func(mode pb.CompareMode) string {
+		t.Helper()
+		stream := &compareStream{}
+		if err := f.service.CompareDirectories(&CompareRequest{LeftPath: "prod", RightPath: "staging", Mode: mode}, stream); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(stream.changes, ",")
+	}
+
+	if resp, err := f.service.Copy(ctx, &CopyRequest{Source: "prod", Destination: "staging"}); err != nil || !resp.Success {
+		t.Fatalf("Copy: %v, %v", resp, err)
+	}
+	if got := compare(pb.CompareMode_COMPARE_CONTENT); got != "" {
+		t.Errorf("content after copy = %s, want none", got)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(f.base, "staging", "a.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := compare(pb.CompareMode_COMPARE_QUICK), "CHANGE_MODIFIED staging/a.txt mtime"; !strings.HasPrefix(got, want) {
+		t.Errorf("quick = %s, want it to start with %s", got, want)
+	}
+
+	f.write(t, "staging/b.txt", "B")
+	f.write(t, "staging/new.txt", "new")
+	if err := os.Remove(filepath.Join(f.base, "staging", "sub", "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+	want := "CHANGE_MODIFIED staging/b.txt content,CHANGE_ADDED staging/new.txt,CHANGE_DELETED staging/sub/c.txt"
+	if got := compare(pb.CompareMode_COMPARE_CONTENT); got != want {
+		t.Errorf("content = %s, want %s", got, want)
+	}
+
+	if err := os.Chmod(filepath.Join(f.base, "staging", "a.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	want = "CHANGE_MODIFIED staging/a.txt mode,CHANGE_ADDED staging/new.txt,CHANGE_DELETED staging/sub/c.txt"
+	if got := compare(pb.CompareMode_COMPARE_METADATA); got != want {
+		t.Errorf("metadata = %s, want %s", got, want)
+	}
+
+	err := f.service.CompareDirectories(&CompareRequest{LeftPath: "prod/a.txt", RightPath: "staging"}, &compareStream{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("file instead of directory: got %v, want InvalidArgument", err)
+	}
+}
+
+// Both trees are walked side by side, so paths must be matched in walk order
+func TestCompareWalkOrder(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{"a", "a", 0},
+		{"a", "a/b", -1},
+		{"a/b", "a-c", -1}, // A directory's entries come before its next sibling
+		{"a-c", "a", 1},
+		{"a/b/c", "a/b", 1},
+		{"a/c", "a/b/c", 1},
+	} {
+		if got := compareWalkOrder(test.a, test.b); got != test.want {
+			t.Errorf("compareWalkOrder(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+
+	f := newTestFixture(t)
+	for _, name := range []string{"x/in", "x-y", "x.z", "x/deeper/file", "y"} {
+		f.write(t, "old/"+name, "same")
+		f.write(t, "new/"+name, "same")
+	}
+	f.write(t, "new/x/added", "new")
+	f.write(t, "new/x-y", "changed")
+	if err := os.Remove(f.path("new/x/deeper/file")); err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []string
+	err := f.service.walkDiff(context.Background(), "old", "new", compareChecks{content: true}, func(change treeChange) error {
+		changes = append(changes, change.change.String()+" "+change.path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "CHANGE_ADDED new/x/added,CHANGE_DELETED new/x/deeper/file,CHANGE_MODIFIED new/x-y"
+	if got := strings.Join(changes, ","); got != want {
+		t.Errorf("changes = %s, want %s", got, want)
+	}
+}
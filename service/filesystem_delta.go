@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/notfrancois/filesystem-daemon/delta"
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+const (
+	// signatureBatch is the number of block signatures sent per message
+	signatureBatch = 4096
+
+	// maxSignatureBlocks bounds the signature a client can make GetDelta hold
+	maxSignatureBlocks = 1 << 20
+
+	// deltaBatchSize is the literal data after which a delta message is sent
+	deltaBatchSize = 1024 * 1024
+)
+
+// errChecksumMismatch is returned when a rebuilt file does not have the SHA-256 the delta announced
+var errChecksumMismatch = errors.New("checksum of the rebuilt file does not match")
+
+// GetSignature implements the GetSignature RPC method (streaming to client)
+func (s *FilesystemService) GetSignature(req *SignatureRequest, stream FilesystemService_GetSignatureServer) error {
+	ctx := stream.Context()
+
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return err
+	}
+	file, info, err := s.root.openRegular(validPath)
+	if err != nil {
+		return rootError(err, "Failed to open file")
+	}
+	defer file.Close()
+
+	blockSize := req.BlockSize
+	if blockSize == 0 {
+		blockSize = delta.BlockSize(info.Size())
+	}
+	if !delta.ValidBlockSize(blockSize) {
+		return status.Errorf(codes.InvalidArgument, "Block size must be between %d and %d bytes", delta.MinBlockSize, delta.MaxBlockSize)
+	}
+
+	chunk := &SignatureChunk{
+		Path:      filepath.ToSlash(validPath),
+		BlockSize: blockSize,
+		FileSize:  info.Size(),
+		Etag:      etagOf(info),
+	}
+	err = delta.Sign(file, blockSize, func(block delta.Block) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk.Blocks = append(chunk.Blocks, &pb.BlockSignature{Weak: block.Weak, Strong: block.Strong})
+		if len(chunk.Blocks) < signatureBatch {
+			return nil
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		chunk = &SignatureChunk{}
+		return nil
+	})
+	if err != nil {
+		return streamError(stream.Context().Err(), err, "Failed to read file")
+	}
+	return stream.Send(chunk)
+}
+
+// ApplyDelta implements the ApplyDelta RPC method (streaming from client).
+// The new file is built in a temporary file from the ops and the current
+// file, which stays locked against in-place edits until it is replaced.
+func (s *FilesystemService) ApplyDelta(stream FilesystemService_ApplyDeltaServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Errorf(codes.InvalidArgument, "No delta received")
+	}
+	if err != nil {
+		return err
+	}
+	if !delta.ValidBlockSize(first.BlockSize) {
+		return status.Errorf(codes.InvalidArgument, "Block size must be between %d and %d bytes", delta.MinBlockSize, delta.MaxBlockSize)
+	}
+	validPath, err := s.validatePath(first.Path)
+	if err != nil {
+		return err
+	}
+
+	// A missing file is created from literal data, unless the delta was made against one
+	var basis io.ReaderAt = bytes.NewReader(nil)
+	var info os.FileInfo
+	file, err := s.openLockedRegular(stream.Context(), validPath)
+	switch {
+	case err == nil:
+		defer file.Close()
+		if info, err = file.Stat(); err != nil {
+			return status.Errorf(codes.Internal, "Failed to stat file: %v", err)
+		}
+		basis = file
+	case os.IsNotExist(err):
+		if err := s.root.MkdirAll(filepath.Dir(validPath), 0755); err != nil {
+			return status.Errorf(codes.Internal, "Failed to create directory: %v", err)
+		}
+	default:
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return rootError(err, "Failed to open file")
+	}
+	if first.BasisEtag != "" {
+		if err := checkETag(first.BasisEtag, "", info); err != nil {
+			return err
+		}
+	}
+	var basisSize int64
+	if info != nil {
+		basisSize = info.Size()
+	}
+
+	var literal int64
+	var pending []*pendingVersion
+	hash := sha256.New()
+	newInfo, err := s.root.replaceFile(validPath, info, func(w io.Writer) error {
+		out := io.MultiWriter(w, hash)
+		var expected []byte
+		for chunk := first; ; {
+			if chunk.Path != "" && chunk.Path != first.Path {
+				return status.Errorf(codes.InvalidArgument, "File path cannot change during a delta")
+			}
+			for _, op := range chunk.Ops {
+				literal += int64(len(op.Data))
+				err := delta.Apply(out, basis, basisSize, first.BlockSize, delta.Op{Index: op.BlockIndex, Count: op.BlockCount, Data: op.Data})
+				if err != nil {
+					return err
+				}
+			}
+			if len(chunk.Sha256) > 0 {
+				expected = chunk.Sha256
+			}
+
+			chunk, err = stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if expected == nil {
+			return status.Errorf(codes.InvalidArgument, "Delta did not include the checksum of the new file")
+		}
+		if !bytes.Equal(hash.Sum(nil), expected) {
+			return errChecksumMismatch
+		}
+
+		// Only a verified file replaces the basis, so only then is it kept
+		if info != nil {
+			if pending, err = s.preserveReplaced(validPath, "sync"); err != nil {
+				return fmt.Errorf("failed to save previous version: %w", err)
+			}
+		}
+		return nil
+	})
+	s.settleVersions(pending)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return stream.SendAndClose(&WriteResponse{
+			Success: false,
+			Error:   "Failed to apply delta: " + err.Error(),
+		})
+	}
+
+	return stream.SendAndClose(&WriteResponse{
+		Success:        true,
+		Message:        fmt.Sprintf("File synced successfully (%d of %d bytes sent)", literal, newInfo.Size()),
+		BytesWritten:   literal,
+		Size:           newInfo.Size(),
+		ModifiedTimeNs: newInfo.ModTime().UnixNano(),
+		Etag:           etagOf(newInfo),
+	})
+}
+
+// GetDelta implements the GetDelta RPC method (bidirectional streaming)
+func (s *FilesystemService) GetDelta(stream FilesystemService_GetDeltaServer) error {
+	ctx := stream.Context()
+
+	var first *SignatureChunk
+	var blocks []delta.Block
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = chunk
+		}
+		if len(blocks)+len(chunk.Blocks) > maxSignatureBlocks {
+			return status.Errorf(codes.InvalidArgument, "Signature has more than %d blocks", maxSignatureBlocks)
+		}
+		for _, block := range chunk.Blocks {
+			blocks = append(blocks, delta.Block{Weak: block.Weak, Strong: block.Strong})
+		}
+	}
+	if first == nil {
+		return status.Errorf(codes.InvalidArgument, "No signature received")
+	}
+	if !delta.ValidBlockSize(first.BlockSize) {
+		return status.Errorf(codes.InvalidArgument, "Block size must be between %d and %d bytes", delta.MinBlockSize, delta.MaxBlockSize)
+	}
+
+	validPath, err := s.validatePath(first.Path)
+	if err != nil {
+		return err
+	}
+	file, _, err := s.root.openRegular(validPath)
+	if err != nil {
+		return rootError(err, "Failed to open file")
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	chunk := &DeltaChunk{Path: filepath.ToSlash(validPath), BlockSize: first.BlockSize}
+	var batched int
+	err = delta.Diff(io.TeeReader(file, hash), first.BlockSize, blocks, func(op delta.Op) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk.Ops = append(chunk.Ops, &pb.DeltaOp{BlockIndex: op.Index, BlockCount: op.Count, Data: op.Data})
+		batched += len(op.Data)
+		if batched < deltaBatchSize && len(chunk.Ops) < signatureBatch {
+			return nil
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		chunk, batched = &DeltaChunk{}, 0
+		return nil
+	})
+	if err != nil {
+		return streamError(ctx.Err(), err, "Failed to read file")
+	}
+
+	chunk.Sha256 = hash.Sum(nil)
+	return stream.Send(chunk)
+}
+
+// streamError maps an error that ended a streaming RPC: cancellation and
+// status errors as they are, anything else as an internal error
+func streamError(ctxErr, err error, msg string) error {
+	if ctxErr != nil {
+		return status.FromContextError(ctxErr).Err()
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
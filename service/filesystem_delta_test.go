@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/notfrancois/filesystem-daemon/delta"
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// signatureStream collects the signature GetSignature sends
+type signatureStream struct {
+	grpc.ServerStream
+	chunks []*SignatureChunk
+}
+
+func (s *signatureStream) Context() context.Context { return context.Background() }
+
+func (s *signatureStream) Send(chunk *SignatureChunk) error {
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+// deltaStream feeds ApplyDelta and GetDelta their input and collects what they send
+type deltaStream struct {
+	grpc.ServerStream
+	in       []*DeltaChunk
+	sigs     []*SignatureChunk
+	out      []*DeltaChunk
+	response *WriteResponse
+}
+
+func (d *deltaStream) Context() context.Context { return context.Background() }
+
+func (d *deltaStream) Recv() (*DeltaChunk, error) {
+	if len(d.in) == 0 {
+		return nil, io.EOF
+	}
+	chunk := d.in[0]
+	d.in = d.in[1:]
+	return chunk, nil
+}
+
+func (d *deltaStream) SendAndClose(response *WriteResponse) error {
+	d.response = response
+	return nil
+}
+
+// getDeltaStream adapts deltaStream to the GetDelta direction
+type getDeltaStream struct{ *deltaStream }
+
+func (g getDeltaStream) Recv() (*SignatureChunk, error) {
+	if len(g.sigs) == 0 {
+		return nil, io.EOF
+	}
+	chunk := g.sigs[0]
+	g.sigs = g.sigs[1:]
+	return chunk, nil
+}
+
+func (g getDeltaStream) Send(chunk *DeltaChunk) error {
+	g.out = append(g.out, chunk)
+	return nil
+}
+
+func TestDeltaSync(t *testing.T) {
+	f := newTestFixture(t)
+	rng := rand.New(rand.NewSource(1))
+	oldData := make([]byte, 200*1024+123)
+	rng.Read(oldData)
+	newData := append(append(append([]byte{}, oldData[:5000]...), "inserted"...), oldData[5000:150000]...)
+	newData = append(append(newData, "changed"...), oldData[150007:190000]...)
+	f.write(t, "big.bin", string(oldData))
+	if err := f.service.SetVersioning([]string{"."}, f.versionDir(), 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	versions := func() []*pb.FileVersion {
+		t.Helper()
+		list, err := f.service.ListVersions(context.Background(), &PathRequest{Path: "big.bin"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list.Versions
+	}
+
+	signature := func(path string) (*SignatureChunk, []delta.Block) {
+		t.Helper()
+		stream := &signatureStream{}
+		if err := f.service.GetSignature(&SignatureRequest{Path: path, BlockSize: 1024}, stream); err != nil {
+			t.Fatal(err)
+		}
+		var blocks []delta.Block
+		for _, chunk := range stream.chunks {
+			for _, block := range chunk.Blocks {
+				blocks = append(blocks, delta.Block{Weak: block.Weak, Strong: block.Strong})
+			}
+		}
+		return stream.chunks[0], blocks
+	}
+	deltaOf := func(data []byte, blockSize int64, blocks []delta.Block) []*pb.DeltaOp {
+		t.Helper()
+		var ops []*pb.DeltaOp
+		err := delta.Diff(bytes.NewReader(data), blockSize, blocks, func(op delta.Op) error {
+			ops = append(ops, &pb.DeltaOp{BlockIndex: op.Index, BlockCount: op.Count, Data: op.Data})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ops
+	}
+
+	// Upload: only the changes travel
+	first, blocks := signature("big.bin")
+	ops := deltaOf(newData, first.BlockSize, blocks)
+	sum := sha256.Sum256(newData)
+	stale := &deltaStream{in: []*DeltaChunk{{Path: "big.bin", BlockSize: 1024, BasisEtag: `"stale"`, Ops: ops, Sha256: sum[:]}}}
+	if err := f.service.ApplyDelta(stale); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("stale basis: got %v, want FailedPrecondition", err)
+	}
+	corrupt := &deltaStream{in: []*DeltaChunk{{Path: "big.bin", BlockSize: 1024, Ops: ops, Sha256: make([]byte, 32)}}}
+	if err := f.service.ApplyDelta(corrupt); err != nil || corrupt.response.Success {
+		t.Errorf("wrong checksum: %v, %v, want a failure", corrupt.response, err)
+	}
+	if list := versions(); len(list) != 0 {
+		t.Errorf("failed deltas saved versions %v", list)
+	}
+	upload := &deltaStream{in: []*DeltaChunk{{Path: "big.bin", BlockSize: 1024, BasisEtag: first.Etag, Ops: ops[:1]}, {Ops: ops[1:], Sha256: sum[:]}}}
+	if err := f.service.ApplyDelta(upload); err != nil || !upload.response.Success {
+		t.Fatalf("ApplyDelta: %v, %v", upload.response, err)
+	}
+	if upload.response.BytesWritten > 4*1024 {
+		t.Errorf("sent %d literal bytes for a small change", upload.response.BytesWritten)
+	}
+	if data, err := os.ReadFile(filepath.Join(f.base, "big.bin")); err != nil || !bytes.Equal(data, newData) {
+		t.Errorf("synced content differs: %v", err)
+	}
+	if list := versions(); len(list) != 1 || list[0].Reason != "sync" || list[0].Size != int64(len(oldData)) {
+		t.Errorf("versions after a sync = %v, want the basis", list)
+	}
+
+	// Download: the server sends the delta against the client's old copy
+	var sigs []*SignatureChunk
+	sigs = append(sigs, &SignatureChunk{Path: "big.bin", BlockSize: 2048})
+	delta.Sign(bytes.NewReader(oldData), 2048, func(block delta.Block) error {
+		sigs[0].Blocks = append(sigs[0].Blocks, &pb.BlockSignature{Weak: block.Weak, Strong: block.Strong})
+		return nil
+	})
+	download := getDeltaStream{&deltaStream{sigs: sigs}}
+	if err := f.service.GetDelta(download); err != nil {
+		t.Fatal(err)
+	}
+	var rebuilt bytes.Buffer
+	for _, chunk := range download.out {
+		for _, op := range chunk.Ops {
+			if err := delta.Apply(&rebuilt, bytes.NewReader(oldData), int64(len(oldData)), 2048, delta.Op{Index: op.BlockIndex, Count: op.BlockCount, Data: op.Data}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if last := download.out[len(download.out)-1]; !bytes.Equal(rebuilt.Bytes(), newData) || !bytes.Equal(last.Sha256, sum[:]) {
+		t.Errorf("downloaded delta does not rebuild the file")
+	}
+
+	// A missing file is created from literal data
+	create := &deltaStream{in: []*DeltaChunk{{Path: "new/file.bin", BlockSize: 1024, Ops: deltaOf(newData, 1024, nil), Sha256: sum[:]}}}
+	if err := f.service.ApplyDelta(create); err != nil || !create.response.Success {
+		t.Fatalf("ApplyDelta of a new file: %v, %v", create.response, err)
+	}
+
+	// Every delta must rebuild its file, whatever was changed
+	for i := 0; i < 200; i++ {
+		basis := make([]byte, rng.Intn(5000))
+		rng.Read(basis)
+		target := append([]byte{}, basis...)
+		for j := rng.Intn(4); j > 0 && len(target) > 0; j-- {
+			at := rng.Intn(len(target))
+			junk := make([]byte, rng.Intn(700))
+			rng.Read(junk)
+			target = append(append(append([]byte{}, target[:at]...), junk...), target[min(len(target), at+rng.Intn(700)):]...)
+		}
+		var blocks []delta.Block
+		delta.Sign(bytes.NewReader(basis), 512, func(block delta.Block) error {
+			blocks = append(blocks, block)
+			return nil
+		})
+		var out bytes.Buffer
+		err := delta.Diff(bytes.NewReader(target), 512, blocks, func(op delta.Op) error {
+			return delta.Apply(&out, bytes.NewReader(basis), int64(len(basis)), 512, op)
+		})
+		if err != nil || !bytes.Equal(out.Bytes(), target) {
+			t.Fatalf("round %d: delta does not rebuild the file: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// SetDenyList configures gitignore-style patterns (".env", ".git/",
+// "wp-config.php") for paths that are hidden from every client. Denied paths
+// are left out of listings and searches and behave as if they did not exist
+// for every other RPC. A path is denied when it or any of its parent
+// directories matches, and when resolving it follows a symlink to a denied
+// entry.
+func (s *FilesystemService) SetDenyList(patterns []string) error {
+	var rules []ignoreRule
+	for _, pattern := range patterns {
+		rule, ok, err := parseIgnoreRule(strings.TrimSpace(pattern), "")
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid deny pattern %q: %v", pattern, err)
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	s.denyRules = nil
+	s.addDenyRules(rules...)
+	return nil
+}
+
+// addDenyRules hides more paths, both by name and behind symlinks
+func (s *FilesystemService) addDenyRules(rules ...ignoreRule) {
+	s.denyRules = append(s.denyRules, rules...)
+	if len(s.denyRules) > 0 {
+		s.root.denied = s.deniedEntry
+	}
+}
+
+// deniedEntry reports whether a single entry matches the deny list. Walks use
+// it on every entry and prune denied directories, so parents need no check.
+func (s *FilesystemService) deniedEntry(relPath string, isDir bool) bool {
+	if len(s.denyRules) == 0 || relPath == "" {
+		return false
+	}
+	matched, negated := lastMatch(s.denyRules, relPath, isDir)
+	return matched && !negated
+}
+
+// isDenied reports whether a slash-separated path relative to the base
+// directory, or any of its parents, matches the deny list
+func (s *FilesystemService) isDenied(relPath string) bool {
+	if len(s.denyRules) == 0 {
+		return false
+	}
+
+	relPath = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(relPath)), "/")
+	if relPath == "" {
+		return false
+	}
+
+	segments := strings.Split(relPath, "/")
+	for i := 1; i < len(segments); i++ {
+		if s.deniedEntry(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+
+	// Directory-only rules need to know what the final entry is
+	asFile, asDir := s.deniedEntry(relPath, false), s.deniedEntry(relPath, true)
+	if asFile == asDir {
+		return asFile
+	}
+	info, err := s.root.Lstat(filepath.FromSlash(relPath))
+	return err == nil && info.IsDir() == asDir
+}
+
+// errDenied is returned for denied paths; it matches the error for missing files
+func errDenied() error {
+	return status.Errorf(codes.NotFound, "File or directory does not exist")
+}
+
+// UnaryServerInterceptor enforces the deny list for every unary RPC: requests
+// naming a denied path are rejected and denied entries are removed from
+// responses, so RPCs added later are covered without extra code.
+func (s *FilesystemService) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := s.checkRequestPaths(req); err != nil {
+			return nil, err
+		}
+		if err := s.checkLocks(ctx, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err == nil {
+			s.scrubDenied(resp)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func (s *FilesystemService) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		denyStream := &denyListStream{ServerStream: stream, service: s}
+		return handler(srv, &lockStream{ServerStream: denyStream, service: s, method: info.FullMethod})
+	}
+}
+
+// denyListStream checks every received message and filters every sent one
+type denyListStream struct {
+	grpc.ServerStream
+	service *FilesystemService
+}
+
+func (d *denyListStream) RecvMsg(m interface{}) error {
+	if err := d.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return d.service.checkRequestPaths(m)
+}
+
+func (d *denyListStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(proto.Message); ok && d.service.messageDenied(msg.ProtoReflect()) {
+		return nil // Drop denied entries silently
+	}
+	d.service.scrubDenied(m)
+	return d.ServerStream.SendMsg(m)
+}
+
+// isPathField reports whether a request field names a path. By convention
+// path fields are called "path", "source", "destination" or end in "_path".
+func isPathField(fd protoreflect.FieldDescriptor) bool {
+	if fd.Kind() != protoreflect.StringKind {
+		return false
+	}
+	name := string(fd.Name())
+	return name == "path" || name == "source" || name == "destination" || strings.HasSuffix(name, "_path")
+}
+
+// checkRequestPaths rejects requests whose path fields point at denied paths
+func (s *FilesystemService) checkRequestPaths(req interface{}) error {
+	msg, ok := req.(proto.Message)
+	if !ok || len(s.denyRules) == 0 {
+		return nil
+	}
+
+	var err error
+	msg.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if !isPathField(fd) {
+			return true
+		}
+		values := []string{}
+		if fd.IsList() {
+			for i := 0; i < v.List().Len(); i++ {
+				values = append(values, v.List().Get(i).String())
+			}
+		} else {
+			values = append(values, v.String())
+		}
+		for _, value := range values {
+			if s.isDenied(value) {
+				err = errDenied()
+				return false
+			}
+		}
+		return true
+	})
+	return err
+}
+
+// messageDenied reports whether a response message describes a denied path
+func (s *FilesystemService) messageDenied(msg protoreflect.Message) bool {
+	// Manifest entries are relative to the manifest's directory, and
+	// GenerateManifest leaves denied files out itself
+	if _, ok := msg.Interface().(*pb.ManifestEntry); ok {
+		return false
+	}
+
+	fd := msg.Descriptor().Fields().ByName("path")
+	if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+		return false
+	}
+	return s.isDenied(msg.Get(fd).String())
+}
+
+// scrubDenied removes denied entries from repeated message fields, recursively
+func (s *FilesystemService) scrubDenied(resp interface{}) {
+	msg, ok := resp.(proto.Message)
+	if !ok || len(s.denyRules) == 0 {
+		return
+	}
+	s.scrubMessage(msg.ProtoReflect())
+}
+
+func (s *FilesystemService) scrubMessage(msg protoreflect.Message) {
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap() || fd.Message() == nil:
+			// Scalars and maps carry no entries
+		case fd.IsList():
+			list := v.List()
+			kept := 0
+			for i := 0; i < list.Len(); i++ {
+				element := list.Get(i)
+				if s.messageDenied(element.Message()) {
+					continue
+				}
+				s.scrubMessage(element.Message())
+				list.Set(kept, element)
+				kept++
+			}
+			list.Truncate(kept)
+		default:
+			s.scrubMessage(v.Message())
+		}
+		return true
+	})
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const deniedContent = "denied secret"
+
+func TestDenyThroughSymlink(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		ctx := context.Background()
+		f.deny(t, ".env", ".git/")
+		f.write(t, ".env", deniedContent)
+		f.write(t, ".git/config", deniedContent)
+		f.write(t, "pub/page.txt", "public")
+		symlink(t, "../.env", f.path("pub/env"))
+		symlink(t, "../.git", f.path("pub/g"))
+		symlink(t, "g/config", f.path("pub/chained"))
+		symlink(t, "../.git/../pub", f.path("pub/detour"))
+
+		for _, path := range []string{"pub/env", "pub/g/config", "pub/chained", "pub/detour/page.txt"} {
+			if data, err := f.download(path); status.Code(err) != codes.NotFound {
+				t.Errorf("download %q: got %q, %v; want NotFound", path, data, err)
+			}
+		}
+		if _, err := f.service.ListDirectory(ctx, &ListRequest{Path: "pub/g"}); status.Code(err) != codes.NotFound {
+			t.Errorf("list through a link to a denied directory: got %v, want NotFound", err)
+		}
+		if info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: "pub/env"}); err == nil && info.Size == int64(len(deniedContent)) {
+			t.Errorf("file info through a link describes the denied file: %v", info)
+		}
+		if err := f.upload("pub/g/HEAD", "x"); err == nil {
+			t.Error("upload through a link to a denied directory succeeded")
+		}
+		if _, err := os.Stat(f.path(".git/HEAD")); err == nil {
+			t.Error("upload through a link created a file in the denied directory")
+		}
+
+		// A copy of the directory must not take the denied content along
+		if _, err := f.service.Copy(ctx, &CopyRequest{Source: "pub", Destination: "copy"}); err != nil {
+			t.Fatal(err)
+		}
+		err := filepath.WalkDir(f.path("copy"), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			if data, err := os.ReadFile(path); err != nil || string(data) == deniedContent {
+				t.Errorf("copy %s: %q, %v", path, data, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if data, err := f.download("pub/page.txt"); err != nil || data != "public" {
+			t.Errorf("download of a public file: %q, %v", data, err)
+		}
+
+		// Links that leave the base directory may come back into it
+		if err := f.service.SetSymlinkPolicy(string(SymlinkFollow)); err != nil {
+			t.Fatal(err)
+		}
+		symlink(t, f.path(".env"), f.path("pub/absolute"))
+		symlink(t, "../../html/.env", f.path("pub/around"))
+		for _, path := range []string{"pub/env", "pub/absolute", "pub/around"} {
+			if data, err := f.download(path); status.Code(err) != codes.NotFound {
+				t.Errorf("download %q under the follow policy: got %q, %v; want NotFound", path, data, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultDiffMaxSize is used when the request does not set max_size
+const defaultDiffMaxSize = 10 * 1024 * 1024
+
+// lineEdit is one step of an edit script turning lines a into lines b. Both
+// indexes are positions in their file: for a deleted line b is where the
+// next line of b is, and for an inserted line a is where the next line of a is.
+type lineEdit struct {
+	op   byte // ' ' keeps a line, '-' deletes one and '+' inserts one
+	a, b int
+}
+
+// DiffFiles implements the DiffFiles RPC method
+func (s *FilesystemService) DiffFiles(ctx context.Context, req *DiffFilesRequest) (*DiffFilesResponse, error) {
+	maxSize := req.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultDiffMaxSize
+	}
+
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	oldData, info, err := s.readDiffFile(validPath, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	newName, newData := validPath, req.Content
+	if req.OtherPath != "" {
+		if newName, err = s.validatePath(req.OtherPath); err != nil {
+			return nil, err
+		}
+		if newData, _, err = s.readDiffFile(newName, maxSize); err != nil {
+			return nil, err
+		}
+	} else if int64(len(newData)) > maxSize {
+		return nil, status.Errorf(codes.FailedPrecondition, "Content is larger than %d bytes", maxSize)
+	}
+
+	response := &DiffFilesResponse{Etag: etagOf(info)}
+	if bytes.Equal(oldData, newData) {
+		response.Identical = true
+		return response, nil
+	}
+	if isBinaryData(oldData) || isBinaryData(newData) {
+		response.Binary = true
+		return response, nil
+	}
+
+	a, b := splitLines(string(oldData)), splitLines(string(newData))
+	edits := diffLines(a, b, req.IgnoreWhitespace)
+	response.Diff = unifiedDiff("a/"+filepath.ToSlash(validPath), "b/"+filepath.ToSlash(newName), a, b, edits, int(req.ContextLines))
+	response.Identical = response.Diff == ""
+	return response, nil
+}
+
+// readDiffFile reads a regular file of at most maxSize bytes
+func (s *FilesystemService) readDiffFile(relPath string, maxSize int64) ([]byte, os.FileInfo, error) {
+	file, info, err := s.root.openRegular(relPath)
+	if err != nil {
+		return nil, nil, rootError(err, "Failed to open file")
+	}
+	defer file.Close()
+
+	if info.Size() > maxSize {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "File %s is larger than %d bytes", filepath.ToSlash(relPath), maxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Failed to read file: %v", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "File %s is larger than %d bytes", filepath.ToSlash(relPath), maxSize)
+	}
+	return data, info, nil
+}
+
+// isBinaryData reports whether data looks binary the way diff and git
+// decide it: a NUL byte near the start
+func isBinaryData(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// splitLines splits text after every newline, keeping the newlines
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns an edit script turning a into b. It is a patience diff:
+// lines that occur exactly once in both files anchor the script, the longest
+// run of anchors in the same order is kept, and the gaps between them are
+// diffed the same way. Small gaps without anchors fall back to a classic
+// longest common subsequence, larger ones are replaced as a whole.
+func diffLines(a, b []string, ignoreWhitespace bool) []lineEdit {
+	keyA, keyB := a, b
+	if ignoreWhitespace {
+		keyA, keyB = stripSpace(a), stripSpace(b)
+	}
+
+	edits := make([]lineEdit, 0, len(a)+len(b))
+	var walk func(aLo, aHi, bLo, bHi int)
+	walk = func(aLo, aHi, bLo, bHi int) {
+		for aLo < aHi && bLo < bHi && keyA[aLo] == keyB[bLo] {
+			edits = append(edits, lineEdit{' ', aLo, bLo})
+			aLo++
+			bLo++
+		}
+		suffix := 0
+		for aLo < aHi-suffix && bLo < bHi-suffix && keyA[aHi-1-suffix] == keyB[bHi-1-suffix] {
+			suffix++
+		}
+		aHi, bHi = aHi-suffix, bHi-suffix
+
+		if anchors := patienceAnchors(keyA[aLo:aHi], keyB[bLo:bHi]); len(anchors) > 0 {
+			a, b := aLo, bLo
+			for _, anchor := range anchors {
+				walk(a, aLo+anchor[0], b, bLo+anchor[1])
+				edits = append(edits, lineEdit{' ', aLo + anchor[0], bLo + anchor[1]})
+				a, b = aLo+anchor[0]+1, bLo+anchor[1]+1
+			}
+			walk(a, aHi, b, bHi)
+		} else {
+			edits = lcsEdits(edits, keyA, keyB, aLo, aHi, bLo, bHi)
+		}
+
+		for i := 0; i < suffix; i++ {
+			edits = append(edits, lineEdit{' ', aHi + i, bHi + i})
+		}
+	}
+	walk(0, len(a), 0, len(b))
+	return edits
+}
+
+// stripSpace returns the lines with all white space removed
+func stripSpace(lines []string) []string {
+	stripped := make([]string, len(lines))
+	for i, line := range lines {
+		stripped[i] = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+	return stripped
+}
+
+// patienceAnchors pairs the lines that are unique in both a and b, and
+// returns the longest sequence of pairs that is increasing in both
+func patienceAnchors(a, b []string) [][2]int {
+	type count struct{ a, b, bIndex int }
+	counts := make(map[string]*count, len(a))
+	for _, line := range a {
+		c := counts[line]
+		if c == nil {
+			c = &count{}
+			counts[line] = c
+		}
+		c.a++
+	}
+	for j, line := range b {
+		if c := counts[line]; c != nil {
+			c.b++
+			c.bIndex = j
+		}
+	}
+
+	var pairs [][2]int
+	for i, line := range a {
+		if c := counts[line]; c.a == 1 && c.b == 1 {
+			pairs = append(pairs, [2]int{i, c.bIndex})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	// Longest increasing subsequence of the b indexes by patience sorting
+	var tops []int // Index into pairs of the top card of every pile
+	previous := make([]int, len(pairs))
+	for i, pair := range pairs {
+		pile := sort.Search(len(tops), func(k int) bool { return pairs[tops[k]][1] > pair[1] })
+		previous[i] = -1
+		if pile > 0 {
+			previous[i] = tops[pile-1]
+		}
+		if pile == len(tops) {
+			tops = append(tops, i)
+		} else {
+			tops[pile] = i
+		}
+	}
+
+	anchors := make([][2]int, len(tops))
+	for i, k := len(tops)-1, tops[len(tops)-1]; i >= 0; i, k = i-1, previous[k] {
+		anchors[i] = pairs[k]
+	}
+	return anchors
+}
+
+// maxLCSCells bounds the table lcsEdits fills before giving up on finding
+// common lines
+const maxLCSCells = 1 << 16
+
+// lcsEdits appends an edit script for a[aLo:aHi] and b[bLo:bHi] that keeps
+// their longest common subsequence, or replaces all lines when that would
+// take too long to find
+func lcsEdits(edits []lineEdit, a, b []string, aLo, aHi, bLo, bHi int) []lineEdit {
+	n, m := aHi-aLo, bHi-bLo
+	if n == 0 || m == 0 || n*m > maxLCSCells {
+		for i := aLo; i < aHi; i++ {
+			edits = append(edits, lineEdit{'-', i, bLo})
+		}
+		for j := bLo; j < bHi; j++ {
+			edits = append(edits, lineEdit{'+', aHi, j})
+		}
+		return edits
+	}
+
+	// lengths[i][j] is the length of the LCS of a[aLo+i:aHi] and b[bLo+j:bHi]
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[aLo+i] == b[bLo+j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[aLo+i] == b[bLo+j]:
+			edits = append(edits, lineEdit{' ', aLo + i, bLo + j})
+			i++
+			j++
+		case j == m || (i < n && lengths[i+1][j] >= lengths[i][j+1]):
+			edits = append(edits, lineEdit{'-', aLo + i, bLo + j})
+			i++
+		default:
+			edits = append(edits, lineEdit{'+', aLo + i, bLo + j})
+			j++
+		}
+	}
+	return edits
+}
+
+// unifiedDiff formats an edit script as a unified diff with the given
+// number of unchanged lines around every change, or "" without changes
+func unifiedDiff(oldName, newName string, a, b []string, edits []lineEdit, context int) string {
+	if context < 0 {
+		context = 0
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// Changes separated by at most twice the context share a hunk
+		last := i
+		for j := i + 1; j < len(edits); j++ {
+			if edits[j].op != ' ' {
+				if j-last-1 > 2*context {
+					break
+				}
+				last = j
+			}
+		}
+		start, end := max(0, i-context), min(len(edits), last+1+context)
+		hunk := edits[start:end]
+		i = end
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		var oldCount, newCount int
+		for _, edit := range hunk {
+			if edit.op != '+' {
+				oldCount++
+			}
+			if edit.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(hunk[0].a, oldCount), hunkRange(hunk[0].b, newCount))
+
+		for _, edit := range hunk {
+			var line string
+			if edit.op == '+' {
+				line = b[edit.b]
+			} else {
+				line = a[edit.a]
+			}
+			out.WriteByte(edit.op)
+			out.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk like GNU diff: a single
+// line omits the length and an empty range names the line before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+func TestDiffAndPatch(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	oldContent := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\n"
+	newContent := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine"
+	f.write(t, "conf.txt", oldContent)
+
+	diff, err := f.service.DiffFiles(ctx, &DiffFilesRequest{Path: "conf.txt", Content: []byte(newContent), ContextLines: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a/conf.txt\n+++ b/conf.txt\n" +
+		"@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n" +
+		"@@ -8 +8,2 @@\n eight\n+nine\n\\ No newline at end of file\n"
+	if diff.Diff != want || diff.Identical || diff.Binary {
+		t.Errorf("DiffFiles = %q, want %q", diff.Diff, want)
+	}
+
+	spaced, err := f.service.DiffFiles(ctx, &DiffFilesRequest{Path: "conf.txt", Content: []byte(strings.ReplaceAll(oldContent, "\n", " \n")), IgnoreWhitespace: true})
+	if err != nil || !spaced.Identical {
+		t.Errorf("whitespace-only change = %v, %v, want identical", spaced, err)
+	}
+	binary, err := f.service.DiffFiles(ctx, &DiffFilesRequest{Path: "conf.txt", Content: []byte("\x00\x01")})
+	if err != nil || !binary.Binary {
+		t.Errorf("binary content = %v, %v, want binary", binary, err)
+	}
+	if _, err := f.service.DiffFiles(ctx, &DiffFilesRequest{Path: "conf.txt", Content: []byte(newContent), MaxSize: 4}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("size cap: got %v, want FailedPrecondition", err)
+	}
+
+	stale := &pb.WritePrecondition{Etag: `"stale"`}
+	if _, err := f.service.ApplyPatch(ctx, &ApplyPatchRequest{Path: "conf.txt", Patch: diff.Diff, Precondition: stale}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("stale ETag: got %v, want FailedPrecondition", err)
+	}
+	resp, err := f.service.ApplyPatch(ctx, &ApplyPatchRequest{Path: "conf.txt", Patch: diff.Diff, Precondition: &pb.WritePrecondition{Etag: diff.Etag}})
+	if err != nil || !resp.Success {
+		t.Fatalf("ApplyPatch: %v, %v", resp, err)
+	}
+	if data, err := f.download("conf.txt"); err != nil || data != newContent {
+		t.Errorf("patched content = %q, %v", data, err)
+	}
+	if _, err := f.service.ApplyPatch(ctx, &ApplyPatchRequest{Path: "conf.txt", Patch: diff.Diff}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("conflicting patch: got %v, want FailedPrecondition", err)
+	}
+	if data, _ := f.download("conf.txt"); data != newContent {
+		t.Errorf("content after a conflict = %q, want it unchanged", data)
+	}
+
+	// Every diff must apply to its old file and produce the new one
+	random := func() string {
+		var b strings.Builder
+		for i := rand.Intn(30); i > 0; i-- {
+			b.WriteString([]string{"a", "b", "c", "", "d"}[rand.Intn(5)] + "\n")
+		}
+		if rand.Intn(3) == 0 {
+			b.WriteString("end")
+		}
+		return b.String()
+	}
+	for i := 0; i < 500; i++ {
+		a, b := splitLines(random()), splitLines(random())
+		text := unifiedDiff("a", "b", a, b, diffLines(a, b, false), rand.Intn(4))
+		if text == "" {
+			if strings.Join(a, "") != strings.Join(b, "") {
+				t.Fatalf("no diff between %q and %q", a, b)
+			}
+			continue
+		}
+		hunks, err := parsePatch(text)
+		if err != nil {
+			t.Fatalf("parsePatch(%q): %v", text, err)
+		}
+		patched, err := applyHunks(a, hunks)
+		if err != nil || strings.Join(patched, "") != strings.Join(b, "") {
+			t.Fatalf("patching %q with %q = %q, %v, want %q", a, text, patched, err, b)
+		}
+	}
+}
+
+// Patches that wait for each other's lock apply to the file the previous one
+// put in place, so none of them is lost
+func TestConcurrentPatches(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "log.txt", "end\n")
+	ctx := context.Background()
+
+	const patches = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, patches)
+	for i := 0; i < patches; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			patch := fmt.Sprintf("@@ -0,0 +1 @@\n+line %d\n", i)
+			resp, err := f.service.ApplyPatch(ctx, &ApplyPatchRequest{Path: "log.txt", Patch: patch})
+			if err == nil && !resp.Success {
+				err = fmt.Errorf("%s", resp.Error)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("ApplyPatch: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(f.read(t, "log.txt"), "\n"), "\n")
+	if len(lines) != patches+1 || lines[patches] != "end" {
+		t.Errorf("content after %d patches has lines %q", patches, lines)
+	}
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"google.golang.org/grpc/status"
+)
+
+// partialHashSize is how much of the start of larger files is hashed to rule
+// out most files of the same size before any is read in full
+const partialHashSize = 64 * 1024
+
+// dupFile is a regular file found by findDuplicates
+type dupFile struct {
+	path string // Relative to the base directory
+	info os.FileInfo
+}
+
+// dupInode is a file with all the paths the scan found for it
+type dupInode struct {
+	files []*dupFile // Sorted by path
+	stat  statFields
+}
+
+// duplicateGroup is a set of distinct files with the same content
+type duplicateGroup struct {
+	size   int64
+	sum    string
+	inodes []*dupInode // Sorted by first path
+}
+
+func (g *duplicateGroup) proto() *DuplicateGroup {
+	group := &DuplicateGroup{
+		Size:        g.size,
+		Sha256:      g.sum,
+		Copies:      int32(len(g.inodes)),
+		WastedBytes: g.size * int64(len(g.inodes)-1),
+	}
+	for _, inode := range g.inodes {
+		for _, file := range inode.files {
+			group.Paths = append(group.Paths, filepath.ToSlash(file.path))
+		}
+	}
+	sort.Strings(group.Paths)
+	return group
+}
+
+// FindDuplicates implements the FindDuplicates RPC method (streaming to client)
+func (s *FilesystemService) FindDuplicates(req *DuplicatesRequest, stream FilesystemService_FindDuplicatesServer) error {
+	ctx := stream.Context()
+
+	validPath, rules, err := s.manifestDir(req.Path, req.Exclude)
+	if err != nil {
+		return err
+	}
+
+	err = s.findDuplicates(ctx, validPath, rules, req.MinSize, func(group *duplicateGroup) error {
+		return stream.Send(group.proto())
+	})
+	if err != nil {
+		return streamError(ctx.Err(), err, "Failed to find duplicates")
+	}
+	return nil
+}
+
+// DeduplicateWithHardlinks implements the DeduplicateWithHardlinks RPC method.
+// Of every group the file with the most links is kept, and the paths of the
+// others are replaced by hard links to it. Files that differ in mode, owner
+// or filesystem, changed since they were hashed or are under a lease are left
+// alone. The replaced paths take on the times and extended attributes of the
+// kept file.
+func (s *FilesystemService) DeduplicateWithHardlinks(ctx context.Context, req *DeduplicateRequest) (*DeduplicateResponse, error) {
+	validPath, rules, err := s.manifestDir(req.Path, req.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DeduplicateResponse{Skipped: []string{}}
+	err = s.findDuplicates(ctx, validPath, rules, req.MinSize, func(group *duplicateGroup) error {
+		response.Groups++
+		return s.linkDuplicates(ctx, group, req.DryRun, response)
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, status.FromContextError(ctxErr).Err()
+	}
+	if err != nil {
+		// Files linked before the failure stay linked, so report them
+		response.Message = "Failed to deduplicate files: " + err.Error()
+		return response, nil
+	}
+
+	response.Success = true
+	verb := "Linked"
+	if req.DryRun {
+		verb = "Would link"
+	}
+	response.Message = fmt.Sprintf("%s %d files in %d groups of duplicates, reclaiming %d bytes", verb, response.LinkedFiles, response.Groups, response.ReclaimedBytes)
+	return response, nil
+}
+
+// findDuplicates calls emit with every group of duplicates below root. Files
+// are grouped by size, then by a hash of their start and only then by a hash
+// of their whole content, so most files are never read. Groups of larger
+// files come first.
+func (s *FilesystemService) findDuplicates(ctx context.Context, root string, rules *pathRules, minSize int64, emit func(*duplicateGroup) error) error {
+	bySize := make(map[int64][]*dupFile)
+	err := s.walkTree(root, rules, func(rel string, entry *treeEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if size := entry.info.Size(); entry.info.Mode().IsRegular() && size > 0 && size >= minSize {
+			bySize[size] = append(bySize[size], &dupFile{path: entry.path, info: entry.info})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sizes := make([]int64, 0, len(bySize))
+	for size, files := range bySize {
+		if len(files) > 1 {
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] > sizes[j] })
+
+	for _, size := range sizes {
+		inodes := groupInodes(bySize[size])
+		delete(bySize, size)
+		if len(inodes) < 2 {
+			continue
+		}
+
+		candidates := map[string][]*dupInode{"": inodes}
+		if size > partialHashSize {
+			candidates, err = s.bucketInodes(ctx, inodes, func(inode *dupInode) (string, error) {
+				return s.partialSHA256(inode.files[0].path)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, partial := range sortedKeys(candidates) {
+			groups, err := s.bucketInodes(ctx, candidates[partial], func(inode *dupInode) (string, error) {
+				sums, info, _, err := s.fileChecksums(ctx, inode.files[0].path, []string{"sha256"}, false)
+				if err != nil {
+					return "", err
+				}
+				if info.Size() != size {
+					return "", os.ErrNotExist // Replaced since the directory was read
+				}
+				return sums["sha256"], nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, sum := range sortedKeys(groups) {
+				if err := emit(&duplicateGroup{size: size, sum: sum, inodes: groups[sum]}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// groupInodes collects the paths of files of the same size by inode, in path order
+func groupInodes(files []*dupFile) []*dupInode {
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+
+	type inodeKey struct{ device, inode uint64 }
+	byKey := make(map[inodeKey]*dupInode)
+	var inodes []*dupInode
+	for _, file := range files {
+		stat := statFieldsOf(file.info)
+		key := inodeKey{stat.device, stat.inode}
+		if inode, ok := byKey[key]; ok && stat.inode != 0 {
+			inode.files = append(inode.files, file)
+			continue
+		}
+		inode := &dupInode{files: []*dupFile{file}, stat: stat}
+		byKey[key] = inode
+		inodes = append(inodes, inode)
+	}
+	return inodes
+}
+
+// bucketInodes groups inodes by a key, keeping the groups with more than one
+// inode. Files that disappeared since the directory was read are dropped.
+func (s *FilesystemService) bucketInodes(ctx context.Context, inodes []*dupInode, key func(*dupInode) (string, error)) (map[string][]*dupInode, error) {
+	buckets := make(map[string][]*dupInode)
+	for _, inode := range inodes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		k, err := key(inode)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		buckets[k] = append(buckets[k], inode)
+	}
+	for k, bucket := range buckets {
+		if len(bucket) < 2 {
+			delete(buckets, k)
+		}
+	}
+	return buckets, nil
+}
+
+// sortedKeys returns the keys of buckets in order
+func sortedKeys(buckets map[string][]*dupInode) []string {
+	keys := make([]string, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// partialSHA256 hashes the start of a regular file
+func (s *FilesystemService) partialSHA256(relPath string) (string, error) {
+	file, _, err := s.root.openRegular(relPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, file, partialHashSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// linkDuplicates replaces the paths of a group by hard links to the file
+// with the most links, recording the outcome in response
+func (s *FilesystemService) linkDuplicates(ctx context.Context, group *duplicateGroup, dryRun bool, response *DeduplicateResponse) error {
+	keep := group.inodes[0]
+	for _, inode := range group.inodes[1:] {
+		if inode.stat.linkCount > keep.stat.linkCount {
+			keep = inode
+		}
+	}
+	keepPath := keep.files[0].path
+	if !s.unchanged(keep.files[0]) {
+		for _, inode := range group.inodes {
+			if inode != keep {
+				response.Skipped = append(response.Skipped, skippedPaths(inode, "kept copy "+filepath.ToSlash(keepPath)+" changed since it was hashed")...)
+			}
+		}
+		return nil
+	}
+
+	for _, inode := range group.inodes {
+		if inode == keep {
+			continue
+		}
+		switch {
+		case inode.stat.device != keep.stat.device:
+			response.Skipped = append(response.Skipped, skippedPaths(inode, "on another filesystem")...)
+			continue
+		case syscallMode(inode.files[0].info.Mode()) != syscallMode(keep.files[0].info.Mode()):
+			response.Skipped = append(response.Skipped, skippedPaths(inode, "mode differs")...)
+			continue
+		case inode.stat.uid != keep.stat.uid || inode.stat.gid != keep.stat.gid:
+			response.Skipped = append(response.Skipped, skippedPaths(inode, "owner differs")...)
+			continue
+		}
+
+		linked := uint64(0)
+		for _, file := range inode.files {
+			slashPath := filepath.ToSlash(file.path)
+			if err := s.checkPathLocks(ctx, file.path); err != nil {
+				response.Skipped = append(response.Skipped, slashPath+": locked")
+				continue
+			}
+			if !s.unchanged(file) {
+				response.Skipped = append(response.Skipped, slashPath+": changed since it was hashed")
+				continue
+			}
+			if !dryRun {
+				if err := s.replaceWithLink(keepPath, file.path); err != nil {
+					response.Skipped = append(response.Skipped, fmt.Sprintf("%s: %v", slashPath, err))
+					continue
+				}
+			}
+			linked++
+			response.LinkedFiles++
+		}
+		// The file's data is only freed once no path refers to it
+		if linked == inode.stat.linkCount {
+			response.ReclaimedBytes += group.size
+		}
+	}
+	return nil
+}
+
+// unchanged reports whether a file still has the ETag it had in the scan
+func (s *FilesystemService) unchanged(file *dupFile) bool {
+	info, err := s.root.Lstat(file.path)
+	return err == nil && etagOf(info) == etagOf(file.info)
+}
+
+// replaceWithLink atomically replaces name with a hard link to target
+func (s *FilesystemService) replaceWithLink(target, name string) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	tmpName := filepath.Join(filepath.Dir(name), ".dedup-"+hex.EncodeToString(suffix))
+	if err := s.root.Link(target, tmpName); err != nil {
+		return err
+	}
+	if err := s.root.Rename(tmpName, name); err != nil {
+		s.root.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// skippedPaths formats the paths of a file that is left alone
+func skippedPaths(inode *dupInode, reason string) []string {
+	skipped := make([]string, 0, len(inode.files))
+	for _, file := range inode.files {
+		skipped = append(skipped, filepath.ToSlash(file.path)+": "+reason)
+	}
+	return skipped
+}
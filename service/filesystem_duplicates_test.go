@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// duplicatesStream collects the groups FindDuplicates sends
+type duplicatesStream struct {
+	grpc.ServerStream
+	groups []string
+}
+
+func (d *duplicatesStream) Context() context.Context { return context.Background() }
+
+func (d *duplicatesStream) Send(group *DuplicateGroup) error {
+	d.groups = append(d.groups, fmt.Sprintf("%s %d/%d", strings.Join(group.Paths, "+"), group.Copies, group.WastedBytes))
+	return nil
+}
+
+func TestDuplicates(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	f.deny(t, ".env")
+	media := filepath.Join(f.base, "media")
+	big := strings.Repeat("0123456789", 10000)
+	writeTestFile(t, filepath.Join(media, "big1"), big)
+	writeTestFile(t, filepath.Join(media, "up", "big2"), big)
+	writeTestFile(t, filepath.Join(media, "big3"), big[:len(big)-1]+"x") // Same start
+	writeTestFile(t, filepath.Join(media, "s1"), "hello")
+	writeTestFile(t, filepath.Join(media, "s2"), "hello")
+	writeTestFile(t, filepath.Join(media, "s3"), "world")
+	writeTestFile(t, filepath.Join(media, "private"), "hello")
+	writeTestFile(t, filepath.Join(media, ".env"), "hello")
+	writeTestFile(t, filepath.Join(media, "empty1"), "")
+	writeTestFile(t, filepath.Join(media, "empty2"), "")
+	if err := os.Link(filepath.Join(media, "s1"), filepath.Join(media, "s1link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(media, "private"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(minSize int64) string {
+		t.Helper()
+		stream := &duplicatesStream{}
+		if err := f.service.FindDuplicates(&DuplicatesRequest{Path: "media", MinSize: minSize}, stream); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(stream.groups, ",")
+	}
+	want := "media/big1+media/up/big2 2/100000,media/private+media/s1+media/s1link+media/s2 3/10"
+	if got := find(0); got != want {
+		t.Errorf("duplicates = %s, want %s", got, want)
+	}
+	if got := find(10); got != "media/big1+media/up/big2 2/100000" {
+		t.Errorf("duplicates of at least 10 bytes = %s", got)
+	}
+
+	inode := func(name string) uint64 {
+		t.Helper()
+		info, err := os.Stat(filepath.Join(media, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return statFieldsOf(info).inode
+	}
+
+	dryRun, err := f.service.DeduplicateWithHardlinks(ctx, &DeduplicateRequest{Path: "media", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dryRun.Success || dryRun.Groups != 2 || dryRun.LinkedFiles != 2 || dryRun.ReclaimedBytes != 100005 {
+		t.Errorf("dry run = %v", dryRun)
+	}
+	if strings.Join(dryRun.Skipped, ",") != "media/private: mode differs" {
+		t.Errorf("dry run skipped %v", dryRun.Skipped)
+	}
+	if inode("big1") == inode("up/big2") || inode("s1") == inode("s2") {
+		t.Error("dry run linked files")
+	}
+
+	response, err := f.service.DeduplicateWithHardlinks(ctx, &DeduplicateRequest{Path: "media"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Success || response.LinkedFiles != 2 || response.ReclaimedBytes != 100005 {
+		t.Errorf("deduplication = %v", response)
+	}
+	if inode("big1") != inode("up/big2") || inode("s1") != inode("s2") || inode("s1") == inode("private") {
+		t.Error("duplicates were not linked as expected")
+	}
+	if data, err := os.ReadFile(filepath.Join(media, "up", "big2")); err != nil || string(data) != big {
+		t.Errorf("linked file has wrong content: %v", err)
+	}
+	if got := find(0); got != "media/private+media/s1+media/s1link+media/s2 2/5" {
+		t.Errorf("duplicates after deduplication = %s", got)
+	}
+
+	if _, err := f.service.DeduplicateWithHardlinks(ctx, &DeduplicateRequest{Path: "media/s1"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("deduplicating a file: got %v, want InvalidArgument", err)
+	}
+}
+
+// Uploads and in-place edits of a deduplicated path leave the paths it was linked with alone
+func TestWritesToDeduplicatedPaths(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		f.write(t, "shared/"+name, "shared content\n")
+		if err := os.Chmod(f.path("shared/"+name), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if response, err := f.service.DeduplicateWithHardlinks(ctx, &DeduplicateRequest{Path: "shared"}); err != nil || response.LinkedFiles != 2 {
+		t.Fatalf("deduplication = %v, %v", response, err)
+	}
+
+	if err := f.upload("shared/a.txt", "uploaded\n"); err != nil {
+		t.Fatal(err)
+	}
+	if response, err := f.service.WriteFile(ctx, &WriteRequest{Path: "shared/b.txt", Data: []byte("SHARED")}); err != nil || !response.Success {
+		t.Fatalf("WriteFile = %v, %v", response, err)
+	}
+	for name, want := range map[string]string{"a.txt": "uploaded\n", "b.txt": "SHARED content\n", "c.txt": "shared content\n"} {
+		if got := f.read(t, "shared/"+name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+		info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: "shared/" + name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.LinkCount != 1 || info.Permissions != "640" {
+			t.Errorf("%s has %d links and mode %s, want 1 and 640", name, info.LinkCount, info.Permissions)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// etagOf derives an ETag from the device, inode, size, mtime and ctime of a
+// file. Any write changes mtime and ctime, and a replaced file has a new inode.
+func etagOf(info os.FileInfo) string {
+	var fields [6]uint64
+	fields[2] = uint64(info.Size())
+	fields[3] = uint64(info.ModTime().UnixNano())
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		fields[0] = uint64(stat.Dev)
+		fields[1] = stat.Ino
+		fields[4] = uint64(stat.Ctim.Sec)
+		fields[5] = uint64(stat.Ctim.Nsec)
+	}
+
+	hash := fnv.New64a()
+	for _, field := range fields {
+		binary.Write(hash, binary.LittleEndian, field)
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// etagMatches reports whether etag is in a comma separated list, or the list is "*"
+func etagMatches(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		// Accept HTTP style quoted and weak ETags as well
+		candidate = strings.Trim(strings.TrimPrefix(strings.TrimSpace(candidate), "W/"), `"`)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkETag enforces if_match and if_none_match preconditions. info describes
+// the path's current state and is nil when nothing exists there.
+func checkETag(ifMatch, ifNoneMatch string, info os.FileInfo) error {
+	if ifMatch != "" {
+		if info == nil {
+			return status.Errorf(codes.FailedPrecondition, "Precondition failed: file does not exist")
+		}
+		if etag := etagOf(info); !etagMatches(ifMatch, etag) {
+			return status.Errorf(codes.FailedPrecondition, "Precondition failed: file has changed (ETag is %s)", etag)
+		}
+	}
+	if ifNoneMatch != "" && info != nil {
+		if strings.TrimSpace(ifNoneMatch) == "*" {
+			return status.Errorf(codes.FailedPrecondition, "Precondition failed: file already exists")
+		}
+		if etag := etagOf(info); etagMatches(ifNoneMatch, etag) {
+			return status.Errorf(codes.FailedPrecondition, "Precondition failed: ETag %s matches", etag)
+		}
+	}
+	return nil
+}
+
+// checkPathETag checks preconditions against a path as GetFileInfo describes
+// it, i.e. a symlink by its target when the policy follows it
+func (s *FilesystemService) checkPathETag(relPath, ifMatch, ifNoneMatch string) error {
+	if ifMatch == "" && ifNoneMatch == "" {
+		return nil
+	}
+
+	linkInfo, err := s.root.Lstat(relPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return checkETag(ifMatch, ifNoneMatch, nil)
+		}
+		return rootError(err, "Failed to check precondition")
+	}
+	info, _ := s.root.resolveLink(relPath, linkInfo)
+	return checkETag(ifMatch, ifNoneMatch, info)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Two editors save the same file; the one with the stale ETag must fail
+func TestETagPreconditions(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "page.txt", "original")
+	ctx := context.Background()
+
+	info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: "page.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	download := &downloadStream{}
+	if err := f.service.DownloadFile(&FileRequest{Path: "page.txt"}, download); err != nil || download.etag != info.Etag {
+		t.Fatalf("download ETag %q, %v; GetFileInfo reported %q", download.etag, err, info.Etag)
+	}
+
+	first := &uploadStream{chunks: []*FileChunk{&FileChunk{FilePath: "page.txt", Content: []byte("first"), IsLast: true, IfMatch: info.Etag}}}
+	if err := f.service.UploadFile(first); err != nil {
+		t.Fatal(err)
+	}
+	if first.response.Etag == "" || first.response.Etag == info.Etag {
+		t.Fatalf("upload returned ETag %q, old one was %q", first.response.Etag, info.Etag)
+	}
+
+	second := &uploadStream{chunks: []*FileChunk{&FileChunk{FilePath: "page.txt", Content: []byte("second"), IsLast: true, IfMatch: info.Etag}}}
+	if err := f.service.UploadFile(second); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("upload with a stale ETag: got %v, want FailedPrecondition", err)
+	}
+	createOnly := &uploadStream{chunks: []*FileChunk{&FileChunk{FilePath: "page.txt", Content: []byte("new"), IsLast: true, IfNoneMatch: "*"}}}
+	if err := f.service.UploadFile(createOnly); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("create-only upload over an existing file: got %v, want FailedPrecondition", err)
+	}
+	if _, err := f.service.Delete(ctx, &DeleteRequest{Path: "page.txt", IfMatch: info.Etag}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("delete with a stale ETag: got %v, want FailedPrecondition", err)
+	}
+	if _, err := f.service.Copy(ctx, &CopyRequest{Source: "page.txt", Destination: "page.txt.bak", Overwrite: true, IfMatch: "*"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("copy onto a missing file with if_match: got %v, want FailedPrecondition", err)
+	}
+
+	if data, err := f.download("page.txt"); err != nil || data != "first" {
+		t.Errorf("content = %q, %v; want the first save", data, err)
+	}
+	if resp, err := f.service.Delete(ctx, &DeleteRequest{Path: "page.txt", IfMatch: `"` + first.response.Etag + `"`}); err != nil || !resp.Success {
+		t.Errorf("delete with the current ETag: %v, %v", resp, err)
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// errNotRegular is returned when file content is requested from a special file
+var errNotRegular = errors.New("not a regular file")
+
+// fileTypeOf maps a file mode to the protobuf FileType
+func fileTypeOf(mode os.FileMode) pb.FileType {
+	switch {
+	case mode.IsRegular():
+		return pb.FileType_FILE_TYPE_REGULAR
+	case mode.IsDir():
+		return pb.FileType_FILE_TYPE_DIRECTORY
+	case mode&os.ModeSymlink != 0:
+		return pb.FileType_FILE_TYPE_SYMLINK
+	case mode&os.ModeNamedPipe != 0:
+		return pb.FileType_FILE_TYPE_FIFO
+	case mode&os.ModeSocket != 0:
+		return pb.FileType_FILE_TYPE_SOCKET
+	case mode&os.ModeCharDevice != 0:
+		return pb.FileType_FILE_TYPE_CHAR_DEVICE
+	case mode&os.ModeDevice != 0:
+		return pb.FileType_FILE_TYPE_BLOCK_DEVICE
+	default:
+		return pb.FileType_FILE_TYPE_UNKNOWN
+	}
+}
+
+// fileTypeName returns a human readable name for error messages
+func fileTypeName(mode os.FileMode) string {
+	switch fileTypeOf(mode) {
+	case pb.FileType_FILE_TYPE_REGULAR:
+		return "regular file"
+	case pb.FileType_FILE_TYPE_DIRECTORY:
+		return "directory"
+	case pb.FileType_FILE_TYPE_SYMLINK:
+		return "symlink"
+	case pb.FileType_FILE_TYPE_FIFO:
+		return "FIFO"
+	case pb.FileType_FILE_TYPE_SOCKET:
+		return "socket"
+	case pb.FileType_FILE_TYPE_CHAR_DEVICE:
+		return "character device"
+	case pb.FileType_FILE_TYPE_BLOCK_DEVICE:
+		return "block device"
+	default:
+		return "special file"
+	}
+}
+
+// modeString formats a file mode the way ls does, e.g. "drwxr-sr-x" or "-rwsr-xr-t"
+func modeString(mode os.FileMode) string {
+	buf := []byte("----------")
+
+	switch fileTypeOf(mode) {
+	case pb.FileType_FILE_TYPE_DIRECTORY:
+		buf[0] = 'd'
+	case pb.FileType_FILE_TYPE_SYMLINK:
+		buf[0] = 'l'
+	case pb.FileType_FILE_TYPE_FIFO:
+		buf[0] = 'p'
+	case pb.FileType_FILE_TYPE_SOCKET:
+		buf[0] = 's'
+	case pb.FileType_FILE_TYPE_CHAR_DEVICE:
+		buf[0] = 'c'
+	case pb.FileType_FILE_TYPE_BLOCK_DEVICE:
+		buf[0] = 'b'
+	}
+
+	const rwx = "rwxrwxrwx"
+	perm := mode.Perm()
+	for i := 0; i < 9; i++ {
+		if perm&(1<<uint(8-i)) != 0 {
+			buf[i+1] = rwx[i]
+		}
+	}
+
+	// Special bits replace the execute bit: lower case when it is also set
+	special := func(pos int, set bool, char byte) {
+		if !set {
+			return
+		}
+		if buf[pos] == '-' {
+			buf[pos] = char - 'a' + 'A'
+		} else {
+			buf[pos] = char
+		}
+	}
+	special(3, mode&os.ModeSetuid != 0, 's')
+	special(6, mode&os.ModeSetgid != 0, 's')
+	special(9, mode&os.ModeSticky != 0, 't')
+
+	return string(buf)
+}
+
+// statFields holds the inode details reported in FileItem and FileInfo
+type statFields struct {
+	inode     uint64
+	device    uint64
+	linkCount uint64
+	blocks    int64
+	uid       uint32
+	gid       uint32
+}
+
+// statFieldsOf extracts inode details where the platform provides them
+func statFieldsOf(info os.FileInfo) statFields {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return statFields{}
+	}
+	return statFields{
+		inode:     stat.Ino,
+		device:    uint64(stat.Dev),
+		linkCount: uint64(stat.Nlink),
+		blocks:    stat.Blocks,
+		uid:       stat.Uid,
+		gid:       stat.Gid,
+	}
+}
+
+// openRegular opens a regular file for reading. O_NONBLOCK keeps the open
+// from hanging on a FIFO that was swapped in after an earlier check.
+func (r *rootFS) openRegular(name string) (*os.File, os.FileInfo, error) {
+	file, err := r.OpenFile(name, os.O_RDONLY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, nil, &os.PathError{Op: "open", Path: name, Err: errNotRegular}
+	}
+	return file, info, nil
+}
+
+// openWritable opens a regular file for writing without blocking on, or
+// writing into, a FIFO or device that exists at name
+func (r *rootFS) openWritable(name string, flag int, perm os.FileMode) (*os.File, os.FileInfo, error) {
+	file, err := r.OpenFile(name, os.O_WRONLY|flag|unix.O_NONBLOCK, perm)
+	if err != nil {
+		// A FIFO without a reader fails with ENXIO instead of blocking
+		if errors.Is(err, unix.ENXIO) {
+			return nil, nil, &os.PathError{Op: "open", Path: name, Err: errNotRegular}
+		}
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err == nil && !info.Mode().IsRegular() {
+		err = &os.PathError{Op: "open", Path: name, Err: errNotRegular}
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// createRegular creates or truncates a regular file for writing
+func (r *rootFS) createRegular(name string, perm os.FileMode) (*os.File, error) {
+	file, _, err := r.openWritable(name, os.O_CREATE, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	// Truncate only once the file is known to be regular
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
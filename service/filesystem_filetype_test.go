@@ -0,0 +1,26 @@
+package service
+
+import (
+	"os"
+	"testing"
+)
+
+func TestModeString(t *testing.T) {
+	for mode, want := range map[os.FileMode]string{
+		0644:                              "-rw-r--r--",
+		os.ModeDir | 0755:                 "drwxr-xr-x",
+		os.ModeSymlink | 0777:             "lrwxrwxrwx",
+		os.ModeSetuid | 0755:              "-rwsr-xr-x",
+		os.ModeSetuid | 0644:              "-rwSr--r--",
+		os.ModeDir | os.ModeSetgid | 0750: "drwxr-s---",
+		os.ModeDir | os.ModeSticky | 0777: "drwxrwxrwt",
+		os.ModeDir | os.ModeSticky | 0770: "drwxrwx--T",
+		os.ModeDevice | os.ModeCharDevice: "c---------",
+		os.ModeDevice | 0660:              "brw-rw----",
+		os.ModeSocket | 0755:              "srwxr-xr-x",
+	} {
+		if got := modeString(mode); got != want {
+			t.Errorf("modeString(%v) = %q, want %q", mode, got, want)
+		}
+	}
+}
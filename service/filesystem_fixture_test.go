@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+// testFixture is a service with default options on an empty base directory
+type testFixture struct {
+	service *FilesystemService
+	base    string
+}
+
+func newTestFixture(t *testing.T) *testFixture {
+	return newTestFixtureAt(t, filepath.Join(t.TempDir(), "base"))
+}
+
+// newTestFixtureAt serves base, creating it if it does not exist
+func newTestFixtureAt(t *testing.T, base string) *testFixture {
+	t.Helper()
+	if err := os.MkdirAll(base, 0755); err != nil {
+		t.Fatal(err)
+	}
+	service, err := NewFilesystemService(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { service.Close() })
+	return &testFixture{service: service, base: base}
+}
+
+// deny hides paths from the service as the daemon's deny list does
+func (f *testFixture) deny(t *testing.T, patterns ...string) {
+	t.Helper()
+	if err := f.service.SetDenyList(patterns); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// write creates a file below the base directory, with its parent directories
+func (f *testFixture) write(t *testing.T, relPath, content string) {
+	t.Helper()
+	writeTestFile(t, f.path(relPath), content)
+}
+
+// read returns the content of a file below the base directory
+func (f *testFixture) read(t *testing.T, relPath string) string {
+	t.Helper()
+	data, err := os.ReadFile(f.path(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// versionDir is a version store next to the base directory, on the same file system
+func (f *testFixture) versionDir() string {
+	return filepath.Join(filepath.Dir(f.base), "versions")
+}
+
+// path is the absolute path of a slash-separated path below the base directory
+func (f *testFixture) path(relPath string) string {
+	return filepath.Join(f.base, filepath.FromSlash(relPath))
+}
+
+func (f *testFixture) download(path string) (string, error) {
+	stream := &downloadStream{}
+	err := f.service.DownloadFile(&FileRequest{Path: path}, stream)
+	return stream.data.String(), err
+}
+
+func (f *testFixture) upload(path, content string) error {
+	return f.service.UploadFile(&uploadStream{chunks: []*FileChunk{&FileChunk{FilePath: path, Content: []byte(content), IsLast: true}}})
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// downloadStream collects the chunks sent by DownloadFile
+type downloadStream struct {
+	grpc.ServerStream
+	data bytes.Buffer
+	etag string
+}
+
+func (d *downloadStream) Context() context.Context { return context.Background() }
+
+func (d *downloadStream) Send(chunk *FileChunk) error {
+	d.data.Write(chunk.Content)
+	d.etag = chunk.Etag
+	return nil
+}
+
+// uploadStream feeds chunks to UploadFile
+type uploadStream struct {
+	grpc.ServerStream
+	chunks   []*FileChunk
+	response *OperationResponse
+}
+
+func (u *uploadStream) Context() context.Context { return context.Background() }
+
+func (u *uploadStream) Recv() (*FileChunk, error) {
+	if len(u.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := u.chunks[0]
+	u.chunks = u.chunks[1:]
+	return chunk, nil
+}
+
+func (u *uploadStream) SendAndClose(response *OperationResponse) error {
+	u.response = response
+	return nil
+}
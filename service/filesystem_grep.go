@@ -0,0 +1,289 @@
+package service
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultGrepMaxFileSize is used when the request does not set max_file_size
+	defaultGrepMaxFileSize = 10 * 1024 * 1024
+
+	// maxGrepLineLength is the longest line that is searched; longer lines
+	// are skipped
+	maxGrepLineLength = 1024 * 1024
+)
+
+// GrepFiles implements the GrepFiles RPC method (streaming to client)
+func (s *FilesystemService) GrepFiles(req *GrepRequest, stream FilesystemService_GrepFilesServer) error {
+	if req.Query == "" {
+		return status.Errorf(codes.InvalidArgument, "Query is required")
+	}
+
+	validPath, err := s.validatePath(req.BasePath)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.root.Stat(validPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return status.Errorf(codes.NotFound, "Base path does not exist")
+		}
+		return rootError(err, "Failed to access base path")
+	}
+
+	matcher, err := newGrepMatcher(req)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid query: %v", err)
+	}
+
+	for _, pattern := range append(append([]string{}, req.Include...), req.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid pattern %q: %v", pattern, err)
+		}
+	}
+
+	maxFileSize := req.MaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = defaultGrepMaxFileSize
+	}
+
+	ctx := stream.Context()
+	var sent int32
+
+	grepOne := func(path string, info os.FileInfo) error {
+		if !info.Mode().IsRegular() || info.Size() > maxFileSize {
+			return nil
+		}
+		if !matchesAnyGlob(req.Include, info.Name(), true) || matchesAnyGlob(req.Exclude, info.Name(), false) {
+			return nil
+		}
+
+		file, _, err := s.root.openRegular(path)
+		if err != nil {
+			return nil // Skip unreadable files
+		}
+		defer file.Close()
+
+		return grepFile(file, filepath.FromSlash(path), req, matcher, func(match *GrepMatch) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := stream.Send(match); err != nil {
+				return err
+			}
+			sent++
+			if req.MaxMatches > 0 && sent >= req.MaxMatches {
+				return errStopWalk
+			}
+			return nil
+		})
+	}
+
+	// A single file can be grepped directly
+	if !info.IsDir() {
+		err = grepOne(validPath, info)
+	} else {
+		err = s.root.WalkDir(validPath, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip entries with errors
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+
+			// Denied paths are invisible to content searches too
+			if s.deniedEntry(slashRel(path), entry.IsDir()) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if entry.IsDir() {
+				if path == filepath.ToSlash(validPath) {
+					return nil
+				}
+				if !req.Recursive || matchesAnyGlob(req.Exclude, entry.Name(), false) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			return grepOne(path, info)
+		})
+	}
+
+	switch {
+	case err == nil, err == errStopWalk:
+		return nil
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	default:
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.Internal, "Grep failed: %v", err)
+	}
+}
+
+// grepMatcher returns the byte offset of the first match in a line, or -1
+type grepMatcher func(line string) int
+
+// newGrepMatcher builds a matcher for the request query
+func newGrepMatcher(req *GrepRequest) (grepMatcher, error) {
+	expr := req.Query
+	if !req.Regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if !req.CaseSensitive {
+		expr = "(?i)" + expr
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(line string) int {
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			return -1
+		}
+		return loc[0]
+	}, nil
+}
+
+// matchesAnyGlob reports whether name matches one of the patterns,
+// returning emptyResult when there are no patterns
+func matchesAnyGlob(patterns []string, name string, emptyResult bool) bool {
+	if len(patterns) == 0 {
+		return emptyResult
+	}
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// grepFile scans a single open file and reports every matching line to emit
+func grepFile(file *os.File, relPath string, req *GrepRequest, matcher grepMatcher, emit func(*GrepMatch) error) error {
+	if !req.IncludeBinary {
+		mimeType, err := detectMimeType(file)
+		if err != nil || !isTextMimeType(mimeType) {
+			return nil
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil
+		}
+	}
+
+	contextLines := int(req.ContextLines)
+	if contextLines < 0 {
+		contextLines = 0
+	}
+
+	var (
+		before  []string     // Most recent lines, used as leading context
+		pending []*GrepMatch // Matches still collecting trailing context
+	)
+
+	// flush sends every pending match whose trailing context is complete
+	flush := func(force bool) error {
+		for len(pending) > 0 && (force || len(pending[0].AfterContext) >= contextLines) {
+			if err := emit(pending[0]); err != nil {
+				return err
+			}
+			pending = pending[1:]
+		}
+		return nil
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+
+	var lineNumber int64
+	for {
+		line, long, err := readGrepLine(reader)
+		if err != nil {
+			break // End of file; a read error keeps what was found
+		}
+		lineNumber++
+		if long {
+			continue // Counted, but neither searched nor used as context
+		}
+
+		for _, match := range pending {
+			if len(match.AfterContext) < contextLines {
+				match.AfterContext = append(match.AfterContext, line)
+			}
+		}
+		if err := flush(false); err != nil {
+			return err
+		}
+
+		if column := matcher(line); column >= 0 {
+			pending = append(pending, &GrepMatch{
+				Path:          relPath,
+				LineNumber:    lineNumber,
+				Column:        int32(column + 1),
+				Line:          line,
+				BeforeContext: append([]string(nil), before...),
+			})
+			if err := flush(false); err != nil {
+				return err
+			}
+		}
+
+		if contextLines > 0 {
+			before = append(before, line)
+			if len(before) > contextLines {
+				before = before[1:]
+			}
+		}
+	}
+
+	return flush(true)
+}
+
+// readGrepLine reads the next line without its line ending. A line longer
+// than maxGrepLineLength is read to its end and reported as long instead.
+func readGrepLine(reader *bufio.Reader) (line string, long bool, err error) {
+	var buf []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !long && len(buf)+len(chunk) > maxGrepLineLength+1 {
+			long, buf = true, nil
+		}
+		if !long {
+			buf = append(buf, chunk...)
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && (len(buf) > 0 || long):
+			// The last line has no newline
+		case err != nil:
+			return "", false, err
+		}
+		if long {
+			return "", true, nil
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(string(buf), "\n"), "\r"), false, nil
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grepStream collects the matches GrepFiles sends
+type grepStream struct {
+	grpc.ServerStream
+	matches []*GrepMatch
+}
+
+func (g *grepStream) Context() context.Context { return context.Background() }
+
+func (g *grepStream) Send(match *GrepMatch) error {
+	g.matches = append(g.matches, match)
+	return nil
+}
+
+func TestGrepFiles(t *testing.T) {
+	f := newTestFixture(t)
+	f.deny(t, ".env")
+	f.write(t, "src/main.go", "package main\n\nfunc main() {\n\tprintln(\"Hello\")\n}\n")
+	f.write(t, "src/util.go", "package main\n\n// TODO: hello again\nfunc helper() {}\n")
+	f.write(t, "src/deep/notes.txt", "hello from below\r\nsecond line\r\n")
+	f.write(t, "src/vendor/lib.go", "// hello vendored\n")
+	f.write(t, "src/image.bin", "hello\x00\x01\x02\x03binary")
+	f.write(t, "src/.env", "HELLO=secret\n")
+
+	grep := func(req *GrepRequest) []string {
+		t.Helper()
+		if req.BasePath == "" {
+			req.BasePath = "src"
+		}
+		stream := &grepStream{}
+		if err := f.service.GrepFiles(req, stream); err != nil {
+			t.Fatalf("GrepFiles(%v): %v", req, err)
+		}
+		var matches []string
+		for _, match := range stream.matches {
+			matches = append(matches, fmt.Sprintf("%s:%d:%d", match.Path, match.LineNumber, match.Column))
+		}
+		return matches
+	}
+	check := func(name string, got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	// Literal queries ignore case unless asked not to; denied and binary files are skipped
+	check("literal", grep(&GrepRequest{Query: "hello", Recursive: true}),
+		"src/deep/notes.txt:1:1", "src/main.go:4:11", "src/util.go:3:10", "src/vendor/lib.go:1:4")
+	check("case sensitive", grep(&GrepRequest{Query: "Hello", CaseSensitive: true, Recursive: true}),
+		"src/main.go:4:11")
+	check("fixed string", grep(&GrepRequest{Query: "main()", Recursive: true}),
+		"src/main.go:3:6")
+	check("regex", grep(&GrepRequest{Query: `^func \w+\(\)`, Regex: true, Recursive: true}),
+		"src/main.go:3:1", "src/util.go:4:1")
+	check("not recursive", grep(&GrepRequest{Query: "hello"}),
+		"src/main.go:4:11", "src/util.go:3:10")
+	check("single file", grep(&GrepRequest{BasePath: "src/util.go", Query: "hello"}),
+		"src/util.go:3:10")
+
+	// Binary files are only searched on request
+	check("binary", grep(&GrepRequest{Query: "binary", Recursive: true}))
+	check("include binary", grep(&GrepRequest{Query: "binary", Recursive: true, IncludeBinary: true}),
+		"src/image.bin:1:10")
+
+	check("include", grep(&GrepRequest{Query: "hello", Recursive: true, Include: []string{"*.txt"}}),
+		"src/deep/notes.txt:1:1")
+	check("exclude", grep(&GrepRequest{Query: "hello", Recursive: true, Exclude: []string{"vendor", "*.txt"}}),
+		"src/main.go:4:11", "src/util.go:3:10")
+	check("max file size", grep(&GrepRequest{Query: "hello", Recursive: true, MaxFileSize: 31}),
+		"src/deep/notes.txt:1:1", "src/vendor/lib.go:1:4")
+	check("max matches", grep(&GrepRequest{Query: "hello", Recursive: true, MaxMatches: 2}),
+		"src/deep/notes.txt:1:1", "src/main.go:4:11")
+
+	for _, req := range []*GrepRequest{
+		{BasePath: "src"},
+		{BasePath: "src", Query: "(", Regex: true},
+		{BasePath: "src", Query: "x", Include: []string{"["}},
+	} {
+		if err := f.service.GrepFiles(req, &grepStream{}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("GrepFiles(%v): got %v, want InvalidArgument", req, err)
+		}
+	}
+	if err := f.service.GrepFiles(&GrepRequest{BasePath: "missing", Query: "x"}, &grepStream{}); status.Code(err) != codes.NotFound {
+		t.Errorf("missing base path: got %v, want NotFound", err)
+	}
+}
+
+// A line too long to search is skipped, and the lines after it still are searched
+func TestGrepLongLine(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "long.txt", "hello\n"+strings.Repeat("hello ", maxGrepLineLength/6+1)+"\nhello again\n")
+
+	stream := &grepStream{}
+	if err := f.service.GrepFiles(&GrepRequest{BasePath: "long.txt", Query: "hello"}, stream); err != nil {
+		t.Fatal(err)
+	}
+	var lines []int64
+	for _, match := range stream.matches {
+		lines = append(lines, match.LineNumber)
+	}
+	if fmt.Sprint(lines) != "[1 3]" {
+		t.Errorf("matched lines %v, want [1 3]", lines)
+	}
+}
+
+// Every match carries its own context, even when the context of matches overlaps
+func TestGrepContext(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "log.txt", "one\ntwo match\nthree\nfour match\nfive\nsix\n")
+
+	stream := &grepStream{}
+	if err := f.service.GrepFiles(&GrepRequest{BasePath: "log.txt", Query: "match", ContextLines: 2}, stream); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		line          int64
+		before, after string
+	}{
+		{2, "one", "three|four match"},
+		{4, "two match|three", "five|six"},
+	}
+	if len(stream.matches) != len(want) {
+		t.Fatalf("got %d matches, want %d", len(stream.matches), len(want))
+	}
+	for i, w := range want {
+		match := stream.matches[i]
+		before, after := strings.Join(match.BeforeContext, "|"), strings.Join(match.AfterContext, "|")
+		if match.LineNumber != w.line || before != w.before || after != w.after {
+			t.Errorf("match %d = line %d, before %q, after %q; want line %d, before %q, after %q", i, match.LineNumber, before, after, w.line, w.before, w.after)
+		}
+	}
+
+	// Context at the end of the file is cut short
+	stream = &grepStream{}
+	if err := f.service.GrepFiles(&GrepRequest{BasePath: "log.txt", Query: "six", ContextLines: 3}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.matches) != 1 || strings.Join(stream.matches[0].BeforeContext, "|") != "three|four match|five" || len(stream.matches[0].AfterContext) != 0 {
+		t.Errorf("match at the end = %v", stream.matches)
+	}
+}
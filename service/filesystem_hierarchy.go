@@ -20,33 +20,34 @@ func (s *FilesystemService) GetHierarchy(ctx context.Context, req *pb.HierarchyR
 	}
 	
 	// Check if path exists and is a directory
-	info, err := os.Stat(validPath)
+	info, err := s.root.Stat(validPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, status.Errorf(codes.NotFound, "Directory does not exist")
 		}
-		return nil, status.Errorf(codes.Internal, "Failed to access directory: %v", err)
+		return nil, rootError(err, "Failed to access directory")
 	}
 	
 	if !info.IsDir() {
 		return nil, status.Errorf(codes.InvalidArgument, "Path is not a directory")
 	}
 	
-	// Get relative path from base for the request path
-	relPath, err := filepath.Rel(s.BaseDir, validPath)
-	if err != nil {
-		relPath = req.Path // Use original path if relative path can't be determined
-	}
-	
 	// Build the root of our hierarchy
 	rootItem := &pb.FileItem{
-		Name:        filepath.Base(validPath),
-		Path:        relPath,
+		Name:        filepath.Base(filepath.Join(s.BaseDir, validPath)),
+		Path:        validPath,
 		IsDirectory: true,
 	}
 	
+	// Compile include/exclude rules
+	rules, err := s.newPathRules(validPath, req.Include, req.Exclude, req.UseIgnoreFiles)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Build hierarchy recursively with depth tracking
-	err = s.buildHierarchy(ctx, rootItem, validPath, relPath, req.Pattern, 1, req.MaxDepth)
+	filter := &hierarchyFilter{pattern: req.Pattern, rules: rules}
+	err = s.buildHierarchy(ctx, rootItem, validPath, filter, 1, req.MaxDepth)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to build hierarchy: %v", err)
 	}
@@ -64,8 +65,30 @@ func (s *FilesystemService) GetHierarchy(ctx context.Context, req *pb.HierarchyR
 	}, nil
 }
 
+// hierarchyFilter selects the entries shown in a hierarchy
+type hierarchyFilter struct {
+	pattern string     // Optional glob matched against entry names
+	rules   *pathRules // Include/exclude rules, scoped to the current directory
+}
+
+// selects reports whether an entry matches the pattern and include rules by itself
+func (f *hierarchyFilter) selects(name, entryRel string, isDir bool) bool {
+	if f.pattern != "" {
+		matched, err := filepath.Match(f.pattern, name)
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return f.rules.included(entryRel, isDir)
+}
+
+// filtersEntries reports whether some entries may be hidden for not matching
+func (f *hierarchyFilter) filtersEntries() bool {
+	return f.pattern != "" || f.rules.hasInclude()
+}
+
 // buildHierarchy recursively builds a directory hierarchy starting from a parent FileItem
-func (s *FilesystemService) buildHierarchy(ctx context.Context, parent *pb.FileItem, fullPath, relPath, pattern string, currentDepth, maxDepth int32) error {
+func (s *FilesystemService) buildHierarchy(ctx context.Context, parent *pb.FileItem, relPath string, filter *hierarchyFilter, currentDepth, maxDepth int32) error {
 	// Check context for cancellation
 	select {
 	case <-ctx.Done():
@@ -80,7 +103,7 @@ func (s *FilesystemService) buildHierarchy(ctx context.Context, parent *pb.FileI
 	}
 
 	// Read directory entries
-	entries, err := os.ReadDir(fullPath)
+	entries, err := s.root.ReadDir(relPath)
 	if err != nil {
 		return err
 	}
@@ -90,14 +113,21 @@ func (s *FilesystemService) buildHierarchy(ctx context.Context, parent *pb.FileI
 		parent.Children = []*pb.FileItem{}
 	}
 
+	// Rules from ignore files in this directory apply to its entries
+	scoped := &hierarchyFilter{pattern: filter.pattern, rules: filter.rules.enterDir(relPath)}
+
 	// Process each entry
 	for _, entry := range entries {
-		// If pattern is specified, check if it matches
-		if pattern != "" {
-			matched, err := filepath.Match(pattern, entry.Name())
-			if err != nil || !matched {
-				continue // Skip non-matching files
-			}
+		entryRel := slashRel(filepath.Join(relPath, entry.Name()))
+		if scoped.rules.excluded(entryRel, entry.IsDir()) {
+			continue // Skip excluded files and directories
+		}
+
+		// Non-matching files are skipped; non-matching directories are kept
+		// below if they contain matches
+		selected := scoped.selects(entry.Name(), entryRel, entry.IsDir())
+		if !selected && !entry.IsDir() {
+			continue // Skip non-matching files
 		}
 
 		// Get file info
@@ -107,29 +137,29 @@ func (s *FilesystemService) buildHierarchy(ctx context.Context, parent *pb.FileI
 		}
 
 		// Create item
-		item := &pb.FileItem{
-			Name:         info.Name(),
-			Path:         filepath.Join(relPath, info.Name()),
-			IsDirectory:  info.IsDir(),
-			Size:         info.Size(),
-			ModifiedTime: info.ModTime().Unix(),
-			Permissions:  fmt.Sprintf("%o", info.Mode().Perm()),
-			ParentPath:   relPath,
-		}
+		item := s.fileItem(filepath.Join(relPath, info.Name()), info)
+		item.ParentPath = relPath
 
-		// If directory, recursively process its contents
+		// If directory, recursively process its contents; symlinked
+		// directories are shown but not descended into
 		if info.IsDir() {
 			// Initialize children slice
 			item.Children = []*pb.FileItem{}
 			
-			entryFullPath := filepath.Join(fullPath, info.Name())
 			entryRelPath := filepath.Join(relPath, info.Name())
 			
 			// Recursively build hierarchy for this directory
-			err = s.buildHierarchy(ctx, item, entryFullPath, entryRelPath, pattern, currentDepth+1, maxDepth)
+			err = s.buildHierarchy(ctx, item, entryRelPath, scoped, currentDepth+1, maxDepth)
 			if err != nil {
 				// Log error but continue with other entries
-				fmt.Printf("Error processing directory %s: %v\n", entryFullPath, err)
+				fmt.Printf("Error processing directory %s: %v\n", entryRelPath, err)
+			}
+
+			// Drop directories without matches, unless the depth limit
+			// stopped us from looking inside them
+			atDepthLimit := maxDepth > 0 && currentDepth+1 > maxDepth
+			if !selected && len(item.Children) == 0 && !atDepthLimit && scoped.filtersEntries() {
+				continue
 			}
 		}
 
@@ -144,11 +174,8 @@ func (s *FilesystemService) buildHierarchy(ctx context.Context, parent *pb.FileI
 func (s *FilesystemService) checkTruncation(item *pb.FileItem, currentDepth, maxDepth int32) bool {
 	// If at max depth and this is a directory, check if it has actual contents on disk
 	if currentDepth == maxDepth && item.IsDirectory {
-		// Construct the full path
-		fullPath := filepath.Join(s.BaseDir, item.Path)
-		
 		// Check if the directory has any entries
-		entries, err := os.ReadDir(fullPath)
+		entries, err := s.root.ReadDir(item.Path)
 		if err == nil && len(entries) > 0 {
 			// Has contents but we didn't add them to our hierarchy due to depth limit
 			return true
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bufio"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ignoreFileNames are read from each directory when a request asks to honor ignore files
+var ignoreFileNames = []string{".gitignore", ".fsdaemonignore"}
+
+// ignoreRule is a single gitignore-style pattern
+type ignoreRule struct {
+	pattern  string // Glob without the leading "!", leading "/" or trailing "/"
+	negate   bool   // "!pattern" re-includes a previously excluded path
+	dirOnly  bool   // "pattern/" only matches directories
+	anchored bool   // Pattern contains a slash and is matched against the whole relative path
+	base     string // Directory the rule is relative to, relative to the base directory ("" for the root)
+}
+
+// parseIgnoreRule parses one gitignore line, returning false for blank lines and comments
+func parseIgnoreRule(line, base string) (ignoreRule, bool, error) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false, nil
+	}
+
+	rule := ignoreRule{base: base}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" {
+		return ignoreRule{}, false, nil
+	}
+
+	for _, segment := range strings.Split(line, "/") {
+		if _, err := filepath.Match(segment, ""); err != nil {
+			return ignoreRule{}, false, err
+		}
+	}
+
+	// "foo/**" matches everything inside foo, but not foo itself
+	if strings.HasSuffix(line, "/**") {
+		line = strings.TrimSuffix(line, "**") + "*/**"
+	}
+
+	rule.pattern = line
+	return rule, true, nil
+}
+
+// matches reports whether the rule applies to a slash-separated path relative to the base directory
+func (rule ignoreRule) matches(relPath string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+
+	if rule.base != "" {
+		if !strings.HasPrefix(relPath, rule.base+"/") {
+			return false
+		}
+		relPath = relPath[len(rule.base)+1:]
+	}
+
+	if rule.anchored {
+		return matchPathGlob(rule.pattern, relPath)
+	}
+	matched, _ := filepath.Match(rule.pattern, path.Base(relPath))
+	return matched
+}
+
+// lastMatch applies gitignore precedence: the last matching rule decides.
+// It reports whether any rule matched and whether that rule was a negation.
+func lastMatch(rules []ignoreRule, relPath string, isDir bool) (matched, negated bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].matches(relPath, isDir) {
+			return true, rules[i].negate
+		}
+	}
+	return false, false
+}
+
+// pathRules combines the include and exclude rules of a request with the
+// rules found in ignore files. Paths are slash-separated and relative to the
+// service base directory.
+type pathRules struct {
+	root           *rootFS
+	deny           []ignoreRule // Service deny list, which nothing can override
+	include        []ignoreRule // Request include rules
+	exclude        []ignoreRule // Request exclude rules, which override ignore files
+	fileRules      []ignoreRule // Rules read from ignore files, shallowest first
+	useIgnoreFiles bool
+}
+
+// newPathRules compiles request rules anchored at rootPath, a path relative
+// to the base directory, on top of the service deny list. When ignore files
+// are honored, the ones in the directories above rootPath are loaded as well.
+func (s *FilesystemService) newPathRules(rootPath string, include, exclude []string, useIgnoreFiles bool) (*pathRules, error) {
+	rootRel := slashRel(rootPath)
+	rules := &pathRules{root: s.root, deny: s.denyRules, useIgnoreFiles: useIgnoreFiles}
+
+	for _, list := range []struct {
+		patterns []string
+		target   *[]ignoreRule
+	}{{include, &rules.include}, {exclude, &rules.exclude}} {
+		for _, pattern := range list.patterns {
+			rule, ok, err := parseIgnoreRule(pattern, rootRel)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "Invalid pattern %q: %v", pattern, err)
+			}
+			if ok {
+				*list.target = append(*list.target, rule)
+			}
+		}
+	}
+
+	if useIgnoreFiles && rootRel != "" {
+		// Ancestors of the root, from the base directory down
+		segments := strings.Split(rootRel, "/")
+		for i := 0; i < len(segments); i++ {
+			rules = rules.enterDir(filepath.FromSlash(strings.Join(segments[:i], "/")))
+		}
+	}
+
+	return rules, nil
+}
+
+// hasInclude reports whether entries must match an include rule to be shown
+func (r *pathRules) hasInclude() bool {
+	return r != nil && len(r.include) > 0
+}
+
+// enterDir returns the rules that apply inside dir, a path relative to the
+// base directory, adding the rules of its ignore files when those are honored
+func (r *pathRules) enterDir(dir string) *pathRules {
+	if r == nil || !r.useIgnoreFiles {
+		return r
+	}
+
+	relDir := slashRel(dir)
+	var added []ignoreRule
+	for _, name := range ignoreFileNames {
+		file, _, err := r.root.openRegular(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			// Invalid lines are ignored, as git does
+			if rule, ok, err := parseIgnoreRule(scanner.Text(), relDir); err == nil && ok {
+				added = append(added, rule)
+			}
+		}
+		file.Close()
+	}
+
+	if len(added) == 0 {
+		return r
+	}
+
+	scoped := *r
+	scoped.fileRules = append(append([]ignoreRule(nil), r.fileRules...), added...)
+	return &scoped
+}
+
+// excluded reports whether a path is hidden by the deny list, exclude rules or ignore files
+func (r *pathRules) excluded(relPath string, isDir bool) bool {
+	if r == nil {
+		return false
+	}
+	if matched, negated := lastMatch(r.deny, relPath, isDir); matched && !negated {
+		return true
+	}
+	if matched, negated := lastMatch(r.exclude, relPath, isDir); matched {
+		return !negated
+	}
+	matched, negated := lastMatch(r.fileRules, relPath, isDir)
+	return matched && !negated
+}
+
+// included reports whether a path passes the include rules
+func (r *pathRules) included(relPath string, isDir bool) bool {
+	if !r.hasInclude() {
+		return true
+	}
+	matched, negated := lastMatch(r.include, relPath, isDir)
+	return matched && !negated
+}
+
+// slashRel returns a path relative to the base directory with forward slashes, or "" for the base itself
+func slashRel(relPath string) string {
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	if relPath == "." {
+		return ""
+	}
+	return relPath
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+func TestIgnoreRule(t *testing.T) {
+	for _, tc := range []struct {
+		line, base string
+		path       string
+		isDir      bool
+		want       bool
+	}{
+		// Patterns without a slash match the name at any depth
+		{"*.log", "", "x.log", false, true},
+		{"*.log", "", "a/b/x.log", false, true},
+		{"*.log", "", "a/x.log/y", false, false},
+		// A slash anchors the pattern to the directory of the rule
+		{"/build", "", "build", true, true},
+		{"/build", "", "src/build", true, false},
+		{"doc/*.md", "", "doc/a.md", false, true},
+		{"doc/*.md", "", "x/doc/a.md", false, false},
+		{"doc/*.md", "", "doc/x/a.md", false, false},
+		// A trailing slash matches directories only
+		{"tmp/", "", "tmp", true, true},
+		{"tmp/", "", "tmp", false, false},
+		{"tmp/", "", "a/tmp", true, true},
+		// "**" spans directories
+		{"**/foo", "", "foo", false, true},
+		{"**/foo", "", "a/b/foo", false, true},
+		{"a/**/b", "", "a/b", false, true},
+		{"a/**/b", "", "a/x/y/b", false, true},
+		{"foo/**", "", "foo/a", false, true},
+		{"foo/**", "", "foo/a/b", true, true},
+		{"foo/**", "", "foo", true, false},
+		{"foo/**", "", "bar/foo/a", false, false},
+		{"a/**/b/**", "", "a/x/b", true, false},
+		{"a/**/b/**", "", "a/x/b/c", false, true},
+		// Escapes
+		{`\!important`, "", "!important", false, true},
+		{`\#hash`, "", "#hash", false, true},
+		// Rules from nested ignore files are relative to their directory
+		{"x", "sub", "sub/y/x", false, true},
+		{"x", "sub", "x", false, false},
+		{"/x", "sub", "sub/x", false, true},
+		{"/x", "sub", "sub/y/x", false, false},
+	} {
+		rule, ok, err := parseIgnoreRule(tc.line, tc.base)
+		if err != nil || !ok {
+			t.Fatalf("parseIgnoreRule(%q): %v, %v", tc.line, ok, err)
+		}
+		if got := rule.matches(tc.path, tc.isDir); got != tc.want {
+			t.Errorf("%q in %q matches %q (dir %v) = %v, want %v", tc.line, tc.base, tc.path, tc.isDir, got, tc.want)
+		}
+	}
+
+	for _, line := range []string{"", "   ", "# comment", "/", "!"} {
+		if _, ok, err := parseIgnoreRule(line, ""); ok || err != nil {
+			t.Errorf("parseIgnoreRule(%q) = %v, %v, want no rule", line, ok, err)
+		}
+	}
+	if _, _, err := parseIgnoreRule("a/[", ""); err == nil {
+		t.Error("parseIgnoreRule accepted an invalid glob")
+	}
+
+	// The last matching rule decides
+	var rules []ignoreRule
+	for _, line := range []string{"*.log", "!keep*.log", "keep-not.log"} {
+		rule, _, _ := parseIgnoreRule(line, "")
+		rules = append(rules, rule)
+	}
+	for path, want := range map[string][2]bool{
+		"a.log":        {true, false},
+		"keep.log":     {true, true},
+		"keep-not.log": {true, false},
+		"a.txt":        {false, false},
+	} {
+		if matched, negated := lastMatch(rules, path, false); matched != want[0] || negated != want[1] {
+			t.Errorf("lastMatch(%q) = %v, %v, want %v, %v", path, matched, negated, want[0], want[1])
+		}
+	}
+}
+
+// newIgnoreFixture creates a tree with ignore files at two levels
+func newIgnoreFixture(t *testing.T) *testFixture {
+	f := newTestFixture(t)
+	f.write(t, ".gitignore", "# build output\n*.log\n!important.log\nbuild/\n/top.txt\ncache/**\n")
+	f.write(t, "sub/.fsdaemonignore", "secret*\n!*.log\n")
+	for _, name := range []string{
+		"a.log", "important.log", "top.txt", "secret.txt",
+		"build/out.bin", "cache/x.bin",
+		"src/build", "src/top.txt", "src/main.go", "src/util/util.go",
+		"sub/secret.txt", "sub/debug.log", "sub/notes.txt",
+	} {
+		f.write(t, name, name)
+	}
+	return f
+}
+
+// listAll returns the paths of a recursive listing
+func listAll(t *testing.T, f *testFixture, req *ListRequest) []string {
+	t.Helper()
+	req.Recursive = true
+	resp, err := f.service.ListDirectory(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, item := range resp.Items {
+		paths = append(paths, item.Path)
+	}
+	return paths
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	f := newIgnoreFixture(t)
+
+	for _, tc := range []struct {
+		name string
+		req  *ListRequest
+		want string
+	}{
+		{"ignore files", &ListRequest{Path: "/", UseIgnoreFiles: true},
+			".gitignore cache important.log secret.txt src src/build src/main.go src/top.txt src/util src/util/util.go sub sub/.fsdaemonignore sub/debug.log sub/notes.txt"},
+		{"without ignore files", &ListRequest{Path: "sub"},
+			"sub/.fsdaemonignore sub/debug.log sub/notes.txt sub/secret.txt"},
+		{"rules of parent directories", &ListRequest{Path: "sub", UseIgnoreFiles: true},
+			"sub/.fsdaemonignore sub/debug.log sub/notes.txt"},
+		{"exclude overrides ignore files", &ListRequest{Path: "/", UseIgnoreFiles: true, Exclude: []string{"src/", "!a.log", ".*"}},
+			"a.log cache important.log secret.txt sub sub/debug.log sub/notes.txt"},
+		{"include", &ListRequest{Path: "/", UseIgnoreFiles: true, Include: []string{"*.go", "src/"}},
+			"src src/main.go src/util/util.go"},
+		{"include anchored", &ListRequest{Path: "src", Include: []string{"/util/**"}},
+			"src/util/util.go"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := listAll(t, f, tc.req); !slices.Equal(got, strings.Fields(tc.want)) {
+				t.Errorf("listed %q, want %q", got, strings.Fields(tc.want))
+			}
+		})
+	}
+}
+
+// hierarchyPaths flattens a hierarchy into the paths of its items
+func hierarchyPaths(item *pb.FileItem) []string {
+	var paths []string
+	for _, child := range item.Children {
+		paths = append(paths, child.Path)
+		paths = append(paths, hierarchyPaths(child)...)
+	}
+	return paths
+}
+
+func TestHierarchyIgnoreFiles(t *testing.T) {
+	f := newIgnoreFixture(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name string
+		req  *pb.HierarchyRequest
+		want string
+	}{
+		{"ignore files", &pb.HierarchyRequest{Path: "/", UseIgnoreFiles: true, Exclude: []string{".*"}},
+			"cache important.log secret.txt src src/build src/main.go src/top.txt src/util src/util/util.go sub sub/debug.log sub/notes.txt"},
+		{"rules of parent directories", &pb.HierarchyRequest{Path: "sub", UseIgnoreFiles: true},
+			"sub/.fsdaemonignore sub/debug.log sub/notes.txt"},
+		{"include keeps directories with matches", &pb.HierarchyRequest{Path: "/", UseIgnoreFiles: true, Include: []string{"*.go"}},
+			"src src/main.go src/util src/util/util.go"},
+		{"include with pattern", &pb.HierarchyRequest{Path: "/", Include: []string{"src/**"}, Pattern: "*.go"},
+			"src src/main.go src/util src/util/util.go"},
+		{"exclude", &pb.HierarchyRequest{Path: "/", Exclude: []string{"/*", "!src", "util/"}},
+			"src src/build src/main.go src/top.txt"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := f.service.GetHierarchy(ctx, tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := hierarchyPaths(resp.Root)
+			slices.Sort(got)
+			if want := strings.Fields(tc.want); !slices.Equal(got, want) {
+				t.Errorf("hierarchy %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// listCandidate is a directory entry that is only stat'ed when it is needed
+type listCandidate struct {
+	path  string      // Path relative to the base directory
+	rel   []string    // Path segments relative to the listed directory
+	entry fs.DirEntry // Entry as read from the directory
+	info  os.FileInfo // Loaded lazily by load
+}
+
+// load stats the candidate if that has not happened yet
+func (c *listCandidate) load() (os.FileInfo, error) {
+	if c.info == nil {
+		info, err := c.entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		c.info = info
+	}
+	return c.info, nil
+}
+
+// listPageToken is the decoded form of a ListDirectory page token. It records
+// the sort key of the last returned item so that pages stay stable while
+// entries are added or removed.
+type listPageToken struct {
+	Sort  pb.SortField `json:"s"`
+	Desc  bool         `json:"d"`
+	Value int64        `json:"v"`
+	Path  string       `json:"p"`
+}
+
+// ListDirectoryStream implements the ListDirectoryStream RPC method (streaming to client).
+// Items are sent in the requested order; page_size is ignored.
+func (s *FilesystemService) ListDirectoryStream(req *ListRequest, stream FilesystemService_ListDirectoryStreamServer) error {
+	ctx := stream.Context()
+
+	validPath, err := s.validateListPath(req)
+	if err != nil {
+		return err
+	}
+	if err := checkAlgorithms(req.Checksums); err != nil {
+		return err
+	}
+
+	return s.eachListCandidate(ctx, validPath, req, func(candidate *listCandidate) error {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		info, err := candidate.load()
+		if err != nil {
+			return nil // Skip entries with errors
+		}
+		return stream.Send(s.listItem(ctx, req, candidate.path, info))
+	})
+}
+
+// listPage returns one page of a directory listing
+func (s *FilesystemService) listPage(ctx context.Context, validPath string, req *ListRequest) (*ListResponse, error) {
+	var response ListResponse
+	var last *listCandidate
+
+	err := s.eachListCandidate(ctx, validPath, req, func(candidate *listCandidate) error {
+		info, err := candidate.load()
+		if err != nil {
+			return nil // Skip entries with errors
+		}
+
+		// There is at least one more item, so another page exists
+		if req.PageSize > 0 && int32(len(response.Items)) >= req.PageSize {
+			response.NextPageToken = encodeListPageToken(req, last)
+			return errStopWalk
+		}
+
+		response.Items = append(response.Items, s.listItem(ctx, req, candidate.path, info))
+		last = candidate
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// listItem converts a listed entry to a FileItem with the checksums the request asks for
+func (s *FilesystemService) listItem(ctx context.Context, req *ListRequest, relPath string, info os.FileInfo) *FileItem {
+	item := s.fileItem(relPath, info)
+	if len(req.Checksums) > 0 {
+		resolved, _ := s.root.resolveLink(relPath, info)
+		item.Checksums = s.addChecksums(ctx, relPath, resolved, req.Checksums)
+	}
+	return item
+}
+
+// validateListPath checks that the requested path is an existing directory
+func (s *FilesystemService) validateListPath(req *ListRequest) (string, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return "", err
+	}
+
+	// Check if path exists and is a directory
+	info, err := s.root.Stat(validPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", status.Errorf(codes.NotFound, "Directory does not exist")
+		}
+		return "", rootError(err, "Failed to access directory")
+	}
+
+	if !info.IsDir() {
+		return "", status.Errorf(codes.InvalidArgument, "Path is not a directory")
+	}
+
+	return validPath, nil
+}
+
+// eachListCandidate calls emit for the entries of a listing that come after
+// the page token, in the requested order. Ascending name order is the order of
+// the directory walk, so those entries are passed on as they are read and the
+// walk skips what earlier pages covered; every other order needs all entries
+// read, and stat'ed for size and time, before the first one can be emitted.
+// Returning errStopWalk from emit ends the listing cleanly.
+func (s *FilesystemService) eachListCandidate(ctx context.Context, validPath string, req *ListRequest, emit func(*listCandidate) error) error {
+	if _, ok := pb.SortField_name[int32(req.SortBy)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "Unknown sort field %d", req.SortBy)
+	}
+
+	token, err := decodeListPageToken(req)
+	if err != nil {
+		return err
+	}
+	var tokenPath []string
+	if token != nil {
+		tokenPath = strings.Split(token.Path, "/")
+	}
+
+	if req.SortBy == pb.SortField_SORT_NAME && !req.Descending {
+		err = s.walkListCandidates(ctx, validPath, req, tokenPath, emit)
+		if err == errStopWalk {
+			return nil
+		}
+		return err
+	}
+
+	var candidates []*listCandidate
+	err = s.walkListCandidates(ctx, validPath, req, nil, func(candidate *listCandidate) error {
+		// Size and time orderings need every entry stat'ed up front
+		if req.SortBy == pb.SortField_SORT_SIZE || req.SortBy == pb.SortField_SORT_MODIFIED {
+			if _, err := candidate.load(); err != nil {
+				return nil
+			}
+		}
+		candidates = append(candidates, candidate)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return compareListCandidates(req, candidates[i], candidates[j]) < 0
+	})
+
+	if token != nil {
+		start := sort.Search(len(candidates), func(i int) bool {
+			return compareListKey(req, listSortValue(req.SortBy, candidates[i]), candidates[i].rel, token.Value, tokenPath) > 0
+		})
+		candidates = candidates[start:]
+	}
+
+	for _, candidate := range candidates {
+		if err := emit(candidate); err != nil {
+			if err == errStopWalk {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// walkListCandidates walks a listing in lexical depth-first order, descending
+// only if the request is recursive, and calls emit for every entry after the
+// given path
+func (s *FilesystemService) walkListCandidates(ctx context.Context, validPath string, req *ListRequest, after []string, emit func(*listCandidate) error) error {
+	rules, err := s.newPathRules(validPath, req.Include, req.Exclude, req.UseIgnoreFiles)
+	if err != nil {
+		return err
+	}
+
+	matchesPattern := func(name string) bool {
+		if req.Pattern == "" {
+			return true
+		}
+		matched, err := filepath.Match(req.Pattern, name)
+		return err == nil && matched
+	}
+
+	// Rules in effect inside each visited directory
+	rootPath := filepath.ToSlash(validPath)
+	scopes := map[string]*pathRules{rootPath: rules.enterDir(validPath)}
+
+	err = s.root.WalkDir(validPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == rootPath {
+				return err // The listed directory itself can not be read
+			}
+			return nil // Skip files with errors
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Skip the root directory itself
+		if path == rootPath {
+			return nil
+		}
+
+		scope := scopes[filepath.Dir(path)]
+		entryRel := slashRel(path)
+		if scope.excluded(entryRel, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		listRelPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return nil
+		}
+		rel := strings.Split(filepath.ToSlash(listRelPath), "/")
+
+		descend := entry.IsDir() && req.Recursive
+		if descend {
+			scopes[path] = scope.enterDir(path)
+		}
+
+		// Resume after the page token, pruning subtrees already covered
+		if after != nil && compareSegments(rel, after) <= 0 {
+			if !entry.IsDir() {
+				return nil
+			}
+			if descend && isSegmentPrefix(rel, after) {
+				return nil // The token lies inside this directory
+			}
+			return filepath.SkipDir
+		}
+
+		if matchesPattern(entry.Name()) && scope.included(entryRel, entry.IsDir()) {
+			err := emit(&listCandidate{
+				path:  path,
+				rel:   rel,
+				entry: entry,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if entry.IsDir() && !descend {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	if err != nil {
+		if err == errStopWalk {
+			return err
+		}
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		if !req.Recursive {
+			return status.Errorf(codes.Internal, "Failed to read directory: %v", err)
+		}
+		return status.Errorf(codes.Internal, "Failed to list directory recursively: %v", err)
+	}
+
+	return nil
+}
+
+// listSortValue returns the primary sort key of a candidate; ties are broken by path
+func listSortValue(field pb.SortField, c *listCandidate) int64 {
+	switch field {
+	case pb.SortField_SORT_SIZE:
+		if c.info != nil {
+			return c.info.Size()
+		}
+	case pb.SortField_SORT_MODIFIED:
+		if c.info != nil {
+			return c.info.ModTime().UnixNano()
+		}
+	case pb.SortField_SORT_TYPE:
+		return typeRank(c.entry.Type())
+	}
+	return 0
+}
+
+// typeRank orders directories first, then regular files, symlinks and everything else
+func typeRank(mode fs.FileMode) int64 {
+	switch {
+	case mode.IsDir():
+		return 0
+	case mode.IsRegular():
+		return 1
+	case mode&fs.ModeSymlink != 0:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// compareListCandidates orders two candidates according to the request
+func compareListCandidates(req *ListRequest, a, b *listCandidate) int {
+	return compareListKey(req, listSortValue(req.SortBy, a), a.rel, listSortValue(req.SortBy, b), b.rel)
+}
+
+// compareListKey compares two (value, path) sort keys, honoring descending order
+func compareListKey(req *ListRequest, aValue int64, aPath []string, bValue int64, bPath []string) int {
+	c := 0
+	switch {
+	case aValue < bValue:
+		c = -1
+	case aValue > bValue:
+		c = 1
+	default:
+		c = compareSegments(aPath, bPath)
+	}
+	if req.Descending {
+		return -c
+	}
+	return c
+}
+
+// encodeListPageToken builds the token that resumes a listing after the given candidate
+func encodeListPageToken(req *ListRequest, last *listCandidate) string {
+	data, _ := json.Marshal(listPageToken{
+		Sort:  req.SortBy,
+		Desc:  req.Descending,
+		Value: listSortValue(req.SortBy, last),
+		Path:  strings.Join(last.rel, "/"),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListPageToken parses the page token of a request, returning nil if there is none
+func decodeListPageToken(req *ListRequest) (*listPageToken, error) {
+	if req.PageToken == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid page token")
+	}
+
+	var token listPageToken
+	if err := json.Unmarshal(data, &token); err != nil || token.Path == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid page token")
+	}
+
+	if token.Sort != req.SortBy || token.Desc != req.Descending {
+		return nil, status.Errorf(codes.InvalidArgument, "Page token was issued for a different sort order")
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// listStream collects the items ListDirectoryStream sends, calling onSend after each
+type listStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	items  []string
+	onSend func()
+}
+
+func (l *listStream) Context() context.Context { return l.ctx }
+
+func (l *listStream) Send(item *FileItem) error {
+	l.items = append(l.items, item.Path)
+	if l.onSend != nil {
+		l.onSend()
+	}
+	return nil
+}
+
+// newListFixture creates a small tree with distinct sizes and modification times
+func newListFixture(t *testing.T) *testFixture {
+	f := newTestFixture(t)
+	files := map[string]string{
+		"b.txt":       "bb",
+		"a/z.txt":     "zzzzzz",
+		"a/b/c.txt":   "c",
+		"a.txt":       "aaaa",
+		"c/d.txt":     "ddddd",
+		"c/e/f/g.txt": "ggg",
+	}
+	for name, content := range files {
+		f.write(t, name, content)
+	}
+	symlink(t, "b.txt", f.path("link"))
+
+	base := time.Unix(1_700_000_000, 0)
+	for i, name := range []string{"a.txt", "c/d.txt", "b.txt", "a/z.txt", "c/e/f/g.txt", "a/b/c.txt"} {
+		at := base.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(f.path(name), at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func TestListDirectoryOrder(t *testing.T) {
+	f := newListFixture(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name string
+		req  *ListRequest
+		want string
+	}{
+		{"name", &ListRequest{}, "a a.txt b.txt c link"},
+		{"name descending", &ListRequest{Descending: true}, "link c b.txt a.txt a"},
+		{"recursive", &ListRequest{Recursive: true}, "a a/b a/b/c.txt a/z.txt a.txt b.txt c c/d.txt c/e c/e/f c/e/f/g.txt link"},
+		{"recursive descending", &ListRequest{Recursive: true, Descending: true}, "link c/e/f/g.txt c/e/f c/e c/d.txt c b.txt a.txt a/z.txt a/b/c.txt a/b a"},
+		{"size", &ListRequest{Recursive: true, SortBy: pb.SortField_SORT_SIZE, Pattern: "*.txt"}, "a/b/c.txt b.txt c/e/f/g.txt a.txt c/d.txt a/z.txt"},
+		{"modified", &ListRequest{Recursive: true, SortBy: pb.SortField_SORT_MODIFIED, Pattern: "*.txt"}, "a.txt c/d.txt b.txt a/z.txt c/e/f/g.txt a/b/c.txt"},
+		{"modified descending", &ListRequest{Recursive: true, SortBy: pb.SortField_SORT_MODIFIED, Descending: true, Pattern: "*.txt"}, "a/b/c.txt c/e/f/g.txt a/z.txt b.txt c/d.txt a.txt"},
+		{"type", &ListRequest{SortBy: pb.SortField_SORT_TYPE}, "a c a.txt b.txt link"},
+		{"pattern", &ListRequest{Recursive: true, Pattern: "?.txt"}, "a/b/c.txt a/z.txt a.txt b.txt c/d.txt c/e/f/g.txt"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.Path = "/"
+			want := strings.Fields(tc.want)
+
+			resp, err := f.service.ListDirectory(ctx, tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, item := range resp.Items {
+				got = append(got, item.Path)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("listed %q, want %q", got, want)
+			}
+
+			stream := &listStream{ctx: ctx}
+			if err := f.service.ListDirectoryStream(tc.req, stream); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(stream.items, want) {
+				t.Errorf("streamed %q, want %q", stream.items, want)
+			}
+
+			// Every page size gives the same items, each exactly once
+			for size := int32(1); size <= int32(len(want)); size++ {
+				req := proto.Clone(tc.req).(*ListRequest)
+				req.PageSize = size
+				var paged []string
+				for pages := 0; ; pages++ {
+					resp, err := f.service.ListDirectory(ctx, req)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(resp.Items) > int(size) || pages > len(want) {
+						t.Fatalf("page size %d: page of %d items", size, len(resp.Items))
+					}
+					for _, item := range resp.Items {
+						paged = append(paged, item.Path)
+					}
+					if resp.NextPageToken == "" {
+						break
+					}
+					req.PageToken = resp.NextPageToken
+				}
+				if !slices.Equal(paged, want) {
+					t.Errorf("page size %d: listed %q, want %q", size, paged, want)
+				}
+			}
+		})
+	}
+}
+
+func TestListDirectoryPages(t *testing.T) {
+	f := newListFixture(t)
+	ctx := context.Background()
+
+	// Pages stay stable while entries are added and removed around the token
+	req := &ListRequest{Path: "/", Recursive: true, PageSize: 3}
+	first, err := f.service.ListDirectory(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := first.Items[len(first.Items)-1].Path; last != "a/b/c.txt" {
+		t.Fatalf("first page ends at %q", last)
+	}
+	if err := os.RemoveAll(f.path("a/b")); err != nil {
+		t.Fatal(err)
+	}
+	f.write(t, "a/a.txt", "new before the token")
+	f.write(t, "a/y.txt", "new after the token")
+	req.PageToken = first.NextPageToken
+	next, err := f.service.ListDirectory(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range next.Items {
+		got = append(got, item.Path)
+	}
+	if want := []string{"a/y.txt", "a/z.txt", "a.txt"}; !slices.Equal(got, want) {
+		t.Errorf("second page = %q, want %q", got, want)
+	}
+
+	// The stream honors the page token too
+	stream := &listStream{ctx: ctx}
+	if err := f.service.ListDirectoryStream(&ListRequest{Path: "c", Recursive: true, PageToken: encodeListPageToken(&ListRequest{}, &listCandidate{rel: []string{"e"}})}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"c/e/f", "c/e/f/g.txt"}; !slices.Equal(stream.items, want) {
+		t.Errorf("streamed %q, want %q", stream.items, want)
+	}
+
+	for _, bad := range []*ListRequest{
+		{Path: "/", PageToken: "%%"},
+		{Path: "/", PageToken: first.NextPageToken, SortBy: pb.SortField_SORT_SIZE},
+		{Path: "/", PageToken: first.NextPageToken, Descending: true},
+		{Path: "/", SortBy: pb.SortField(42)},
+	} {
+		if _, err := f.service.ListDirectory(ctx, bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListDirectory(%v): got %v, want InvalidArgument", bad, err)
+		}
+	}
+}
+
+func TestListDirectoryStreaming(t *testing.T) {
+	f := newListFixture(t)
+	ctx := context.Background()
+
+	// In name order items go out while the walk runs, so a file created after
+	// the first item was sent is still found; other orders read everything first
+	for _, tc := range []struct {
+		sortBy pb.SortField
+		late   bool
+	}{
+		{pb.SortField_SORT_NAME, true},
+		{pb.SortField_SORT_SIZE, false},
+	} {
+		stream := &listStream{ctx: ctx}
+		stream.onSend = func() {
+			if len(stream.items) == 1 {
+				f.write(t, "a/late.txt", "")
+			}
+		}
+		if err := f.service.ListDirectoryStream(&ListRequest{Path: "/", Recursive: true, SortBy: tc.sortBy}, stream); err != nil {
+			t.Fatal(err)
+		}
+		if late := slices.Contains(stream.items, "a/late.txt"); late != tc.late {
+			t.Errorf("sort %v: streamed %q", tc.sortBy, stream.items)
+		}
+		if err := os.Remove(f.path("a/late.txt")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A client that goes away stops the walk
+	canceled, cancel := context.WithCancel(ctx)
+	stream := &listStream{ctx: canceled, onSend: cancel}
+	if err := f.service.ListDirectoryStream(&ListRequest{Path: "/", Recursive: true}, stream); status.Code(err) != codes.Canceled {
+		t.Errorf("canceled stream: got %v, want Canceled", err)
+	}
+	if len(stream.items) != 1 {
+		t.Errorf("sent %q after the client went away", stream.items)
+	}
+}
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// Lease lifetimes
+const (
+	defaultLockTTL = 60 * time.Second
+	maxLockTTL     = 24 * time.Hour
+)
+
+// editLockTimeout bounds how long an edit waits for the flock(2) of another
+// edit or process before it gives up
+const editLockTimeout = 10 * time.Second
+
+// leaseMetadataKey is the request metadata that presents held leases
+const leaseMetadataKey = "lease-id"
+
+// lockedFields lists, for every RPC that changes files, the request fields
+// naming the paths it changes. Copy only reads its source.
+var lockedFields = map[string][]string{
+	"/filesystem.FilesystemService/CreateDirectory": {"path"},
+	"/filesystem.FilesystemService/Delete":          {"path"},
+	"/filesystem.FilesystemService/Copy":            {"destination"},
+	"/filesystem.FilesystemService/Move":            {"source", "destination"},
+	"/filesystem.FilesystemService/UploadFile":      {"file_path"},
+	"/filesystem.FilesystemService/CreateSymlink":   {"path"},
+	"/filesystem.FilesystemService/SetPermissions":  {"path"},
+	"/filesystem.FilesystemService/SetOwner":        {"path"},
+	"/filesystem.FilesystemService/SetTimes":        {"path"},
+	"/filesystem.FilesystemService/SetXattr":        {"path"},
+	"/filesystem.FilesystemService/RemoveXattr":     {"path"},
+	"/filesystem.FilesystemService/SetAcl":          {"path"},
+	"/filesystem.FilesystemService/WriteFile":       {"path"},
+	"/filesystem.FilesystemService/AppendFile":      {"path"},
+	"/filesystem.FilesystemService/Truncate":        {"path"},
+	"/filesystem.FilesystemService/RestoreVersion":  {"path"},
+	"/filesystem.FilesystemService/ApplyPatch":      {"path"},
+	"/filesystem.FilesystemService/ApplyDelta":      {"path"},
+}
+
+// lease is a lock held on a path until it is released or expires
+type lease struct {
+	id       string
+	path     string // Slash separated and relative to the base directory
+	mode     pb.LockMode
+	owner    string
+	acquired time.Time
+	expires  time.Time
+	ttl      time.Duration
+	file     *os.File    // Holds the flock(2), if one was taken
+	timer    *time.Timer // Releases the lease when it expires
+}
+
+// lockManager keeps the leases of a service. Leases live in memory only, so
+// a restart of the daemon releases them all.
+type lockManager struct {
+	mu     sync.Mutex
+	leases map[string]*lease
+}
+
+func newLockManager() *lockManager {
+	return &lockManager{leases: make(map[string]*lease)}
+}
+
+// AcquireLock implements the AcquireLock RPC method
+func (s *FilesystemService) AcquireLock(ctx context.Context, req *LockRequest) (*pb.Lock, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if req.Mode != pb.LockMode_LOCK_EXCLUSIVE && req.Mode != pb.LockMode_LOCK_SHARED {
+		return nil, status.Errorf(codes.InvalidArgument, "Unknown lock mode %d", req.Mode)
+	}
+	ttl, err := lockTTL(req.TtlSeconds, defaultLockTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newLeaseID()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to create lease: %v", err)
+	}
+	now := time.Now()
+	l := &lease{
+		id:       id,
+		path:     filepath.ToSlash(validPath),
+		mode:     req.Mode,
+		owner:    req.Owner,
+		acquired: now,
+		expires:  now.Add(ttl),
+		ttl:      ttl,
+	}
+
+	m := s.locks
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, other := range m.activeLeases(now) {
+		if pathsOverlap(l.path, other.path) && (l.mode == pb.LockMode_LOCK_EXCLUSIVE || other.mode == pb.LockMode_LOCK_EXCLUSIVE) {
+			return nil, lockedError(other)
+		}
+	}
+
+	if req.Flock {
+		if l.file, err = s.root.flock(validPath, l.mode); err != nil {
+			if errors.Is(err, unix.EWOULDBLOCK) {
+				return nil, status.Errorf(codes.FailedPrecondition, "Path is locked by another process")
+			}
+			return nil, rootError(err, "Failed to lock path")
+		}
+	}
+
+	l.timer = time.AfterFunc(ttl, func() { m.expire(id) })
+	m.leases[id] = l
+	return l.proto(), nil
+}
+
+// RenewLock implements the RenewLock RPC method
+func (s *FilesystemService) RenewLock(ctx context.Context, req *RenewLockRequest) (*pb.Lock, error) {
+	m := s.locks
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.active(req.LeaseId, time.Now())
+	if l == nil {
+		return nil, status.Errorf(codes.NotFound, "Lease does not exist or has expired")
+	}
+	ttl, err := lockTTL(req.TtlSeconds, l.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	l.ttl = ttl
+	l.expires = time.Now().Add(ttl)
+	l.timer.Reset(ttl)
+	return l.proto(), nil
+}
+
+// ReleaseLock implements the ReleaseLock RPC method
+func (s *FilesystemService) ReleaseLock(ctx context.Context, req *LeaseRequest) (*OperationResponse, error) {
+	m := s.locks
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.active(req.LeaseId, time.Now())
+	if l == nil {
+		return nil, status.Errorf(codes.NotFound, "Lease does not exist or has expired")
+	}
+	m.remove(l)
+
+	return &OperationResponse{
+		Success: true,
+		Message: "Lock released successfully",
+	}, nil
+}
+
+// ListLocks implements the ListLocks RPC method
+func (s *FilesystemService) ListLocks(ctx context.Context, req *PathRequest) (*ListLocksResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	slashPath := filepath.ToSlash(validPath)
+
+	m := s.locks
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	response := &ListLocksResponse{Locks: []*pb.Lock{}}
+	for _, l := range m.activeLeases(time.Now()) {
+		if pathsOverlap(slashPath, l.path) {
+			response.Locks = append(response.Locks, l.proto())
+		}
+	}
+	return response, nil
+}
+
+// checkLocks refuses a request of method that changes a path under a lease
+// it does not present
+func (s *FilesystemService) checkLocks(ctx context.Context, method string, req interface{}) error {
+	fields, ok := lockedFields[method]
+	if !ok {
+		return nil
+	}
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	var paths []string
+	reflectMsg := msg.ProtoReflect()
+	for _, name := range fields {
+		fd := reflectMsg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd != nil && reflectMsg.Has(fd) {
+			paths = append(paths, reflectMsg.Get(fd).String())
+		}
+	}
+	return s.checkPathLocks(ctx, paths...)
+}
+
+// checkPathLocks refuses changes to paths under a lease the request does not
+// present. Paths are compared as given, so a lease does not cover other
+// names a symlink or hard link gives the same file.
+func (s *FilesystemService) checkPathLocks(ctx context.Context, paths ...string) error {
+	m := s.locks
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	presented := make(map[string]bool)
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, id := range md.Get(leaseMetadataKey) {
+		// A client relying on a lease it lost must not go ahead unprotected
+		if m.active(id, now) == nil {
+			return status.Errorf(codes.FailedPrecondition, "Lease %s does not exist or has expired", id)
+		}
+		presented[id] = true
+	}
+
+	leases := m.activeLeases(now)
+	for _, path := range paths {
+		slashPath := filepath.ToSlash(cleanRel(path))
+		for _, l := range leases {
+			if !presented[l.id] && pathsOverlap(slashPath, l.path) {
+				return lockedError(l)
+			}
+		}
+	}
+	return nil
+}
+
+// lockStream checks every message an RPC receives against the held leases
+type lockStream struct {
+	grpc.ServerStream
+	service *FilesystemService
+	method  string
+}
+
+func (l *lockStream) RecvMsg(m interface{}) error {
+	if err := l.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return l.service.checkLocks(l.Context(), l.method, m)
+}
+
+// active returns an unexpired lease, or nil
+func (m *lockManager) active(id string, now time.Time) *lease {
+	l, ok := m.leases[id]
+	if !ok || !now.Before(l.expires) {
+		return nil
+	}
+	return l
+}
+
+// activeLeases returns the unexpired leases, oldest first
+func (m *lockManager) activeLeases(now time.Time) []*lease {
+	var leases []*lease
+	for _, l := range m.leases {
+		if now.Before(l.expires) {
+			leases = append(leases, l)
+		}
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		if !leases[i].acquired.Equal(leases[j].acquired) {
+			return leases[i].acquired.Before(leases[j].acquired)
+		}
+		return leases[i].id < leases[j].id
+	})
+	return leases
+}
+
+// expire releases a lease whose timer fired, unless it was renewed meanwhile
+func (m *lockManager) expire(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.leases[id]; ok && !time.Now().Before(l.expires) {
+		m.remove(l)
+	}
+}
+
+// remove drops a lease and its flock. The caller holds m.mu.
+func (m *lockManager) remove(l *lease) {
+	l.timer.Stop()
+	if l.file != nil {
+		l.file.Close()
+	}
+	delete(m.leases, l.id)
+}
+
+// Close releases every lease
+func (m *lockManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, l := range m.leases {
+		m.remove(l)
+	}
+}
+
+func (l *lease) proto() *pb.Lock {
+	return &pb.Lock{
+		LeaseId:      l.id,
+		Path:         l.path,
+		Mode:         l.mode,
+		Owner:        l.owner,
+		AcquiredTime: l.acquired.Unix(),
+		ExpiresTime:  l.expires.Unix(),
+		TtlSeconds:   int64(l.ttl / time.Second),
+		Flock:        l.file != nil,
+	}
+}
+
+// flock opens name and takes a flock(2) without waiting, so that local
+// processes using flock on the same file or directory see the lease
+func (r *rootFS) flock(name string, mode pb.LockMode) (*os.File, error) {
+	// O_NONBLOCK keeps a FIFO from blocking the open
+	f, err := r.OpenFile(name, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	how := unix.LOCK_EX
+	if mode == pb.LockMode_LOCK_SHARED {
+		how = unix.LOCK_SH
+	}
+	if err := unix.Flock(int(f.Fd()), how|unix.LOCK_NB); err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "flock", Path: name, Err: err}
+	}
+	return f, nil
+}
+
+// lockForEdit takes the exclusive flock that in-place edits hold on an open
+// file. The lock is polled rather than waited for, so a request ends when its
+// context does and an edit fails with FailedPrecondition once another holder
+// keeps the file locked for editLockTimeout. A flock held by a lease the
+// request presents stands in for the edit's own, which would never be granted.
+func (s *FilesystemService) lockForEdit(ctx context.Context, file *os.File) error {
+	if s.presentsFlock(ctx, file) {
+		return nil
+	}
+
+	deadline := time.NewTimer(editLockTimeout)
+	defer deadline.Stop()
+	for delay := time.Millisecond; ; delay = min(2*delay, 50*time.Millisecond) {
+		err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, unix.EWOULDBLOCK) {
+			return status.Errorf(codes.Internal, "Failed to lock file: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-deadline.C:
+			return status.Errorf(codes.FailedPrecondition, "File is locked by another process")
+		case <-time.After(delay):
+		}
+	}
+}
+
+// presentsFlock reports whether the request presents an active lease whose
+// flock is held on the same file as the open file
+func (s *FilesystemService) presentsFlock(ctx context.Context, file *os.File) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ids := md.Get(leaseMetadataKey)
+	if len(ids) == 0 {
+		return false
+	}
+	var target unix.Stat_t
+	if err := unix.Fstat(int(file.Fd()), &target); err != nil {
+		return false
+	}
+
+	m := s.locks
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		l := m.active(id, now)
+		if l == nil || l.file == nil {
+			continue
+		}
+		var held unix.Stat_t
+		if err := unix.Fstat(int(l.file.Fd()), &held); err == nil && held.Dev == target.Dev && held.Ino == target.Ino {
+			return true
+		}
+	}
+	return false
+}
+
+// lockTTL validates a requested lifetime in seconds, 0 meaning fallback
+func lockTTL(seconds int64, fallback time.Duration) (time.Duration, error) {
+	if seconds < 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "TTL must not be negative")
+	}
+	if seconds == 0 {
+		return fallback, nil
+	}
+	if seconds > int64(maxLockTTL/time.Second) {
+		return 0, status.Errorf(codes.InvalidArgument, "TTL must not exceed %d seconds", int64(maxLockTTL/time.Second))
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// pathsOverlap reports whether one slash path is the other or one of its parents
+func pathsOverlap(a, b string) bool {
+	return a == b || a == "." || b == "." || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// lockedError describes the lease that blocks a request
+func lockedError(l *lease) error {
+	holder := l.id
+	if l.owner != "" {
+		holder = l.owner + " (" + l.id + ")"
+	}
+	mode := "exclusive"
+	if l.mode == pb.LockMode_LOCK_SHARED {
+		mode = "shared"
+	}
+	return status.Errorf(codes.FailedPrecondition, "Path is locked: %s holds a %s lease on %s until %s",
+		holder, mode, l.path, l.expires.UTC().Format(time.RFC3339))
+}
+
+// newLeaseID returns a random 128-bit lease ID
+func newLeaseID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
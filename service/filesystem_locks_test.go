@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// A deployer's exclusive lease keeps others from changing the tree it covers
+func TestLeaseLocks(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "page.txt", "original")
+	ctx := context.Background()
+	const write = "/filesystem.FilesystemService/WriteFile"
+
+	lock, err := f.service.AcquireLock(ctx, &LockRequest{Path: "releases", Owner: "deployer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.AcquireLock(ctx, &LockRequest{Path: "releases/v1", Mode: pb.LockMode_LOCK_SHARED}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("shared lease below an exclusive one: got %v, want FailedPrecondition", err)
+	}
+
+	holder := metadata.NewIncomingContext(ctx, metadata.Pairs(leaseMetadataKey, lock.LeaseId))
+	for _, tc := range []struct {
+		ctx    context.Context
+		method string
+		req    interface{}
+		locked bool
+	}{
+		{ctx, write, &WriteRequest{Path: "releases/v1/app"}, true},
+		{ctx, write, &WriteRequest{Path: "/releases/../releases"}, true},
+		{ctx, write, &WriteRequest{Path: "releases-old/app"}, false},
+		{ctx, "/filesystem.FilesystemService/Delete", &DeleteRequest{Path: "."}, true},
+		{ctx, "/filesystem.FilesystemService/Copy", &CopyRequest{Source: "releases/v1", Destination: "backup"}, false},
+		{ctx, "/filesystem.FilesystemService/Move", &MoveRequest{Source: "releases/v1", Destination: "backup"}, true},
+		{ctx, "/filesystem.FilesystemService/GetFileInfo", &FileRequest{Path: "releases/v1"}, false},
+		{holder, write, &WriteRequest{Path: "releases/v1/app"}, false},
+	} {
+		err := f.service.checkLocks(tc.ctx, tc.method, tc.req)
+		if locked := status.Code(err) == codes.FailedPrecondition; locked != tc.locked || (err != nil && !locked) {
+			t.Errorf("%s %v: got %v, want locked %v", tc.method, tc.req, err, tc.locked)
+		}
+	}
+
+	list, err := f.service.ListLocks(ctx, &PathRequest{Path: "releases/v1"})
+	if err != nil || len(list.Locks) != 1 || list.Locks[0].Owner != "deployer" {
+		t.Errorf("ListLocks = %v, %v", list, err)
+	}
+
+	if _, err := f.service.ReleaseLock(ctx, &LeaseRequest{LeaseId: lock.LeaseId}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.checkLocks(holder, write, &WriteRequest{Path: "releases/v1/app"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("write with a released lease: got %v, want FailedPrecondition", err)
+	}
+	if err := f.service.checkLocks(ctx, write, &WriteRequest{Path: "releases/v1/app"}); err != nil {
+		t.Errorf("write after release: %v", err)
+	}
+
+	// Leases run out on their own, and take their flock along
+	lock, err = f.service.AcquireLock(ctx, &LockRequest{Path: "page.txt", TtlSeconds: 1, Flock: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(f.path("page.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_SH|unix.LOCK_NB); err != unix.EWOULDBLOCK {
+		t.Errorf("flock while the lease is held: got %v, want EWOULDBLOCK", err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := f.service.RenewLock(ctx, &RenewLockRequest{LeaseId: lock.LeaseId}); status.Code(err) != codes.NotFound {
+		t.Errorf("renewing an expired lease: got %v, want NotFound", err)
+	}
+	// The expiry timer may run a little late
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		err := unix.Flock(int(file.Fd()), unix.LOCK_SH|unix.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("flock after the lease expired: %v", err)
+		}
+	}
+}
+
+// Edits through the daemon go ahead under the flock of a presented lease and
+// give up, rather than hang, while someone else holds the file locked
+func TestLeaseFlockEdits(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "page.txt", "original\n")
+	ctx := context.Background()
+
+	lock, err := f.service.AcquireLock(ctx, &LockRequest{Path: "page.txt", Flock: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder := metadata.NewIncomingContext(ctx, metadata.Pairs(leaseMetadataKey, lock.LeaseId))
+
+	response, err := f.service.WriteFile(holder, &WriteRequest{Path: "page.txt", Data: []byte("modified\n")})
+	if err != nil || !response.Success {
+		t.Fatalf("WriteFile under the lease = %v, %v", response, err)
+	}
+
+	// Without the lease, the edit waits for the flock only as long as the request lives
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := f.service.WriteFile(short, &WriteRequest{Path: "page.txt", Data: []byte("lost")}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("WriteFile while the flock is held: got %v, want DeadlineExceeded", err)
+	}
+
+	patch := "@@ -1 +1 @@\n-modified\n+patched\n"
+	if response, err := f.service.ApplyPatch(holder, &ApplyPatchRequest{Path: "page.txt", Patch: patch}); err != nil || !response.Success {
+		t.Fatalf("ApplyPatch under the lease = %v, %v", response, err)
+	}
+	if got := f.read(t, "page.txt"); got != "patched\n" {
+		t.Errorf("content = %q, want %q", got, "patched\n")
+	}
+
+	if _, err := f.service.ReleaseLock(ctx, &LeaseRequest{LeaseId: lock.LeaseId}); err != nil {
+		t.Fatal(err)
+	}
+	if response, err := f.service.WriteFile(ctx, &WriteRequest{Path: "page.txt", Data: []byte("after\n")}); err != nil || !response.Success {
+		t.Errorf("WriteFile after release = %v, %v", response, err)
+	}
+}
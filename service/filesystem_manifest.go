@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// maxManifestEntries bounds the manifest a client can make VerifyManifest hold
+const maxManifestEntries = 1 << 20
+
+// GenerateManifest implements the GenerateManifest RPC method (streaming to client)
+func (s *FilesystemService) GenerateManifest(req *ManifestRequest, stream FilesystemService_GenerateManifestServer) error {
+	ctx := stream.Context()
+
+	validPath, rules, err := s.manifestDir(req.Path, req.Exclude)
+	if err != nil {
+		return err
+	}
+
+	// Paths are relative to the manifest's directory, as VerifyManifest and
+	// sha256sum -c expect them
+	err = s.walkTree(validPath, rules, func(rel string, entry *treeEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.info.Mode().IsRegular() {
+			return nil
+		}
+		sum, err := s.fileSHA256(ctx, entry.path)
+		if os.IsNotExist(err) {
+			return nil // Removed since the directory was read
+		}
+		if err != nil {
+			return err
+		}
+		return stream.Send(&ManifestEntry{
+			Path:           rel,
+			Size:           entry.info.Size(),
+			Mode:           manifestMode(entry.info),
+			ModifiedTimeNs: entry.info.ModTime().UnixNano(),
+			Sha256:         hex.EncodeToString(sum),
+		})
+	})
+	if err != nil {
+		return streamError(ctx.Err(), err, "Failed to generate manifest")
+	}
+	return nil
+}
+
+// VerifyManifest implements the VerifyManifest RPC method (bidirectional streaming)
+func (s *FilesystemService) VerifyManifest(stream FilesystemService_VerifyManifestServer) error {
+	ctx := stream.Context()
+
+	// The first message names the directory, which is checked before the
+	// entries are read
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Errorf(codes.InvalidArgument, "No manifest received")
+	}
+	if err != nil {
+		return err
+	}
+	validPath, rules, err := s.manifestDir(first.Path, first.Exclude)
+	if err != nil {
+		return err
+	}
+	rootSlash := filepath.ToSlash(validPath)
+
+	// Entry paths are relative to the manifest's directory; the deny list and
+	// exclude rules apply to them resolved against the base directory, so
+	// hidden files are left out of the comparison as they are of the walk
+	expected := make(map[string]*ManifestEntry)
+	for req := first; ; {
+		for _, entry := range req.Entries {
+			rel, err := checkManifestEntry(entry)
+			if err != nil {
+				return err
+			}
+			if _, ok := expected[rel]; ok {
+				return status.Errorf(codes.InvalidArgument, "Manifest lists %s more than once", rel)
+			}
+			if len(expected) >= maxManifestEntries {
+				return status.Errorf(codes.InvalidArgument, "Manifest has more than %d entries", maxManifestEntries)
+			}
+			expected[rel] = entry
+		}
+
+		req, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Directories are not part of manifests, everything else is
+	actual := make(map[string]*treeEntry)
+	err = s.walkTree(validPath, rules, func(rel string, entry *treeEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.info.IsDir() {
+			actual[rel] = entry
+		}
+		return nil
+	})
+	if err != nil {
+		return streamError(ctx.Err(), err, "Failed to read directory")
+	}
+
+	rels := make([]string, 0, len(expected))
+	for rel := range expected {
+		if !manifestExcluded(rules, rootSlash, rel) && !s.isDenied(path.Join(rootSlash, rel)) {
+			rels = append(rels, rel)
+		}
+	}
+	for rel := range actual {
+		if _, ok := expected[rel]; !ok {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		want, have := expected[rel], actual[rel]
+		change := treeChange{path: path.Join(rootSlash, rel), new: have}
+		switch {
+		case want == nil:
+			change.change = pb.ChangeType_CHANGE_ADDED
+		case have == nil:
+			if err := stream.Send(&pb.PathChange{
+				Path:           change.path,
+				Change:         pb.ChangeType_CHANGE_DELETED,
+				FileType:       pb.FileType_FILE_TYPE_REGULAR,
+				Size:           want.Size,
+				ModifiedTimeNs: want.ModifiedTimeNs,
+			}); err != nil {
+				return err
+			}
+			continue
+		default:
+			differences, err := s.manifestDifferences(ctx, want, have, first.CheckMetadata)
+			if os.IsNotExist(err) {
+				continue // Removed since the directory was read
+			}
+			if err != nil {
+				return streamError(ctx.Err(), err, "Failed to read file")
+			}
+			if len(differences) == 0 {
+				continue
+			}
+			change.change = pb.ChangeType_CHANGE_MODIFIED
+			change.differences = differences
+		}
+		if err := stream.Send(change.proto()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifestDir validates the directory of a manifest and compiles its exclude rules
+func (s *FilesystemService) manifestDir(dir string, exclude []string) (string, *pathRules, error) {
+	validPath, err := s.validatePath(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := s.root.Stat(validPath)
+	if err != nil {
+		return "", nil, rootError(err, "Failed to access path")
+	}
+	if !info.IsDir() {
+		return "", nil, status.Errorf(codes.InvalidArgument, "Path is not a directory")
+	}
+	rules, err := s.newPathRules(validPath, nil, exclude, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return validPath, rules, nil
+}
+
+// checkManifestEntry validates an entry sent to VerifyManifest and returns
+// its path in the form walkTree reports
+func checkManifestEntry(entry *ManifestEntry) (string, error) {
+	rel := path.Clean(entry.Path)
+	if entry.Path == "" || path.IsAbs(rel) || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid manifest path %q", entry.Path)
+	}
+	if entry.Sha256 != "" {
+		if sum, err := hex.DecodeString(entry.Sha256); err != nil || len(sum) != 32 {
+			return "", status.Errorf(codes.InvalidArgument, "Invalid SHA-256 for %s", rel)
+		}
+	}
+	if entry.Mode != "" {
+		if _, err := strconv.ParseUint(entry.Mode, 8, 32); err != nil {
+			return "", status.Errorf(codes.InvalidArgument, "Invalid mode %q for %s", entry.Mode, rel)
+		}
+	}
+	return rel, nil
+}
+
+// manifestExcluded reports whether a manifest entry is excluded by the rules,
+// itself or through one of its directories
+func manifestExcluded(rules *pathRules, rootSlash, rel string) bool {
+	segments := strings.Split(rel, "/")
+	for i := range segments {
+		isDir := i < len(segments)-1
+		relPath := slashRel(path.Join(rootSlash, strings.Join(segments[:i+1], "/")))
+		if rules.excluded(relPath, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestDifferences lists what differs between a manifest entry and the
+// file at its path. The content is only hashed when the sizes match.
+func (s *FilesystemService) manifestDifferences(ctx context.Context, want *ManifestEntry, have *treeEntry, checkMetadata bool) ([]string, error) {
+	if !have.info.Mode().IsRegular() {
+		return []string{"type"}, nil
+	}
+
+	var differences []string
+	switch {
+	case (want.Size != 0 || want.Sha256 == "") && want.Size != have.info.Size():
+		differences = append(differences, "size")
+	case want.Sha256 != "":
+		sum, err := s.fileSHA256(ctx, have.path)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(hex.EncodeToString(sum), want.Sha256) {
+			differences = append(differences, "content")
+		}
+	}
+	if checkMetadata {
+		if want.Mode != "" {
+			mode, _ := strconv.ParseUint(want.Mode, 8, 32)
+			if uint32(mode) != syscallMode(have.info.Mode()) {
+				differences = append(differences, "mode")
+			}
+		}
+		if want.ModifiedTimeNs != 0 && want.ModifiedTimeNs != have.info.ModTime().UnixNano() {
+			differences = append(differences, "mtime")
+		}
+	}
+	return differences, nil
+}
+
+// manifestMode formats the permission bits of a file for a manifest
+func manifestMode(info os.FileInfo) string {
+	return fmt.Sprintf("%04o", syscallMode(info.Mode()))
+}
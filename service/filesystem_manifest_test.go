@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// manifestStream collects the entries GenerateManifest sends
+type manifestStream struct {
+	grpc.ServerStream
+	entries []*ManifestEntry
+}
+
+func (m *manifestStream) Context() context.Context { return context.Background() }
+
+func (m *manifestStream) Send(entry *ManifestEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+// verifyStream sends a manifest to VerifyManifest and collects the changes
+type verifyStream struct {
+	compareStream
+	in []*VerifyManifestRequest
+}
+
+func (v *verifyStream) Recv() (*VerifyManifestRequest, error) {
+	if len(v.in) == 0 {
+		return nil, io.EOF
+	}
+	req := v.in[0]
+	v.in = v.in[1:]
+	return req, nil
+}
+
+func TestManifest(t *testing.T) {
+	f := newTestFixture(t)
+	f.deny(t, ".env", "/a.txt")
+	f.write(t, "release/a.txt", "a")
+	f.write(t, "release/sub/b.txt", "bb")
+	f.write(t, "release/logs/x.log", "log")
+	f.write(t, "release/.env", "secret")
+
+	generated := &manifestStream{}
+	if err := f.service.GenerateManifest(&ManifestRequest{Path: "release", Exclude: []string{"logs/"}}, generated); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, entry := range generated.entries {
+		paths = append(paths, entry.Path)
+	}
+	if got, want := strings.Join(paths, ","), "a.txt,sub/b.txt"; got != want {
+		t.Fatalf("manifest paths = %s, want %s", got, want)
+	}
+	// The deny list interceptor must not read them as paths of the base directory
+	for _, entry := range generated.entries {
+		if f.service.messageDenied(entry.ProtoReflect()) {
+			t.Errorf("manifest entry %s would be dropped as denied", entry.Path)
+		}
+	}
+	sum := sha256.Sum256([]byte("a"))
+	if entry := generated.entries[0]; entry.Sha256 != hex.EncodeToString(sum[:]) || entry.Size != 1 || entry.Mode != "0644" {
+		t.Errorf("manifest entry = %v", entry)
+	}
+
+	// A generated manifest verifies as it is
+	entries := generated.entries
+	verify := func(checkMetadata bool, entries ...*ManifestEntry) (string, error) {
+		t.Helper()
+		stream := &verifyStream{in: []*VerifyManifestRequest{
+			{Path: "release", Entries: entries[:1], CheckMetadata: checkMetadata, Exclude: []string{"logs/"}},
+			{Entries: entries[1:]},
+		}}
+		err := f.service.VerifyManifest(stream)
+		return strings.Join(stream.changes, ","), err
+	}
+	if got, err := verify(true, entries...); err != nil || got != "" {
+		t.Errorf("unchanged directory: %s, %v, want no changes", got, err)
+	}
+
+	f.write(t, "release/a.txt", "A")
+	f.write(t, "release/c.txt", "c")
+	if err := os.Remove(filepath.Join(f.base, "release", "sub", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	want := "CHANGE_MODIFIED release/a.txt content,CHANGE_ADDED release/c.txt,CHANGE_DELETED release/sub/b.txt"
+	if got, err := verify(false, entries...); err != nil || got != want {
+		t.Errorf("changed directory: %s, %v, want %s", got, err, want)
+	}
+
+	// sha256sum manifests have no sizes, modes or times
+	if err := os.Chmod(filepath.Join(f.base, "release", "c.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sumC := sha256.Sum256([]byte("c"))
+	cEntry := &ManifestEntry{Path: "./c.txt", Sha256: hex.EncodeToString(sumC[:]), Mode: "0644"}
+	aEntry := &ManifestEntry{Path: "a.txt", Sha256: hex.EncodeToString(sum[:])}
+	if got, err := verify(false, aEntry, cEntry); err != nil || got != "CHANGE_MODIFIED release/a.txt content" {
+		t.Errorf("sha256sum manifest: %s, %v", got, err)
+	}
+	if got, err := verify(true, aEntry, cEntry); err != nil || got != "CHANGE_MODIFIED release/a.txt content,CHANGE_MODIFIED release/c.txt mode" {
+		t.Errorf("metadata: %s, %v", got, err)
+	}
+
+	if _, err := verify(false, aEntry, &ManifestEntry{Path: "../public.txt"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("path outside the directory: got %v, want InvalidArgument", err)
+	}
+	if _, err := verify(false, aEntry, aEntry); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("duplicate entry: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestVerifyManifestDenied(t *testing.T) {
+	f := newTestFixture(t)
+	f.deny(t, "release/private/", "/release/key.pem")
+	f.write(t, "release/a.txt", "a")
+	f.write(t, "release/key.pem", "key")
+	f.write(t, "release/private/data.txt", "data")
+	f.write(t, "key.pem", "not denied at the top")
+
+	// Entries are relative to the manifest's directory, the deny list to the
+	// base directory; denied entries must not be compared or reported
+	sum := sha256.Sum256([]byte("a"))
+	stream := &verifyStream{in: []*VerifyManifestRequest{{Path: "release", Entries: []*ManifestEntry{
+		{Path: "a.txt", Sha256: hex.EncodeToString(sum[:])},
+		{Path: "key.pem", Size: 1},
+		{Path: "private", Size: 1},
+		{Path: "private/data.txt", Size: 1},
+		{Path: "private/gone.txt", Size: 1},
+	}}}}
+	if err := f.service.VerifyManifest(stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.changes) != 0 {
+		t.Errorf("changes to denied paths: %q", stream.changes)
+	}
+
+	// The same names elsewhere are compared as usual
+	stream = &verifyStream{in: []*VerifyManifestRequest{{Path: "/", Exclude: []string{"release/"}, Entries: []*ManifestEntry{
+		{Path: "key.pem", Size: 1},
+	}}}}
+	if err := f.service.VerifyManifest(stream); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(stream.changes, ","); got != "CHANGE_MODIFIED key.pem size" {
+		t.Errorf("changes = %s", got)
+	}
+
+	if err := f.service.VerifyManifest(&verifyStream{in: []*VerifyManifestRequest{{Path: "release/private", Entries: []*ManifestEntry{{Path: "data.txt", Size: 4}}}}}); status.Code(err) != codes.NotFound {
+		t.Errorf("denied manifest directory: got %v, want NotFound", err)
+	}
+	if err := f.service.VerifyManifest(&verifyStream{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty stream: got %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// setIDBits are the mode bits that run a program with its owner's or group's rights
+const setIDBits = unix.S_ISUID | unix.S_ISGID
+
+// SetAllowSetID lets clients set the setuid and setgid bits of files, which
+// SetPermissions refuses by default. Files owned by root never get them, nor
+// do directories need permission, where setgid only passes on the group.
+func (s *FilesystemService) SetAllowSetID(allow bool) {
+	s.allowSetID = allow
+}
+
+// SetPermissions implements the SetPermissions RPC method
+func (s *FilesystemService) SetPermissions(ctx context.Context, req *SetPermissionsRequest) (*OperationResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Mode == "" && req.FileMode == "" && req.DirMode == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "A mode is required")
+	}
+
+	// file_mode and dir_mode fall back to mode; an empty change keeps the mode
+	changes := make(map[bool]modeChange, 2)
+	for isDir, spec := range map[bool]string{false: req.FileMode, true: req.DirMode} {
+		if spec == "" {
+			spec = req.Mode
+		}
+		if spec == "" {
+			continue
+		}
+		change, err := parseModeChange(spec)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid mode %q: %v", spec, err)
+		}
+		changes[isDir] = change
+	}
+
+	// Refused up front, so a recursive change is not left half done
+	if change, ok := changes[false]; ok && !s.allowSetID && change(0, false)&setIDBits != 0 {
+		return nil, status.Errorf(codes.PermissionDenied, "Setting the setuid or setgid bit of files is not allowed")
+	}
+
+	err = s.walkMetadata(ctx, validPath, req.Recursive, func(path string, info os.FileInfo) error {
+		change, ok := changes[info.IsDir()]
+		if !ok {
+			return nil
+		}
+		mode := syscallMode(info.Mode())
+		newMode := change(mode, info.IsDir())
+		if added := newMode &^ mode & setIDBits; added != 0 && !info.IsDir() && statFieldsOf(info).uid == 0 {
+			return status.Errorf(codes.PermissionDenied, "Setting the setuid or setgid bit of files owned by root is not allowed: %s", slashRel(path))
+		}
+		return s.root.Chmod(path, newMode)
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return metadataError("Failed to change permissions", err)
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: "Permissions changed successfully",
+	}, nil
+}
+
+// SetOwner implements the SetOwner RPC method
+func (s *FilesystemService) SetOwner(ctx context.Context, req *SetOwnerRequest) (*OperationResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Owner == "" && req.Group == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Owner or group is required")
+	}
+
+	// -1 leaves the ID unchanged
+	uid, gid := -1, -1
+	if req.Owner != "" {
+		id, err := userNames.id(req.Owner)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown owner %q", req.Owner)
+		}
+		uid = int(id)
+	}
+	if req.Group != "" {
+		id, err := groupNames.id(req.Group)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown group %q", req.Group)
+		}
+		gid = int(id)
+	}
+
+	err = s.walkMetadata(ctx, validPath, req.Recursive, func(path string, info os.FileInfo) error {
+		return s.root.Chown(path, uid, gid)
+	})
+	if err != nil {
+		return metadataError("Failed to change owner", err)
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: "Owner changed successfully",
+	}, nil
+}
+
+// SetTimes implements the SetTimes RPC method
+func (s *FilesystemService) SetTimes(ctx context.Context, req *SetTimesRequest) (*OperationResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ModifiedTimeNs < -1 || req.AccessTimeNs < -1 {
+		return nil, status.Errorf(codes.InvalidArgument, "Timestamps must not be negative")
+	}
+
+	if _, err := s.root.Lstat(validPath); os.IsNotExist(err) {
+		if !req.Create {
+			return &OperationResponse{
+				Success: false,
+				Error:   "File or directory does not exist",
+			}, nil
+		}
+
+		// Like touch, an existing file is never truncated
+		file, err := s.root.OpenFile(validPath, os.O_WRONLY|os.O_CREATE|unix.O_NONBLOCK, 0666)
+		if err != nil {
+			return metadataError("Failed to create file", err)
+		}
+		file.Close()
+	}
+
+	times := [2]unix.Timespec{timespecOf(req.AccessTimeNs), timespecOf(req.ModifiedTimeNs)}
+	if err := s.root.Chtimes(validPath, times); err != nil {
+		return metadataError("Failed to change times", err)
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: "Times changed successfully",
+	}, nil
+}
+
+// timespecOf converts a SetTimesRequest timestamp for utimensat
+func timespecOf(ns int64) unix.Timespec {
+	switch ns {
+	case 0:
+		return unix.Timespec{Nsec: unix.UTIME_NOW}
+	case -1:
+		return unix.Timespec{Nsec: unix.UTIME_OMIT}
+	default:
+		return unix.NsecToTimespec(ns)
+	}
+}
+
+// metadataError reports a failed metadata change. Symlinks leading outside
+// or refused by the policy are errors, as they are for reads.
+func metadataError(msg string, err error) (*OperationResponse, error) {
+	if isOutsideRoot(err) || isSymlinkRefused(err) {
+		return nil, rootError(err, msg)
+	}
+	if os.IsNotExist(err) {
+		return &OperationResponse{
+			Success: false,
+			Error:   "File or directory does not exist",
+		}, nil
+	}
+	return &OperationResponse{
+		Success: false,
+		Error:   msg + ": " + err.Error(),
+	}, nil
+}
+
+// walkMetadata calls fn for path, resolved as the symlink policy allows, and
+// with recursive for everything beneath it. Symlinks met while recursing and
+// denied paths are skipped. fn receives paths relative to the base directory.
+func (s *FilesystemService) walkMetadata(ctx context.Context, path string, recursive bool, fn func(path string, info os.FileInfo) error) error {
+	info, err := s.root.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(path, info); err != nil {
+		return err
+	}
+	if !recursive || !info.IsDir() {
+		return nil
+	}
+
+	// A followed symlink is walked through its own path so children stay beneath it
+	return s.root.WalkDir(path, func(walkPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if walkPath == filepath.ToSlash(cleanRel(path)) || entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if s.deniedEntry(walkPath, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		entryInfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(filepath.FromSlash(walkPath), entryInfo)
+	})
+}
+
+// modeChange computes new permission bits from the current ones
+type modeChange func(mode uint32, isDir bool) uint32
+
+// parseModeChange parses an octal mode such as "0644" or a symbolic mode such
+// as "u=rwX,go-w" like chmod does. Clauses without u, g, o or a apply to
+// everyone; the daemon's umask is not consulted.
+func parseModeChange(spec string) (modeChange, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("empty mode")
+	}
+	if spec[0] >= '0' && spec[0] <= '7' {
+		mode, err := strconv.ParseUint(spec, 8, 32)
+		if err != nil || mode > 07777 {
+			return nil, fmt.Errorf("octal modes range from 0 to 7777")
+		}
+		return func(uint32, bool) uint32 { return uint32(mode) }, nil
+	}
+
+	var clauses []func(mode uint32, isDir bool) uint32
+	for _, clause := range strings.Split(spec, ",") {
+		apply, err := parseModeClause(clause)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, apply)
+	}
+	return func(mode uint32, isDir bool) uint32 {
+		for _, apply := range clauses {
+			mode = apply(mode, isDir)
+		}
+		return mode
+	}, nil
+}
+
+// parseModeClause parses one symbolic clause, e.g. "ug+rw" or "o=u"
+func parseModeClause(clause string) (func(mode uint32, isDir bool) uint32, error) {
+	var who uint32
+	i := 0
+	for ; i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0; i++ {
+		switch clause[i] {
+		case 'u':
+			who |= unix.S_ISUID | 0700
+		case 'g':
+			who |= unix.S_ISGID | 0070
+		case 'o':
+			who |= unix.S_ISVTX | 0007
+		case 'a':
+			who |= 07777
+		}
+	}
+	if who == 0 {
+		who = 07777
+	}
+	if i == len(clause) {
+		return nil, fmt.Errorf("clause %q has no operator", clause)
+	}
+
+	type action struct {
+		op    byte
+		perms string
+	}
+	var actions []action
+	for i < len(clause) {
+		op := clause[i]
+		if op != '+' && op != '-' && op != '=' {
+			return nil, fmt.Errorf("unexpected %q in clause %q", op, clause)
+		}
+		i++
+		start := i
+		for i < len(clause) && clause[i] != '+' && clause[i] != '-' && clause[i] != '=' {
+			i++
+		}
+		perms := clause[start:i]
+		if (len(perms) > 1 && strings.ContainsAny(perms, "ugo")) || strings.Trim(perms, "rwxXstugo") != "" {
+			return nil, fmt.Errorf("invalid permissions %q in clause %q", perms, clause)
+		}
+		actions = append(actions, action{op: op, perms: perms})
+	}
+
+	return func(mode uint32, isDir bool) uint32 {
+		for _, a := range actions {
+			bits := permBits(a.perms, mode, isDir) & who
+			switch a.op {
+			case '+':
+				mode |= bits
+			case '-':
+				mode &^= bits
+			case '=':
+				clear := who
+				if isDir {
+					// As with chmod, "=" keeps set-ID bits of directories unless named
+					clear &^= unix.S_ISUID | unix.S_ISGID
+				}
+				mode = mode&^clear | bits
+			}
+		}
+		return mode
+	}, nil
+}
+
+// permBits returns the mode bits named by the permission letters of a clause,
+// before they are masked to the clause's user classes
+func permBits(perms string, mode uint32, isDir bool) uint32 {
+	// Copying from a class replicates its bits to every class
+	if perms != "" && strings.ContainsAny(perms, "ugo") {
+		shift := map[byte]uint{'u': 6, 'g': 3, 'o': 0}[perms[0]]
+		v := (mode >> shift) & 7
+		return v | v<<3 | v<<6
+	}
+
+	var bits uint32
+	for _, p := range perms {
+		switch p {
+		case 'r':
+			bits |= 0444
+		case 'w':
+			bits |= 0222
+		case 'x':
+			bits |= 0111
+		case 'X':
+			// Execute only for directories and files already executable by someone
+			if isDir || mode&0111 != 0 {
+				bits |= 0111
+			}
+		case 's':
+			bits |= unix.S_ISUID | unix.S_ISGID
+		case 't':
+			bits |= unix.S_ISVTX
+		}
+	}
+	return bits
+}
+
+// openPath opens an O_PATH descriptor of name, following a final symlink as
+// the policy allows. os.Root opens a final symlink itself with O_PATH, so on
+// the fallback resolver such links are followed here one at a time.
+func (r *rootFS) openPath(name string) (*os.File, error) {
+	name = cleanRel(name)
+	for hops := 0; ; hops++ {
+		f, err := r.OpenFile(name, unix.O_PATH, 0)
+		if err != nil || r.fallback == nil || r.policy != SymlinkFollowWithinRoot {
+			return f, err
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return f, nil
+		}
+		f.Close()
+
+		if hops == 40 {
+			return nil, &os.PathError{Op: "open", Path: name, Err: unix.ELOOP}
+		}
+		target, err := r.Readlink(name)
+		if err != nil {
+			return nil, err
+		}
+
+		// Absolute links are refused by RESOLVE_BENEATH as well
+		next := filepath.Join(filepath.Dir(name), target)
+		if filepath.IsAbs(target) || next == ".." || strings.HasPrefix(next, ".."+string(filepath.Separator)) {
+			return nil, &os.PathError{Op: "open", Path: name, Err: unix.EXDEV}
+		}
+		name = next
+	}
+}
+
+// procPath names an open descriptor through /proc, for system calls that
+// have no variant taking an O_PATH descriptor
+func procPath(f *os.File) string {
+	return "/proc/self/fd/" + strconv.Itoa(int(f.Fd()))
+}
+
+// Chmod changes the permission bits of name, following a final symlink as the policy allows
+func (r *rootFS) Chmod(name string, mode uint32) error {
+	f, err := r.openPath(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// fchmodat2 (Linux 6.6+) accepts AT_EMPTY_PATH; older kernels go through /proc
+	err = unix.Fchmodat(int(f.Fd()), "", mode, unix.AT_EMPTY_PATH)
+	if err == unix.EOPNOTSUPP || err == unix.ENOSYS || err == unix.EINVAL {
+		err = unix.Fchmodat(unix.AT_FDCWD, procPath(f), mode, 0)
+	}
+	if err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: err}
+	}
+	return nil
+}
+
+// Chown changes the owner and group of name; -1 leaves an ID unchanged
+func (r *rootFS) Chown(name string, uid, gid int) error {
+	f, err := r.openPath(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := unix.Fchownat(int(f.Fd()), "", uid, gid, unix.AT_EMPTY_PATH); err != nil {
+		return &os.PathError{Op: "chown", Path: name, Err: err}
+	}
+	return nil
+}
+
+// Chtimes sets the access and modification times of name
+func (r *rootFS) Chtimes(name string, times [2]unix.Timespec) error {
+	f, err := r.openPath(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := unix.UtimesNanoAt(unix.AT_FDCWD, procPath(f), times[:], 0); err != nil {
+		return &os.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseModeChange(t *testing.T) {
+	for _, tc := range []struct {
+		spec  string
+		mode  uint32
+		isDir bool
+		want  uint32
+	}{
+		{"0644", 0777, false, 0644},
+		{"2750", 0, true, 02750},
+		{"u=rwX,go=rX", 0600, false, 0644},
+		{"u=rwX,go=rX", 0700, true, 0755},
+		{"u=rwX,go=rX", 0744, false, 0755},
+		{"go-w", 0777, false, 0755},
+		{"+x", 0644, false, 0755},
+		{"a=r,u+w", 0777, false, 0644},
+		{"g=u", 0640, false, 0660},
+		{"u+s,o+t", 0755, true, 0755 | 04000 | 01000},
+		{"=rx", 02775, true, 02555},
+	} {
+		change, err := parseModeChange(tc.spec)
+		if err != nil {
+			t.Errorf("parseModeChange(%q): %v", tc.spec, err)
+			continue
+		}
+		if got := change(tc.mode, tc.isDir); got != tc.want {
+			t.Errorf("%q applied to %o = %o, want %o", tc.spec, tc.mode, got, tc.want)
+		}
+	}
+
+	for _, spec := range []string{"", "8", "17777", "u", "u+q", "u=go", "z+x"} {
+		if _, err := parseModeChange(spec); err == nil {
+			t.Errorf("parseModeChange(%q) succeeded", spec)
+		}
+	}
+}
+
+// Clients must not make programs run with someone else's rights unless allowed
+func TestSetIDBits(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	f.write(t, "bin/tool", "#!/bin/sh\n")
+	mode := func(relPath string) os.FileMode {
+		t.Helper()
+		info, err := os.Stat(f.path(relPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Mode()
+	}
+
+	for _, spec := range []string{"4755", "u+s", "g=rxs"} {
+		if _, err := f.service.SetPermissions(ctx, &SetPermissionsRequest{Path: "bin", Mode: spec, Recursive: true}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("mode %s: got %v, want PermissionDenied", spec, err)
+		}
+	}
+	if got := mode("bin/tool"); got&(os.ModeSetuid|os.ModeSetgid) != 0 {
+		t.Fatalf("tool mode = %v after refused changes", got)
+	}
+
+	// Directories only pass their group on to new files
+	if resp, err := f.service.SetPermissions(ctx, &SetPermissionsRequest{Path: "bin", DirMode: "g+s"}); err != nil || !resp.Success {
+		t.Fatalf("setgid directory: %v, %v", resp, err)
+	}
+	if got := mode("bin"); got&os.ModeSetgid == 0 {
+		t.Errorf("directory mode = %v, want setgid", got)
+	}
+
+	f.service.SetAllowSetID(true)
+	if os.Getuid() == 0 {
+		if _, err := f.service.SetPermissions(ctx, &SetPermissionsRequest{Path: "bin/tool", Mode: "u+s"}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("setuid file owned by root: got %v, want PermissionDenied", err)
+		}
+		if err := os.Chown(f.path("bin/tool"), 65534, 65534); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resp, err := f.service.SetPermissions(ctx, &SetPermissionsRequest{Path: "bin/tool", Mode: "u+s"}); err != nil || !resp.Success {
+		t.Fatalf("allowed setuid: %v, %v", resp, err)
+	}
+	if got := mode("bin/tool"); got&os.ModeSetuid == 0 {
+		t.Errorf("tool mode = %v, want setuid", got)
+	}
+}
@@ -3,10 +3,11 @@ package service
 import (
 	"context"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"golang.org/x/sys/unix"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -19,7 +20,7 @@ func (s *FilesystemService) CreateDirectory(ctx context.Context, req *CreateDire
 	}
 
 	// Check if directory already exists
-	if _, err := os.Stat(validPath); err == nil {
+	if _, err := s.root.Lstat(validPath); err == nil {
 		return &OperationResponse{
 			Success: false,
 			Error:   "Directory already exists",
@@ -32,7 +33,7 @@ func (s *FilesystemService) CreateDirectory(ctx context.Context, req *CreateDire
 		perm = os.FileMode(req.Permissions)
 	}
 
-	if err := os.MkdirAll(validPath, perm); err != nil {
+	if err := s.root.MkdirAll(validPath, perm); err != nil {
 		return &OperationResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -52,8 +53,12 @@ func (s *FilesystemService) Delete(ctx context.Context, req *DeleteRequest) (*Op
 		return nil, err
 	}
 
-	// Check if path exists
-	info, err := os.Stat(validPath)
+	if err := s.checkPathETag(validPath, req.IfMatch, req.IfNoneMatch); err != nil {
+		return nil, err
+	}
+
+	// Check if path exists; a symlink is deleted itself, never its target
+	info, err := s.root.Lstat(validPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &OperationResponse{
@@ -70,7 +75,7 @@ func (s *FilesystemService) Delete(ctx context.Context, req *DeleteRequest) (*Op
 	// If it's a directory, check recursive flag
 	if info.IsDir() && !req.Recursive {
 		// Check if directory is empty
-		entries, err := os.ReadDir(validPath)
+		entries, err := s.root.ReadDir(validPath)
 		if err != nil {
 			return &OperationResponse{
 				Success: false,
@@ -86,23 +91,42 @@ func (s *FilesystemService) Delete(ctx context.Context, req *DeleteRequest) (*Op
 		}
 
 		// Directory is empty, delete it
-		if err := os.Remove(validPath); err != nil {
+		if err := s.root.Remove(validPath); err != nil {
 			return &OperationResponse{
 				Success: false,
 				Error:   err.Error(),
 			}, nil
 		}
 	} else if info.IsDir() && req.Recursive {
+		// Keep the files of versioned directories before they go
+		pending, err := s.preserveTree(validPath, "delete")
+		if err != nil {
+			return &OperationResponse{
+				Success: false,
+				Error:   "Failed to save previous version: " + err.Error(),
+			}, nil
+		}
+		defer s.settleVersions(pending)
+
 		// Recursive delete for directory
-		if err := os.RemoveAll(validPath); err != nil {
+		if err := s.root.RemoveAll(validPath); err != nil {
 			return &OperationResponse{
 				Success: false,
 				Error:   err.Error(),
 			}, nil
 		}
 	} else {
+		pending, err := s.preserveReplaced(validPath, "delete")
+		if err != nil {
+			return &OperationResponse{
+				Success: false,
+				Error:   "Failed to save previous version: " + err.Error(),
+			}, nil
+		}
+		defer s.settleVersions(pending)
+
 		// Delete file
-		if err := os.Remove(validPath); err != nil {
+		if err := s.root.Remove(validPath); err != nil {
 			return &OperationResponse{
 				Success: false,
 				Error:   err.Error(),
@@ -129,7 +153,7 @@ func (s *FilesystemService) Copy(ctx context.Context, req *CopyRequest) (*Operat
 	}
 
 	// Check if source exists
-	srcInfo, err := os.Stat(validSourcePath)
+	srcInfo, err := s.root.Lstat(validSourcePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &OperationResponse{
@@ -144,16 +168,19 @@ func (s *FilesystemService) Copy(ctx context.Context, req *CopyRequest) (*Operat
 	}
 
 	// Check if destination already exists
-	if _, err := os.Stat(validDestPath); err == nil && !req.Overwrite {
+	if _, err := s.root.Lstat(validDestPath); err == nil && !req.Overwrite {
 		return &OperationResponse{
 			Success: false,
 			Error:   "Destination already exists and overwrite is not enabled",
 		}, nil
 	}
+	if err := s.checkPathETag(validDestPath, req.IfMatch, req.IfNoneMatch); err != nil {
+		return nil, err
+	}
 
 	// Handle directory copy
 	if srcInfo.IsDir() {
-		err = copyDir(validSourcePath, validDestPath)
+		err = s.copyDir(validSourcePath, validDestPath, copyOptions{xattrs: req.PreserveXattrs})
 		if err != nil {
 			return &OperationResponse{
 				Success: false,
@@ -163,15 +190,15 @@ func (s *FilesystemService) Copy(ctx context.Context, req *CopyRequest) (*Operat
 	} else {
 		// Create destination directory if it doesn't exist
 		destDir := filepath.Dir(validDestPath)
-		if err := os.MkdirAll(destDir, 0755); err != nil {
+		if err := s.root.MkdirAll(destDir, 0755); err != nil {
 			return &OperationResponse{
 				Success: false,
 				Error:   "Failed to create destination directory: " + err.Error(),
 			}, nil
 		}
 
-		// Copy file
-		err = copyFile(validSourcePath, validDestPath)
+		// Copy file, or the symlink itself when the policy does not follow it
+		err = s.copyEntry(validSourcePath, validDestPath, srcInfo, copyOptions{xattrs: req.PreserveXattrs})
 		if err != nil {
 			return &OperationResponse{
 				Success: false,
@@ -198,8 +225,8 @@ func (s *FilesystemService) Move(ctx context.Context, req *MoveRequest) (*Operat
 		return nil, err
 	}
 
-	// Check if source exists
-	if _, err := os.Stat(validSourcePath); err != nil {
+	// Check if source exists; a symlink is moved itself
+	if _, err := s.root.Lstat(validSourcePath); err != nil {
 		if os.IsNotExist(err) {
 			return &OperationResponse{
 				Success: false,
@@ -213,24 +240,40 @@ func (s *FilesystemService) Move(ctx context.Context, req *MoveRequest) (*Operat
 	}
 
 	// Check if destination already exists
-	if _, err := os.Stat(validDestPath); err == nil && !req.Overwrite {
+	if _, err := s.root.Lstat(validDestPath); err == nil && !req.Overwrite {
 		return &OperationResponse{
 			Success: false,
 			Error:   "Destination already exists and overwrite is not enabled",
 		}, nil
 	}
+	if err := s.checkPathETag(validSourcePath, req.SourceIfMatch, ""); err != nil {
+		return nil, err
+	}
+	if err := s.checkPathETag(validDestPath, req.IfMatch, req.IfNoneMatch); err != nil {
+		return nil, err
+	}
 
 	// Create destination directory if it doesn't exist
 	destDir := filepath.Dir(validDestPath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := s.root.MkdirAll(destDir, 0755); err != nil {
 		return &OperationResponse{
 			Success: false,
 			Error:   "Failed to create destination directory: " + err.Error(),
 		}, nil
 	}
 
+	// An overwritten destination file is kept as a version
+	pending, err := s.preserveReplaced(validDestPath, "move")
+	if err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to save previous version: " + err.Error(),
+		}, nil
+	}
+	defer s.settleVersions(pending)
+
 	// Move/rename the file or directory
-	if err := os.Rename(validSourcePath, validDestPath); err != nil {
+	if err := s.root.Rename(validSourcePath, validDestPath); err != nil {
 		return &OperationResponse{
 			Success: false,
 			Error:   "Failed to move: " + err.Error(),
@@ -251,12 +294,12 @@ func (s *FilesystemService) GetDirectorySize(ctx context.Context, req *PathReque
 	}
 
 	// Check if path exists
-	info, err := os.Stat(validPath)
+	info, err := s.root.Stat(validPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, status.Errorf(codes.NotFound, "Path does not exist")
 		}
-		return nil, status.Errorf(codes.Internal, "Failed to access path: %v", err)
+		return nil, rootError(err, "Failed to access path")
 	}
 
 	// If it's a file, return its size directly
@@ -266,12 +309,21 @@ func (s *FilesystemService) GetDirectorySize(ctx context.Context, req *PathReque
 
 	// For a directory, calculate total size recursively
 	var totalSize int64
-	err = filepath.Walk(validPath, func(_ string, info os.FileInfo, err error) error {
+	err = s.root.WalkDir(validPath, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return nil // Skip files with errors
 		}
-		if !info.IsDir() {
-			totalSize += info.Size()
+		// Denied paths do not count towards the total
+		if s.deniedEntry(slashRel(path), entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			if info, err := entry.Info(); err == nil {
+				totalSize += info.Size()
+			}
 		}
 		return nil
 	})
@@ -285,87 +337,47 @@ func (s *FilesystemService) GetDirectorySize(ctx context.Context, req *PathReque
 
 // Search implements the Search RPC method
 func (s *FilesystemService) Search(ctx context.Context, req *SearchRequest) (*ListResponse, error) {
-	validPath, err := s.validatePath(req.BasePath)
+	validPath, filter, err := s.prepareSearch(req)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if base path exists and is a directory
-	info, err := os.Stat(validPath)
+	after, err := decodePageToken(req.PageToken)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, status.Errorf(codes.NotFound, "Base directory does not exist")
-		}
-		return nil, status.Errorf(codes.Internal, "Failed to access directory: %v", err)
+		return nil, err
 	}
 
-	if !info.IsDir() {
-		return nil, status.Errorf(codes.InvalidArgument, "Base path is not a directory")
+	// With paging the page size replaces max_results as the cutoff
+	limit := req.MaxResults
+	if req.PageSize > 0 {
+		limit = req.PageSize
 	}
 
 	var response ListResponse
-	var count int32
-
-	// Walk through directory recursively
-	err = filepath.Walk(validPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip files with errors
-		}
-
-		// Skip root directory
-		if path == validPath {
-			return nil
-		}
-
-		// If max results is specified and reached, stop search
-		if req.MaxResults > 0 && count >= req.MaxResults {
-			return filepath.SkipDir
-		}
-
-		// Skip directories if files_only is true
-		if info.IsDir() && req.FilesOnly {
-			return nil
-		}
-
-		// Skip files if directories_only is true
-		if !info.IsDir() && req.DirectoriesOnly {
-			return nil
-		}
-
-		// Skip non-recursive search
-		if !req.Recursive && filepath.Dir(path) != validPath {
-			return nil
-		}
-
-		// Apply pattern matching
-		if req.Pattern != "" {
-			var matched bool
-			if req.CaseSensitive {
-				matched, _ = filepath.Match(req.Pattern, info.Name())
-			} else {
-				matched, _ = filepath.Match(strings.ToLower(req.Pattern), strings.ToLower(info.Name()))
-			}
-
-			if !matched {
-				return nil
+	var lastPath string
+
+	err = s.walkSearch(ctx, validPath, req, filter, after, func(searchRelPath string, item *FileItem) error {
+		// If the limit is reached, stop search
+		if limit > 0 && int32(len(response.Items)) >= limit {
+			// There is at least one more match, so another page exists
+			if req.PageSize > 0 {
+				response.NextPageToken = encodePageToken(lastPath)
 			}
+			return errStopWalk
 		}
 
-		// Get relative path from base directory
-		relPath, err := filepath.Rel(s.BaseDir, path)
-		if err != nil {
-			return nil
-		}
-
-		// Add to results
-		item := fileItemToProto(filepath.Dir(relPath), info)
 		response.Items = append(response.Items, item)
-		count++
-
+		lastPath = searchRelPath
 		return nil
 	})
 
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
 		return nil, status.Errorf(codes.Internal, "Search failed: %v", err)
 	}
 
@@ -374,50 +386,137 @@ func (s *FilesystemService) Search(ctx context.Context, req *SearchRequest) (*Li
 
 // Helper functions for file operations
 
-func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
+// copyOptions select how copyDir, copyEntry and copyFile copy
+type copyOptions struct {
+	xattrs bool // Copy extended attributes, including ACLs, along
+	// Make an exact copy: keep modification times, give directories their
+	// mode only once they are filled, and recreate every symlink as a link
+	exact bool
+	// Hard link files that are unchanged since this earlier exact copy of the
+	// source instead of copying them, like rsync --link-dest
+	linkDest string
+	skipSame bool       // Leave destination files whose size and modification time already match
+	stats    *copyStats // Counts what was copied, if set
+}
+
+// copyStats counts the files of a copy
+type copyStats struct {
+	files  int64 // Regular files in the copy
+	bytes  int64 // Their total size
+	linked int64 // Files linked to linkDest, or left in place with skipSame
+}
+
+// entry returns the options for an entry of the directory being copied
+func (o copyOptions) entry(name string) copyOptions {
+	if o.linkDest != "" {
+		o.linkDest = filepath.Join(o.linkDest, name)
+	}
+	return o
+}
+
+// copyFile copies a regular file and its permissions, and with xattrs its extended attributes
+func (s *FilesystemService) copyFile(src, dst string, opts copyOptions) error {
+	// Only regular files have content to copy
+	sourceFile, sourceInfo, err := s.root.openRegular(src)
 	if err != nil {
 		return err
 	}
 	defer sourceFile.Close()
 
+	if opts.stats != nil {
+		opts.stats.files++
+		opts.stats.bytes += sourceInfo.Size()
+	}
+	if s.unchangedCopy(sourceInfo, dst, opts) {
+		if opts.stats != nil {
+			opts.stats.linked++
+		}
+		return nil
+	}
+
+	// Keep an overwritten destination as a version
+	if err := s.preserve(dst, "copy"); err != nil {
+		return err
+	}
+
 	// Create destination file
-	destFile, err := os.Create(dst)
+	destFile, err := s.root.createRegular(dst, 0666)
 	if err != nil {
 		return err
 	}
 	defer destFile.Close()
 
-	// Copy content
-	_, err = io.Copy(destFile, sourceFile)
-	if err != nil {
-		return err
+	// Share the data blocks where the filesystem can (btrfs, XFS), copy otherwise
+	if err := unix.IoctlFileClone(int(destFile.Fd()), int(sourceFile.Fd())); err != nil {
+		if _, err := io.Copy(destFile, sourceFile); err != nil {
+			return err
+		}
 	}
 
-	// Get source file mode
-	sourceInfo, err := os.Stat(src)
-	if err != nil {
+	// Set same permissions
+	if err := destFile.Chmod(sourceInfo.Mode()); err != nil {
 		return err
 	}
 
-	// Set same permissions
-	return os.Chmod(dst, sourceInfo.Mode())
+	// Attributes last, so that an ACL's mask is not reset by the chmod
+	if opts.xattrs {
+		if err := s.root.copyXattrs(src, dst); err != nil {
+			return err
+		}
+	}
+	if opts.exact {
+		return s.root.Chtimes(dst, copyTimes(sourceInfo))
+	}
+	return nil
+}
+
+// unchangedCopy reports whether dst needs no copy of a file: either it was
+// linked to the file's unchanged copy in linkDest, or with skipSame it has
+// the same size and modification time already
+func (s *FilesystemService) unchangedCopy(info os.FileInfo, dst string, opts copyOptions) bool {
+	same := func(other os.FileInfo) bool {
+		return other.Mode().IsRegular() && other.Size() == info.Size() && other.ModTime().Equal(info.ModTime())
+	}
+
+	if opts.linkDest != "" {
+		if prev, err := s.root.Lstat(opts.linkDest); err == nil && same(prev) && prev.Mode() == info.Mode() {
+			return s.root.Link(opts.linkDest, dst) == nil
+		}
+	}
+	if opts.skipSame {
+		if existing, err := s.root.Lstat(dst); err == nil && same(existing) {
+			return true
+		}
+	}
+	return false
 }
 
-func copyDir(src, dst string) error {
+// copyDir copies a directory tree, and with xattrs the extended attributes of
+// every entry. Denied entries are left out, as they do not exist for clients.
+func (s *FilesystemService) copyDir(src, dst string, opts copyOptions) error {
 	// Get source info
-	srcInfo, err := os.Stat(src)
+	srcInfo, err := s.root.Stat(src)
 	if err != nil {
 		return err
 	}
 
-	// Create destination directory
-	if err = os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+	// Create destination directory; an exact copy gets the mode once it is
+	// filled, so a read-only directory can be copied as well
+	mode := srcInfo.Mode()
+	if opts.exact {
+		mode = 0700
+	}
+	if err = s.root.MkdirAll(dst, mode); err != nil {
 		return err
 	}
+	if opts.xattrs {
+		if err := s.root.copyXattrs(src, dst); err != nil {
+			return err
+		}
+	}
 
 	// Read source directory entries
-	entries, err := os.ReadDir(src)
+	entries, err := s.root.ReadDir(src)
 	if err != nil {
 		return err
 	}
@@ -431,19 +530,68 @@ func copyDir(src, dst string) error {
 		if err != nil {
 			continue
 		}
+		// Denied entries, and links to them, stay behind
+		if s.deniedEntry(slashRel(srcPath), entryInfo.IsDir()) || s.root.linkDenied(srcPath) {
+			continue
+		}
 
 		if entryInfo.IsDir() {
 			// Recursive copy for directories
-			if err = copyDir(srcPath, dstPath); err != nil {
+			if err = s.copyDir(srcPath, dstPath, opts.entry(entry.Name())); err != nil {
 				return err
 			}
+		} else if !entryInfo.Mode().IsRegular() && entryInfo.Mode()&os.ModeSymlink == 0 {
+			// FIFOs, sockets and devices are skipped
+			continue
 		} else {
-			// Copy file
-			if err = copyFile(srcPath, dstPath); err != nil {
+			// Copy file or symlink
+			if err = s.copyEntry(srcPath, dstPath, entryInfo, opts.entry(entry.Name())); err != nil {
 				return err
 			}
 		}
 	}
 
+	if opts.exact {
+		if err := s.root.Chmod(dst, syscallMode(srcInfo.Mode())); err != nil {
+			return err
+		}
+		return s.root.Chtimes(dst, copyTimes(srcInfo))
+	}
 	return nil
 }
+
+// copyEntry copies a file found by Lstat. A symlink is copied as its target's
+// content when the policy follows it to a regular file, and recreated as a
+// link otherwise; symlinked directories are never copied recursively.
+func (s *FilesystemService) copyEntry(src, dst string, info os.FileInfo, opts copyOptions) error {
+	if info.Mode()&os.ModeSymlink == 0 {
+		return s.copyFile(src, dst, opts)
+	}
+
+	resolved, target := s.root.resolveLink(src, info)
+	if resolved.Mode().IsRegular() && !opts.exact {
+		return s.copyFile(src, dst, opts)
+	}
+
+	// An exact copy already holding the same link stays as it is
+	if opts.skipSame {
+		if existing, err := s.root.Readlink(dst); err == nil && existing == target {
+			return nil
+		}
+	}
+
+	// Replace an existing destination, as copyFile would
+	if err := s.root.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return s.root.Symlink(target, dst)
+}
+
+// copyTimes returns the access and modification times of info for Chtimes
+func copyTimes(info os.FileInfo) [2]unix.Timespec {
+	times := fileTimesOf(info)
+	return [2]unix.Timespec{
+		unix.NsecToTimespec(times.access.UnixNano()),
+		unix.NsecToTimespec(times.modified.UnixNano()),
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bufio"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idNameRecheck is how long a loaded ID database is used before its
+// modification time is checked again
+const idNameRecheck = 5 * time.Second
+
+// idNameCache maps numeric user or group IDs to names and back. It parses an
+// /etc/passwd style file, reloading it when the file changes, and asks os/user
+// for entries the file does not list (e.g. users from NSS modules in cgo builds).
+type idNameCache struct {
+	path     string
+	lookup   func(id string) (string, error)
+	lookupID func(name string) (string, error)
+
+	mu        sync.Mutex
+	names     map[uint32]string
+	ids       map[string]uint32
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+var (
+	userNames = &idNameCache{path: "/etc/passwd", lookup: func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	}, lookupID: func(name string) (string, error) {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return u.Uid, nil
+	}}
+	groupNames = &idNameCache{path: "/etc/group", lookup: func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	}, lookupID: func(name string) (string, error) {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return "", err
+		}
+		return g.Gid, nil
+	}}
+)
+
+// name returns the name of id, or the ID itself when it has no name
+func (c *idNameCache) name(id uint32) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refresh()
+	if name, ok := c.names[id]; ok {
+		return name
+	}
+
+	// Remember misses too, so unknown IDs are looked up once per reload
+	name, err := c.lookup(strconv.FormatUint(uint64(id), 10))
+	if err != nil || name == "" {
+		name = strconv.FormatUint(uint64(id), 10)
+	}
+	c.names[id] = name
+	return name
+}
+
+// id resolves a numeric ID or a name; unknown names are an error
+func (c *idNameCache) id(nameOrID string) (uint32, error) {
+	if id, err := strconv.ParseUint(nameOrID, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+
+	c.mu.Lock()
+	c.refresh()
+	id, ok := c.ids[nameOrID]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	// Names are not cached when missing: clients choose them freely
+	found, err := c.lookupID(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+	parsed, err := strconv.ParseUint(found, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(parsed), nil
+}
+
+// refresh reloads the file when it was modified since it was last read
+func (c *idNameCache) refresh() {
+	now := time.Now()
+	if c.names != nil && now.Sub(c.checkedAt) < idNameRecheck {
+		return
+	}
+	c.checkedAt = now
+
+	info, err := os.Stat(c.path)
+	if err == nil && c.names != nil && info.ModTime().Equal(c.modTime) {
+		return
+	}
+
+	var names map[uint32]string
+	var ids map[string]uint32
+	if err == nil {
+		names, ids, err = parseIDFile(c.path)
+	}
+	if err != nil {
+		// Without the file every lookup goes through os/user
+		c.names = make(map[uint32]string)
+		c.ids = nil
+		c.modTime = time.Time{}
+		return
+	}
+	c.names = names
+	c.ids = ids
+	c.modTime = info.ModTime()
+}
+
+// parseIDFile reads the name and ID columns of /etc/passwd or /etc/group into
+// maps in both directions. Both files use "name:password:id:..." lines; the
+// first entry for an ID or a name wins.
+func parseIDFile(path string) (map[uint32]string, map[string]uint32, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	names := make(map[uint32]string)
+	ids := make(map[string]uint32)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, ":", 4)
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		// NIS compat entries such as "+" or "-name" are not real names
+		if strings.HasPrefix(fields[0], "+") || strings.HasPrefix(fields[0], "-") {
+			continue
+		}
+
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		if _, ok := names[uint32(id)]; !ok {
+			names[uint32(id)] = fields[0]
+		}
+		if _, ok := ids[fields[0]]; !ok {
+			ids[fields[0]] = uint32(id)
+		}
+	}
+	return names, ids, scanner.Err()
+}
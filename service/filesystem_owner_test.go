@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwd")
+	writeTestFile(t, path, "# comment\nroot:x:0:0:root:/root:/bin/sh\n+::::::\nwww-data:x:33:33::/var/www:/usr/sbin/nologin\ntoor:x:0:0::/root:/bin/sh\nbroken:x:abc:0::/:/bin/sh\nroot:x:7:7::/:/bin/sh\n")
+
+	names, ids, err := parseIDFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[0] != "root" || names[33] != "www-data" || names[7] != "root" {
+		t.Errorf("parseIDFile names = %v, want root, www-data and the second root", names)
+	}
+	if len(ids) != 3 || ids["root"] != 0 || ids["www-data"] != 33 || ids["toor"] != 0 {
+		t.Errorf("parseIDFile ids = %v, want root, www-data and toor", ids)
+	}
+}
+
+func TestIDNameCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwd")
+	writeTestFile(t, path, "root:x:0:0:root:/root:/bin/sh\nwww-data:x:33:33::/var/www:/usr/sbin/nologin\n")
+	var lookups []string
+	cache := &idNameCache{
+		path: path,
+		lookup: func(id string) (string, error) {
+			lookups = append(lookups, id)
+			if id == "1000" {
+				return "nss-user", nil
+			}
+			return "", errors.New("unknown")
+		},
+		lookupID: func(name string) (string, error) {
+			lookups = append(lookups, name)
+			if name == "nss-user" {
+				return "1000", nil
+			}
+			return "", errors.New("unknown")
+		},
+	}
+
+	for _, tc := range []struct {
+		nameOrID string
+		id       uint32
+		ok       bool
+	}{
+		{"www-data", 33, true},
+		{"0", 0, true},
+		{"4242", 4242, true},
+		{"nss-user", 1000, true},
+		{"nobody-here", 0, false},
+		{"-1", 0, false},
+		{"4294967296", 0, false},
+	} {
+		id, err := cache.id(tc.nameOrID)
+		if (err == nil) != tc.ok || id != tc.id {
+			t.Errorf("id(%q) = %d, %v, want %d (ok %v)", tc.nameOrID, id, err, tc.id, tc.ok)
+		}
+	}
+	if cache.name(33) != "www-data" || cache.name(1000) != "nss-user" || cache.name(5) != "5" {
+		t.Errorf("names = %q, %q, %q", cache.name(33), cache.name(1000), cache.name(5))
+	}
+
+	// Only entries missing from the file reach os/user
+	want := []string{"nss-user", "nobody-here", "-1", "4294967296", "1000", "5"}
+	if len(lookups) != len(want) {
+		t.Fatalf("lookups = %q, want %q", lookups, want)
+	}
+	for i := range want {
+		if lookups[i] != want[i] {
+			t.Errorf("lookups = %q, want %q", lookups, want)
+			break
+		}
+	}
+}
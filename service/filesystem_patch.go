@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// hunkHeader matches "@@ -start,count +start,count @@"; a missing count is 1
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// patchHunk is a hunk of a unified diff. Lines start with their ' ', '-'
+// or '+' marker and keep their newline, if they have one.
+type patchHunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+	lines              []string
+}
+
+// ApplyPatch implements the ApplyPatch RPC method
+func (s *FilesystemService) ApplyPatch(ctx context.Context, req *ApplyPatchRequest) (*WriteResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	hunks, err := parsePatch(req.Patch)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid patch: %v", err)
+	}
+
+	// Serialize with in-place edits, which lock the same way
+	file, err := s.openLockedRegular(ctx, validPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &WriteResponse{
+				Success: false,
+				Error:   "File does not exist",
+			}, nil
+		}
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, rootError(err, "Failed to open file")
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to stat file: %v", err)
+	}
+	if err := checkPrecondition(req.Precondition, info); err != nil {
+		return nil, err
+	}
+	if info.Size() > defaultDiffMaxSize {
+		return nil, status.Errorf(codes.FailedPrecondition, "File is larger than %d bytes", defaultDiffMaxSize)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to read file: %v", err)
+	}
+	patched, err := applyHunks(splitLines(string(data)), hunks)
+	if err != nil {
+		return nil, err
+	}
+	content := []byte(strings.Join(patched, ""))
+
+	pending, err := s.preserveReplaced(validPath, "patch")
+	if err != nil {
+		return &WriteResponse{
+			Success: false,
+			Error:   "Failed to save previous version: " + err.Error(),
+		}, nil
+	}
+	defer s.settleVersions(pending)
+	newInfo, err := s.root.replaceFile(validPath, info, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+	if err != nil {
+		return &WriteResponse{
+			Success: false,
+			Error:   "Failed to write file: " + err.Error(),
+		}, nil
+	}
+
+	return &WriteResponse{
+		Success:        true,
+		Message:        fmt.Sprintf("Patch applied successfully (%d hunks)", len(hunks)),
+		BytesWritten:   int64(len(content)),
+		Size:           newInfo.Size(),
+		ModifiedTimeNs: newInfo.ModTime().UnixNano(),
+		Etag:           etagOf(newInfo),
+	}, nil
+}
+
+// parsePatch reads the hunks of a unified diff of a single file. Lines
+// outside hunks, such as "diff --git" and "index" headers, are ignored.
+func parsePatch(patch string) ([]patchHunk, error) {
+	lines := splitLines(patch)
+	var hunks []patchHunk
+	files := 0
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			if files++; files > 1 {
+				return nil, fmt.Errorf("patch changes more than one file")
+			}
+			i++
+			continue
+		}
+
+		match := hunkHeader.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		hunk := patchHunk{
+			oldStart: atoiDefault(match[1], 0),
+			oldCount: atoiDefault(match[2], 1),
+			newStart: atoiDefault(match[3], 0),
+			newCount: atoiDefault(match[4], 1),
+		}
+		if len(hunks) > 0 {
+			previous := hunks[len(hunks)-1]
+			if hunk.oldStart < previous.oldStart+previous.oldCount {
+				return nil, fmt.Errorf("hunk %d overlaps the one before it", len(hunks)+1)
+			}
+		}
+
+		oldSeen, newSeen := 0, 0
+		for oldSeen < hunk.oldCount || newSeen < hunk.newCount {
+			if i++; i >= len(lines) {
+				return nil, fmt.Errorf("hunk %d is truncated", len(hunks)+1)
+			}
+			body := lines[i]
+			if body == "\n" {
+				body = " \n" // Context line of an empty line whose space was stripped
+			}
+			switch body[0] {
+			case ' ':
+				oldSeen++
+				newSeen++
+			case '-':
+				oldSeen++
+			case '+':
+				newSeen++
+			case '\\':
+				hunk.lines = noNewline(hunk.lines)
+				continue
+			default:
+				return nil, fmt.Errorf("hunk %d has a malformed line %q", len(hunks)+1, strings.TrimSuffix(body, "\n"))
+			}
+			hunk.lines = append(hunk.lines, body)
+		}
+		if oldSeen != hunk.oldCount || newSeen != hunk.newCount {
+			return nil, fmt.Errorf("hunk %d does not match its line counts", len(hunks)+1)
+		}
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\\") {
+			hunk.lines = noNewline(hunk.lines)
+			i++
+		}
+		hunks = append(hunks, hunk)
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("no hunks found")
+	}
+	return hunks, nil
+}
+
+// noNewline drops the newline of the last line, for "\ No newline at end of file"
+func noNewline(lines []string) []string {
+	if n := len(lines); n > 0 {
+		lines[n-1] = strings.TrimSuffix(lines[n-1], "\n")
+	}
+	return lines
+}
+
+func atoiDefault(s string, fallback int) int {
+	if s == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// applyHunks applies hunks to lines. Every hunk must find its context and
+// deleted lines exactly at the position it names.
+func applyHunks(lines []string, hunks []patchHunk) ([]string, error) {
+	var out []string
+	next := 0 // First line not yet copied to out
+	for n, hunk := range hunks {
+		// An empty old range names the line after which to insert
+		start := hunk.oldStart - 1
+		if hunk.oldCount == 0 {
+			start = hunk.oldStart
+		}
+		if start < next || start > len(lines) {
+			return nil, status.Errorf(codes.FailedPrecondition, "Hunk %d does not apply at line %d", n+1, hunk.oldStart)
+		}
+		out = append(out, lines[next:start]...)
+
+		i := start
+		for _, line := range hunk.lines {
+			op, text := line[0], line[1:]
+			if op == '+' {
+				out = append(out, text)
+				continue
+			}
+			if i >= len(lines) || lines[i] != text {
+				return nil, status.Errorf(codes.FailedPrecondition, "Hunk %d does not apply at line %d", n+1, i+1)
+			}
+			if op == ' ' {
+				out = append(out, text)
+			}
+			i++
+		}
+		next = i
+	}
+	return append(out, lines[next:]...), nil
+}
+
+// openLockedRegular opens the regular file name and takes the edit lock on
+// it. A patch or delta holding the lock before may have replaced the file
+// while this one waited, so the open is retried until the locked file is the
+// one at name; otherwise the edit would be based on stale content and undo
+// the other when it replaces the file in turn.
+func (s *FilesystemService) openLockedRegular(ctx context.Context, name string) (*os.File, error) {
+	for {
+		file, _, err := s.root.openRegular(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.lockForEdit(ctx, file); err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		locked, err := file.Stat()
+		if err == nil {
+			var current os.FileInfo
+			if current, err = s.root.Stat(name); err == nil && os.SameFile(locked, current) {
+				return file, nil
+			}
+		}
+		file.Close()
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+}
+
+// replaceFile atomically replaces the regular file name with what write
+// writes, through a temporary file in the same directory. The new file takes
+// over the permissions, owner and extended attributes of the file described
+// by info, or is created like an upload when info is nil.
+func (r *rootFS) replaceFile(name string, info os.FileInfo, write func(w io.Writer) error) (os.FileInfo, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	tmpName := filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".tmp-"+hex.EncodeToString(suffix))
+
+	perm := os.FileMode(0600)
+	if info == nil {
+		perm = 0666 // Less the umask, like any new file
+	}
+	tmp, err := r.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return nil, err
+	}
+	newInfo, err := func() (os.FileInfo, error) {
+		defer tmp.Close()
+		if err := write(tmp); err != nil {
+			return nil, err
+		}
+		if info != nil {
+			// Only root can give the file away; others keep their own ownership
+			fields := statFieldsOf(info)
+			tmp.Chown(int(fields.uid), int(fields.gid))
+			if err := r.Chmod(tmpName, syscallMode(info.Mode())); err != nil {
+				return nil, err
+			}
+			if err := r.copyXattrs(name, tmpName); err != nil {
+				return nil, err
+			}
+		}
+		if err := tmp.Sync(); err != nil {
+			return nil, err
+		}
+		return tmp.Stat()
+	}()
+	if err == nil {
+		err = r.Rename(tmpName, name)
+	}
+	if err != nil {
+		r.Remove(tmpName)
+		return nil, err
+	}
+	return newInfo, nil
+}
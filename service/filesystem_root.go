@@ -0,0 +1,628 @@
+package service
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// resolveFlags confine every lookup to the base directory: "..", absolute
+// symlinks and /proc magic links can not lead outside of it
+const resolveFlags = unix.RESOLVE_BENEATH | unix.RESOLVE_NO_MAGICLINKS
+
+// SymlinkPolicy controls which symbolic links are followed when resolving paths
+type SymlinkPolicy string
+
+const (
+	SymlinkFollow           SymlinkPolicy = "follow"             // Follow every symlink, even out of the base directory
+	SymlinkFollowWithinRoot SymlinkPolicy = "follow-within-root" // Follow symlinks that resolve beneath the base directory
+	SymlinkNever            SymlinkPolicy = "never"              // Never follow symlinks
+)
+
+// rootFS performs all file access relative to a descriptor of the base
+// directory, so that a path is resolved by the kernel in the same system call
+// that uses it. Paths are relative to the base directory.
+//
+// openat2 (Linux 5.6+) resolves paths with RESOLVE_BENEATH. On older kernels
+// lookups fall back to os.Root, which walks each component with O_NOFOLLOW.
+// Either way, operations on names (mkdir, unlink, rename, ...) are done with
+// the *at system calls on a descriptor of the already resolved parent.
+//
+// The symlink policy decides which symlinks are followed during resolution.
+// A final symlink is never followed by Lstat, Readlink, Remove and Rename.
+// A lookup that follows a symlink to an entry the denied hook matches fails
+// as if the path did not exist.
+type rootFS struct {
+	path     string   // Absolute path of the base directory
+	dir      *os.File // O_PATH descriptor of the base directory
+	fallback *os.Root // Resolver used when openat2 is not available
+	policy   SymlinkPolicy
+	denied   func(relPath string, isDir bool) bool // Entries symlinks must not lead to
+}
+
+// maxSymlinkHops is the number of symlinks the kernel follows in one lookup
+const maxSymlinkHops = 40
+
+// openRootFS opens the base directory and probes for openat2 support
+func openRootFS(path string) (*rootFS, error) {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+
+	r := &rootFS{path: path, dir: os.NewFile(uintptr(fd), path), policy: SymlinkFollowWithinRoot}
+
+	// ENOSYS before Linux 5.6, EPERM under some seccomp profiles
+	probe, err := unix.Openat2(fd, ".", &unix.OpenHow{Flags: unix.O_PATH | unix.O_CLOEXEC, Resolve: resolveFlags})
+	if err == nil {
+		unix.Close(probe)
+		return r, nil
+	}
+
+	if err := r.useFallback(); err != nil {
+		r.dir.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// useFallback switches lookups from openat2 to os.Root
+func (r *rootFS) useFallback() error {
+	root, err := os.OpenRoot(r.path)
+	if err != nil {
+		return err
+	}
+	r.fallback = root
+	return nil
+}
+
+// Close releases the base directory descriptors
+func (r *rootFS) Close() error {
+	if r.fallback != nil {
+		r.fallback.Close()
+	}
+	return r.dir.Close()
+}
+
+// cleanRel turns a client path into a clean path relative to the base
+// directory, "." for the base itself. Lexically it can never begin with "..".
+func cleanRel(name string) string {
+	name = strings.TrimPrefix(filepath.Clean("/"+filepath.FromSlash(name)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// isOutsideRoot reports whether an error means a path resolved outside the base directory
+func isOutsideRoot(err error) bool {
+	return errors.Is(err, unix.EXDEV)
+}
+
+// isSymlinkRefused reports whether an error means a symlink the policy does not follow was met
+func isSymlinkRefused(err error) bool {
+	return errors.Is(err, unix.ELOOP)
+}
+
+// fallbackError maps os.Root's escape error to the errno openat2 would return
+func fallbackError(err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) && pathErr.Err.Error() == "path escapes from parent" {
+		return &os.PathError{Op: pathErr.Op, Path: pathErr.Path, Err: unix.EXDEV}
+	}
+	return err
+}
+
+// OpenFile opens a file beneath the base directory, following symlinks as the policy allows
+func (r *rootFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	name = cleanRel(name)
+	if r.linkDenied(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: unix.ENOENT}
+	}
+
+	var mode uint32
+	if flag&os.O_CREATE != 0 {
+		mode = syscallMode(perm)
+	}
+
+	var fd int
+	var err error
+	switch {
+	case r.policy == SymlinkFollow:
+		// The clean name can not climb out by itself, but its symlinks may
+		fd, err = unix.Openat(int(r.dir.Fd()), name, flag|unix.O_CLOEXEC, mode)
+	case r.fallback == nil:
+		resolve := uint64(resolveFlags)
+		if r.policy == SymlinkNever {
+			resolve |= unix.RESOLVE_NO_SYMLINKS
+		}
+		fd, err = unix.Openat2(int(r.dir.Fd()), name, &unix.OpenHow{Flags: uint64(flag | unix.O_CLOEXEC), Mode: uint64(mode), Resolve: resolve})
+	case r.policy == SymlinkNever:
+		fd, err = openNoSymlinks(int(r.dir.Fd()), name, flag, mode)
+	default:
+		f, err := r.fallback.OpenFile(name, flag, perm)
+		return f, fallbackError(err)
+	}
+
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return os.NewFile(uintptr(fd), filepath.Join(r.path, name)), nil
+}
+
+// linkDenied reports whether resolving name follows a symlink to an entry
+// the denied hook matches, or through a directory it matches. Entries named
+// before the first symlink are the caller's to check, so the hidden stores
+// stay reachable by their own paths. The path is resolved component by
+// component as the kernel will resolve it right after; the check is only as
+// good as the tree holding still in between.
+func (r *rootFS) linkDenied(name string) bool {
+	if r.denied == nil || r.policy == SymlinkNever {
+		return false
+	}
+
+	pending := strings.Split(filepath.ToSlash(name), "/")
+	var resolved []string
+	followed := false
+	for hops := 0; len(pending) > 0; {
+		part := pending[0]
+		pending = pending[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return r.outsideDenied(path.Join(pending...))
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		current := path.Join(path.Join(resolved...), part)
+		var st unix.Stat_t
+		if err := unix.Fstatat(int(r.dir.Fd()), current, &st, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+			return false // Nothing further along can be reached
+		}
+		if st.Mode&unix.S_IFMT != unix.S_IFLNK {
+			resolved = append(resolved, part)
+			if followed && r.denied(current, st.Mode&unix.S_IFMT == unix.S_IFDIR) {
+				return true
+			}
+			continue
+		}
+
+		if hops++; hops > maxSymlinkHops {
+			return false // The lookup fails with ELOOP
+		}
+		buf := make([]byte, unix.PathMax)
+		n, err := unix.Readlinkat(int(r.dir.Fd()), current, buf)
+		if err != nil {
+			return false
+		}
+		target := string(buf[:n])
+		if filepath.IsAbs(target) {
+			if r.policy != SymlinkFollow {
+				return false // Absolute targets are not resolved beneath the base directory
+			}
+			return r.outsideDenied(path.Join(target, path.Join(pending...)))
+		}
+		pending = append(strings.Split(target, "/"), pending...)
+		followed = true
+	}
+	return false
+}
+
+// outsideDenied reports whether a path that left the base directory on the
+// way comes back into it at a denied entry. Only the follow policy resolves
+// such paths; for the others the lookup fails anyway.
+func (r *rootFS) outsideDenied(rest string) bool {
+	if r.policy != SymlinkFollow {
+		return false
+	}
+	if !filepath.IsAbs(rest) {
+		rest = filepath.Join(filepath.Dir(r.path), rest)
+	}
+	resolved, err := filepath.EvalSymlinks(rest)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(r.path, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i < len(segments); i++ {
+		if r.denied(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+	info, err := os.Stat(resolved)
+	return err == nil && r.denied(filepath.ToSlash(rel), info.IsDir())
+}
+
+// openNoSymlinks opens name one component at a time with O_NOFOLLOW, for
+// kernels without openat2. A symlink anywhere on the way fails with ELOOP.
+func openNoSymlinks(dirfd int, name string, flag int, mode uint32) (int, error) {
+	parts := strings.Split(name, string(filepath.Separator))
+
+	current := dirfd
+	closeCurrent := func() {
+		if current != dirfd {
+			unix.Close(current)
+		}
+	}
+
+	for _, part := range parts[:len(parts)-1] {
+		fd, err := unix.Openat(current, part, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		err = symlinkError(current, part, err)
+		closeCurrent()
+		if err != nil {
+			return -1, err
+		}
+		current = fd
+	}
+
+	fd, err := unix.Openat(current, parts[len(parts)-1], flag|unix.O_NOFOLLOW|unix.O_CLOEXEC, mode)
+	err = symlinkError(current, parts[len(parts)-1], err)
+	closeCurrent()
+	if err != nil {
+		return -1, err
+	}
+
+	// O_PATH|O_NOFOLLOW opens a symlink itself instead of failing
+	if flag&unix.O_PATH != 0 {
+		var st unix.Stat_t
+		if err := unix.Fstat(fd, &st); err != nil || st.Mode&unix.S_IFMT == unix.S_IFLNK {
+			unix.Close(fd)
+			if err == nil {
+				err = unix.ELOOP
+			}
+			return -1, err
+		}
+	}
+	return fd, nil
+}
+
+// Open opens a file beneath the base directory for reading
+func (r *rootFS) Open(name string) (*os.File, error) {
+	return r.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// openDir opens a directory beneath the base directory
+func (r *rootFS) openDir(name string) (*os.File, error) {
+	return r.OpenFile(name, os.O_RDONLY|unix.O_DIRECTORY, 0)
+}
+
+// openParent opens the parent directory of name and returns it with the final path element
+func (r *rootFS) openParent(name string) (*os.File, string, error) {
+	name = cleanRel(name)
+	if name == "." {
+		return nil, "", &os.PathError{Op: "open", Path: name, Err: unix.EINVAL}
+	}
+
+	dir, err := r.openDir(filepath.Dir(name))
+	if err != nil {
+		return nil, "", err
+	}
+	return dir, filepath.Base(name), nil
+}
+
+// Stat returns file information, following a final symlink as long as it stays beneath the base directory
+func (r *rootFS) Stat(name string) (os.FileInfo, error) {
+	name = cleanRel(name)
+
+	if r.fallback != nil && r.policy == SymlinkFollowWithinRoot {
+		if r.linkDenied(name) {
+			return nil, &os.PathError{Op: "stat", Path: name, Err: unix.ENOENT}
+		}
+		info, err := r.fallback.Stat(name)
+		return info, fallbackError(err)
+	}
+
+	// O_PATH never opens the file itself, so FIFOs and devices are safe to stat
+	f, err := r.OpenFile(name, unix.O_PATH, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Stat()
+}
+
+// Lstat returns file information without following a final symlink
+func (r *rootFS) Lstat(name string) (os.FileInfo, error) {
+	name = cleanRel(name)
+	if name == "." {
+		return r.Stat(name)
+	}
+
+	dir, base, err := r.openParent(name)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	fd, err := unix.Openat(int(dir.Fd()), base, unix.O_PATH|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	f := os.NewFile(uintptr(fd), base)
+	defer f.Close()
+	return f.Stat()
+}
+
+// symlinkError reports an open that failed on a symlink with ENOTDIR, as
+// O_DIRECTORY|O_NOFOLLOW does, as ELOOP like openat2 would
+func symlinkError(dirfd int, name string, err error) error {
+	if err != unix.ENOTDIR {
+		return err
+	}
+	var st unix.Stat_t
+	if unix.Fstatat(dirfd, name, &st, unix.AT_SYMLINK_NOFOLLOW) == nil && st.Mode&unix.S_IFMT == unix.S_IFLNK {
+		return unix.ELOOP
+	}
+	return err
+}
+
+// Readlink returns the target stored in a symlink
+func (r *rootFS) Readlink(name string) (string, error) {
+	dir, base, err := r.openParent(name)
+	if err != nil {
+		return "", err
+	}
+	defer dir.Close()
+
+	buf := make([]byte, unix.PathMax)
+	n, err := unix.Readlinkat(int(dir.Fd()), base, buf)
+	if err != nil {
+		return "", &os.PathError{Op: "readlinkat", Path: name, Err: err}
+	}
+	return string(buf[:n]), nil
+}
+
+// Symlink creates name as a symlink to target. The target is stored as given.
+func (r *rootFS) Symlink(target, name string) error {
+	dir, base, err := r.openParent(name)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	if err := unix.Symlinkat(target, int(dir.Fd()), base); err != nil {
+		return &os.LinkError{Op: "symlinkat", Old: target, New: name, Err: err}
+	}
+	return nil
+}
+
+// resolveLink describes an entry returned by Lstat. For a symlink it returns
+// the stored target and, when the policy follows the link, the information
+// of what it points to; otherwise the entry's own information.
+func (r *rootFS) resolveLink(name string, info os.FileInfo) (os.FileInfo, string) {
+	if info.Mode()&os.ModeSymlink == 0 {
+		return info, ""
+	}
+
+	target, _ := r.Readlink(name)
+	if r.policy != SymlinkNever {
+		if resolved, err := r.Stat(name); err == nil {
+			return resolved, target
+		}
+	}
+	return info, target
+}
+
+// rootDirEntry is a directory entry whose Info is resolved through the rootFS
+type rootDirEntry struct {
+	fs.DirEntry
+	root *rootFS
+	path string // Path of the entry relative to the base directory
+}
+
+// Info stats the entry lazily, like os.DirEntry does
+func (e rootDirEntry) Info() (fs.FileInfo, error) {
+	return e.root.Lstat(e.path)
+}
+
+// ReadDir reads a directory and returns its entries sorted by name
+func (r *rootFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	name = cleanRel(name)
+
+	dir, err := r.openDir(name)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	// Names and types come from getdents; Info goes through the rootFS
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, rootDirEntry{DirEntry: entry, root: r, path: filepath.Join(name, entry.Name())})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+// rootDirFS adapts a rootFS to fs.FS for use with fs.WalkDir
+type rootDirFS struct {
+	root *rootFS
+}
+
+func (f rootDirFS) Open(name string) (fs.File, error) {
+	return f.root.Open(name)
+}
+
+func (f rootDirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return f.root.ReadDir(name)
+}
+
+func (f rootDirFS) Stat(name string) (fs.FileInfo, error) {
+	return f.root.Stat(name)
+}
+
+// WalkDir walks the tree at name in lexical order like filepath.WalkDir.
+// Paths passed to fn are slash-separated and relative to the base directory,
+// "." for the base itself. Symlinks are reported but not followed.
+func (r *rootFS) WalkDir(name string, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(rootDirFS{root: r}, filepath.ToSlash(cleanRel(name)), fn)
+}
+
+// Mkdir creates a directory
+func (r *rootFS) Mkdir(name string, perm os.FileMode) error {
+	dir, base, err := r.openParent(name)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	if err := unix.Mkdirat(int(dir.Fd()), base, syscallMode(perm)); err != nil {
+		return &os.PathError{Op: "mkdirat", Path: name, Err: err}
+	}
+	return nil
+}
+
+// MkdirAll creates a directory and any missing parents
+func (r *rootFS) MkdirAll(name string, perm os.FileMode) error {
+	name = cleanRel(name)
+	if name == "." {
+		return nil
+	}
+
+	current := ""
+	for _, part := range strings.Split(name, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		err := r.Mkdir(current, perm)
+		if err == nil || !errors.Is(err, unix.EEXIST) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// An existing entry must be a directory to continue
+		info, statErr := r.Stat(current)
+		if statErr != nil {
+			return statErr
+		}
+		if !info.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: current, Err: unix.ENOTDIR}
+		}
+	}
+	return nil
+}
+
+// Remove removes a file or an empty directory
+func (r *rootFS) Remove(name string) error {
+	dir, base, err := r.openParent(name)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	err = unix.Unlinkat(int(dir.Fd()), base, 0)
+	if err == unix.EISDIR {
+		err = unix.Unlinkat(int(dir.Fd()), base, unix.AT_REMOVEDIR)
+	}
+	if err != nil {
+		return &os.PathError{Op: "unlinkat", Path: name, Err: err}
+	}
+	return nil
+}
+
+// RemoveAll removes a path and everything it contains. Symlinks are removed, never followed.
+func (r *rootFS) RemoveAll(name string) error {
+	dir, base, err := r.openParent(name)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return nil
+		}
+		return err
+	}
+	defer dir.Close()
+
+	if err := removeAllAt(int(dir.Fd()), base); err != nil {
+		return &os.PathError{Op: "removeall", Path: name, Err: err}
+	}
+	return nil
+}
+
+// removeAllAt removes name inside the directory dirfd, recursing into directories by descriptor
+func removeAllAt(dirfd int, name string) error {
+	err := unix.Unlinkat(dirfd, name, 0)
+	if err == nil || err == unix.ENOENT {
+		return nil
+	}
+	if err != unix.EISDIR {
+		return err
+	}
+
+	fd, err := unix.Openat(dirfd, name, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	dir := os.NewFile(uintptr(fd), name)
+	defer dir.Close()
+
+	for {
+		names, err := dir.Readdirnames(256)
+		for _, child := range names {
+			if err := removeAllAt(fd, child); err != nil {
+				return err
+			}
+		}
+		if err != nil || len(names) == 0 {
+			break
+		}
+	}
+
+	err = unix.Unlinkat(dirfd, name, unix.AT_REMOVEDIR)
+	if err == unix.ENOENT {
+		return nil
+	}
+	return err
+}
+
+// Rename moves a file or directory within the base directory
+func (r *rootFS) Rename(oldName, newName string) error {
+	oldDir, oldBase, err := r.openParent(oldName)
+	if err != nil {
+		return err
+	}
+	defer oldDir.Close()
+
+	newDir, newBase, err := r.openParent(newName)
+	if err != nil {
+		return err
+	}
+	defer newDir.Close()
+
+	if err := unix.Renameat(int(oldDir.Fd()), oldBase, int(newDir.Fd()), newBase); err != nil {
+		return &os.LinkError{Op: "renameat", Old: oldName, New: newName, Err: err}
+	}
+	return nil
+}
+
+// syscallMode converts an os.FileMode to the bits expected by the kernel
+func syscallMode(mode os.FileMode) uint32 {
+	m := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		m |= unix.S_ISUID
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= unix.S_ISGID
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= unix.S_ISVTX
+	}
+	return m
+}
@@ -0,0 +1,455 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errStopWalk ends a directory walk early without reporting an error
+var errStopWalk = errors.New("stop walk")
+
+// searchFilter holds the compiled predicates of a SearchRequest
+type searchFilter struct {
+	req       *SearchRequest
+	nameRegex *regexp.Regexp
+	pathGlob  string
+	uid       int64 // -1 when not filtering by owner
+	gid       int64 // -1 when not filtering by group
+	rules     *pathRules
+	root      *rootFS // Used by predicates that open the file
+}
+
+// newSearchFilter validates a SearchRequest and compiles its filters
+func newSearchFilter(req *SearchRequest) (*searchFilter, error) {
+	filter := &searchFilter{
+		req:      req,
+		pathGlob: filepath.ToSlash(req.PathGlob),
+		uid:      -1,
+		gid:      -1,
+	}
+
+	if req.Pattern != "" {
+		if _, err := filepath.Match(req.Pattern, ""); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid pattern: %v", err)
+		}
+	}
+
+	if req.NameRegex != "" {
+		expr := req.NameRegex
+		if !req.CaseSensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid name regex: %v", err)
+		}
+		filter.nameRegex = re
+	}
+
+	if filter.pathGlob != "" {
+		if !req.CaseSensitive {
+			filter.pathGlob = strings.ToLower(filter.pathGlob)
+		}
+		for _, segment := range strings.Split(filter.pathGlob, "/") {
+			if _, err := filepath.Match(segment, ""); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "Invalid path glob: %v", err)
+			}
+		}
+	}
+
+	if req.MaxSize > 0 && req.MinSize > req.MaxSize {
+		return nil, status.Errorf(codes.InvalidArgument, "min_size is larger than max_size")
+	}
+
+	if req.Owner != "" {
+		uid, err := userNames.id(req.Owner)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown owner %q", req.Owner)
+		}
+		filter.uid = int64(uid)
+	}
+
+	if req.Group != "" {
+		gid, err := groupNames.id(req.Group)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown group %q", req.Group)
+		}
+		filter.gid = int64(gid)
+	}
+
+	return filter, nil
+}
+
+// matches reports whether an entry satisfies every filter in the request.
+// relPath is relative to the search base path and uses forward slashes.
+func (f *searchFilter) matches(path, relPath string, info os.FileInfo) bool {
+	req := f.req
+	name := info.Name()
+	isSymlink := info.Mode()&os.ModeSymlink != 0
+
+	// Type predicates
+	if info.IsDir() && req.FilesOnly {
+		return false
+	}
+	if !info.IsDir() && req.DirectoriesOnly {
+		return false
+	}
+	if req.SymlinksOnly && !isSymlink {
+		return false
+	}
+
+	// Name predicates
+	if req.Pattern != "" {
+		var matched bool
+		if req.CaseSensitive {
+			matched, _ = filepath.Match(req.Pattern, name)
+		} else {
+			matched, _ = filepath.Match(strings.ToLower(req.Pattern), strings.ToLower(name))
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.nameRegex != nil && !f.nameRegex.MatchString(name) {
+		return false
+	}
+	if f.pathGlob != "" {
+		candidate := relPath
+		if !req.CaseSensitive {
+			candidate = strings.ToLower(candidate)
+		}
+		if !matchPathGlob(f.pathGlob, candidate) {
+			return false
+		}
+	}
+
+	// Size and time predicates
+	if req.MinSize > 0 && info.Size() < req.MinSize {
+		return false
+	}
+	if req.MaxSize > 0 && info.Size() > req.MaxSize {
+		return false
+	}
+	if req.ModifiedAfter > 0 && info.ModTime().Unix() <= req.ModifiedAfter {
+		return false
+	}
+	if req.ModifiedBefore > 0 && info.ModTime().Unix() >= req.ModifiedBefore {
+		return false
+	}
+
+	// Ownership predicates
+	if f.uid >= 0 || f.gid >= 0 {
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return false
+		}
+		if f.uid >= 0 && int64(stat.Uid) != f.uid {
+			return false
+		}
+		if f.gid >= 0 && int64(stat.Gid) != f.gid {
+			return false
+		}
+	}
+
+	// Predicates that need to touch the file are evaluated last
+	if req.EmptyOnly && !isEmpty(f.root, path, info) {
+		return false
+	}
+	if len(req.MimeTypes) > 0 && !matchesMimeType(f.root, path, info, req.MimeTypes) {
+		return false
+	}
+
+	return true
+}
+
+// isEmpty reports whether a regular file has no content or a directory has no entries
+func isEmpty(root *rootFS, path string, info os.FileInfo) bool {
+	switch {
+	case info.Mode().IsRegular():
+		return info.Size() == 0
+	case info.IsDir():
+		dir, err := root.openDir(path)
+		if err != nil {
+			return false
+		}
+		defer dir.Close()
+		names, _ := dir.Readdirnames(1)
+		return len(names) == 0
+	default:
+		return false
+	}
+}
+
+// matchesMimeType sniffs a regular file and compares it against MIME types or prefixes
+func matchesMimeType(root *rootFS, path string, info os.FileInfo, mimeTypes []string) bool {
+	if !info.Mode().IsRegular() {
+		return false
+	}
+
+	file, _, err := root.openRegular(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	mimeType, err := detectMimeType(file)
+	if err != nil {
+		return false
+	}
+
+	// Ignore parameters such as "; charset=utf-8"
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = strings.TrimSpace(mimeType[:i])
+	}
+
+	for _, want := range mimeTypes {
+		// "image/" and "image/*" both select the whole image family
+		want = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(want)), "*")
+		if want == mimeType || (strings.HasSuffix(want, "/") && strings.HasPrefix(mimeType, want)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathGlob matches a slash-separated path against a glob in which
+// "**" stands for zero or more whole path segments
+func matchPathGlob(pattern, path string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse consecutive "**" and try every possible split
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern, path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(path) == 0 {
+			return false
+		}
+		if matched, _ := filepath.Match(pattern[0], path[0]); !matched {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+// SearchStream implements the SearchStream RPC method (streaming to client)
+func (s *FilesystemService) SearchStream(req *SearchRequest, stream FilesystemService_SearchStreamServer) error {
+	ctx := stream.Context()
+
+	validPath, filter, err := s.prepareSearch(req)
+	if err != nil {
+		return err
+	}
+
+	after, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return err
+	}
+
+	var sent int32
+	err = s.walkSearch(ctx, validPath, req, filter, after, func(_ string, item *FileItem) error {
+		if err := stream.Send(item); err != nil {
+			return err
+		}
+		sent++
+		if req.MaxResults > 0 && sent >= req.MaxResults {
+			return errStopWalk
+		}
+		return nil
+	})
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.Internal, "Search failed: %v", err)
+	}
+	return nil
+}
+
+// prepareSearch validates the base path of a search and compiles its filters
+func (s *FilesystemService) prepareSearch(req *SearchRequest) (string, *searchFilter, error) {
+	validPath, err := s.validatePath(req.BasePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Check if base path exists and is a directory
+	info, err := s.root.Stat(validPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, status.Errorf(codes.NotFound, "Base directory does not exist")
+		}
+		return "", nil, rootError(err, "Failed to access directory")
+	}
+
+	if !info.IsDir() {
+		return "", nil, status.Errorf(codes.InvalidArgument, "Base path is not a directory")
+	}
+
+	filter, err := newSearchFilter(req)
+	if err != nil {
+		return "", nil, err
+	}
+	filter.root = s.root
+
+	filter.rules, err = s.newPathRules(validPath, req.Include, req.Exclude, req.UseIgnoreFiles)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return validPath, filter, nil
+}
+
+// walkSearch walks the search base in lexical depth-first order and calls emit
+// for every match that comes after the given path. Returning errStopWalk from
+// emit ends the walk cleanly.
+func (s *FilesystemService) walkSearch(ctx context.Context, validPath string, req *SearchRequest, filter *searchFilter, after string, emit func(searchRelPath string, item *FileItem) error) error {
+	var afterSegments []string
+	if after != "" {
+		afterSegments = strings.Split(after, "/")
+	}
+
+	// Rules in effect inside each visited directory
+	rootPath := filepath.ToSlash(validPath)
+	scopes := map[string]*pathRules{rootPath: filter.rules.enterDir(validPath)}
+
+	err := s.root.WalkDir(validPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip files with errors
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Skip root directory
+		if path == rootPath {
+			return nil
+		}
+
+		// Excluded directories are not descended into
+		scope := scopes[filepath.Dir(path)]
+		entryRel := slashRel(path)
+		if scope.excluded(entryRel, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		searchRelPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return nil
+		}
+		searchRelPath = filepath.ToSlash(searchRelPath)
+
+		// Non-recursive searches only look at direct children
+		descend := entry.IsDir() && req.Recursive
+		if descend {
+			scopes[path] = scope.enterDir(path)
+		}
+
+		// Resume after the page token, pruning subtrees already covered
+		if afterSegments != nil {
+			segments := strings.Split(searchRelPath, "/")
+			if compareSegments(segments, afterSegments) <= 0 {
+				if !entry.IsDir() {
+					return nil
+				}
+				if descend && isSegmentPrefix(segments, afterSegments) {
+					return nil // The token lies inside this directory
+				}
+				return filepath.SkipDir
+			}
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		if scope.included(entryRel, entry.IsDir()) && filter.matches(path, searchRelPath, info) {
+			if err := emit(searchRelPath, s.fileItem(filepath.FromSlash(path), info)); err != nil {
+				return err
+			}
+		}
+
+		if entry.IsDir() && !descend {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	if err == errStopWalk {
+		return nil
+	}
+	return err
+}
+
+// compareSegments orders two paths the way a lexical depth-first walk visits them
+func compareSegments(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// isSegmentPrefix reports whether prefix is an ancestor of, or equal to, path
+func isSegmentPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// encodePageToken turns the last returned path into an opaque page token
+func encodePageToken(lastPath string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastPath))
+}
+
+// decodePageToken returns the path encoded in a page token
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	path, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(path) == 0 {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid page token")
+	}
+	return string(path), nil
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// searchPaths runs a unary search and returns the paths it found
+func searchPaths(t *testing.T, f *testFixture, req *SearchRequest) []string {
+	t.Helper()
+	resp, err := f.service.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search(%v): %v", req, err)
+	}
+	var paths []string
+	for _, item := range resp.Items {
+		paths = append(paths, item.Path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func TestSearchFilter(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "small.txt", "tiny")
+	f.write(t, "docs/big.txt", string(make([]byte, 4096)))
+	f.write(t, "docs/api/spec.md", "# Spec")
+	f.write(t, "docs/api/v1/old.md", "# Old")
+	f.write(t, "src/main.go", "package main")
+	symlink(t, "small.txt", f.path("link.txt"))
+
+	old := time.Unix(1_000_000_000, 0)
+	if err := os.Chtimes(f.path("docs/api/v1/old.md"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Lchown(f.path("src/main.go"), 1, 1); err != nil {
+		t.Skipf("can not change owners: %v", err)
+	}
+	owner, group := userNames.name(1), groupNames.name(1)
+	if owner == "1" || group == "1" {
+		t.Skip("uid or gid 1 has no name")
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  *SearchRequest
+		want []string
+	}{
+		{"min size", &SearchRequest{MinSize: 1000, FilesOnly: true}, []string{"docs/big.txt"}},
+		{"max size", &SearchRequest{MaxSize: 5, FilesOnly: true}, []string{"docs/api/v1/old.md", "small.txt"}},
+		{"size range", &SearchRequest{MinSize: 5, MaxSize: 100, FilesOnly: true}, []string{"docs/api/v1/old.md", "docs/api/spec.md", "link.txt", "src/main.go"}},
+		{"modified before", &SearchRequest{ModifiedBefore: old.Unix() + 1}, []string{"docs/api/v1/old.md"}},
+		{"modified after", &SearchRequest{ModifiedAfter: old.Unix(), Pattern: "*.md"}, []string{"docs/api/spec.md"}},
+		{"modified after is exclusive", &SearchRequest{ModifiedAfter: old.Unix(), ModifiedBefore: old.Unix() + 1}, nil},
+		{"files only", &SearchRequest{FilesOnly: true, Pattern: "*.go"}, []string{"src/main.go"}},
+		{"directories only", &SearchRequest{DirectoriesOnly: true}, []string{"docs", "docs/api", "docs/api/v1", "src"}},
+		{"symlinks only", &SearchRequest{SymlinksOnly: true}, []string{"link.txt"}},
+		{"glob with a segment", &SearchRequest{PathGlob: "docs/*/spec.md"}, []string{"docs/api/spec.md"}},
+		{"glob ** spans directories", &SearchRequest{PathGlob: "docs/**/*.md"}, []string{"docs/api/spec.md", "docs/api/v1/old.md"}},
+		{"glob ** matches no directory", &SearchRequest{PathGlob: "**/small.txt"}, []string{"small.txt"}},
+		{"glob trailing **", &SearchRequest{PathGlob: "docs/api/**", FilesOnly: true}, []string{"docs/api/spec.md", "docs/api/v1/old.md"}},
+		{"glob ignores case", &SearchRequest{PathGlob: "DOCS/**/SPEC.MD"}, []string{"docs/api/spec.md"}},
+		{"glob honours case", &SearchRequest{PathGlob: "DOCS/**/SPEC.MD", CaseSensitive: true}, nil},
+		{"owner by name", &SearchRequest{Owner: owner}, []string{"src/main.go"}},
+		{"owner by ID", &SearchRequest{Owner: "1"}, []string{"src/main.go"}},
+		{"group by name", &SearchRequest{Group: group}, []string{"src/main.go"}},
+		{"owner and group", &SearchRequest{Owner: userNames.name(0), Group: group}, nil},
+		{"filters combine", &SearchRequest{PathGlob: "docs/**", FilesOnly: true, MinSize: 6}, []string{"docs/api/spec.md", "docs/big.txt"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.BasePath = "/"
+			tc.req.Recursive = true
+			want := slices.Clone(tc.want)
+			slices.Sort(want)
+			if got := searchPaths(t, f, tc.req); !slices.Equal(got, want) {
+				t.Errorf("found %q, want %q", got, want)
+			}
+		})
+	}
+
+	for _, req := range []*SearchRequest{
+		{Owner: "no-such-user"},
+		{Group: "no-such-group"},
+		{Owner: "-1"},
+		{MinSize: 10, MaxSize: 5},
+		{PathGlob: "docs/[/x"},
+		{NameRegex: "("},
+		{Pattern: "["},
+	} {
+		if _, err := f.service.Search(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Search(%v): got %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"a/*/c", "a/b/c", true},
+		{"a/*/c", "a/b/x/c", false},
+		{"a/**/c", "a/c", true},
+		{"a/**/c", "a/b/x/c", true},
+		{"a/**", "a/b/c", true},
+		{"a/**", "a", true},
+		{"**", "a/b", true},
+		{"**/**/c", "c", true},
+		{"**/b", "a/bb", false},
+		{"*.go", "src/main.go", false},
+	} {
+		if got := matchPathGlob(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+// searchStream collects the items SearchStream sends
+type searchStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	items []string
+}
+
+func (s *searchStream) Context() context.Context { return s.ctx }
+
+func (s *searchStream) Send(item *FileItem) error {
+	s.items = append(s.items, item.Path)
+	return nil
+}
+
+func TestSearchPages(t *testing.T) {
+	f := newTestFixture(t)
+	for _, name := range []string{"a.txt", "b/a.txt", "b/c/a.txt", "b/c/b.txt", "b/d.txt", "c.txt", "d/e/f/a.txt"} {
+		f.write(t, name, name)
+	}
+	ctx := context.Background()
+	all := &SearchRequest{BasePath: "/", Pattern: "*.txt", Recursive: true}
+	want := []string{"a.txt", "b/a.txt", "b/c/a.txt", "b/c/b.txt", "b/d.txt", "c.txt", "d/e/f/a.txt"}
+
+	// Pages resume where the previous one ended, in walk order
+	for _, size := range []int32{1, 2, 3, 7} {
+		req := proto.Clone(all).(*SearchRequest)
+		req.PageSize = size
+		var got []string
+		for pages := 0; ; pages++ {
+			resp, err := f.service.Search(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Items) > int(size) || pages > len(want) {
+				t.Fatalf("page size %d: page of %d items", size, len(resp.Items))
+			}
+			for _, item := range resp.Items {
+				got = append(got, item.Path)
+			}
+			if resp.NextPageToken == "" {
+				break
+			}
+			req.PageToken = resp.NextPageToken
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("page size %d: found %q, want %q", size, got, want)
+		}
+	}
+
+	// A page token stays valid when the entry it names is removed
+	req := proto.Clone(all).(*SearchRequest)
+	req.PageSize = 3
+	first, err := f.service.Search(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(f.path("b/c")); err != nil {
+		t.Fatal(err)
+	}
+	req.PageToken = first.NextPageToken
+	rest, err := f.service.Search(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest.Items) != 3 || rest.Items[0].Path != "b/d.txt" || rest.Items[2].Path != "d/e/f/a.txt" {
+		t.Errorf("page after a removed entry = %v", rest.Items)
+	}
+
+	req.PageToken = "not base64!"
+	if _, err := f.service.Search(ctx, req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("invalid page token: got %v, want InvalidArgument", err)
+	}
+	if _, err := f.service.Search(ctx, &SearchRequest{BasePath: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("missing base: got %v, want NotFound", err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := f.service.Search(canceled, all); status.Code(err) != codes.Canceled {
+		t.Errorf("canceled search: got %v, want Canceled", err)
+	}
+}
+
+func TestSearchStream(t *testing.T) {
+	f := newTestFixture(t)
+	for _, name := range []string{"x/1.log", "x/2.txt", "y/3.log", "4.log"} {
+		f.write(t, name, name)
+	}
+	ctx := context.Background()
+
+	stream := &searchStream{ctx: ctx}
+	if err := f.service.SearchStream(&SearchRequest{BasePath: "/", Pattern: "*.log", Recursive: true}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"4.log", "x/1.log", "y/3.log"}; !slices.Equal(stream.items, want) {
+		t.Errorf("streamed %q, want %q", stream.items, want)
+	}
+
+	// max_results ends the stream; a page token resumes it
+	stream = &searchStream{ctx: ctx}
+	if err := f.service.SearchStream(&SearchRequest{BasePath: "/", Pattern: "*.log", Recursive: true, MaxResults: 1, PageToken: encodePageToken("4.log")}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"x/1.log"}; !slices.Equal(stream.items, want) {
+		t.Errorf("streamed %q, want %q", stream.items, want)
+	}
+
+	if err := f.service.SearchStream(&SearchRequest{BasePath: "x/1.log"}, &searchStream{ctx: ctx}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("file as base: got %v, want InvalidArgument", err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := f.service.SearchStream(&SearchRequest{BasePath: "/", Recursive: true}, &searchStream{ctx: canceled}); status.Code(err) != codes.Canceled {
+		t.Errorf("canceled stream: got %v, want Canceled", err)
+	}
+}
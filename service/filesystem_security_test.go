@@ -0,0 +1,505 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+const (
+	secretContent = "outside secret"
+	publicContent = "inside public"
+)
+
+// securityFixture is a base directory "html" next to directories that must stay unreachable:
+//
+//	tmp/html/          base directory
+//	tmp/html2/         sibling sharing the base directory's name as a prefix
+//	tmp/outside/       unrelated directory
+type securityFixture struct {
+	*testFixture
+	sibling string
+	outside string
+}
+
+// forEachResolver runs a test against both the openat2 resolver and the os.Root fallback
+func forEachResolver(t *testing.T, test func(t *testing.T, f *securityFixture)) {
+	for _, mode := range []string{"openat2", "fallback"} {
+		t.Run(mode, func(t *testing.T) {
+			f := newSecurityFixture(t)
+			if mode == "fallback" {
+				if err := f.service.root.useFallback(); err != nil {
+					t.Fatal(err)
+				}
+			} else if f.service.root.fallback != nil {
+				t.Skip("openat2 is not supported by this kernel")
+			}
+			test(t, f)
+		})
+	}
+}
+
+func newSecurityFixture(t *testing.T) *securityFixture {
+	tmp := t.TempDir()
+	f := &securityFixture{
+		sibling: filepath.Join(tmp, "html2"),
+		outside: filepath.Join(tmp, "outside"),
+	}
+
+	writeTestFile(t, filepath.Join(tmp, "html", "public.txt"), publicContent)
+	writeTestFile(t, filepath.Join(f.sibling, "secret.txt"), secretContent)
+	writeTestFile(t, filepath.Join(f.outside, "secret.txt"), secretContent)
+
+	f.testFixture = newTestFixtureAt(t, filepath.Join(tmp, "html"))
+	return f
+}
+
+// assertOutsideUntouched fails if anything outside the base directory was read, changed or created
+func (f *securityFixture) assertOutsideUntouched(t *testing.T) {
+	t.Helper()
+	for _, dir := range []string{f.sibling, f.outside} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "secret.txt" {
+			t.Fatalf("%s was modified: %v", dir, entries)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "secret.txt"))
+		if err != nil || string(data) != secretContent {
+			t.Fatalf("%s/secret.txt was modified: %q, %v", dir, data, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(f.base), "evil")); err == nil {
+		t.Fatal("a file was created next to the base directory")
+	}
+}
+
+// TestDotDotTraversal checks that ".." can not climb above the base directory
+func TestDotDotTraversal(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		ctx := context.Background()
+		for _, path := range []string{
+			"../outside/secret.txt",
+			"../../outside/secret.txt",
+			"/../outside/secret.txt",
+			"public.txt/../../outside/secret.txt",
+			"a/b/../../../outside/secret.txt",
+			"..\\outside\\secret.txt",
+		} {
+			if data, err := f.download(path); err == nil || data == secretContent {
+				t.Errorf("download %q: got %q, %v", path, data, err)
+			}
+			if _, err := f.service.GetFileInfo(ctx, &FileRequest{Path: path}); status.Code(err) != codes.NotFound {
+				t.Errorf("stat %q: expected NotFound, got %v", path, err)
+			}
+		}
+
+		// Writes land inside the base directory
+		if err := f.upload("../evil", "x"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(f.base, "evil")); err != nil {
+			t.Errorf("upload of ../evil did not stay in the base directory: %v", err)
+		}
+		if _, err := f.service.CreateDirectory(ctx, &CreateDirectoryRequest{Path: "../../evil"}); err != nil {
+			t.Fatal(err)
+		}
+
+		f.assertOutsideUntouched(t)
+	})
+}
+
+// TestSiblingPrefix checks the case a string prefix check gets wrong: "/tmp/html2" starts with "/tmp/html"
+func TestSiblingPrefix(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		ctx := context.Background()
+		symlink(t, "../html2", filepath.Join(f.base, "relative"))
+		symlink(t, f.sibling, filepath.Join(f.base, "absolute"))
+
+		for _, path := range []string{"relative/secret.txt", "absolute/secret.txt"} {
+			if _, err := f.service.GetFileInfo(ctx, &FileRequest{Path: path}); status.Code(err) != codes.PermissionDenied {
+				t.Errorf("stat %q: expected PermissionDenied, got %v", path, err)
+			}
+		}
+
+		for _, path := range []string{"relative/secret.txt", "absolute/secret.txt", "relative", "absolute"} {
+			if data, err := f.download(path); err == nil || data == secretContent {
+				t.Errorf("download %q: got %q, %v", path, data, err)
+			}
+		}
+
+		// The links themselves are described without being followed
+		for _, path := range []string{"relative", "absolute"} {
+			info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !info.IsSymlink || info.IsDirectory {
+				t.Errorf("stat %q: expected an unfollowed symlink, got %v", path, info)
+			}
+		}
+
+		if _, err := f.service.ListDirectory(ctx, &ListRequest{Path: "relative"}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("list: expected PermissionDenied, got %v", err)
+		}
+
+		f.assertOutsideUntouched(t)
+	})
+}
+
+// TestSymlinkEscape checks that no RPC follows a symlink out of the base directory
+func TestSymlinkEscape(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		ctx := context.Background()
+		symlink(t, f.outside, filepath.Join(f.base, "link"))
+		symlink(t, filepath.Join(f.outside, "secret.txt"), filepath.Join(f.base, "file-link"))
+		symlink(t, "../../outside", filepath.Join(f.base, "nested-link"))
+		symlink(t, "/proc/self/root"+f.outside, filepath.Join(f.base, "magic-link"))
+
+		for _, path := range []string{"link/secret.txt", "file-link", "nested-link/secret.txt", "magic-link/secret.txt"} {
+			if data, err := f.download(path); err == nil || data == secretContent {
+				t.Errorf("download %q: got %q, %v", path, data, err)
+			}
+			if err := f.upload(path, "overwritten"); err == nil {
+				t.Errorf("upload %q succeeded", path)
+			}
+		}
+
+		if err := f.upload("link/evil", "x"); err == nil {
+			t.Error("upload into a symlinked directory succeeded")
+		}
+		if resp, err := f.service.Copy(ctx, &CopyRequest{Source: "public.txt", Destination: "link/evil"}); err == nil && resp.Success {
+			t.Error("copy into a symlinked directory succeeded")
+		}
+		if resp, err := f.service.Move(ctx, &MoveRequest{Source: "public.txt", Destination: "link/evil"}); err == nil && resp.Success {
+			t.Error("move into a symlinked directory succeeded")
+		}
+		if resp, err := f.service.Delete(ctx, &DeleteRequest{Path: "link/secret.txt"}); err == nil && resp.Success {
+			t.Error("delete through a symlinked directory succeeded")
+		}
+
+		// Walks report the symlinks but never descend into them
+		resp, err := f.service.Search(ctx, &SearchRequest{BasePath: "/", Pattern: "secret*", Recursive: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Items) != 0 {
+			t.Errorf("search found files outside the base directory: %v", resp.Items)
+		}
+		size, err := f.service.GetDirectorySize(ctx, &PathRequest{Path: "/"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected int64
+		filepath.Walk(f.base, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				expected += info.Size() // Symlinks count with their own size
+			}
+			return nil
+		})
+		if size.Size != expected {
+			t.Errorf("directory size counted files outside the base directory: got %d, want %d", size.Size, expected)
+		}
+
+		f.assertOutsideUntouched(t)
+	})
+}
+
+// TestSymlinkSwapRace swaps a directory with a symlink to the outside while
+// it is being read and written. With check-then-use path handling the swap
+// can land between the check and the access.
+func TestSymlinkSwapRace(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		ctx := context.Background()
+		writeTestFile(t, filepath.Join(f.base, "dir", "secret.txt"), publicContent)
+		symlink(t, f.outside, filepath.Join(f.base, "swap"))
+
+		dir := filepath.Join(f.base, "dir")
+		swap := filepath.Join(f.base, "swap")
+
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// Atomically exchange the directory and the symlink
+				if err := unix.Renameat2(unix.AT_FDCWD, dir, unix.AT_FDCWD, swap, unix.RENAME_EXCHANGE); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+
+		deadline := time.Now().Add(500 * time.Millisecond)
+		for time.Now().Before(deadline) {
+			if data, _ := f.download("dir/secret.txt"); data == secretContent {
+				t.Error("read a file outside the base directory")
+				break
+			}
+			f.upload("dir/evil", "x")
+			f.service.Copy(ctx, &CopyRequest{Source: "public.txt", Destination: "dir/evil", Overwrite: true})
+			f.service.CreateDirectory(ctx, &CreateDirectoryRequest{Path: "dir/evil-dir"})
+			f.service.Delete(ctx, &DeleteRequest{Path: "dir/secret.txt"})
+		}
+
+		close(stop)
+		wg.Wait()
+
+		f.assertOutsideUntouched(t)
+	})
+}
+
+// policyFixture is a base directory with symlinks inside and out of it:
+//
+//	data/file.txt
+//	data-link    -> data
+//	file-link    -> data/file.txt
+//	escape       -> ../outside
+func newPolicyFixture(t *testing.T, policy SymlinkPolicy) *securityFixture {
+	f := newSecurityFixture(t)
+	if err := f.service.SetSymlinkPolicy(string(policy)); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(f.base, "data", "file.txt"), publicContent)
+	symlink(t, "data", filepath.Join(f.base, "data-link"))
+	symlink(t, "data/file.txt", filepath.Join(f.base, "file-link"))
+	symlink(t, "../outside", filepath.Join(f.base, "escape"))
+	return f
+}
+
+// TestSymlinkPolicyFollow checks that "follow" resolves every link, even out of the base directory
+func TestSymlinkPolicyFollow(t *testing.T) {
+	f := newPolicyFixture(t, SymlinkFollow)
+
+	for path, want := range map[string]string{
+		"file-link":          publicContent,
+		"data-link/file.txt": publicContent,
+		"escape/secret.txt":  secretContent,
+	} {
+		if data, err := f.download(path); err != nil || data != want {
+			t.Errorf("download %q: got %q, %v", path, data, err)
+		}
+	}
+}
+
+// TestSymlinkPolicyWithinRoot checks that "follow-within-root" resolves links that stay inside
+func TestSymlinkPolicyWithinRoot(t *testing.T) {
+	f := newPolicyFixture(t, SymlinkFollowWithinRoot)
+	ctx := context.Background()
+
+	if data, err := f.download("data-link/file.txt"); err != nil || data != publicContent {
+		t.Errorf("download through an inside link: got %q, %v", data, err)
+	}
+	if _, err := f.download("escape/secret.txt"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("download through an escaping link: expected PermissionDenied, got %v", err)
+	}
+
+	info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: "file-link"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsSymlink || info.LinkTarget != "data/file.txt" || info.Size != int64(len(publicContent)) {
+		t.Errorf("expected the followed link, got %v", info)
+	}
+
+	resp, err := f.service.ListDirectory(ctx, &ListRequest{Path: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range resp.Items {
+		switch item.Name {
+		case "data-link":
+			if !item.IsSymlink || !item.IsDirectory {
+				t.Errorf("expected a followed directory link, got %v", item)
+			}
+		case "escape":
+			if !item.IsSymlink || item.IsDirectory || item.LinkTarget != "../outside" {
+				t.Errorf("expected an unfollowed link, got %v", item)
+			}
+		}
+	}
+
+	if _, err := f.service.CreateSymlink(ctx, &SymlinkRequest{Path: "new", Target: "../outside"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("create escaping link: expected PermissionDenied, got %v", err)
+	}
+	if _, err := f.service.CreateSymlink(ctx, &SymlinkRequest{Path: "new", Target: f.outside}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("create absolute link: expected PermissionDenied, got %v", err)
+	}
+	if resp, err := f.service.CreateSymlink(ctx, &SymlinkRequest{Path: "sub/new", Target: "../data/file.txt"}); err != nil || !resp.Success {
+		t.Fatalf("create inside link: %v, %v", resp, err)
+	}
+	if data, err := f.download("sub/new"); err != nil || data != publicContent {
+		t.Errorf("download through a created link: got %q, %v", data, err)
+	}
+
+	// Copies follow links to files and recreate links to directories
+	for _, name := range []string{"file-link", "escape"} {
+		if _, err := f.service.Copy(ctx, &CopyRequest{Source: name, Destination: "copy/" + name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if info, err := os.Lstat(filepath.Join(f.base, "copy", "file-link")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("expected a copied file, got %v, %v", info, err)
+	}
+	if target, err := os.Readlink(filepath.Join(f.base, "copy", "escape")); err != nil || target != "../outside" {
+		t.Errorf("expected a recreated link, got %q, %v", target, err)
+	}
+
+	f.assertOutsideUntouched(t)
+}
+
+// TestSymlinkPolicyNever checks that "never" refuses every link but still shows and deletes them
+func TestSymlinkPolicyNever(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		if err := f.service.SetSymlinkPolicy(string(SymlinkNever)); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(f.base, "data", "file.txt"), publicContent)
+		symlink(t, "data", filepath.Join(f.base, "data-link"))
+		symlink(t, "data/file.txt", filepath.Join(f.base, "file-link"))
+		ctx := context.Background()
+
+		for _, path := range []string{"file-link", "data-link/file.txt"} {
+			if _, err := f.download(path); status.Code(err) != codes.PermissionDenied {
+				t.Errorf("download %q: expected PermissionDenied, got %v", path, err)
+			}
+		}
+		if err := f.upload("data-link/new.txt", "x"); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("upload through a link: expected PermissionDenied, got %v", err)
+		}
+
+		info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: "file-link"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.IsSymlink || info.MimeType != "" {
+			t.Errorf("expected the link itself, got %v", info)
+		}
+
+		if _, err := f.service.CreateSymlink(ctx, &SymlinkRequest{Path: "new", Target: "data"}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("create link: expected PermissionDenied, got %v", err)
+		}
+
+		if resp, err := f.service.Delete(ctx, &DeleteRequest{Path: "data-link"}); err != nil || !resp.Success {
+			t.Fatalf("delete link: %v, %v", resp, err)
+		}
+		if _, err := os.Stat(filepath.Join(f.base, "data", "file.txt")); err != nil {
+			t.Errorf("deleting a link removed its target: %v", err)
+		}
+	})
+}
+
+// TestSpecialFiles checks that FIFOs are reported as such and never opened for content
+func TestSpecialFiles(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		ctx := context.Background()
+		if err := unix.Mkfifo(filepath.Join(f.base, "pipe"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: "pipe"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.FileType != pb.FileType_FILE_TYPE_FIFO || info.Mode != "prw-r--r--" {
+			t.Errorf("expected a FIFO, got %v", info)
+		}
+
+		// Each of these would block forever if the FIFO were opened normally
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if _, err := f.download("pipe"); status.Code(err) != codes.FailedPrecondition {
+				t.Errorf("download: expected FailedPrecondition, got %v", err)
+			}
+			if err := f.upload("pipe", "x"); status.Code(err) != codes.FailedPrecondition {
+				t.Errorf("upload: expected FailedPrecondition, got %v", err)
+			}
+			if resp, err := f.service.Copy(ctx, &CopyRequest{Source: "pipe", Destination: "copy"}); err == nil && resp.Success {
+				t.Error("copying a FIFO succeeded")
+			}
+			if resp, err := f.service.Copy(ctx, &CopyRequest{Source: "public.txt", Destination: "pipe", Overwrite: true}); err == nil && resp.Success {
+				t.Error("copying onto a FIFO succeeded")
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("an RPC blocked on a FIFO")
+		}
+	})
+}
+
+// Metadata changes through links to the outside must not reach the target
+func TestMetadataEscape(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		ctx := context.Background()
+		symlink(t, f.outside, filepath.Join(f.base, "link"))
+		symlink(t, "../outside/secret.txt", filepath.Join(f.base, "file-link"))
+		secret := filepath.Join(f.outside, "secret.txt")
+		before, err := os.Stat(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, path := range []string{"link/secret.txt", "file-link"} {
+			if resp, err := f.service.SetPermissions(ctx, &SetPermissionsRequest{Path: path, Mode: "0777"}); err == nil && resp.Success {
+				t.Errorf("chmod %q succeeded", path)
+			}
+			if resp, err := f.service.SetTimes(ctx, &SetTimesRequest{Path: path, ModifiedTimeNs: 1e18}); err == nil && resp.Success {
+				t.Errorf("touch %q succeeded", path)
+			}
+			if resp, err := f.service.SetOwner(ctx, &SetOwnerRequest{Path: path, Group: strconv.Itoa(os.Getgid())}); err == nil && resp.Success {
+				t.Errorf("chown %q succeeded", path)
+			}
+		}
+		if resp, err := f.service.SetTimes(ctx, &SetTimesRequest{Path: "link/evil", Create: true}); err == nil && resp.Success {
+			t.Error("touch created a file through a symlinked directory")
+		}
+
+		after, err := os.Stat(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after.Mode() != before.Mode() || !after.ModTime().Equal(before.ModTime()) {
+			t.Errorf("outside file changed: %v %v, was %v %v", after.Mode(), after.ModTime(), before.Mode(), before.ModTime())
+		}
+		f.assertOutsideUntouched(t)
+	})
+}
+
+// In-place edits through a link to the outside must not reach the target
+func TestEditEscape(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		ctx := context.Background()
+		symlink(t, filepath.Join(f.outside, "secret.txt"), filepath.Join(f.base, "file-link"))
+		if _, err := f.service.AppendFile(ctx, &AppendRequest{Path: "file-link", Data: []byte("x")}); err == nil {
+			t.Error("append through a symlink to the outside succeeded")
+		}
+		if _, err := f.service.WriteFile(ctx, &WriteRequest{Path: "file-link", Data: []byte("x")}); err == nil {
+			t.Error("write through a symlink to the outside succeeded")
+		}
+		if _, err := f.service.Truncate(ctx, &TruncateRequest{Path: "file-link"}); err == nil {
+			t.Error("truncate through a symlink to the outside succeeded")
+		}
+		f.assertOutsideUntouched(t)
+	})
+}
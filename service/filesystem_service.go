@@ -2,14 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http" // For MIME type detection
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"syscall" // For detailed file info
-	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -22,167 +22,157 @@ import (
 type FilesystemService struct {
 	BaseDir string // Root directory for all operations
 	pb.UnimplementedFilesystemServiceServer
+
+	root       *rootFS        // All file access goes through this descriptor of BaseDir
+	denyRules  []ignoreRule   // Hidden paths, see SetDenyList
+	locks      *lockManager   // Leases taken with AcquireLock
+	versions   *versionStore  // Earlier content of files, see SetVersioning
+	snapshots  *snapshotStore // Directory snapshots, see SetSnapshotDir
+	checksums  *checksumCache // Checksums of unchanged files, see SetChecksumCacheDir
+	allowSetID bool           // Whether clients may make files setuid or setgid, see SetAllowSetID
 }
 
 // NewFilesystemService creates a new instance of the filesystem service
-func NewFilesystemService(baseDir string) *FilesystemService {
+func NewFilesystemService(baseDir string) (*FilesystemService, error) {
+	root, err := openRootFS(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open base directory: %w", err)
+	}
+
 	return &FilesystemService{
 		BaseDir: baseDir,
+		root:    root,
+		locks:   newLockManager(),
+	}, nil
+}
+
+// Close releases the held leases and the base directory descriptor
+func (s *FilesystemService) Close() error {
+	s.locks.Close()
+	if s.versions != nil {
+		s.versions.close()
 	}
+	return s.root.Close()
 }
 
-// validatePath ensures the path is within the allowed base directory
-// It resolves the full path and checks for directory traversal attacks
+// validatePath ensures the path is within the allowed base directory and
+// returns it relative to the base directory ("." for the base itself).
+// The result must only be used with s.root, which resolves it again in the
+// kernel on every access, so a symlink swapped in after this check can not
+// lead outside the base directory either.
 func (s *FilesystemService) validatePath(path string) (string, error) {
-	// Normalize path separators for the current OS
-	path = filepath.FromSlash(path)
+	// Clean lexically; ".." can not climb above the base directory
+	relPath := cleanRel(path)
 	
-	// Join with base directory to get absolute path
-	fullPath := filepath.Join(s.BaseDir, path)
-	
-	// Get canonical path with symlinks resolved
-	realPath, err := filepath.EvalSymlinks(fullPath)
-	if err != nil {
-		// If path doesn't exist yet, check its parent directory
-		if os.IsNotExist(err) {
-			parentDir := filepath.Dir(fullPath)
-			realParentPath, err := filepath.EvalSymlinks(parentDir)
-			if err != nil {
-				return "", status.Errorf(codes.InvalidArgument, "Invalid path: %v", err)
-			}
-			// Check if parent is within base directory
-			if !strings.HasPrefix(realParentPath, s.BaseDir) {
-				return "", status.Errorf(codes.PermissionDenied, "Path is outside allowed directory")
-			}
-			return fullPath, nil
+	// Resolve the parent directories to reject early any path that leaves the
+	// base directory; a final symlink is checked by the operation using it
+	if _, err := s.root.Lstat(relPath); err != nil {
+		if isOutsideRoot(err) || isSymlinkRefused(err) {
+			return "", rootError(err, "Invalid path")
+		}
+		// Paths that do not exist yet are fine; callers create or report them
+		if !os.IsNotExist(err) {
+			return "", status.Errorf(codes.InvalidArgument, "Invalid path: %v", err)
 		}
-		return "", status.Errorf(codes.InvalidArgument, "Invalid path: %v", err)
 	}
 	
-	// Check if the path is within the allowed base directory
-	if !strings.HasPrefix(realPath, s.BaseDir) {
-		return "", status.Errorf(codes.PermissionDenied, "Path is outside allowed directory")
+	// Paths on the deny list look like they do not exist; s.root refuses the
+	// symlinks leading to them
+	if s.isDenied(filepath.ToSlash(relPath)) {
+		return "", errDenied()
 	}
 	
-	return fullPath, nil
+	return relPath, nil
+}
+
+// rootError maps a failed access to an error status, using msg for unexpected failures
+func rootError(err error, msg string) error {
+	switch {
+	case isOutsideRoot(err):
+		return status.Errorf(codes.PermissionDenied, "Path is outside allowed directory")
+	case isSymlinkRefused(err):
+		return status.Errorf(codes.PermissionDenied, "Path contains a symlink that is not followed")
+	case errors.Is(err, errNotRegular):
+		return status.Errorf(codes.FailedPrecondition, "Path is not a regular file")
+	case os.IsNotExist(err):
+		return status.Errorf(codes.NotFound, "File or directory does not exist")
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
 }
 
 // fileInfoToProto converts os.FileInfo to the protobuf FileInfo message
 func fileInfoToProto(path string, info os.FileInfo) *pb.FileInfo {
+	stat := statFieldsOf(info)
 	return &pb.FileInfo{
-		Name:         info.Name(),
-		Path:         path,
-		IsDirectory:  info.IsDir(),
-		Size:         info.Size(),
-		ModifiedTime: info.ModTime().Unix(),
-		Permissions:  fmt.Sprintf("%o", info.Mode().Perm()),
+		Name:           info.Name(),
+		Path:           path,
+		IsDirectory:    info.IsDir(),
+		Size:           info.Size(),
+		ModifiedTime:   info.ModTime().Unix(),
+		ModifiedTimeNs: info.ModTime().UnixNano(),
+		Permissions:    fmt.Sprintf("%o", info.Mode().Perm()),
+		FileType:       fileTypeOf(info.Mode()),
+		Mode:           modeString(info.Mode()),
+		Inode:          stat.inode,
+		Device:         stat.device,
+		LinkCount:      stat.linkCount,
+		Blocks:         stat.blocks,
+		Etag:           etagOf(info),
 	}
 }
 
+// detectMimeType sniffs the MIME type from the first 512 bytes of a file
+func detectMimeType(file *os.File) (string, error) {
+	buffer := make([]byte, 512)
+	n, err := file.Read(buffer)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(buffer[:n]), nil
+}
+
+// isTextMimeType reports whether a sniffed MIME type describes text content
+func isTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") ||
+		strings.Contains(mimeType, "json") ||
+		strings.Contains(mimeType, "xml") ||
+		strings.Contains(mimeType, "javascript")
+}
+
 // fileItemToProto converts os.FileInfo to the protobuf FileItem message (simpler than FileInfo)
 func fileItemToProto(basePath string, info os.FileInfo) *pb.FileItem {
+	stat := statFieldsOf(info)
 	return &pb.FileItem{
-		Name:         info.Name(),
-		Path:         filepath.Join(basePath, info.Name()),
-		IsDirectory:  info.IsDir(),
-		Size:         info.Size(),
-		ModifiedTime: info.ModTime().Unix(),
-		Permissions:  fmt.Sprintf("%o", info.Mode().Perm()),
+		Name:           info.Name(),
+		Path:           filepath.Join(basePath, info.Name()),
+		IsDirectory:    info.IsDir(),
+		Size:           info.Size(),
+		ModifiedTime:   info.ModTime().Unix(),
+		ModifiedTimeNs: info.ModTime().UnixNano(),
+		Permissions:    fmt.Sprintf("%o", info.Mode().Perm()),
+		FileType:       fileTypeOf(info.Mode()),
+		Mode:           modeString(info.Mode()),
+		Inode:          stat.inode,
+		Device:         stat.device,
+		LinkCount:      stat.linkCount,
+		Blocks:         stat.blocks,
+		Etag:           etagOf(info),
 		// Children and ParentPath will be available after proto regeneration
 	}
 }
 
 // ListDirectory implements the ListDirectory RPC method
 func (s *FilesystemService) ListDirectory(ctx context.Context, req *ListRequest) (*ListResponse, error) {
-	validPath, err := s.validatePath(req.Path)
+	validPath, err := s.validateListPath(req)
 	if err != nil {
 		return nil, err
 	}
-	
-	// Check if path exists and is a directory
-	info, err := os.Stat(validPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, status.Errorf(codes.NotFound, "Directory does not exist")
-		}
-		return nil, status.Errorf(codes.Internal, "Failed to access directory: %v", err)
-	}
-	
-	if !info.IsDir() {
-		return nil, status.Errorf(codes.InvalidArgument, "Path is not a directory")
-	}
-	
-	var response ListResponse
-	
-	// Handle recursive listing
-	if req.Recursive {
-		err = filepath.Walk(validPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // Skip files with errors
-			}
-			
-			// Skip the root directory itself
-			if path == validPath {
-				return nil
-			}
-			
-			// If pattern is specified, check if it matches
-			if req.Pattern != "" {
-				matched, err := filepath.Match(req.Pattern, info.Name())
-				if err != nil || !matched {
-					return nil // Skip non-matching files
-				}
-			}
-			
-			// Get relative path from base
-			relPath, err := filepath.Rel(s.BaseDir, path)
-			if err != nil {
-				return nil
-			}
-			
-			item := fileItemToProto(filepath.Dir(relPath), info)
-			response.Items = append(response.Items, item)
-			return nil
-		})
-		
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "Failed to list directory recursively: %v", err)
-		}
-		
-		return &response, nil
-	}
-	
-	// Non-recursive directory listing
-	entries, err := os.ReadDir(validPath)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to read directory: %v", err)
-	}
-	
-	// Get relative path from base for the request path
-	relPath, err := filepath.Rel(s.BaseDir, validPath)
-	if err != nil {
-		relPath = req.Path // Use original path if relative path can't be determined
-	}
-	
-	for _, entry := range entries {
-		// If pattern is specified, check if it matches
-		if req.Pattern != "" {
-			matched, err := filepath.Match(req.Pattern, entry.Name())
-			if err != nil || !matched {
-				continue // Skip non-matching files
-			}
-		}
-		
-		info, err := entry.Info()
-		if err != nil {
-			continue // Skip entries with errors
-		}
-		
-		item := fileItemToProto(relPath, info)
-		response.Items = append(response.Items, item)
+	if err := checkAlgorithms(req.Checksums); err != nil {
+		return nil, err
 	}
 	
-	return &response, nil
+	// Sorting and paging are handled in filesystem_listing.go
+	return s.listPage(ctx, validPath, req)
 }
 
 // GetFileInfo implements the GetFileInfo RPC method
@@ -192,43 +182,63 @@ func (s *FilesystemService) GetFileInfo(ctx context.Context, req *FileRequest) (
 		return nil, err
 	}
 	
-	info, err := os.Stat(validPath)
+	linkInfo, err := s.root.Lstat(validPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, status.Errorf(codes.NotFound, "File or directory does not exist")
-		}
-		return nil, status.Errorf(codes.Internal, "Failed to get file info: %v", err)
+		return nil, rootError(err, "Failed to get file info")
 	}
 	
-	// Get relative path from base
-	relPath, err := filepath.Rel(s.BaseDir, validPath)
-	if err != nil {
-		relPath = req.Path
-	}
+	// Symlinks are described by their target when the policy follows them
+	info, target := s.root.resolveLink(validPath, linkInfo)
 	
 	// Create basic file info
-	fileInfo := fileInfoToProto(relPath, info)
+	fileInfo := fileInfoToProto(validPath, info)
+	if linkInfo.Mode()&os.ModeSymlink != 0 {
+		fileInfo.IsSymlink = true
+		fileInfo.LinkTarget = target
+	}
 	
-	// Additional file information (these might not be available on all platforms)
+	// Timestamps with nanosecond precision; birth time only where statx reports it
+	times := fileTimesOf(info)
+	if birth, ok := s.root.birthTime(validPath, info); ok {
+		times.birth = birth
+	}
+	fileInfo.CreationTime = unixSeconds(times.birth)
+	fileInfo.CreationTimeNs = unixNanos(times.birth)
+	fileInfo.AccessTime = unixSeconds(times.access)
+	fileInfo.AccessTimeNs = unixNanos(times.access)
+	fileInfo.ChangeTime = unixSeconds(times.change)
+	fileInfo.ChangeTimeNs = unixNanos(times.change)
+	
+	// Owner and group names (these might not be available on all platforms)
 	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
-		fileInfo.CreationTime = time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec).Unix()
-		fileInfo.AccessTime = time.Unix(stat.Atim.Sec, stat.Atim.Nsec).Unix()
-		fileInfo.Owner = strconv.FormatUint(uint64(stat.Uid), 10)
-		fileInfo.Group = strconv.FormatUint(uint64(stat.Gid), 10)
+		fileInfo.Uid = stat.Uid
+		fileInfo.Gid = stat.Gid
+		fileInfo.Owner = userNames.name(stat.Uid)
+		fileInfo.Group = groupNames.name(stat.Gid)
+	}
+	
+	// Extended attributes on request; filesystems without support have none
+	if req.IncludeXattrs {
+		if xattrs, err := s.root.xattrs(validPath); err == nil {
+			fileInfo.Xattrs = xattrs
+		}
+	}
+	
+	// Checksums on request, from the cache where the file is unchanged
+	if err := checkAlgorithms(req.Checksums); err != nil {
+		return nil, err
 	}
+	fileInfo.Checksums = s.addChecksums(ctx, validPath, info, req.Checksums)
 	
-	// Determine MIME type for files (not directories)
-	if !info.IsDir() {
+	// Determine MIME type for regular files; opening a FIFO would block
+	if info.Mode().IsRegular() {
 		// Open file to detect MIME type
-		file, err := os.Open(validPath)
+		file, _, err := s.root.openRegular(validPath)
 		if err == nil {
 			defer file.Close()
 			
-			// Read first 512 bytes for MIME detection
-			buffer := make([]byte, 512)
-			_, err := file.Read(buffer)
-			if err == nil {
-				fileInfo.MimeType = http.DetectContentType(buffer)
+			if mimeType, err := detectMimeType(file); err == nil {
+				fileInfo.MimeType = mimeType
 			}
 		}
 	}
@@ -243,13 +253,14 @@ func (s *FilesystemService) Exists(ctx context.Context, req *PathRequest) (*Exis
 		return nil, err
 	}
 	
-	info, err := os.Stat(validPath)
+	linkInfo, err := s.root.Lstat(validPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &ExistsResponse{Exists: false}, nil
 		}
 		return nil, status.Errorf(codes.Internal, "Failed to check path: %v", err)
 	}
+	info, _ := s.root.resolveLink(validPath, linkInfo)
 	
 	return &ExistsResponse{
 		Exists:      true,
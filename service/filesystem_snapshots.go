@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// snapshotNamePattern restricts snapshot names to a single harmless path element
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]{0,127}$`)
+
+// snapshotNameLayout names snapshots created without a name
+const snapshotNameLayout = "20060102T150405Z"
+
+// snapshotStore keeps directory snapshots, each in a directory named after
+// the snapshot with the captured tree and a metadata file. Snapshots are
+// built in a hidden directory first and renamed into place when complete.
+//
+// Files are hard linked between snapshots only, never to the live tree: a
+// file that is changed in place, as uploads and edits do, would change its
+// snapshots along with it.
+type snapshotStore struct {
+	dir string     // Store directory, relative to the base directory
+	mu  sync.Mutex // Serializes creating, restoring and deleting snapshots
+}
+
+// snapshotMeta is the metadata file of a snapshot
+type snapshotMeta struct {
+	Path           string `json:"path"`
+	CreatedTimeNs  int64  `json:"created_time_ns"`
+	Files          int64  `json:"files"`
+	Size           int64  `json:"size"`
+	LinkedFiles    int64  `json:"linked_files"`
+	Previous       string `json:"previous,omitempty"`
+	PreserveXattrs bool   `json:"preserve_xattrs"`
+
+	name string
+}
+
+// SetSnapshotDir enables snapshots, kept in dir below the base directory.
+// The directory is hidden from clients like a deny list entry, so
+// SetSnapshotDir must be called after SetDenyList. It is not hidden from
+// anything else serving the base directory, such as a web server.
+func (s *FilesystemService) SetSnapshotDir(dir string) error {
+	dir = cleanRel(dir)
+	if dir == "." {
+		return fmt.Errorf("snapshot store must be a directory below the base directory")
+	}
+
+	rule, _, err := parseIgnoreRule("/"+filepath.ToSlash(dir)+"/", "")
+	if err != nil {
+		return fmt.Errorf("invalid snapshot store %q: %w", dir, err)
+	}
+	s.addDenyRules(rule)
+	s.snapshots = &snapshotStore{dir: dir}
+	return nil
+}
+
+// CreateSnapshot implements the CreateSnapshot RPC method
+func (s *FilesystemService) CreateSnapshot(ctx context.Context, req *CreateSnapshotRequest) (*pb.Snapshot, error) {
+	store, err := s.snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.root.Stat(validPath)
+	if err != nil {
+		return nil, rootError(err, "Failed to access path")
+	}
+	if !info.IsDir() {
+		return nil, status.Errorf(codes.InvalidArgument, "Path is not a directory")
+	}
+
+	now := time.Now()
+	name := req.Name
+	if name == "" {
+		name = now.UTC().Format(snapshotNameLayout)
+	}
+	if !snapshotNamePattern.MatchString(name) {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid snapshot name %q", name)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	snapshotPath := filepath.Join(store.dir, name)
+	if _, err := s.root.Lstat(snapshotPath); err == nil {
+		return nil, status.Errorf(codes.AlreadyExists, "Snapshot %s already exists", name)
+	}
+
+	meta := snapshotMeta{
+		Path:           filepath.ToSlash(validPath),
+		CreatedTimeNs:  now.UnixNano(),
+		PreserveXattrs: req.PreserveXattrs,
+		name:           name,
+	}
+	var stats copyStats
+	opts := copyOptions{xattrs: req.PreserveXattrs, exact: true, stats: &stats}
+
+	// Share unchanged files with the newest snapshot of the same directory
+	snapshots, err := s.readSnapshots(store)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to read snapshots: %v", err)
+	}
+	for _, previous := range snapshots {
+		if previous.Path == meta.Path && previous.PreserveXattrs == req.PreserveXattrs {
+			meta.Previous = previous.name
+			opts.linkDest = filepath.Join(store.dir, previous.name, "tree")
+			break
+		}
+	}
+
+	partial := filepath.Join(store.dir, "."+name+".partial")
+	if err := s.root.RemoveAll(partial); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to create snapshot: %v", err)
+	}
+	err = s.root.MkdirAll(partial, 0700)
+	if err == nil {
+		err = s.copyDir(validPath, filepath.Join(partial, "tree"), opts)
+	}
+	if err == nil {
+		meta.Files, meta.Size, meta.LinkedFiles = stats.files, stats.bytes, stats.linked
+		err = s.writeSnapshotMeta(partial, meta)
+	}
+	if err == nil {
+		err = s.root.Rename(partial, snapshotPath)
+	}
+	if err != nil {
+		s.root.RemoveAll(partial)
+		return nil, status.Errorf(codes.Internal, "Failed to create snapshot: %v", err)
+	}
+
+	return meta.proto(), nil
+}
+
+// ListSnapshots implements the ListSnapshots RPC method
+func (s *FilesystemService) ListSnapshots(ctx context.Context, req *ListSnapshotsRequest) (*ListSnapshotsResponse, error) {
+	store, err := s.snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+
+	var slashPath string
+	if req.Path != "" {
+		validPath, err := s.validatePath(req.Path)
+		if err != nil {
+			return nil, err
+		}
+		slashPath = filepath.ToSlash(validPath)
+	}
+
+	snapshots, err := s.readSnapshots(store)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to read snapshots: %v", err)
+	}
+
+	response := &ListSnapshotsResponse{Snapshots: []*pb.Snapshot{}}
+	for _, meta := range snapshots {
+		if slashPath == "" || meta.Path == slashPath {
+			response.Snapshots = append(response.Snapshots, meta.proto())
+		}
+	}
+	return response, nil
+}
+
+// DiffSnapshot implements the DiffSnapshot RPC method
+func (s *FilesystemService) DiffSnapshot(ctx context.Context, req *SnapshotRequest) (*DiffResponse, error) {
+	store, err := s.snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+	meta, err := s.readSnapshot(store, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.diffTrees(ctx, filepath.Join(store.dir, meta.name, "tree"), meta.Path)
+	if err != nil {
+		return nil, rootError(err, "Failed to compare snapshot")
+	}
+
+	response := &DiffResponse{Changes: []*pb.PathChange{}}
+	for _, change := range changes {
+		response.Changes = append(response.Changes, change.proto())
+	}
+	return response, nil
+}
+
+// RestoreSnapshot implements the RestoreSnapshot RPC method
+func (s *FilesystemService) RestoreSnapshot(ctx context.Context, req *RestoreSnapshotRequest) (*OperationResponse, error) {
+	store, err := s.snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	meta, err := s.readSnapshot(store, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	destination := meta.Path
+	if req.Destination != "" {
+		if destination, err = s.validatePath(req.Destination); err != nil {
+			return nil, err
+		}
+	}
+	// The destination defaults to the snapshotted directory, so locks are
+	// checked here rather than through lockedFields
+	if err := s.checkPathLocks(ctx, destination); err != nil {
+		return nil, err
+	}
+
+	tree := filepath.Join(store.dir, meta.name, "tree")
+	changes, err := s.diffTrees(ctx, tree, destination)
+	if err != nil {
+		return nil, rootError(err, "Failed to compare snapshot")
+	}
+
+	// Clear what the copy can not overwrite, and with delete_extra what the
+	// snapshot does not have
+	for _, change := range changes {
+		typeChanged := change.change == pb.ChangeType_CHANGE_MODIFIED &&
+			change.old.info.Mode().Type() != change.new.info.Mode().Type()
+		extra := change.change == pb.ChangeType_CHANGE_ADDED && req.DeleteExtra
+		if !typeChanged && !extra {
+			continue
+		}
+		if err := s.removeRestored(change.path, change.new.info); err != nil {
+			return &OperationResponse{
+				Success: false,
+				Error:   "Failed to remove " + change.path + ": " + err.Error(),
+			}, nil
+		}
+	}
+
+	var stats copyStats
+	opts := copyOptions{xattrs: meta.PreserveXattrs, exact: true, skipSame: true, stats: &stats}
+	if err := s.copyDir(tree, destination, opts); err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to restore snapshot: " + err.Error(),
+		}, nil
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: fmt.Sprintf("Snapshot restored successfully (%d files copied, %d unchanged)", stats.files-stats.linked, stats.linked),
+	}, nil
+}
+
+// DeleteSnapshot implements the DeleteSnapshot RPC method
+func (s *FilesystemService) DeleteSnapshot(ctx context.Context, req *SnapshotRequest) (*OperationResponse, error) {
+	store, err := s.snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	meta, err := s.readSnapshot(store, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.root.RemoveAll(filepath.Join(store.dir, meta.name)); err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to delete snapshot: " + err.Error(),
+		}, nil
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: "Snapshot deleted successfully",
+	}, nil
+}
+
+// snapshotStore returns the snapshot store, or an error when snapshots are disabled
+func (s *FilesystemService) snapshotStore() (*snapshotStore, error) {
+	if s.snapshots == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "Snapshots are not enabled")
+	}
+	return s.snapshots, nil
+}
+
+// removeRestored removes an entry a restore replaces, keeping versions of its files
+func (s *FilesystemService) removeRestored(slashPath string, info os.FileInfo) error {
+	relPath := filepath.FromSlash(slashPath)
+	if info.IsDir() {
+		pending, err := s.preserveTree(relPath, "restore")
+		if err != nil {
+			return err
+		}
+		defer s.settleVersions(pending)
+		return s.root.RemoveAll(relPath)
+	}
+
+	pending, err := s.preserveReplaced(relPath, "restore")
+	if err != nil {
+		return err
+	}
+	defer s.settleVersions(pending)
+	if err := s.root.Remove(relPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// readSnapshots reads the metadata of every complete snapshot, newest first
+func (s *FilesystemService) readSnapshots(store *snapshotStore) ([]snapshotMeta, error) {
+	entries, err := s.root.ReadDir(store.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []snapshotMeta
+	for _, entry := range entries {
+		// Hidden entries are snapshots being built
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		meta, err := s.readSnapshotMeta(store, entry.Name())
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, meta)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedTimeNs > snapshots[j].CreatedTimeNs })
+	return snapshots, nil
+}
+
+// readSnapshot reads the metadata of the named snapshot, failing with a status error
+func (s *FilesystemService) readSnapshot(store *snapshotStore, name string) (snapshotMeta, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return snapshotMeta{}, status.Errorf(codes.InvalidArgument, "Invalid snapshot name %q", name)
+	}
+
+	meta, err := s.readSnapshotMeta(store, name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snapshotMeta{}, status.Errorf(codes.NotFound, "Snapshot %s does not exist", name)
+		}
+		return snapshotMeta{}, status.Errorf(codes.Internal, "Failed to read snapshot: %v", err)
+	}
+	return meta, nil
+}
+
+func (s *FilesystemService) readSnapshotMeta(store *snapshotStore, name string) (snapshotMeta, error) {
+	file, err := s.root.Open(filepath.Join(store.dir, name, "snapshot.json"))
+	if err != nil {
+		return snapshotMeta{}, err
+	}
+	defer file.Close()
+
+	var meta snapshotMeta
+	if err := json.NewDecoder(file).Decode(&meta); err != nil {
+		return snapshotMeta{}, err
+	}
+	meta.name = name
+	return meta, nil
+}
+
+func (s *FilesystemService) writeSnapshotMeta(dir string, meta snapshotMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return s.root.writeNew(filepath.Join(dir, "snapshot.json"), data)
+}
+
+func (m snapshotMeta) proto() *pb.Snapshot {
+	return &pb.Snapshot{
+		Name:           m.name,
+		Path:           m.Path,
+		CreatedTime:    time.Unix(0, m.CreatedTimeNs).Unix(),
+		Files:          m.Files,
+		Size:           m.Size,
+		LinkedFiles:    m.LinkedFiles,
+		Previous:       m.Previous,
+		PreserveXattrs: m.PreserveXattrs,
+	}
+}
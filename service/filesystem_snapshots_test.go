@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSnapshots(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	if err := f.service.SetSnapshotDir(".snapshots"); err != nil {
+		t.Fatal(err)
+	}
+	f.write(t, "site/a.txt", "a")
+	f.write(t, "site/b.txt", "b")
+
+	diff := func(name string) string {
+		t.Helper()
+		response, err := f.service.DiffSnapshot(ctx, &SnapshotRequest{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var changes []string
+		for _, change := range response.Changes {
+			changes = append(changes, change.Change.String()+" "+change.Path)
+		}
+		return strings.Join(changes, ",")
+	}
+
+	one, err := f.service.CreateSnapshot(ctx, &CreateSnapshotRequest{Path: "site", Name: "one"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one.Files != 2 || one.LinkedFiles != 0 || one.Previous != "" {
+		t.Errorf("first snapshot = %v", one)
+	}
+	two, err := f.service.CreateSnapshot(ctx, &CreateSnapshotRequest{Path: "site", Name: "two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if two.Files != 2 || two.LinkedFiles != 2 || two.Previous != "one" {
+		t.Errorf("second snapshot = %v, want both files linked to the first", two)
+	}
+	if _, err := f.service.CreateSnapshot(ctx, &CreateSnapshotRequest{Path: "site", Name: "two"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("duplicate name: got %v, want AlreadyExists", err)
+	}
+	if _, err := f.service.CreateSnapshot(ctx, &CreateSnapshotRequest{Path: "site", Name: "../escape"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("malformed name: got %v, want InvalidArgument", err)
+	}
+
+	// Changing the live tree must leave the snapshots alone
+	if err := f.upload("site/a.txt", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(f.base, "site", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	f.write(t, "site/c.txt", "c")
+	for _, name := range []string{"one", "two"} {
+		if data, err := os.ReadFile(filepath.Join(f.base, ".snapshots", name, "tree", "a.txt")); err != nil || string(data) != "a" {
+			t.Errorf("snapshot %s content = %q, %v", name, data, err)
+		}
+	}
+	if got, want := diff("two"), "CHANGE_MODIFIED site/a.txt,CHANGE_DELETED site/b.txt,CHANGE_ADDED site/c.txt"; got != want {
+		t.Errorf("diff = %s, want %s", got, want)
+	}
+
+	if resp, err := f.service.RestoreSnapshot(ctx, &RestoreSnapshotRequest{Name: "two", DeleteExtra: true}); err != nil || !resp.Success {
+		t.Fatalf("RestoreSnapshot: %v, %v", resp, err)
+	}
+	if got := diff("two"); got != "" {
+		t.Errorf("diff after restore = %s, want none", got)
+	}
+	if data, err := f.download("site/a.txt"); err != nil || data != "a" {
+		t.Errorf("restored content = %q, %v", data, err)
+	}
+
+	list, err := f.service.ListSnapshots(ctx, &ListSnapshotsRequest{Path: "site"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Snapshots) != 2 || list.Snapshots[0].Name != "two" {
+		t.Errorf("ListSnapshots = %v, want two then one", list.Snapshots)
+	}
+	if resp, err := f.service.DeleteSnapshot(ctx, &SnapshotRequest{Name: "one"}); err != nil || !resp.Success {
+		t.Fatalf("DeleteSnapshot: %v, %v", resp, err)
+	}
+	if _, err := f.service.DiffSnapshot(ctx, &SnapshotRequest{Name: "one"}); status.Code(err) != codes.NotFound {
+		t.Errorf("deleted snapshot: got %v, want NotFound", err)
+	}
+	if _, err := f.service.GetFileInfo(ctx, &FileRequest{Path: ".snapshots"}); status.Code(err) != codes.NotFound {
+		t.Errorf("snapshot store: got %v, want it hidden", err)
+	}
+}
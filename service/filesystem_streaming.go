@@ -1,9 +1,14 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -32,6 +37,10 @@ func (s *FilesystemService) UploadFile(stream FilesystemService_UploadFileServer
 			break
 		}
 		if err != nil {
+			// The deny list interceptor rejects chunks for hidden paths
+			if status.Code(err) == codes.NotFound {
+				return err
+			}
 			return status.Errorf(codes.Internal, "Error receiving file chunk: %v", err)
 		}
 		
@@ -44,19 +53,20 @@ func (s *FilesystemService) UploadFile(stream FilesystemService_UploadFileServer
 			
 			// Create directory structure if needed
 			dir := filepath.Dir(validPath)
-			if err := os.MkdirAll(dir, 0755); err != nil {
+			if err := s.root.MkdirAll(dir, 0755); err != nil {
 				return status.Errorf(codes.Internal, "Failed to create directory: %v", err)
 			}
 			
-			// Open file for writing
-			fileData, err = os.Create(validPath)
+			// Open file for writing once its ETag preconditions hold
+			fileData, err = s.openUpload(stream.Context(), validPath, chunk.IfMatch, chunk.IfNoneMatch)
 			if err != nil {
-				return status.Errorf(codes.Internal, "Failed to create file: %v", err)
+				return err
 			}
 			
 			currentPath = validPath
-		} else if chunk.FilePath != "" && currentPath != chunk.FilePath {
-			// Path changed mid-stream - this is not allowed
+		} else if chunk.FilePath != "" && cleanRel(chunk.FilePath) != currentPath {
+			// Path changed mid-stream - this is not allowed; "/site" and
+			// "./site" name the same file as "site"
 			return status.Errorf(codes.InvalidArgument, "File path cannot change during upload")
 		}
 		
@@ -75,7 +85,11 @@ func (s *FilesystemService) UploadFile(stream FilesystemService_UploadFileServer
 	}
 	
 	// Close the file to ensure all data is written
+	var etag string
 	if fileData != nil {
+		if info, err := fileData.Stat(); err == nil {
+			etag = etagOf(info)
+		}
 		fileData.Close()
 		fileData = nil
 	}
@@ -84,42 +98,111 @@ func (s *FilesystemService) UploadFile(stream FilesystemService_UploadFileServer
 	return stream.SendAndClose(&OperationResponse{
 		Success: true,
 		Message: "File uploaded successfully",
+		Etag:    etag,
 	})
 }
 
+// openUpload opens the destination of an upload and truncates it once the
+// ETag preconditions hold. The check and the truncation happen on the open
+// descriptor under the same exclusive flock that in-place edits take. A
+// file with other hard links is replaced rather than truncated.
+func (s *FilesystemService) openUpload(ctx context.Context, validPath, ifMatch, ifNoneMatch string) (*os.File, error) {
+	flag := os.O_CREATE
+	switch {
+	case ifMatch != "":
+		// Only an existing file can match
+		flag = 0
+	case strings.TrimSpace(ifNoneMatch) == "*":
+		// Create-only uploads are atomic with O_EXCL
+		flag |= os.O_EXCL
+	}
+
+	file, _, err := s.root.openWritable(validPath, flag, 0666)
+	if err != nil {
+		switch {
+		case errors.Is(err, unix.EEXIST):
+			return nil, status.Errorf(codes.FailedPrecondition, "Precondition failed: file already exists")
+		case ifMatch != "" && os.IsNotExist(err):
+			return nil, checkETag(ifMatch, "", nil)
+		}
+		return nil, rootError(err, "Failed to create file")
+	}
+
+	err = s.lockForEdit(ctx, file)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = file.Stat(); err == nil {
+			if err = checkETag(ifMatch, ifNoneMatch, info); err == nil {
+				if err = s.preserveOpen(validPath, "upload", file, info); err == nil {
+					// The content is replaced, so a hard link is broken without copying it
+					if file, info, err = s.breakHardLink(ctx, validPath, file, info, flag, false); err == nil {
+						err = file.Truncate(0)
+					}
+				}
+			}
+		}
+	}
+	if err != nil {
+		file.Close()
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to prepare file: %v", err)
+	}
+	return file, nil
+}
+
 // DownloadFile implements the DownloadFile RPC method (streaming to client)
 func (s *FilesystemService) DownloadFile(req *FileRequest, stream FilesystemService_DownloadFileServer) error {
 	validPath, err := s.validatePath(req.Path)
 	if err != nil {
 		return err
 	}
+
+	// A saved version is sent with the ETag the file had back then
+	if req.VersionId != "" {
+		version, meta, err := s.openVersion(validPath, req.VersionId)
+		if err != nil {
+			return err
+		}
+		defer version.Close()
+		return sendFile(stream, validPath, version, meta.Etag)
+	}
 	
 	// Check if file exists and is not a directory
-	info, err := os.Stat(validPath)
+	info, err := s.root.Stat(validPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return status.Errorf(codes.NotFound, "File does not exist")
 		}
-		return status.Errorf(codes.Internal, "Failed to access file: %v", err)
+		return rootError(err, "Failed to access file")
 	}
 	
 	if info.IsDir() {
 		return status.Errorf(codes.InvalidArgument, "Path is a directory, not a file")
 	}
 	
+	// Reading a FIFO, socket or device would block or never end
+	if !info.Mode().IsRegular() {
+		return status.Errorf(codes.FailedPrecondition, "Path is a %s, not a regular file", fileTypeName(info.Mode()))
+	}
+	
 	// Open the file
-	file, err := os.Open(validPath)
+	// Opened without blocking, in case a FIFO was swapped in after the check
+	file, openInfo, err := s.root.openRegular(validPath)
 	if err != nil {
-		return status.Errorf(codes.Internal, "Failed to open file: %v", err)
+		return rootError(err, "Failed to open file")
 	}
 	defer file.Close()
 	
-	// Get relative path for client
-	relPath, err := filepath.Rel(s.BaseDir, validPath)
-	if err != nil {
-		relPath = req.Path
-	}
+	// The ETag describes the file that is actually read
+	etag := etagOf(openInfo)
 	
+	return sendFile(stream, validPath, file, etag)
+}
+
+// sendFile streams the content of an open file in chunks that all carry its ETag
+func sendFile(stream FilesystemService_DownloadFileServer, validPath string, file *os.File, etag string) error {
 	// Send file in chunks
 	buffer := make([]byte, 64*1024) // 64KB chunks
 	offset := int64(0)
@@ -127,13 +210,14 @@ func (s *FilesystemService) DownloadFile(req *FileRequest, stream FilesystemServ
 	for {
 		n, err := file.Read(buffer)
 		if err == io.EOF {
-			// End of file, send last chunk
-			if n > 0 {
+			// End of file, send last chunk; an empty file still gets one for its ETag
+			if n > 0 || offset == 0 {
 				chunk := &FileChunk{
-					FilePath: relPath,
+					FilePath: validPath,
 					Content:  buffer[:n],
 					Offset:   offset,
 					IsLast:   true,
+					Etag:     etag,
 				}
 				
 				if err := stream.Send(chunk); err != nil {
@@ -149,10 +233,11 @@ func (s *FilesystemService) DownloadFile(req *FileRequest, stream FilesystemServ
 		
 		// Send chunk to client
 		chunk := &FileChunk{
-			FilePath: relPath,
+			FilePath: validPath,
 			Content:  buffer[:n],
 			Offset:   offset,
 			IsLast:   false,
+			Etag:     etag,
 		}
 		
 		if err := stream.Send(chunk); err != nil {
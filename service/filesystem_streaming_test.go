@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Every chunk may name the file, in any spelling of the same path
+func TestUploadChunks(t *testing.T) {
+	f := newTestFixture(t)
+
+	for _, path := range []string{"site/index.html", "/site/index.html", "./site//index.html"} {
+		upload := &uploadStream{chunks: []*FileChunk{
+			{FilePath: path, Content: []byte("<html>")},
+			{FilePath: path, Content: []byte("</html>")},
+			{Content: []byte("\n"), IsLast: true},
+		}}
+		if err := f.service.UploadFile(upload); err != nil || !upload.response.Success {
+			t.Fatalf("upload to %q: %v, %v", path, upload.response, err)
+		}
+		if data := f.read(t, "site/index.html"); data != "<html></html>\n" {
+			t.Errorf("upload to %q: content = %q", path, data)
+		}
+	}
+
+	f.write(t, "other.txt", "unchanged")
+	upload := &uploadStream{chunks: []*FileChunk{
+		{FilePath: "site/index.html", Content: []byte("a")},
+		{FilePath: "other.txt", Content: []byte("b"), IsLast: true},
+	}}
+	if err := f.service.UploadFile(upload); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("path change mid-upload: got %v, want InvalidArgument", err)
+	}
+	if data := f.read(t, "other.txt"); data != "unchanged" {
+		t.Errorf("other file content = %q", data)
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetSymlinkPolicy configures which symlinks are followed for reads,
+// listings, copies and deletes: "follow", "follow-within-root" (the default)
+// or "never". Recursive walks never descend into symlinked directories, and
+// deleting or moving a symlink always acts on the link itself.
+func (s *FilesystemService) SetSymlinkPolicy(policy string) error {
+	switch p := SymlinkPolicy(policy); p {
+	case SymlinkFollow, SymlinkFollowWithinRoot, SymlinkNever:
+		s.root.policy = p
+		return nil
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unknown symlink policy %q", policy)
+	}
+}
+
+// CreateSymlink implements the CreateSymlink RPC method
+func (s *FilesystemService) CreateSymlink(ctx context.Context, req *SymlinkRequest) (*OperationResponse, error) {
+	if req.Target == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Target is required")
+	}
+
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Links may only point where the policy would follow them
+	targetRel, inside := s.linkTargetRel(validPath, req.Target)
+	switch s.root.policy {
+	case SymlinkNever:
+		return nil, status.Errorf(codes.PermissionDenied, "Symlinks are disabled by the symlink policy")
+	case SymlinkFollowWithinRoot:
+		// Absolute targets are never resolved beneath the base directory
+		if filepath.IsAbs(req.Target) || !inside {
+			return nil, status.Errorf(codes.PermissionDenied, "Symlink target is outside allowed directory")
+		}
+	}
+
+	// A link must not expose a path on the deny list
+	if inside && s.isDenied(filepath.ToSlash(targetRel)) {
+		return nil, errDenied()
+	}
+
+	// Check if something already exists at the link location
+	if info, err := s.root.Lstat(validPath); err == nil {
+		if !req.Overwrite {
+			return &OperationResponse{
+				Success: false,
+				Error:   "Path already exists and overwrite is not enabled",
+			}, nil
+		}
+		if info.IsDir() {
+			return &OperationResponse{
+				Success: false,
+				Error:   "Cannot replace a directory with a symlink",
+			}, nil
+		}
+		if err := s.root.Remove(validPath); err != nil {
+			return &OperationResponse{
+				Success: false,
+				Error:   "Failed to replace existing file: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// Create parent directory if it doesn't exist
+	if err := s.root.MkdirAll(filepath.Dir(validPath), 0755); err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to create parent directory: " + err.Error(),
+		}, nil
+	}
+
+	if err := s.root.Symlink(req.Target, validPath); err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to create symlink: " + err.Error(),
+		}, nil
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: "Symlink created successfully",
+	}, nil
+}
+
+// linkTargetRel lexically resolves the target of a link at linkPath. It
+// returns the target relative to the base directory and whether it stays
+// inside; symlinks within the target are not taken into account.
+func (s *FilesystemService) linkTargetRel(linkPath, target string) (string, bool) {
+	var rel string
+	if filepath.IsAbs(target) {
+		var err error
+		rel, err = filepath.Rel(s.BaseDir, filepath.Clean(target))
+		if err != nil {
+			return "", false
+		}
+	} else {
+		rel = filepath.Join(filepath.Dir(linkPath), target)
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// fileItem converts an entry returned by Lstat to a FileItem, describing
+// what a symlink points to when the policy follows it
+func (s *FilesystemService) fileItem(relPath string, info os.FileInfo) *FileItem {
+	resolved, target := s.root.resolveLink(relPath, info)
+	item := fileItemToProto(filepath.Dir(relPath), resolved)
+	if info.Mode()&os.ModeSymlink != 0 {
+		item.IsSymlink = true
+		item.LinkTarget = target
+	}
+	return item
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileTimes holds the timestamps reported in FileInfo
+type fileTimes struct {
+	modified time.Time
+	access   time.Time
+	change   time.Time
+	birth    time.Time // Zero when the filesystem does not record it
+}
+
+// fileTimesOf extracts the timestamps stat provides; birth time needs statx
+func fileTimesOf(info os.FileInfo) fileTimes {
+	times := fileTimes{modified: info.ModTime()}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		times.access = time.Unix(stat.Atim.Sec, stat.Atim.Nsec)
+		times.change = time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec)
+	}
+	return times
+}
+
+// unixSeconds and unixNanos report a zero time as 0 rather than a negative value
+func unixSeconds(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func unixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// birthTime returns when the file described by info was created, if the
+// kernel (Linux 4.11+) and filesystem report it through statx. info is the
+// result of Lstat or Stat on name; a final symlink is followed only in the
+// latter case, and the answer is discarded unless it is for the same inode.
+func (r *rootFS) birthTime(name string, info os.FileInfo) (time.Time, bool) {
+	name = cleanRel(name)
+
+	var stx unix.Statx_t
+	const mask = unix.STATX_INO | unix.STATX_BTIME
+	if name == "." {
+		if unix.Statx(int(r.dir.Fd()), "", unix.AT_EMPTY_PATH|unix.AT_STATX_SYNC_AS_STAT, mask, &stx) != nil {
+			return time.Time{}, false
+		}
+	} else {
+		dir, base, err := r.openParent(name)
+		if err != nil {
+			return time.Time{}, false
+		}
+		defer dir.Close()
+
+		flags := unix.AT_STATX_SYNC_AS_STAT
+		if info.Mode()&os.ModeSymlink != 0 {
+			flags |= unix.AT_SYMLINK_NOFOLLOW
+		}
+		if unix.Statx(int(dir.Fd()), base, flags, mask, &stx) != nil {
+			return time.Time{}, false
+		}
+	}
+
+	// The link may have been replaced since it was resolved within the policy
+	stat := statFieldsOf(info)
+	if stx.Mask&unix.STATX_BTIME == 0 || stx.Ino != stat.inode || unix.Mkdev(stx.Dev_major, stx.Dev_minor) != stat.device {
+		return time.Time{}, false
+	}
+	return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec)), true
+}
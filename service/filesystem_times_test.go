@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Under follow-within-root the birth time of a link describes its target
+func TestBirthTime(t *testing.T) {
+	forEachResolver(t, func(t *testing.T, f *securityFixture) {
+		symlink(t, "public.txt", filepath.Join(f.base, "link"))
+
+		target, err := f.service.GetFileInfo(context.Background(), &FileRequest{Path: "public.txt"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target.CreationTimeNs == 0 {
+			t.Skip("the filesystem does not report birth times")
+		}
+
+		link, err := f.service.GetFileInfo(context.Background(), &FileRequest{Path: "link"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.CreationTimeNs != target.CreationTimeNs || link.ModifiedTimeNs != target.ModifiedTimeNs {
+			t.Errorf("link times = %d/%d, want the target's %d/%d", link.CreationTimeNs, link.ModifiedTimeNs, target.CreationTimeNs, target.ModifiedTimeNs)
+		}
+		if target.CreationTime != target.CreationTimeNs/int64(time.Second) {
+			t.Errorf("creation_time %d does not match creation_time_ns %d", target.CreationTime, target.CreationTimeNs)
+		}
+	})
+}
@@ -19,17 +19,76 @@ type (
 	MoveRequest            = proto.MoveRequest
 	PathRequest            = proto.PathRequest
 	SearchRequest          = proto.SearchRequest
+	GrepRequest            = proto.GrepRequest
+	SetPermissionsRequest  = proto.SetPermissionsRequest
+	SetOwnerRequest        = proto.SetOwnerRequest
+	SetTimesRequest        = proto.SetTimesRequest
+	XattrRequest           = proto.XattrRequest
+	SetXattrRequest        = proto.SetXattrRequest
+	AclRequest             = proto.AclRequest
+	SetAclRequest          = proto.SetAclRequest
+	WriteRequest           = proto.WriteRequest
+	AppendRequest          = proto.AppendRequest
+	TruncateRequest        = proto.TruncateRequest
+	SymlinkRequest         = proto.SymlinkRequest
+	LockRequest            = proto.LockRequest
+	RenewLockRequest       = proto.RenewLockRequest
+	LeaseRequest           = proto.LeaseRequest
+	RestoreVersionRequest  = proto.RestoreVersionRequest
+	CreateSnapshotRequest  = proto.CreateSnapshotRequest
+	ListSnapshotsRequest   = proto.ListSnapshotsRequest
+	SnapshotRequest        = proto.SnapshotRequest
+	RestoreSnapshotRequest = proto.RestoreSnapshotRequest
+	CompareRequest         = proto.CompareRequest
+	DiffFilesRequest       = proto.DiffFilesRequest
+	ApplyPatchRequest      = proto.ApplyPatchRequest
+	SignatureRequest       = proto.SignatureRequest
+	SignatureChunk         = proto.SignatureChunk
+	DeltaChunk             = proto.DeltaChunk
+	ManifestRequest        = proto.ManifestRequest
+	VerifyManifestRequest  = proto.VerifyManifestRequest
+	ChecksumRequest        = proto.ChecksumRequest
+	DuplicatesRequest      = proto.DuplicatesRequest
+	DeduplicateRequest     = proto.DeduplicateRequest
+	DiskUsageRequest       = proto.DiskUsageRequest
 
 	// Service response types
-	ListResponse      = proto.ListResponse
-	FileInfo          = proto.FileInfo
-	FileItem          = proto.FileItem
-	OperationResponse = proto.OperationResponse
-	ExistsResponse    = proto.ExistsResponse
-	SizeResponse      = proto.SizeResponse
-	FileChunk         = proto.FileChunk
+	ListResponse          = proto.ListResponse
+	FileInfo              = proto.FileInfo
+	FileItem              = proto.FileItem
+	OperationResponse     = proto.OperationResponse
+	ExistsResponse        = proto.ExistsResponse
+	SizeResponse          = proto.SizeResponse
+	FileChunk             = proto.FileChunk
+	GrepMatch             = proto.GrepMatch
+	XattrResponse         = proto.XattrResponse
+	AclResponse           = proto.AclResponse
+	WriteResponse         = proto.WriteResponse
+	ListLocksResponse     = proto.ListLocksResponse
+	ListVersionsResponse  = proto.ListVersionsResponse
+	ListSnapshotsResponse = proto.ListSnapshotsResponse
+	DiffResponse          = proto.DiffResponse
+	DiffFilesResponse     = proto.DiffFilesResponse
+	ManifestEntry         = proto.ManifestEntry
+	ChecksumResponse      = proto.ChecksumResponse
+	DuplicateGroup        = proto.DuplicateGroup
+	DeduplicateResponse   = proto.DeduplicateResponse
+	DiskUsageResponse     = proto.DiskUsageResponse
+	DiskUsageEntry        = proto.DiskUsageEntry
+	DiskUsageGroup        = proto.DiskUsageGroup
 
 	// Streaming service interfaces
 	FilesystemService_UploadFileServer   = proto.FilesystemService_UploadFileServer
 	FilesystemService_DownloadFileServer = proto.FilesystemService_DownloadFileServer
+	FilesystemService_GrepFilesServer    = proto.FilesystemService_GrepFilesServer
+	FilesystemService_SearchStreamServer = proto.FilesystemService_SearchStreamServer
+
+	FilesystemService_ListDirectoryStreamServer = proto.FilesystemService_ListDirectoryStreamServer
+	FilesystemService_CompareDirectoriesServer  = proto.FilesystemService_CompareDirectoriesServer
+	FilesystemService_GetSignatureServer        = proto.FilesystemService_GetSignatureServer
+	FilesystemService_ApplyDeltaServer          = proto.FilesystemService_ApplyDeltaServer
+	FilesystemService_GetDeltaServer            = proto.FilesystemService_GetDeltaServer
+	FilesystemService_GenerateManifestServer    = proto.FilesystemService_GenerateManifestServer
+	FilesystemService_VerifyManifestServer      = proto.FilesystemService_VerifyManifestServer
+	FilesystemService_FindDuplicatesServer      = proto.FilesystemService_FindDuplicatesServer
 )
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultUsageTop and maxUsageTop bound the largest entries GetDiskUsage reports
+	defaultUsageTop = 10
+	maxUsageTop     = 1000
+
+	// maxUsageEntries bounds the breakdown and each grouping of GetDiskUsage
+	maxUsageEntries = 10000
+)
+
+// usageTotals sums the usage of a set of files
+type usageTotals struct {
+	diskUsage    int64
+	apparentSize int64
+	files        int64
+	directories  int64
+}
+
+func (u *usageTotals) add(other usageTotals) {
+	u.diskUsage += other.diskUsage
+	u.apparentSize += other.apparentSize
+	u.files += other.files
+	u.directories += other.directories
+}
+
+func (u *usageTotals) entry(slashPath string, isDir bool) *DiskUsageEntry {
+	return &DiskUsageEntry{
+		Path:         slashPath,
+		IsDirectory:  isDir,
+		DiskUsage:    u.diskUsage,
+		ApparentSize: u.apparentSize,
+		Files:        u.files,
+		Directories:  u.directories,
+	}
+}
+
+func (u *usageTotals) group(name string) *DiskUsageGroup {
+	return &DiskUsageGroup{
+		Name:         name,
+		DiskUsage:    u.diskUsage,
+		ApparentSize: u.apparentSize,
+		Files:        u.files,
+	}
+}
+
+// largestEntries keeps the n entries with the most disk usage, largest first
+type largestEntries struct {
+	n       int
+	entries []*DiskUsageEntry
+}
+
+func (l *largestEntries) add(entry *DiskUsageEntry) {
+	if len(l.entries) == l.n && entry.DiskUsage <= l.entries[l.n-1].DiskUsage {
+		return
+	}
+	i := sort.Search(len(l.entries), func(i int) bool { return l.entries[i].DiskUsage < entry.DiskUsage })
+	if len(l.entries) < l.n {
+		l.entries = append(l.entries, nil)
+	}
+	copy(l.entries[i+1:], l.entries[i:])
+	l.entries[i] = entry
+}
+
+// GetDiskUsage implements the GetDiskUsage RPC method. Usage is counted in
+// allocated blocks like du, so sparse files count for less than their size;
+// a file with several hard links below the path is counted at the first
+// link found.
+func (s *FilesystemService) GetDiskUsage(ctx context.Context, req *DiskUsageRequest) (*DiskUsageResponse, error) {
+	if req.MaxDepth < 0 || req.Top < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Depth and top must not be negative")
+	}
+	validPath, rules, err := s.manifestDir(req.Path, req.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	rootInfo, err := s.root.Lstat(validPath)
+	if err != nil {
+		return nil, rootError(err, "Failed to access path")
+	}
+	top := int(req.Top)
+	if top == 0 {
+		top = defaultUsageTop
+	}
+	top = min(top, maxUsageTop)
+
+	type inodeKey struct{ device, inode uint64 }
+	seen := make(map[inodeKey]bool)
+	dirs := map[string]*usageTotals{".": {}}
+	var files []*DiskUsageEntry // Files within the breakdown's depth
+	largestFiles := &largestEntries{n: top}
+	byExtension := make(map[string]*usageTotals)
+	byMimeType := make(map[string]*usageTotals)
+
+	err = s.walkTree(validPath, rules, func(rel string, entry *treeEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info := entry.info
+		stat := statFieldsOf(info)
+
+		var own usageTotals
+		if stat.linkCount < 2 || info.IsDir() || !seen[inodeKey{stat.device, stat.inode}] {
+			own.diskUsage = stat.blocks * 512
+			if !info.IsDir() {
+				own.apparentSize = info.Size()
+			}
+			if stat.linkCount > 1 && !info.IsDir() {
+				seen[inodeKey{stat.device, stat.inode}] = true
+			}
+		}
+		if info.IsDir() {
+			dirs[rel] = &usageTotals{}
+			own.directories = 1
+		} else {
+			own.files = 1
+		}
+
+		// Every directory above the entry includes it
+		for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+			dirs[dir].add(own)
+			if dir == "." {
+				break
+			}
+		}
+		if info.IsDir() {
+			dirs[rel].add(usageTotals{diskUsage: own.diskUsage})
+			return nil
+		}
+
+		slashPath := filepath.ToSlash(entry.path)
+		fileEntry := own.entry(slashPath, false)
+		largestFiles.add(fileEntry)
+		if usageDepth(rel) <= int(req.MaxDepth) {
+			files = append(files, fileEntry)
+		}
+
+		extension := usageExtension(path.Base(rel))
+		if byExtension[extension] == nil {
+			byExtension[extension] = &usageTotals{}
+		}
+		byExtension[extension].add(own)
+		if req.ByMimeType && info.Mode().IsRegular() {
+			mimeType := usageMimeType(s.root, entry.path)
+			if byMimeType[mimeType] == nil {
+				byMimeType[mimeType] = &usageTotals{}
+			}
+			byMimeType[mimeType].add(own)
+		}
+		return nil
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, status.FromContextError(ctxErr).Err()
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to calculate disk usage: %v", err)
+	}
+
+	rootSlash := filepath.ToSlash(validPath)
+	total := dirs["."]
+	total.diskUsage += statFieldsOf(rootInfo).blocks * 512
+	response := &DiskUsageResponse{
+		Total:              total.entry(rootSlash, true),
+		Breakdown:          files,
+		LargestFiles:       largestFiles.entries,
+		LargestDirectories: []*DiskUsageEntry{},
+	}
+
+	largestDirs := &largestEntries{n: top}
+	for rel, totals := range dirs {
+		if rel == "." {
+			continue
+		}
+		dirEntry := totals.entry(path.Join(rootSlash, rel), true)
+		largestDirs.add(dirEntry)
+		if usageDepth(rel) <= int(req.MaxDepth) {
+			response.Breakdown = append(response.Breakdown, dirEntry)
+		}
+	}
+	response.LargestDirectories = largestDirs.entries
+	if response.LargestFiles == nil {
+		response.LargestFiles = []*DiskUsageEntry{}
+	}
+
+	sortUsageEntries(response.Breakdown)
+	if len(response.Breakdown) > maxUsageEntries {
+		response.Breakdown = response.Breakdown[:maxUsageEntries]
+		response.Truncated = true
+	}
+	if response.Breakdown == nil {
+		response.Breakdown = []*DiskUsageEntry{}
+	}
+
+	var truncated bool
+	response.ByExtension, truncated = usageGroups(byExtension)
+	response.Truncated = response.Truncated || truncated
+	response.ByMimeType, truncated = usageGroups(byMimeType)
+	response.Truncated = response.Truncated || truncated
+	return response, nil
+}
+
+// usageDepth is the number of path elements of a path relative to the analyzed directory
+func usageDepth(rel string) int {
+	return strings.Count(rel, "/") + 1
+}
+
+// usageExtension is the lower case extension of a file name. Names that
+// only start with a dot, like .bashrc, have none.
+func usageExtension(name string) string {
+	extension := path.Ext(name)
+	if extension == name {
+		return ""
+	}
+	return strings.ToLower(extension)
+}
+
+// usageMimeType sniffs the MIME type of a regular file, without parameters
+func usageMimeType(root *rootFS, relPath string) string {
+	file, _, err := root.openRegular(relPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	mimeType, err := detectMimeType(file)
+	if err != nil {
+		return ""
+	}
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = strings.TrimSpace(mimeType[:i])
+	}
+	return mimeType
+}
+
+// sortUsageEntries orders entries by disk usage, largest first, then by path
+func sortUsageEntries(entries []*DiskUsageEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].DiskUsage != entries[j].DiskUsage {
+			return entries[i].DiskUsage > entries[j].DiskUsage
+		}
+		return entries[i].Path < entries[j].Path
+	})
+}
+
+// usageGroups lists totals by name, largest first, and reports whether the
+// list was cut short
+func usageGroups(totals map[string]*usageTotals) ([]*DiskUsageGroup, bool) {
+	groups := make([]*DiskUsageGroup, 0, len(totals))
+	for name, total := range totals {
+		groups = append(groups, total.group(name))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].DiskUsage != groups[j].DiskUsage {
+			return groups[i].DiskUsage > groups[j].DiskUsage
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	if len(groups) > maxUsageEntries {
+		return groups[:maxUsageEntries], true
+	}
+	return groups, false
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDiskUsage(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	f.deny(t, ".env")
+	data := filepath.Join(f.base, "data")
+	writeTestFile(t, filepath.Join(data, "photos", "a.JPG"), strings.Repeat("a", 10000))
+	writeTestFile(t, filepath.Join(data, "photos", "2024", "b.jpg"), strings.Repeat("b", 20000))
+	writeTestFile(t, filepath.Join(data, "notes.txt"), "hello world\n")
+	writeTestFile(t, filepath.Join(data, ".bashrc"), "export A=1\n")
+	writeTestFile(t, filepath.Join(data, ".env"), strings.Repeat("s", 50000))
+	if err := os.Link(filepath.Join(data, "photos", "a.JPG"), filepath.Join(data, "a-link.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	sparse, err := os.Create(filepath.Join(data, "sparse.img"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sparse.Truncate(1 << 30); err != nil {
+		t.Fatal(err)
+	}
+	sparse.Close()
+
+	blocks := func(name string) int64 {
+		t.Helper()
+		info, err := os.Lstat(filepath.Join(data, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return statFieldsOf(info).blocks * 512
+	}
+
+	response, err := f.service.GetDiskUsage(ctx, &DiskUsageRequest{Path: "data", MaxDepth: 1, Top: 2, ByMimeType: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := response.Total
+	wantUsage := blocks(".") + blocks("photos") + blocks("photos/2024") + blocks("photos/a.JPG") + blocks("photos/2024/b.jpg") + blocks("notes.txt") + blocks(".bashrc") + blocks("sparse.img")
+	if total.Path != "data" || total.DiskUsage != wantUsage || total.Files != 6 || total.Directories != 2 {
+		t.Errorf("total = %v, want disk usage %d, 6 files, 2 directories", total, wantUsage)
+	}
+	if want := int64(10000 + 20000 + 12 + 11 + 1<<30); total.ApparentSize != want {
+		t.Errorf("apparent size = %d, want %d with the hard link counted once", total.ApparentSize, want)
+	}
+
+	var breakdown []string
+	for _, entry := range response.Breakdown {
+		breakdown = append(breakdown, entry.Path)
+		// a.JPG is counted at a-link.jpg, which comes first
+		if entry.Path == "data/photos" && (entry.Files != 2 || entry.Directories != 1 || entry.ApparentSize != 20000) {
+			t.Errorf("photos = %v", entry)
+		}
+		if entry.Path == "data/.env" {
+			t.Error("breakdown includes a denied file")
+		}
+	}
+	sort.Strings(breakdown)
+	if got, want := strings.Join(breakdown, ","), "data/.bashrc,data/a-link.jpg,data/notes.txt,data/photos,data/sparse.img"; got != want {
+		t.Errorf("breakdown = %s, want %s", got, want)
+	}
+	for i := 1; i < len(response.Breakdown); i++ {
+		if response.Breakdown[i].DiskUsage > response.Breakdown[i-1].DiskUsage {
+			t.Errorf("breakdown is not sorted by disk usage: %v", response.Breakdown)
+		}
+	}
+	if len(response.LargestFiles) != 2 || response.LargestFiles[0].Path != "data/photos/2024/b.jpg" {
+		t.Errorf("largest files = %v", response.LargestFiles)
+	}
+	if len(response.LargestDirectories) != 2 || response.LargestDirectories[0].Path != "data/photos" {
+		t.Errorf("largest directories = %v", response.LargestDirectories)
+	}
+
+	extensions := make(map[string]int64)
+	for _, group := range response.ByExtension {
+		extensions[group.Name] = group.Files
+	}
+	if extensions[".jpg"] != 3 || extensions[".txt"] != 1 || extensions[""] != 1 || extensions[".img"] != 1 {
+		t.Errorf("extensions = %v", extensions)
+	}
+	mimeTypes := make(map[string]int64)
+	for _, group := range response.ByMimeType {
+		mimeTypes[group.Name] = group.ApparentSize
+	}
+	if mimeTypes["text/plain"] != 10000+20000+12+11 {
+		t.Errorf("MIME types = %v", mimeTypes)
+	}
+
+	// Depth 0 reports the total only
+	response, err = f.service.GetDiskUsage(ctx, &DiskUsageRequest{Path: "data", Exclude: []string{"*.img"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Breakdown) != 0 || len(response.ByMimeType) != 0 || response.Total.Files != 5 {
+		t.Errorf("depth 0 = %v", response)
+	}
+
+	if _, err := f.service.GetDiskUsage(ctx, &DiskUsageRequest{Path: "data/notes.txt"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("disk usage of a file: got %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,669 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// versionIDLayout formats the save time of a version as its ID
+const versionIDLayout = "20060102T150405.000000000Z"
+
+// versionSweepInterval is how often versions of every file are checked against the retention policy
+const versionSweepInterval = time.Hour
+
+// versionStore keeps the earlier content of files below versioned
+// directories. Every file has a directory in the store named after a hash of
+// its path, holding each version as a data file and a JSON metadata file.
+type versionStore struct {
+	root    *rootFS       // Store directory, outside the base directory
+	roots   []string      // Slash separated directories whose files are versioned
+	keep    int           // Versions kept per file, 0 for no limit
+	keepFor time.Duration // Age at which versions are removed, 0 for no limit
+	mu      sync.Mutex    // Serializes saving and pruning
+	stop    chan struct{}
+}
+
+// versionMeta is the metadata file of a version
+type versionMeta struct {
+	Path           string      `json:"path"`
+	Reason         string      `json:"reason"`
+	Size           int64       `json:"size"`
+	Mode           os.FileMode `json:"mode"`
+	ModifiedTimeNs int64       `json:"modified_time_ns"`
+	CreatedTimeNs  int64       `json:"created_time_ns"`
+	Etag           string      `json:"etag"`
+
+	id string
+}
+
+// SetVersioning keeps the previous content of files below roots whenever an
+// upload, copy, move or delete replaces them. Versions are stored in dir, an
+// absolute path outside the base directory, so that neither clients nor
+// anything else serving the base directory can reach them; on the same file
+// system, versions of replaced files are hard links rather than copies. keep
+// limits the versions per file and keepDays their age; 0 disables either limit.
+func (s *FilesystemService) SetVersioning(roots []string, dir string, keep, keepDays int) error {
+	if keep < 0 || keepDays < 0 {
+		return fmt.Errorf("retention limits must not be negative")
+	}
+
+	v := &versionStore{
+		keep:    keep,
+		keepFor: time.Duration(keepDays) * 24 * time.Hour,
+		stop:    make(chan struct{}),
+	}
+	for _, root := range roots {
+		if root = strings.TrimSpace(root); root != "" {
+			v.roots = append(v.roots, filepath.ToSlash(cleanRel(root)))
+		}
+	}
+	if len(v.roots) == 0 {
+		return fmt.Errorf("no versioned directories given")
+	}
+
+	root, err := s.openVersionStore(dir)
+	if err != nil {
+		return err
+	}
+	v.root = root
+
+	// Replace an earlier store along with its sweeper
+	if s.versions != nil {
+		s.versions.close()
+	}
+	s.versions = v
+
+	go v.sweepVersions()
+	return nil
+}
+
+// openVersionStore creates and opens a version store directory, refusing one
+// inside the base directory
+func (s *FilesystemService) openVersionStore(dir string) (*rootFS, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, fmt.Errorf("version store %q must be an absolute path outside the base directory", dir)
+	}
+	base, err := filepath.EvalSymlinks(s.root.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve base directory: %w", err)
+	}
+
+	// Compare resolved paths, so a symlink can not lead the store into the
+	// base directory; check before creating it, and again once it exists
+	var resolved string
+	for _, create := range []bool{true, false} {
+		if resolved, err = resolveExisting(dir); err != nil {
+			return nil, fmt.Errorf("failed to resolve version store: %w", err)
+		}
+		if rel, err := filepath.Rel(base, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("version store %q must be outside the base directory", dir)
+		}
+		if create {
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return nil, fmt.Errorf("failed to create version store: %w", err)
+			}
+		}
+	}
+
+	root, err := openRootFS(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open version store: %w", err)
+	}
+	return root, nil
+}
+
+// resolveExisting resolves the symlinks in the longest existing prefix of an
+// absolute path and appends the rest unchanged
+func resolveExisting(path string) (string, error) {
+	rest := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) || filepath.Dir(path) == path {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = filepath.Dir(path)
+	}
+}
+
+// close stops the sweeper of the store and releases its directory
+func (v *versionStore) close() {
+	close(v.stop)
+	v.root.Close()
+}
+
+// covers reports whether files at a slash path are versioned
+func (v *versionStore) covers(slashPath string) bool {
+	for _, root := range v.roots {
+		if root == "." || slashPath == root || strings.HasPrefix(slashPath, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// fileDir returns the store directory holding the versions of a file
+func (v *versionStore) fileDir(relPath string) string {
+	sum := sha256.Sum256([]byte(slashRel(relPath)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ListVersions implements the ListVersions RPC method
+func (s *FilesystemService) ListVersions(ctx context.Context, req *PathRequest) (*ListVersionsResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	v := s.versions
+	if v == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "Versioning is not enabled")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	fileDir := v.fileDir(validPath)
+	if err := v.pruneVersions(fileDir, time.Now()); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to read versions: %v", err)
+	}
+	metas, err := v.readVersions(fileDir)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to read versions: %v", err)
+	}
+
+	response := &ListVersionsResponse{Versions: []*pb.FileVersion{}}
+	for _, meta := range metas {
+		response.Versions = append(response.Versions, meta.proto())
+	}
+	return response, nil
+}
+
+// RestoreVersion implements the RestoreVersion RPC method
+func (s *FilesystemService) RestoreVersion(ctx context.Context, req *RestoreVersionRequest) (*OperationResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPathETag(validPath, req.IfMatch, req.IfNoneMatch); err != nil {
+		return nil, err
+	}
+
+	version, meta, err := s.openVersion(validPath, req.VersionId)
+	if err != nil {
+		return nil, err
+	}
+	defer version.Close()
+
+	if err := s.root.MkdirAll(filepath.Dir(validPath), 0755); err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to create parent directory: " + err.Error(),
+		}, nil
+	}
+
+	// The content being replaced becomes a version too, so a restore can be undone
+	pending, err := s.preserveReplaced(validPath, "restore")
+	if err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to save current version: " + err.Error(),
+		}, nil
+	}
+	defer s.settleVersions(pending)
+
+	// The version replaces the file as a whole, so no reader sees it half
+	// restored and other hard links to the file keep their content
+	var current os.FileInfo
+	if info, err := s.root.Lstat(validPath); err == nil && info.Mode().IsRegular() {
+		current = info
+	}
+	if _, err := s.root.replaceFile(validPath, current, func(w io.Writer) error {
+		_, err := io.Copy(w, version)
+		return err
+	}); err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to restore version: " + err.Error(),
+		}, nil
+	}
+	if err := s.root.Chmod(validPath, syscallMode(meta.Mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky))); err != nil {
+		return &OperationResponse{
+			Success: false,
+			Error:   "Failed to restore permissions: " + err.Error(),
+		}, nil
+	}
+
+	response := &OperationResponse{
+		Success: true,
+		Message: "Version restored successfully",
+	}
+	if info, err := s.root.Lstat(validPath); err == nil {
+		response.Etag = etagOf(info)
+	}
+	return response, nil
+}
+
+// openVersion opens the content of a saved version of a file
+func (s *FilesystemService) openVersion(relPath, id string) (*os.File, versionMeta, error) {
+	v := s.versions
+	if v == nil {
+		return nil, versionMeta{}, status.Errorf(codes.FailedPrecondition, "Versioning is not enabled")
+	}
+	if created, err := time.Parse(versionIDLayout, id); err != nil || created.Format(versionIDLayout) != id {
+		return nil, versionMeta{}, status.Errorf(codes.InvalidArgument, "Invalid version ID %q", id)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	dataPath := filepath.Join(v.fileDir(relPath), id)
+	meta, err := v.readVersion(dataPath + ".json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, versionMeta{}, status.Errorf(codes.NotFound, "Version does not exist")
+		}
+		return nil, versionMeta{}, status.Errorf(codes.Internal, "Failed to read version: %v", err)
+	}
+	file, _, err := v.root.openRegular(dataPath)
+	if err != nil {
+		return nil, versionMeta{}, rootError(err, "Failed to open version")
+	}
+	return file, meta, nil
+}
+
+// preserve saves the content of the file at relPath as a version before a
+// change described by reason overwrites it in place or through another file
+func (s *FilesystemService) preserve(relPath, reason string) error {
+	v := s.versions
+	if v == nil || !v.covers(filepath.ToSlash(cleanRel(relPath))) {
+		return nil
+	}
+
+	file, info, err := s.root.openRegular(relPath)
+	if os.IsNotExist(err) || errors.Is(err, errNotRegular) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.preserveOpen(relPath, reason, file, info)
+}
+
+// pendingVersion is a version saved ahead of an operation that replaces its
+// file. Its metadata is only written once the operation is known to have
+// replaced the file, so until then it is not listed.
+type pendingVersion struct {
+	relPath  string
+	reason   string
+	info     os.FileInfo // The file about to be replaced
+	dataPath string
+	created  time.Time
+}
+
+// preserveReplaced saves the file at relPath ahead of a change described by
+// reason that unlinks or renames over the name. A hard link keeps the
+// content without copying it, and a symlink there loses nothing. The caller
+// must pass the result to settleVersions once the change is done or failed.
+func (s *FilesystemService) preserveReplaced(relPath, reason string) ([]*pendingVersion, error) {
+	v := s.versions
+	if v == nil || !v.covers(filepath.ToSlash(cleanRel(relPath))) {
+		return nil, nil
+	}
+
+	info, err := s.root.Lstat(relPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// An empty file has no content to lose
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return nil, nil
+	}
+
+	// Another name of the file could still change the content of a link
+	if statFieldsOf(info).linkCount == 1 {
+		pending, err := s.stageVersion(relPath, reason, info, func(dataPath string) error {
+			return s.root.linkTo(relPath, v.root, dataPath)
+		})
+		if err == nil {
+			return []*pendingVersion{pending}, nil
+		}
+	}
+
+	file, info, err := s.root.openRegular(relPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	pending, err := s.stageVersion(relPath, reason, info, copyFileTo(v.root, file))
+	if err != nil {
+		return nil, err
+	}
+	return []*pendingVersion{pending}, nil
+}
+
+// preserveTree saves every file below a directory that is about to be
+// removed, like preserveReplaced
+func (s *FilesystemService) preserveTree(relPath, reason string) ([]*pendingVersion, error) {
+	if s.versions == nil {
+		return nil, nil
+	}
+	var pending []*pendingVersion
+	err := s.root.WalkDir(relPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		saved, err := s.preserveReplaced(path, reason)
+		pending = append(pending, saved...)
+		return err
+	})
+	if err != nil {
+		s.settleVersions(pending)
+		return nil, err
+	}
+	return pending, nil
+}
+
+// settleVersions lists the pending versions whose file was replaced and
+// drops those whose file is still in place, so that a failed operation
+// leaves no version sharing its inode with a live file. The operation has
+// already happened, so a version that can not be listed is given up.
+func (s *FilesystemService) settleVersions(pending []*pendingVersion) {
+	for _, version := range pending {
+		current, err := s.root.Lstat(version.relPath)
+		if err == nil && os.SameFile(current, version.info) {
+			s.versions.root.Remove(version.dataPath)
+			continue
+		}
+		if err := s.commitVersion(version); err != nil {
+			s.versions.root.Remove(version.dataPath)
+		}
+	}
+}
+
+// preserveOpen saves the content of an open file that is about to be changed in place
+func (s *FilesystemService) preserveOpen(relPath, reason string, file *os.File, info os.FileInfo) error {
+	v := s.versions
+	if v == nil || !v.covers(filepath.ToSlash(cleanRel(relPath))) || info.Size() == 0 {
+		return nil
+	}
+
+	// Reopen the same inode for reading; file may be write-only, and its
+	// offset stays untouched for the caller
+	src, err := os.Open(procPath(file))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return s.saveVersion(relPath, reason, info, copyFileTo(v.root, src))
+}
+
+// copyFileTo returns a version data writer copying the content of src
+func copyFileTo(root *rootFS, src io.Reader) func(dataPath string) error {
+	return func(dataPath string) error {
+		dst, err := root.OpenFile(dataPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			return err
+		}
+		return dst.Close()
+	}
+}
+
+// saveVersion adds a version of relPath whose data write puts in place, then
+// applies the retention policy to the versions of the file
+func (s *FilesystemService) saveVersion(relPath, reason string, info os.FileInfo, write func(dataPath string) error) error {
+	pending, err := s.stageVersion(relPath, reason, info, write)
+	if err != nil {
+		return err
+	}
+	if err := s.commitVersion(pending); err != nil {
+		s.versions.root.Remove(pending.dataPath)
+		return err
+	}
+	return nil
+}
+
+// stageVersion puts the data of a version of relPath in the store with
+// write. The version is not listed until commitVersion writes its metadata.
+func (s *FilesystemService) stageVersion(relPath, reason string, info os.FileInfo, write func(dataPath string) error) (*pendingVersion, error) {
+	v := s.versions
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	fileDir := v.fileDir(relPath)
+	if err := v.root.MkdirAll(fileDir, 0700); err != nil {
+		return nil, err
+	}
+
+	// Saves are serialized, so only a coarse clock can repeat an ID
+	now := time.Now()
+	dataPath := filepath.Join(fileDir, now.UTC().Format(versionIDLayout))
+	for {
+		if _, err := v.root.Lstat(dataPath); os.IsNotExist(err) {
+			break
+		}
+		now = now.Add(time.Nanosecond)
+		dataPath = filepath.Join(fileDir, now.UTC().Format(versionIDLayout))
+	}
+
+	if err := write(dataPath); err != nil {
+		v.root.Remove(dataPath)
+		return nil, err
+	}
+	return &pendingVersion{relPath: relPath, reason: reason, info: info, dataPath: dataPath, created: now}, nil
+}
+
+// commitVersion writes the metadata of a staged version, then applies the
+// retention policy to the versions of the file
+func (s *FilesystemService) commitVersion(pending *pendingVersion) error {
+	v := s.versions
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	saved, err := v.root.Lstat(pending.dataPath)
+	if err != nil {
+		return err
+	}
+	info := pending.info
+	data, err := json.Marshal(versionMeta{
+		Path:           slashRel(pending.relPath),
+		Reason:         pending.reason,
+		Size:           saved.Size(),
+		Mode:           info.Mode(),
+		ModifiedTimeNs: info.ModTime().UnixNano(),
+		CreatedTimeNs:  pending.created.UnixNano(),
+		Etag:           etagOf(info),
+	})
+	if err != nil {
+		return err
+	}
+	if err := v.root.writeNew(pending.dataPath+".json", data); err != nil {
+		return err
+	}
+
+	return v.pruneVersions(filepath.Dir(pending.dataPath), pending.created)
+}
+
+// readVersions reads the metadata of the versions in a file's store directory, newest first
+func (v *versionStore) readVersions(fileDir string) ([]versionMeta, error) {
+	entries, err := v.root.ReadDir(fileDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var metas []versionMeta
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		meta, err := v.readVersion(filepath.Join(fileDir, entry.Name()))
+		if err != nil {
+			// Removed by a concurrent prune, or left half written by a crash
+			continue
+		}
+		metas = append(metas, meta)
+	}
+	sort.Slice(metas, func(i, j int) bool { return metas[i].id > metas[j].id })
+	return metas, nil
+}
+
+// readVersion reads the metadata file of a version
+func (v *versionStore) readVersion(metaPath string) (versionMeta, error) {
+	file, err := v.root.Open(metaPath)
+	if err != nil {
+		return versionMeta{}, err
+	}
+	defer file.Close()
+
+	var meta versionMeta
+	if err := json.NewDecoder(file).Decode(&meta); err != nil {
+		return versionMeta{}, err
+	}
+	meta.id = strings.TrimSuffix(filepath.Base(metaPath), ".json")
+	return meta, nil
+}
+
+// pruneVersions removes the versions of a file the retention policy no
+// longer keeps, and the file's store directory once it is empty. The caller
+// holds v.mu.
+func (v *versionStore) pruneVersions(fileDir string, now time.Time) error {
+	metas, err := v.readVersions(fileDir)
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for i, meta := range metas {
+		tooMany := v.keep > 0 && i >= v.keep
+		tooOld := v.keepFor > 0 && now.Sub(time.Unix(0, meta.CreatedTimeNs)) > v.keepFor
+		if !tooMany && !tooOld {
+			kept++
+			continue
+		}
+		dataPath := filepath.Join(fileDir, meta.id)
+		if err := v.root.Remove(dataPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := v.root.Remove(dataPath + ".json"); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if kept == 0 {
+		// Fails while a version is being saved or data without metadata is left
+		v.root.Remove(fileDir)
+	}
+	return nil
+}
+
+// sweepVersions applies the retention policy to every file in the store
+// now and then, so versions of files that stopped changing expire as well
+func (v *versionStore) sweepVersions() {
+	ticker := time.NewTicker(versionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		v.mu.Lock()
+		if entries, err := v.root.ReadDir("."); err == nil {
+			now := time.Now()
+			for _, entry := range entries {
+				if entry.IsDir() {
+					v.pruneVersions(entry.Name(), now)
+				}
+			}
+		}
+		v.mu.Unlock()
+
+		select {
+		case <-ticker.C:
+		case <-v.stop:
+			return
+		}
+	}
+}
+
+func (m versionMeta) proto() *pb.FileVersion {
+	created := time.Unix(0, m.CreatedTimeNs)
+	return &pb.FileVersion{
+		VersionId:      m.id,
+		Path:           m.Path,
+		Reason:         m.Reason,
+		Size:           m.Size,
+		Permissions:    fmt.Sprintf("%o", m.Mode.Perm()),
+		ModifiedTimeNs: m.ModifiedTimeNs,
+		CreatedTime:    created.Unix(),
+		CreatedTimeNs:  m.CreatedTimeNs,
+		Etag:           m.Etag,
+	}
+}
+
+// Link creates newName as a hard link to the file oldName, without following symlinks
+func (r *rootFS) Link(oldName, newName string) error {
+	return r.linkTo(oldName, r, newName)
+}
+
+// linkTo creates newName below another root as a hard link to the file
+// oldName, without following symlinks. Both must be on the same file system.
+func (r *rootFS) linkTo(oldName string, dst *rootFS, newName string) error {
+	oldDir, oldBase, err := r.openParent(oldName)
+	if err != nil {
+		return err
+	}
+	defer oldDir.Close()
+
+	newDir, newBase, err := dst.openParent(newName)
+	if err != nil {
+		return err
+	}
+	defer newDir.Close()
+
+	if err := unix.Linkat(int(oldDir.Fd()), oldBase, int(newDir.Fd()), newBase, 0); err != nil {
+		return &os.LinkError{Op: "linkat", Old: oldName, New: newName, Err: err}
+	}
+	return nil
+}
+
+// writeNew creates a file that must not exist yet with the given content
+func (r *rootFS) writeNew(name string, data []byte) error {
+	file, err := r.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
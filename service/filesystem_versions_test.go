@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Every replaced save stays available until the retention policy drops it
+func TestVersionHistory(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "page.txt", "v1")
+	ctx := context.Background()
+	if err := f.service.SetVersioning([]string{"."}, f.versionDir(), 2, 30); err != nil {
+		t.Fatal(err)
+	}
+
+	versions := func() (contents, reasons []string) {
+		t.Helper()
+		list, err := f.service.ListVersions(ctx, &PathRequest{Path: "page.txt"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, version := range list.Versions {
+			download := &downloadStream{}
+			if err := f.service.DownloadFile(&FileRequest{Path: "page.txt", VersionId: version.VersionId}, download); err != nil {
+				t.Fatal(err)
+			}
+			contents = append(contents, download.data.String())
+			reasons = append(reasons, version.Reason)
+		}
+		return contents, reasons
+	}
+	check := func(wantContents, wantReasons string) {
+		t.Helper()
+		contents, reasons := versions()
+		if got := strings.Join(contents, ","); got != wantContents {
+			t.Errorf("versions = %s, want %s", got, wantContents)
+		}
+		if got := strings.Join(reasons, ","); got != wantReasons {
+			t.Errorf("reasons = %s, want %s", got, wantReasons)
+		}
+	}
+
+	for _, content := range []string{"v2", "v3"} {
+		if err := f.upload("page.txt", content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check("v2,v1", "upload,upload")
+	if err := f.upload("page.txt", "v4"); err != nil {
+		t.Fatal(err)
+	}
+	check("v3,v2", "upload,upload")
+
+	if resp, err := f.service.Delete(ctx, &DeleteRequest{Path: "page.txt"}); err != nil || !resp.Success {
+		t.Fatalf("Delete: %v, %v", resp, err)
+	}
+	check("v4,v3", "delete,upload")
+
+	list, err := f.service.ListVersions(ctx, &PathRequest{Path: "page.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := f.service.RestoreVersion(ctx, &RestoreVersionRequest{Path: "page.txt", VersionId: list.Versions[1].VersionId}); err != nil || !resp.Success {
+		t.Fatalf("RestoreVersion: %v, %v", resp, err)
+	}
+	if data, err := f.download("page.txt"); err != nil || data != "v3" {
+		t.Errorf("restored content = %q, %v", data, err)
+	}
+
+	f.write(t, "draft.txt", "draft")
+	if resp, err := f.service.Move(ctx, &MoveRequest{Source: "draft.txt", Destination: "page.txt", Overwrite: true}); err != nil || !resp.Success {
+		t.Fatalf("Move: %v, %v", resp, err)
+	}
+	check("v3,v4", "move,delete")
+
+	if err := f.service.DownloadFile(&FileRequest{Path: "page.txt", VersionId: "../../secret"}, &downloadStream{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("malformed version ID: got %v, want InvalidArgument", err)
+	}
+}
+
+// The version store must be outside the served tree, and replacing it stops the old one
+func TestVersionStoreOutsideBase(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "inside/page.txt", "content")
+	if err := os.Symlink(f.path("inside"), f.path("../link")); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{".versions", f.path(".versions"), f.base, f.path("../link"), f.path("../link/store")} {
+		if err := f.service.SetVersioning([]string{"."}, dir, 0, 0); err == nil {
+			t.Errorf("version store %s was accepted", dir)
+		}
+	}
+	for _, name := range []string{".versions", "inside/store"} {
+		if _, err := os.Stat(f.path(name)); !os.IsNotExist(err) {
+			t.Errorf("refused version store %s was created: %v", name, err)
+		}
+	}
+
+	first := f.versionDir()
+	if err := f.service.SetVersioning([]string{"."}, first, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	old := f.service.versions
+	if err := f.service.SetVersioning([]string{"."}, first+"-new", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-old.stop:
+	default:
+		t.Error("the replaced store was not stopped")
+	}
+}
+
+// A replacing operation that fails must not leave a version sharing the
+// live file, or the next in-place write would change the version too
+func TestVersionOfFailedReplace(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	if err := f.service.SetVersioning([]string{"."}, f.versionDir(), 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	f.write(t, "page.txt", "original")
+	f.write(t, "dir/file.txt", "content")
+
+	// A directory can not be renamed over a file
+	if resp, err := f.service.Move(ctx, &MoveRequest{Source: "dir", Destination: "page.txt", Overwrite: true}); err == nil && resp.Success {
+		t.Fatal("moving a directory over a file succeeded")
+	}
+	list, err := f.service.ListVersions(ctx, &PathRequest{Path: "page.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Versions) != 0 {
+		t.Fatalf("failed move left versions %v", list.Versions)
+	}
+	info, err := f.service.GetFileInfo(ctx, &FileRequest{Path: "page.txt"})
+	if err != nil || info.LinkCount != 1 {
+		t.Fatalf("file after a failed move: %v, %v", info, err)
+	}
+
+	if _, err := f.service.WriteFile(ctx, &WriteRequest{Path: "page.txt", Data: []byte("ORIG")}); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := f.service.Delete(ctx, &DeleteRequest{Path: "page.txt"}); err != nil || !resp.Success {
+		t.Fatalf("Delete: %v, %v", resp, err)
+	}
+	list, err = f.service.ListVersions(ctx, &PathRequest{Path: "page.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Versions) != 1 || list.Versions[0].Reason != "delete" {
+		t.Fatalf("versions after delete = %v, want only the deleted file", list.Versions)
+	}
+	download := &downloadStream{}
+	if err := f.service.DownloadFile(&FileRequest{Path: "page.txt", VersionId: list.Versions[0].VersionId}, download); err != nil || download.data.String() != "ORIGinal" {
+		t.Errorf("deleted version = %q, %v", download.data.String(), err)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// WriteFile implements the WriteFile RPC method
+func (s *FilesystemService) WriteFile(ctx context.Context, req *WriteRequest) (*WriteResponse, error) {
+	if req.Offset < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Offset must not be negative")
+	}
+
+	return s.editFile(ctx, req.Path, req.Create, 0, req.Precondition, "Data written successfully", func(file *os.File, info os.FileInfo) (int64, error) {
+		n, err := file.WriteAt(req.Data, req.Offset)
+		if err == nil && req.Sync {
+			err = file.Sync()
+		}
+		return int64(n), err
+	})
+}
+
+// AppendFile implements the AppendFile RPC method
+func (s *FilesystemService) AppendFile(ctx context.Context, req *AppendRequest) (*WriteResponse, error) {
+	data := req.Data
+	flag := 0
+	if req.Lines {
+		// A single write(2) with O_APPEND is placed at the end as a whole
+		flag = os.O_APPEND
+		if len(data) == 0 || data[len(data)-1] != '\n' {
+			data = append(data[:len(data):len(data)], '\n')
+		}
+	}
+
+	return s.editFile(ctx, req.Path, req.Create, flag, req.Precondition, "Data appended successfully", func(file *os.File, info os.FileInfo) (int64, error) {
+		var n int
+		var err error
+		if req.Lines {
+			n, err = file.Write(data)
+		} else {
+			n, err = file.WriteAt(data, info.Size())
+		}
+		if err == nil && req.Sync {
+			err = file.Sync()
+		}
+		return int64(n), err
+	})
+}
+
+// Truncate implements the Truncate RPC method
+func (s *FilesystemService) Truncate(ctx context.Context, req *TruncateRequest) (*WriteResponse, error) {
+	if req.Size < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Size must not be negative")
+	}
+
+	return s.editFile(ctx, req.Path, false, 0, req.Precondition, "File truncated successfully", func(file *os.File, info os.FileInfo) (int64, error) {
+		return 0, file.Truncate(req.Size)
+	})
+}
+
+// editFile opens a regular file for writing, holds an exclusive flock while
+// it checks the precondition and applies edit, and reports the new state.
+// Every in-place edit takes the lock, so edits through the daemon are
+// serialized; the lock is advisory for other processes. A file with other
+// hard links is edited in a copy that replaces it.
+func (s *FilesystemService) editFile(ctx context.Context, path string, create bool, flag int, precondition *pb.WritePrecondition, message string, edit func(file *os.File, info os.FileInfo) (int64, error)) (*WriteResponse, error) {
+	validPath, err := s.validatePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if create {
+		flag |= os.O_CREATE
+		if err := s.root.MkdirAll(filepath.Dir(validPath), 0755); err != nil {
+			return &WriteResponse{
+				Success: false,
+				Error:   "Failed to create parent directory: " + err.Error(),
+			}, nil
+		}
+	}
+
+	file, _, err := s.root.openWritable(validPath, flag, 0666)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &WriteResponse{
+				Success: false,
+				Error:   "File does not exist",
+			}, nil
+		}
+		return nil, rootError(err, "Failed to open file")
+	}
+	defer func() { file.Close() }()
+
+	if err := s.lockForEdit(ctx, file); err != nil {
+		return nil, err
+	}
+
+	// Check against the state under the lock, not the one seen when opening
+	info, err := file.Stat()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to stat file: %v", err)
+	}
+	if err := checkPrecondition(precondition, info); err != nil {
+		return nil, err
+	}
+	if file, info, err = s.breakHardLink(ctx, validPath, file, info, flag, true); err != nil {
+		return nil, streamError(ctx.Err(), err, "Failed to separate file from its hard links")
+	}
+
+	written, err := edit(file, info)
+	if err != nil {
+		return &WriteResponse{
+			Success:      false,
+			Error:        "Failed to write file: " + err.Error(),
+			BytesWritten: written,
+		}, nil
+	}
+
+	response := &WriteResponse{
+		Success:      true,
+		Message:      message,
+		BytesWritten: written,
+	}
+	if info, err := file.Stat(); err == nil {
+		response.Size = info.Size()
+		response.ModifiedTimeNs = info.ModTime().UnixNano()
+		response.Etag = etagOf(info)
+	}
+	return response, nil
+}
+
+// breakHardLink gives a locked regular file that shares its inode with other
+// paths, as deduplication leaves them, an inode of its own before it is
+// changed in place, so that the change does not show through the other
+// names. A replacement with the file's metadata, and its content if
+// keepContent is set, takes the place of the file and is returned opened
+// with flag and locked; file is closed. A file with a single link, or a
+// failure, returns file unchanged.
+func (s *FilesystemService) breakHardLink(ctx context.Context, validPath string, file *os.File, info os.FileInfo, flag int, keepContent bool) (*os.File, os.FileInfo, error) {
+	if !info.Mode().IsRegular() || statFieldsOf(info).linkCount < 2 {
+		return file, info, nil
+	}
+
+	newInfo, err := s.root.replaceFile(validPath, info, func(w io.Writer) error {
+		if !keepContent {
+			return nil
+		}
+		// Reopened for reading, since file may be write-only
+		src, err := os.Open(procPath(file))
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(w, contextReader{ctx, src})
+		return err
+	})
+	if err != nil {
+		return file, info, err
+	}
+
+	replacement, _, err := s.root.openWritable(validPath, flag&^(os.O_CREATE|os.O_EXCL), 0)
+	if err != nil {
+		return file, info, err
+	}
+	replacementInfo, err := replacement.Stat()
+	if err == nil {
+		err = s.lockForEdit(ctx, replacement)
+	}
+	if err == nil && !os.SameFile(replacementInfo, newInfo) {
+		err = status.Errorf(codes.Aborted, "File was replaced while it was separated from its hard links")
+	}
+	if err != nil {
+		replacement.Close()
+		return file, info, err
+	}
+	file.Close()
+	return replacement, replacementInfo, nil
+}
+
+// checkPrecondition fails with FailedPrecondition when the file no longer
+// has the size or modification time the client based its change on
+func checkPrecondition(precondition *pb.WritePrecondition, info os.FileInfo) error {
+	if precondition == nil {
+		return nil
+	}
+	if precondition.Size != nil && *precondition.Size != info.Size() {
+		return status.Errorf(codes.FailedPrecondition, "File size is %d, expected %d", info.Size(), *precondition.Size)
+	}
+	if precondition.ModifiedTimeNs != 0 && precondition.ModifiedTimeNs != info.ModTime().UnixNano() {
+		return status.Errorf(codes.FailedPrecondition, "File was modified at %d, expected %d", info.ModTime().UnixNano(), precondition.ModifiedTimeNs)
+	}
+	if precondition.Etag != "" {
+		return checkETag(precondition.Etag, "", info)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+func TestEditFile(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	const content = "inside public"
+	f.write(t, "notes.txt", content)
+
+	resp, err := f.service.WriteFile(ctx, &WriteRequest{Path: "notes.txt", Offset: 7, Data: []byte("PUBLIC")})
+	if err != nil || !resp.Success {
+		t.Fatalf("write: %v, %v", resp, err)
+	}
+
+	// A writer that saw the old size must not append at a stale offset
+	stale := &pb.WritePrecondition{Size: proto.Int64(int64(len(content)) - 1)}
+	if _, err := f.service.AppendFile(ctx, &AppendRequest{Path: "notes.txt", Data: []byte("!"), Precondition: stale}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("append with a stale size: got %v, want FailedPrecondition", err)
+	}
+	current := &pb.WritePrecondition{Size: proto.Int64(resp.Size), ModifiedTimeNs: resp.ModifiedTimeNs}
+	if _, err := f.service.AppendFile(ctx, &AppendRequest{Path: "notes.txt", Data: []byte("line"), Lines: true, Precondition: current}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Truncate(ctx, &TruncateRequest{Path: "notes.txt", Size: 100, Precondition: current}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("truncate with a stale precondition: got %v, want FailedPrecondition", err)
+	}
+
+	if data := f.read(t, "notes.txt"); data != "inside PUBLICline\n" {
+		t.Errorf("content = %q", data)
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/notfrancois/filesystem-daemon/proto"
+)
+
+// Kernel limits for attribute names and values (XATTR_NAME_MAX, XATTR_SIZE_MAX)
+const (
+	xattrNameMax = 255
+	xattrSizeMax = 65536
+)
+
+// xattrNamespaces are the attribute namespaces Linux knows about
+var xattrNamespaces = []string{"user.", "trusted.", "security.", "system."}
+
+// writableXattrNamespace is the only namespace clients may change. trusted.
+// and security. attributes drive the kernel and security modules, and the
+// system. ACL attributes are set through SetAcl, which validates them.
+const writableXattrNamespace = "user."
+
+// GetXattrs implements the GetXattrs RPC method
+func (s *FilesystemService) GetXattrs(ctx context.Context, req *XattrRequest) (*XattrResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		if err := validateXattrName(req.Name); err != nil {
+			return nil, err
+		}
+		value, err := s.root.Getxattr(validPath, req.Name)
+		if err != nil {
+			return nil, xattrError(err, "Failed to read attribute")
+		}
+		return &XattrResponse{Xattrs: []*pb.Xattr{{Name: req.Name, Value: value}}}, nil
+	}
+
+	xattrs, err := s.root.xattrs(validPath)
+	if err != nil {
+		return nil, xattrError(err, "Failed to read attributes")
+	}
+	return &XattrResponse{Xattrs: xattrs}, nil
+}
+
+// SetXattr implements the SetXattr RPC method
+func (s *FilesystemService) SetXattr(ctx context.Context, req *SetXattrRequest) (*OperationResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateWritableXattrName(req.Name); err != nil {
+		return nil, err
+	}
+	if len(req.Value) > xattrSizeMax {
+		return nil, status.Errorf(codes.InvalidArgument, "Attribute value exceeds %d bytes", xattrSizeMax)
+	}
+	if req.Create && req.Replace {
+		return nil, status.Errorf(codes.InvalidArgument, "create and replace are mutually exclusive")
+	}
+
+	var flags int
+	if req.Create {
+		flags = unix.XATTR_CREATE
+	} else if req.Replace {
+		flags = unix.XATTR_REPLACE
+	}
+
+	if err := s.root.Setxattr(validPath, req.Name, req.Value, flags); err != nil {
+		switch {
+		case errors.Is(err, unix.EEXIST):
+			return &OperationResponse{Success: false, Error: "Attribute already exists"}, nil
+		case errors.Is(err, unix.ENODATA):
+			return &OperationResponse{Success: false, Error: "Attribute does not exist"}, nil
+		}
+		return metadataError("Failed to set attribute", err)
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: "Attribute set successfully",
+	}, nil
+}
+
+// RemoveXattr implements the RemoveXattr RPC method
+func (s *FilesystemService) RemoveXattr(ctx context.Context, req *XattrRequest) (*OperationResponse, error) {
+	validPath, err := s.validatePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateWritableXattrName(req.Name); err != nil {
+		return nil, err
+	}
+
+	if err := s.root.Removexattr(validPath, req.Name); err != nil {
+		if errors.Is(err, unix.ENODATA) {
+			return &OperationResponse{Success: false, Error: "Attribute does not exist"}, nil
+		}
+		return metadataError("Failed to remove attribute", err)
+	}
+
+	return &OperationResponse{
+		Success: true,
+		Message: "Attribute removed successfully",
+	}, nil
+}
+
+// validateXattrName checks that an attribute name has a namespace and fits the kernel limit
+func validateXattrName(name string) error {
+	if len(name) > xattrNameMax {
+		return status.Errorf(codes.InvalidArgument, "Attribute name exceeds %d bytes", xattrNameMax)
+	}
+	for _, namespace := range xattrNamespaces {
+		if strings.HasPrefix(name, namespace) && len(name) > len(namespace) {
+			return nil
+		}
+	}
+	return status.Errorf(codes.InvalidArgument, "Attribute name must start with user., trusted., security. or system.")
+}
+
+// validateWritableXattrName checks that an attribute name may be set or removed by clients
+func validateWritableXattrName(name string) error {
+	if err := validateXattrName(name); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(name, writableXattrNamespace) {
+		return status.Errorf(codes.PermissionDenied, "Only attributes in the user. namespace can be changed; use SetAcl for ACLs")
+	}
+	return nil
+}
+
+// xattrError maps extended attribute errors to gRPC status errors
+func xattrError(err error, msg string) error {
+	switch {
+	case errors.Is(err, unix.ENODATA):
+		return status.Errorf(codes.NotFound, "Attribute does not exist")
+	case errors.Is(err, unix.EOPNOTSUPP):
+		return status.Errorf(codes.FailedPrecondition, "Extended attributes are not supported by the filesystem")
+	case errors.Is(err, unix.EPERM), errors.Is(err, unix.EACCES):
+		return status.Errorf(codes.PermissionDenied, "%s: %v", msg, err)
+	}
+	return rootError(err, msg)
+}
+
+// copyXattrs copies the extended attributes of src, including POSIX ACLs,
+// to dst. Like cp --preserve=xattr, attributes the destination filesystem
+// or the daemon's privileges do not allow are skipped.
+func (r *rootFS) copyXattrs(src, dst string) error {
+	xattrs, err := r.xattrs(src)
+	if err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			return nil
+		}
+		return err
+	}
+
+	for _, xattr := range xattrs {
+		err := r.Setxattr(dst, xattr.Name, xattr.Value, 0)
+		if err == nil || errors.Is(err, unix.EOPNOTSUPP) {
+			continue
+		}
+		if errors.Is(err, unix.EPERM) && !strings.HasPrefix(xattr.Name, "user.") {
+			continue
+		}
+		return err
+	}
+	return nil
+}
+
+// xattrs reads every extended attribute of name, sorted by name. Attributes
+// that vanish or can not be read while listing are left out.
+func (r *rootFS) xattrs(name string) ([]*pb.Xattr, error) {
+	names, err := r.Listxattr(name)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make([]*pb.Xattr, 0, len(names))
+	for _, attr := range names {
+		value, err := r.Getxattr(name, attr)
+		if err != nil {
+			continue
+		}
+		xattrs = append(xattrs, &pb.Xattr{Name: attr, Value: value})
+	}
+	return xattrs, nil
+}
+
+// xattrBuffer calls a size-probing xattr system call with a large enough buffer
+func xattrBuffer(call func(buf []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := call(nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return []byte{}, nil
+		}
+
+		buf := make([]byte, size)
+		n, err := call(buf)
+		// The attribute grew between the two calls
+		if err == unix.ERANGE {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+// Listxattr lists the extended attribute names of name, following a final
+// symlink as the policy allows. Attributes go through /proc because the
+// f*xattr calls do not accept O_PATH descriptors.
+func (r *rootFS) Listxattr(name string) ([]string, error) {
+	f, err := r.openPath(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf, err := xattrBuffer(func(buf []byte) (int, error) {
+		return unix.Listxattr(procPath(f), buf)
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "listxattr", Path: name, Err: err}
+	}
+
+	var names []string
+	for _, attr := range strings.Split(string(buf), "\x00") {
+		if attr != "" {
+			names = append(names, attr)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Getxattr returns the value of an extended attribute
+func (r *rootFS) Getxattr(name, attr string) ([]byte, error) {
+	f, err := r.openPath(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	value, err := xattrBuffer(func(buf []byte) (int, error) {
+		return unix.Getxattr(procPath(f), attr, buf)
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "getxattr", Path: name, Err: err}
+	}
+	return value, nil
+}
+
+// Setxattr sets an extended attribute; flags are XATTR_CREATE or XATTR_REPLACE
+func (r *rootFS) Setxattr(name, attr string, value []byte, flags int) error {
+	f, err := r.openPath(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := unix.Setxattr(procPath(f), attr, value, flags); err != nil {
+		return &os.PathError{Op: "setxattr", Path: name, Err: err}
+	}
+	return nil
+}
+
+// Removexattr removes an extended attribute
+func (r *rootFS) Removexattr(name, attr string) error {
+	f, err := r.openPath(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := unix.Removexattr(procPath(f), attr); err != nil {
+		return &os.PathError{Op: "removexattr", Path: name, Err: err}
+	}
+	return nil
+}
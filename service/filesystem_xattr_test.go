@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestXattrRPCs(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "file.txt", "content")
+	ctx := context.Background()
+
+	set := func(name, value string, create, replace bool) (*OperationResponse, error) {
+		return f.service.SetXattr(ctx, &SetXattrRequest{Path: "file.txt", Name: name, Value: []byte(value), Create: create, Replace: replace})
+	}
+
+	response, err := set("user.color", "blue", true, false)
+	if status.Code(err) == codes.FailedPrecondition {
+		t.Skip("extended attributes are not supported here:", err)
+	}
+	if err != nil || !response.Success {
+		t.Fatalf("SetXattr = %v, %v", response, err)
+	}
+	if response, err := set("user.color", "red", true, false); err != nil || response.Success {
+		t.Errorf("SetXattr with create on an existing attribute = %v, %v", response, err)
+	}
+	if response, err := set("user.size", "1", false, true); err != nil || response.Success {
+		t.Errorf("SetXattr with replace on a missing attribute = %v, %v", response, err)
+	}
+	if response, err := set("user.size", "1", false, false); err != nil || !response.Success {
+		t.Fatalf("SetXattr = %v, %v", response, err)
+	}
+
+	got, err := f.service.GetXattrs(ctx, &XattrRequest{Path: "file.txt", Name: "user.color"})
+	if err != nil || len(got.Xattrs) != 1 || string(got.Xattrs[0].Value) != "blue" {
+		t.Errorf("GetXattrs(user.color) = %v, %v", got, err)
+	}
+
+	list, err := f.service.GetXattrs(ctx, &XattrRequest{Path: "file.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, xattr := range list.Xattrs {
+		names = append(names, xattr.Name)
+	}
+	if len(names) != 2 || names[0] != "user.color" || names[1] != "user.size" {
+		t.Errorf("GetXattrs listed %v, want [user.color user.size]", names)
+	}
+
+	response, err = f.service.RemoveXattr(ctx, &XattrRequest{Path: "file.txt", Name: "user.color"})
+	if err != nil || !response.Success {
+		t.Fatalf("RemoveXattr = %v, %v", response, err)
+	}
+	if _, err := f.service.GetXattrs(ctx, &XattrRequest{Path: "file.txt", Name: "user.color"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetXattrs of a removed attribute: %v, want NotFound", err)
+	}
+	if response, err := f.service.RemoveXattr(ctx, &XattrRequest{Path: "file.txt", Name: "user.color"}); err != nil || response.Success {
+		t.Errorf("RemoveXattr of a missing attribute = %v, %v", response, err)
+	}
+
+	if _, err := set("color", "blue", false, false); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SetXattr without a namespace: %v, want InvalidArgument", err)
+	}
+}
+
+func TestXattrWritesOnlyUserNamespace(t *testing.T) {
+	f := newTestFixture(t)
+	f.write(t, "file.txt", "content")
+	ctx := context.Background()
+
+	for _, name := range []string{"trusted.overlay.opaque", "security.capability", "security.selinux", "system.posix_acl_access", "system.posix_acl_default"} {
+		_, err := f.service.SetXattr(ctx, &SetXattrRequest{Path: "file.txt", Name: name, Value: []byte("x")})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("SetXattr(%s): %v, want PermissionDenied", name, err)
+		}
+		_, err = f.service.RemoveXattr(ctx, &XattrRequest{Path: "file.txt", Name: name})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("RemoveXattr(%s): %v, want PermissionDenied", name, err)
+		}
+	}
+}